@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// reconcileCounters は投稿のいいね数・リプライ数・リポスト数、ユーザーのフォロワー数・フォロー数と投票の票数を元のテーブルから数え直します
+// 件数の列を追加した後の初回と、手作業でデータを修正した後に使います
+func reconcileCounters(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("reconcile-counters", flag.ExitOnError)
+	fs.Parse(args)
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	result, err := models.ReconcileCounters(db)
+	if err != nil {
+		return err
+	}
+	log.Printf("Fixed counters of %d posts, %d users and %d poll options", result.Posts, result.Users, result.PollOptions)
+	return nil
+}
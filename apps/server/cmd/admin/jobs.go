@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"sns-server/internal/config"
+	"sns-server/internal/jobs"
+)
+
+// retryDeadJobs はデッドレターになったジョブを再実行するようキューに戻します
+// 原因（外部サービスの障害など）を取り除いた後に使います
+func retryDeadJobs(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("retry-dead-jobs", flag.ExitOnError)
+	jobType := fs.String("type", "", "ジョブの種類（省略すると全て）")
+	fs.Parse(args)
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	requeued, err := jobs.NewDatabaseStore(db).RequeueDead(*jobType, time.Now())
+	if err != nil {
+		return err
+	}
+	log.Printf("Requeued %d dead jobs", requeued)
+	return nil
+}
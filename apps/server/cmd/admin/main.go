@@ -0,0 +1,90 @@
+// adminは運用作業用のコマンドラインツールです
+//
+// 使い方:
+//
+//	go run ./cmd/admin <command> [flags]
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"sns-server/internal/config"
+)
+
+// command はサブコマンドの定義です
+type command struct {
+	description string
+	run         func(cfg *config.Config, args []string) error
+}
+
+var commands = map[string]command{
+	"grant-role": {
+		description: "ユーザーの役割（USER / MODERATOR / ADMIN）を変更する",
+		run:         grantRole,
+	},
+	"rebuild-timelines": {
+		description: "ホームタイムラインをフォロー中のユーザーの投稿から作り直す",
+		run:         rebuildTimelines,
+	},
+	"reconcile-counters": {
+		description: "投稿・ユーザーの件数を元のテーブルから数え直す",
+		run:         reconcileCounters,
+	},
+	"load-persisted-queries": {
+		description: "マニフェストの操作を永続化クエリとして登録する",
+		run:         loadPersistedQueries,
+	},
+	"retry-dead-jobs": {
+		description: "デッドレターになったバックグラウンドジョブを再実行する",
+		run:         retryDeadJobs,
+	},
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(config.Load(), os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: admin <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-24s %s\n", name, commands[name].description)
+	}
+}
+
+func connectDB(cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
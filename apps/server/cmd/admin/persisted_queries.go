@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/vektah/gqlparser/v2"
+
+	"sns-server/internal/config"
+	"sns-server/internal/graph"
+	"sns-server/internal/models"
+	"sns-server/internal/persisted"
+)
+
+// loadPersistedQueries はビルド時に生成したマニフェストをpersisted_queriesテーブルに登録します
+// 許可リストモード（PERSISTED_QUERY_MODE=allowlist）ではここで登録したクエリのみ実行できます
+func loadPersistedQueries(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("load-persisted-queries", flag.ExitOnError)
+	manifestPath := fs.String("manifest", "", "マニフェストファイルのパス（必須）")
+	prune := fs.Bool("prune", false, "マニフェストに含まれない許可済みクエリを削除する")
+	dryRun := fs.Bool("dry-run", false, "検証のみ行い、登録しない")
+	fs.Parse(args)
+
+	if *manifestPath == "" {
+		fs.Usage()
+		return fmt.Errorf("-manifest is required")
+	}
+
+	data, err := os.ReadFile(*manifestPath)
+	if err != nil {
+		return err
+	}
+	entries, err := persisted.ParseManifest(data)
+	if err != nil {
+		return err
+	}
+
+	// スキーマに合わないクエリは実行時に必ず失敗するため、登録前に弾く
+	schema, err := graph.Schema()
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if _, errs := gqlparser.LoadQuery(schema, entry.Query); len(errs) > 0 {
+			return fmt.Errorf("operation %q (%s) is invalid: %v", entry.OperationName, entry.Hash, errs)
+		}
+	}
+
+	if *dryRun {
+		log.Printf("%d operations are valid", len(entries))
+		return nil
+	}
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.PersistedQuery{}); err != nil {
+		return err
+	}
+
+	removed, err := persisted.NewDBStore(db).ImportManifest(entries, *prune)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Loaded %d persisted queries from %s", len(entries), *manifestPath)
+	if *prune {
+		log.Printf("Removed %d persisted queries not in the manifest", removed)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// grantRole はユーザーの役割を変更します
+// 最初の管理者はAPIから作成できないため、このコマンドで付与します
+func grantRole(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("grant-role", flag.ExitOnError)
+	username := fs.String("user", "", "ユーザー名（必須）")
+	role := fs.String("role", "", "役割 USER / MODERATOR / ADMIN（必須、USERで権限を外す）")
+	fs.Parse(args)
+
+	if *username == "" || *role == "" {
+		fs.Usage()
+		return fmt.Errorf("-user and -role are required")
+	}
+	roleName := strings.ToUpper(*role)
+	if !models.IsValidRole(roleName) {
+		return fmt.Errorf("%w: %s", models.ErrInvalidRole, *role)
+	}
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := db.Where("LOWER(username) = ?", strings.ToLower(models.NormalizeUsername(*username))).First(&user).Error; err != nil {
+		return fmt.Errorf("user %q not found", *username)
+	}
+
+	previous := user.Role
+	if err := models.SetUserRole(db, &user, roleName); err != nil {
+		return err
+	}
+	log.Printf("%s: %s -> %s", user.Username, previous, user.Role)
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/timeline"
+)
+
+// rebuildTimelines はホームタイムラインを作り直します
+// 配信の導入前に作成された投稿をタイムラインに追加する場合や、不整合を修復する場合に使います
+func rebuildTimelines(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("rebuild-timelines", flag.ExitOnError)
+	username := fs.String("user", "", "ユーザー名（省略すると全てのユーザー）")
+	fs.Parse(args)
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		return err
+	}
+
+	query := db.Model(&models.User{})
+	if *username != "" {
+		query = query.Where("LOWER(username) = ?", strings.ToLower(models.NormalizeUsername(*username)))
+	}
+	var userIDs []uint
+	if err := query.Order("id").Pluck("id", &userIDs).Error; err != nil {
+		return err
+	}
+	if *username != "" && len(userIDs) == 0 {
+		return fmt.Errorf("user %q not found", *username)
+	}
+
+	fanout := timeline.NewFanout(db, timeline.PolicyFromConfig(cfg))
+	total := 0
+	for _, id := range userIDs {
+		added, err := fanout.Rebuild(id)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild timeline of user %d: %w", id, err)
+		}
+		total += added
+	}
+	log.Printf("Rebuilt %d timelines with %d entries", len(userIDs), total)
+	return nil
+}
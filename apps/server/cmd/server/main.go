@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -10,8 +13,19 @@ import (
 	"gorm.io/gorm"
 
 	"sns-server/internal/config"
+	"sns-server/internal/dataexport"
+	"sns-server/internal/idempotency"
+	"sns-server/internal/jobs"
+	"sns-server/internal/lockout"
+	"sns-server/internal/mailer"
 	"sns-server/internal/models"
+	"sns-server/internal/persisted"
+	"sns-server/internal/pubsub"
+	"sns-server/internal/ratelimit"
 	"sns-server/internal/server"
+	"sns-server/internal/storage"
+	"sns-server/internal/timeline"
+	"sns-server/internal/trending"
 )
 
 func main() {
@@ -27,29 +41,137 @@ func main() {
 	err := db.AutoMigrate(
 		&models.User{},
 		&models.Post{},
+		&models.Draft{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.PollVote{},
 		&models.Like{},
 		&models.Follow{},
+		&models.Block{},
+		&models.Mute{},
+		&models.FollowRequest{},
+		&models.Repost{},
+		&models.BookmarkCollection{},
+		&models.Bookmark{},
+		&models.Report{},
+		&models.ModerationAction{},
+		&models.DataExport{},
+		&models.Job{},
+		&models.TimelineEntry{},
+		&models.UserToken{},
+		&models.RateLimitBucket{},
+		&models.LoginAttempt{},
+		&models.LockoutEvent{},
+		&models.PersistedQuery{},
+		&models.IdempotencyKey{},
+		&models.Notification{},
+		&models.NotificationActor{},
+		&models.PostEntity{},
+		&models.TrendingHashtag{},
+		&models.TrendingPost{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	// メール送信設定
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure mailer: %v", err)
+	}
+
+	// レート制限設定
+	limiter, err := ratelimit.New(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to configure rate limiter: %v", err)
+	}
+	if pg, ok := limiter.(*ratelimit.PostgresLimiter); ok {
+		go cleanupRateLimits(pg)
+	}
+
+	// 永続化クエリ設定
+	pqStore, err := persisted.NewStore(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to configure persisted query store: %v", err)
+	}
+	pqResolver, err := persisted.NewResolver(pqStore, cfg.PersistedQueryMode)
+	if err != nil {
+		log.Fatalf("Failed to configure persisted queries: %v", err)
+	}
+	pqResolver.APQTTL = cfg.PersistedQueryAPQTTL
+
+	// サブスクリプション用のPub/Sub設定
+	broker, err := pubsub.New(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to configure pubsub: %v", err)
+	}
+	defer broker.Close()
+
+	// データエクスポートのファイルの保存先
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to configure storage: %v", err)
+	}
+
+	// バックグラウンドジョブのキューとワーカー
+	jobStore, err := jobs.NewStore(cfg, db)
+	if err != nil {
+		log.Fatalf("Failed to configure job queue: %v", err)
+	}
+	queue := jobs.NewQueue(jobStore, jobs.PolicyFromConfig(cfg))
+	registerJobs(queue, db, cfg, dataexport.NewExporter(db, store, cfg.DataExportRetention))
+
+	scheduler, err := scheduleJobs(queue, cfg)
+	if err != nil {
+		log.Fatalf("Failed to schedule jobs: %v", err)
+	}
+	go scheduler.Run(context.Background())
+
+	// トレンドは起動時にも集計する
+	if _, err := queue.Enqueue(jobTypeRefreshTrending, nil); err != nil {
+		log.Printf("Failed to enqueue trending refresh: %v", err)
+	}
+
 	// サーバー作成
 	srv := &server.Server{
-		DB:     db,
-		Config: cfg,
+		DB:          db,
+		Config:      cfg,
+		Mailer:      mail,
+		RateLimiter: limiter,
+		LoginGuard:  lockout.NewGuard(db, lockout.PolicyFromConfig(cfg)),
+
+		PersistedQueries: pqResolver,
+		PubSub:           broker,
+		Storage:          store,
+		Jobs:             queue,
+		Idempotency:      idempotency.NewGuard(db, idempotency.PolicyFromConfig(cfg)),
 	}
 
+	// 予約投稿の公開は通知・配信を行うサーバーのハンドラーで実行する
+	queue.Register(server.PublishDraftJobType, srv.HandlePublishDraftJob)
+
+	pool := queue.Start(jobs.PoolConfig{
+		Workers:      cfg.JobWorkers,
+		PollInterval: cfg.JobPollInterval,
+		LockTimeout:  cfg.JobLockTimeout,
+	})
+	defer pool.Stop()
+
 	// ルーター設定
 	router := chi.NewRouter()
 
 	// ミドルウェア
+	router.Use(srv.RealIPMiddleware) // 信頼するプロキシの転送ヘッダーのみ使う
 	router.Use(middleware.Logger)
 	router.Use(middleware.Recoverer)
 	router.Use(corsMiddleware(cfg))
 
 	// GraphQLエンドポイント
-	router.Post("/query", srv.HandleGraphQL)
+	router.With(srv.AuthMiddleware, srv.RateLimitMiddleware).Post("/query", srv.HandleGraphQL)
+	router.Get("/query", srv.HandleSubscriptions) // WebSocket（graphql-transport-ws）
+
+	// データエクスポートのダウンロード（署名付きURLで認可するため認証ミドルウェアを通さない）
+	router.Get("/exports/{id}", srv.HandleDataExportDownload)
 	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		w.Write([]byte(`
@@ -61,6 +183,7 @@ func main() {
 <body>
     <h1>SNS GraphQL API Server</h1>
     <p>GraphQLエンドポイント: <code>POST /query</code></p>
+    <p>サブスクリプション: <code>ws://.../query</code>（graphql-transport-ws）</p>
     <h2>サンプルクエリ:</h2>
     <pre>
 # ユーザー一覧
@@ -133,6 +256,102 @@ func connectDB(cfg *config.Config) *gorm.DB {
 	return db
 }
 
+// cleanupRateLimits は期限切れのレート制限カウンターを定期的に削除します
+func cleanupRateLimits(limiter *ratelimit.PostgresLimiter) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := limiter.Cleanup(); err != nil {
+			log.Printf("Failed to clean up rate limits: %v", err)
+		}
+	}
+}
+
+// 定期実行するジョブの種類
+const (
+	jobTypeRefreshTrending = "trending.refresh"
+	jobTypePurgeAccounts   = "accounts.purge"
+	jobTypeCleanupJobs     = "jobs.cleanup"
+	jobTypeCleanupIdemKeys = "idempotency.cleanup"
+	jobTypeCleanupAPQ      = "persisted_queries.cleanup"
+	jobTypeCleanupLogins   = "login_history.cleanup"
+)
+
+// registerJobs はバックグラウンドジョブのハンドラーを登録します
+func registerJobs(queue *jobs.Queue, db *gorm.DB, cfg *config.Config, exporter *dataexport.Exporter) {
+	calculator := trending.NewCalculator(db, trending.PolicyFromConfig(cfg))
+	queue.Register(jobTypeRefreshTrending, func(ctx context.Context, job *models.Job) error {
+		return calculator.Refresh()
+	})
+
+	// 猶予期間を過ぎた退会済みアカウントを削除する（データエクスポートのファイルも削除する）
+	queue.Register(jobTypePurgeAccounts, func(ctx context.Context, job *models.Job) error {
+		purged, err := models.PurgeDeactivatedUsers(db, time.Now().Add(-cfg.AccountDeactivationPeriod), exporter.PurgeUser)
+		if purged > 0 {
+			log.Printf("Purged %d deactivated accounts", purged)
+		}
+		return err
+	})
+
+	// 保持期間を過ぎた完了済みのジョブを削除する
+	queue.Register(jobTypeCleanupJobs, func(ctx context.Context, job *models.Job) error {
+		_, err := queue.Store().DeleteFinished(time.Now().Add(-cfg.JobRetention))
+		return err
+	})
+
+	// 期限切れのIdempotency-Keyを削除する
+	queue.Register(jobTypeCleanupIdemKeys, func(ctx context.Context, job *models.Job) error {
+		_, err := models.DeleteExpiredIdempotencyKeys(db, time.Now())
+		return err
+	})
+
+	// 保持期間を過ぎたログイン試行・ロックアウトの記録を削除する（失敗回数を数える期間の記録は残す）
+	queue.Register(jobTypeCleanupLogins, func(ctx context.Context, job *models.Job) error {
+		retention := max(cfg.LoginHistoryRetention, cfg.LoginFailureWindow)
+		_, err := models.DeleteLoginHistory(db, time.Now().Add(-retention))
+		return err
+	})
+
+	// 有効期限を過ぎたAPQのクエリを削除する（マニフェストのクエリは削除しない）
+	queue.Register(jobTypeCleanupAPQ, func(ctx context.Context, job *models.Job) error {
+		_, err := models.DeleteExpiredPersistedQueries(db, time.Now())
+		return err
+	})
+
+	queue.Register(dataexport.JobType, exporter.HandleJob)
+	queue.Register(dataexport.CleanupJobType, exporter.HandleCleanupJob)
+
+	fanout := timeline.NewFanout(db, timeline.PolicyFromConfig(cfg))
+	queue.Register(timeline.FanoutJobType, fanout.HandleFanoutJob)
+	queue.Register(timeline.BackfillJobType, fanout.HandleBackfillJob)
+}
+
+// scheduleJobs は定期実行するジョブを登録したSchedulerを作成します
+func scheduleJobs(queue *jobs.Queue, cfg *config.Config) (*jobs.Scheduler, error) {
+	scheduler := jobs.NewScheduler(queue)
+
+	entries := []struct {
+		name    string
+		spec    string
+		jobType string
+	}{
+		{"refresh-trending", "@every " + cfg.TrendingRefreshInterval.String(), jobTypeRefreshTrending},
+		{"purge-accounts", "@every " + cfg.AccountPurgeInterval.String(), jobTypePurgeAccounts},
+		{"cleanup-data-exports", "*/10 * * * *", dataexport.CleanupJobType},
+		{"cleanup-jobs", "@daily", jobTypeCleanupJobs},
+		{"cleanup-idempotency-keys", "@hourly", jobTypeCleanupIdemKeys},
+		{"cleanup-persisted-queries", "@daily", jobTypeCleanupAPQ},
+		{"cleanup-login-history", "@hourly", jobTypeCleanupLogins},
+	}
+	for _, e := range entries {
+		if err := scheduler.Add(e.name, e.spec, e.jobType, nil); err != nil {
+			return nil, fmt.Errorf("%s: %w", e.name, err)
+		}
+	}
+	return scheduler, nil
+}
+
 func corsMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -151,7 +370,8 @@ func corsMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
 			}
 
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, Idempotency-Key")
+			w.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed")
 
 			if r.Method == "OPTIONS" {
 				w.WriteHeader(http.StatusOK)
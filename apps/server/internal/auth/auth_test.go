@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "8文字は有効", password: "abcdefgh", wantErr: false},
+		{name: "7文字はエラー", password: "abcdefg", wantErr: true},
+		{name: "日本語8文字は有効", password: "あいうえおかきく", wantErr: false},
+		{name: "72バイトを超える場合はエラー", password: strings.Repeat("a", 73), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword(tt.password)
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("password123")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	if hash == "password123" {
+		t.Error("Hash should not equal the plain password")
+	}
+	if !CheckPassword(hash, "password123") {
+		t.Error("Expected password to match")
+	}
+	if CheckPassword(hash, "wrong-password") {
+		t.Error("Expected wrong password not to match")
+	}
+}
+
+func TestSignToken_Verify(t *testing.T) {
+	secret := "test-secret"
+	expiresAt := time.Now().Add(time.Hour)
+
+	token, err := SignToken(secret, "email_verification", 42, expiresAt)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	claims, err := VerifyToken(secret, token, "email_verification")
+	if err != nil {
+		t.Fatalf("Failed to verify token: %v", err)
+	}
+	if claims.UserID != 42 {
+		t.Errorf("Expected user ID 42, got %d", claims.UserID)
+	}
+	if claims.ExpiresAt.Unix() != expiresAt.Unix() {
+		t.Errorf("Expected expiry %v, got %v", expiresAt, claims.ExpiresAt)
+	}
+
+	// 同じ内容でも毎回異なるトークンが発行される
+	other, _ := SignToken(secret, "email_verification", 42, expiresAt)
+	if other == token {
+		t.Error("Expected tokens to differ by nonce")
+	}
+}
+
+func TestVerifyToken_Invalid(t *testing.T) {
+	secret := "test-secret"
+	token, _ := SignToken(secret, "password_reset", 1, time.Now().Add(time.Hour))
+	expired, _ := SignToken(secret, "password_reset", 1, time.Now().Add(-time.Minute))
+
+	tests := []struct {
+		name    string
+		secret  string
+		token   string
+		purpose string
+		wantErr error
+	}{
+		{name: "署名が異なる", secret: "other-secret", token: token, purpose: "password_reset", wantErr: ErrInvalidToken},
+		{name: "用途が異なる", secret: secret, token: token, purpose: "email_verification", wantErr: ErrInvalidToken},
+		{name: "改ざんされたトークン", secret: secret, token: "x" + token, purpose: "password_reset", wantErr: ErrInvalidToken},
+		{name: "形式が不正", secret: secret, token: "not-a-token", purpose: "password_reset", wantErr: ErrInvalidToken},
+		{name: "有効期限切れ", secret: secret, token: expired, purpose: "password_reset", wantErr: ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyToken(tt.secret, tt.token, tt.purpose)
+			if err != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSessionToken(t *testing.T) {
+	secret := "test-secret"
+
+	token, err := IssueSessionToken(secret, 7, time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to issue session token: %v", err)
+	}
+
+	userID, err := ParseSessionToken(secret, token)
+	if err != nil {
+		t.Fatalf("Failed to parse session token: %v", err)
+	}
+	if userID != 7 {
+		t.Errorf("Expected user ID 7, got %d", userID)
+	}
+
+	// 他の用途のトークンはセッションとして受け付けない
+	resetToken, _ := SignToken(secret, "password_reset", 7, time.Now().Add(time.Hour))
+	if _, err := ParseSessionToken(secret, resetToken); err != ErrInvalidToken {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestUserIDFromContext(t *testing.T) {
+	if _, ok := UserIDFromContext(context.Background()); ok {
+		t.Error("Expected no user in empty context")
+	}
+
+	ctx := WithUserID(context.Background(), 3)
+	userID, ok := UserIDFromContext(ctx)
+	if !ok || userID != 3 {
+		t.Errorf("Expected user 3, got %d (ok=%v)", userID, ok)
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	secret := "test-secret"
+	signed := SignURL(secret, "/exports/1", time.Now().Add(time.Hour))
+	parsed, _ := url.Parse(signed)
+
+	if err := VerifySignedURL(secret, "/exports/1", parsed.Query()); err != nil {
+		t.Fatalf("Expected valid signature, got %v", err)
+	}
+
+	expired, _ := url.Parse(SignURL(secret, "/exports/1", time.Now().Add(-time.Minute)))
+	tampered := parsed.Query()
+	tampered.Set("expires", "9999999999")
+
+	tests := []struct {
+		name    string
+		secret  string
+		path    string
+		query   url.Values
+		wantErr error
+	}{
+		{name: "別のパス", secret: secret, path: "/exports/2", query: parsed.Query(), wantErr: ErrInvalidToken},
+		{name: "別のシークレット", secret: "other-secret", path: "/exports/1", query: parsed.Query(), wantErr: ErrInvalidToken},
+		{name: "有効期限の改ざん", secret: secret, path: "/exports/1", query: tampered, wantErr: ErrInvalidToken},
+		{name: "署名なし", secret: secret, path: "/exports/1", query: url.Values{}, wantErr: ErrInvalidToken},
+		{name: "期限切れ", secret: secret, path: "/exports/1", query: expired.Query(), wantErr: ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := VerifySignedURL(tt.secret, tt.path, tt.query); err != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
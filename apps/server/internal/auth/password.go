@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// パスワードの長さ制限（bcryptは72バイトまでしか扱えない）
+const (
+	PasswordMinLength = 8
+	PasswordMaxBytes  = 72
+)
+
+var ErrPasswordInvalid = errors.New("password must be at least 8 characters and at most 72 bytes")
+
+// ValidatePassword はパスワードの長さをチェックします
+func ValidatePassword(password string) error {
+	if utf8.RuneCountInString(password) < PasswordMinLength || len(password) > PasswordMaxBytes {
+		return ErrPasswordInvalid
+	}
+	return nil
+}
+
+// HashPassword はパスワードをbcryptでハッシュ化します
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword はハッシュとパスワードが一致するかを判定します
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// DummyPasswordHash は存在しないユーザーのログイン時に照合するダミーのハッシュを返します
+// 実在ユーザーと同じコストのbcrypt照合を行うことで、応答時間からアカウントの有無を推測させない
+func DummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+		if err != nil {
+			panic(err)
+		}
+		dummyHash = string(hash)
+	})
+	return dummyHash
+}
@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// PurposeSession はログインセッション用トークンの用途です
+const PurposeSession = "session"
+
+type contextKey struct{}
+
+// IssueSessionToken はログインセッション用の署名付きトークンを発行します
+func IssueSessionToken(secret string, userID uint, ttl time.Duration) (string, error) {
+	return SignToken(secret, PurposeSession, userID, time.Now().Add(ttl))
+}
+
+// ParseSessionToken はセッショントークンを検証してユーザーIDを返します
+func ParseSessionToken(secret, token string) (uint, error) {
+	claims, err := VerifyToken(secret, token, PurposeSession)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// WithUserID は認証済みユーザーIDをコンテキストに設定します
+func WithUserID(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserIDFromContext はコンテキストから認証済みユーザーIDを取得します
+func UserIDFromContext(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(contextKey{}).(uint)
+	return userID, ok && userID != 0
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SignURL はパスに有効期限と署名のクエリを付けた署名付きURLのパスを返します
+// ダウンロードリンクのように、Authorizationヘッダーを付けられないリクエスト用です
+func SignURL(secret, path string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	query := url.Values{
+		"expires":   {expires},
+		"signature": {sign(secret, path+"?expires="+expires)},
+	}
+	return path + "?" + query.Encode()
+}
+
+// VerifySignedURL はSignURLで作成したURLの署名と有効期限を検証します
+func VerifySignedURL(secret, path string, query url.Values) error {
+	expires := query.Get("expires")
+	signature := query.Get("signature")
+	if expires == "" || signature == "" {
+		return ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, path+"?expires="+expires))) {
+		return ErrInvalidToken
+	}
+
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if time.Now().After(time.Unix(exp, 0)) {
+		return ErrTokenExpired
+	}
+	return nil
+}
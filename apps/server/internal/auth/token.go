@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token has expired")
+)
+
+// TokenClaims は署名付きトークンに含まれる情報です
+type TokenClaims struct {
+	Purpose   string
+	UserID    uint
+	ExpiresAt time.Time
+	Nonce     string
+}
+
+// SignToken は用途・ユーザーID・有効期限を含むHMAC-SHA256署名付きトークンを発行します
+// 形式: base64url(purpose:userID:expiresAt:nonce).base64url(signature)
+func SignToken(secret, purpose string, userID uint, expiresAt time.Time) (string, error) {
+	if secret == "" {
+		return "", errors.New("token secret is not configured")
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	payload := fmt.Sprintf("%s:%d:%d:%s", purpose, userID, expiresAt.Unix(), base64.RawURLEncoding.EncodeToString(nonce))
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	return encoded + "." + sign(secret, encoded), nil
+}
+
+// VerifyToken は署名・用途・有効期限を検証してクレームを返します
+// 使い捨てかどうかの確認は呼び出し側（DB）で行います
+func VerifyToken(secret, token, purpose string) (*TokenClaims, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || encoded == "" || signature == "" {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, encoded))) {
+		return nil, ErrInvalidToken
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 || parts[0] != purpose {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || userID == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	exp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	claims := &TokenClaims{
+		Purpose:   parts[0],
+		UserID:    uint(userID),
+		ExpiresAt: time.Unix(exp, 0),
+		Nonce:     parts[3],
+	}
+
+	if time.Now().After(claims.ExpiresAt) {
+		return nil, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// HashToken はDB保存用にトークンをSHA-256でハッシュ化します（トークン自体は保存しない）
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("%x", sum)
+}
+
+func sign(secret, encoded string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
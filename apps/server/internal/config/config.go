@@ -2,9 +2,11 @@ package config
 
 import (
 	"log"
+	"net"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -26,6 +28,97 @@ type Config struct {
 
 	// ログレベル
 	LogLevel string
+
+	// アプリのURL（メール内リンク用）
+	AppBaseURL string
+
+	// メール設定
+	MailerDriver  string // smtp / file / memory
+	MailFrom      string
+	MailOutboxDir string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+
+	// メール確認・パスワードリセットトークンの有効期限
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+
+	// ログインセッションの有効期限
+	SessionTTL time.Duration
+
+	// ログイン失敗時のロックアウト設定
+	LoginMaxFailures      int // アカウント単位
+	LoginIPMaxFailures    int // IP単位
+	LoginFailureWindow    time.Duration
+	LoginLockoutDuration  time.Duration
+	LoginHistoryRetention time.Duration // ログイン試行・ロックアウトの記録の保持期間
+
+	// GraphQLクエリの制限（0の場合は無制限）
+	MaxQueryDepth       int
+	MaxQueryComplexity  int
+	DefaultListSize     int // limit引数がないリストの想定件数（複雑度計算用）
+	MaxRequestBodyBytes int
+
+	// 永続化クエリ設定
+	PersistedQueryMode    string        // off / apq / allowlist
+	PersistedQueryBackend string        // postgres / memory
+	PersistedQueryAPQTTL  time.Duration // APQで登録したクエリの有効期限（マニフェストは無期限）
+
+	// サブスクリプション（WebSocket）設定
+	PubSubBackend        string        // memory / postgres（複数インスタンス構成ではpostgres）
+	WebSocketInitTimeout time.Duration // connection_initを待つ時間
+	WebSocketKeepAlive   time.Duration // pingの送信間隔
+
+	// トレンドの集計設定
+	TrendingRefreshInterval time.Duration // 集計ジョブの実行間隔
+	TrendingMinAccounts     int           // トレンドになるために必要なアカウント数
+
+	// ホームタイムラインの設定
+	TimelineFanoutMaxFollowers int // フォロワーがこれ以上のアカウントは書き込み時に配信せず読み込み時に取得する（0で常に配信）
+	TimelineBackfillLimit      int // フォローした時にタイムラインに追加する投稿・リポストのそれぞれの件数
+
+	// ファイル保存設定
+	StorageDriver string // local / memory
+	StorageDir    string
+	PublicBaseURL string // APIサーバーの公開URL（署名付きダウンロードURL用）
+
+	// データエクスポートの設定
+	DataExportRetention time.Duration // 作成したファイルを保持する期間
+	DataExportURLTTL    time.Duration // ダウンロードURLの有効期限
+
+	// 退会の設定
+	AccountDeactivationPeriod time.Duration // 退会を取り消せる猶予期間（過ぎると個人情報を削除する）
+	AccountPurgeInterval      time.Duration // 猶予期間を過ぎたアカウントの削除ジョブの実行間隔
+
+	// バックグラウンドジョブの設定
+	JobBackend        string        // postgres / memory（memoryは再起動でジョブが失われるためテスト用）
+	JobWorkers        int           // 同時に実行するジョブの数
+	JobPollInterval   time.Duration // 実行できるジョブがないときに待つ時間
+	JobMaxAttempts    int           // 失敗したジョブを再試行する最大回数（超えるとデッドレター）
+	JobRetryBaseDelay time.Duration // 1回目の失敗後の待ち時間（失敗するたびに2倍）
+	JobRetryMaxDelay  time.Duration // 再試行の待ち時間の上限
+	JobLockTimeout    time.Duration // 実行中のまま止まったジョブを戻すまでの時間
+	JobRetention      time.Duration // 完了したジョブを保持する期間
+
+	// Idempotency-Key設定
+	IdempotencyKeyTTL         time.Duration // ミューテーションの結果を保存し、同じキーの再送に返す期間
+	IdempotencyKeyLockTimeout time.Duration // 実行中のまま応答しなかったキーを再実行できるようにするまでの時間
+
+	// レート制限設定
+	RateLimitEnabled bool
+	RateLimitBackend string               // memory / postgres
+	RateLimits       map[string]RateLimit // 操作名ごとの上限（"*" はその他のミューテーション）
+	// 転送ヘッダー（X-Forwarded-For・X-Real-IP）を信頼するプロキシのアドレス範囲
+	// 空の場合はヘッダーを無視し、接続元のアドレスをクライアントIPとする
+	TrustedProxies []*net.IPNet
+}
+
+// RateLimit は一定期間あたりのリクエスト上限です
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
 }
 
 // Load は環境変数から設定を読み込みます
@@ -43,6 +136,80 @@ func Load() *Config {
 		JWTSecret:       getEnv("JWT_SECRET", "default-secret-key-change-in-production"),
 		CORSOrigins:     getCORSOrigins(),
 		LogLevel:        getEnv("LOG_LEVEL", "info"),
+
+		AppBaseURL: getEnv("APP_BASE_URL", "http://localhost:3000"),
+
+		MailerDriver:  getEnv("MAILER_DRIVER", "file"),
+		MailFrom:      getEnv("MAIL_FROM", "noreply@sns.local"),
+		MailOutboxDir: getEnv("MAIL_OUTBOX_DIR", "tmp/mail"),
+		SMTPHost:      getEnv("SMTP_HOST", ""),
+		SMTPPort:      getEnvAsInt("SMTP_PORT", 587),
+		SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+
+		EmailVerificationTTL: getEnvAsDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		PasswordResetTTL:     getEnvAsDuration("PASSWORD_RESET_TTL", time.Hour),
+
+		SessionTTL: getEnvAsDuration("SESSION_TTL", 30*24*time.Hour),
+
+		LoginMaxFailures:      getEnvAsInt("LOGIN_MAX_FAILURES", 10),
+		LoginIPMaxFailures:    getEnvAsInt("LOGIN_IP_MAX_FAILURES", 50),
+		LoginFailureWindow:    getEnvAsDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockoutDuration:  getEnvAsDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginHistoryRetention: getEnvAsDuration("LOGIN_HISTORY_RETENTION", 30*24*time.Hour),
+
+		MaxQueryDepth:       getEnvAsInt("MAX_QUERY_DEPTH", 10),
+		MaxQueryComplexity:  getEnvAsInt("MAX_QUERY_COMPLEXITY", 5000),
+		DefaultListSize:     getEnvAsInt("DEFAULT_LIST_SIZE", 20),
+		MaxRequestBodyBytes: getEnvAsInt("MAX_REQUEST_BODY_BYTES", 1<<20),
+
+		PersistedQueryMode:    getEnv("PERSISTED_QUERY_MODE", "apq"),
+		PersistedQueryBackend: getEnv("PERSISTED_QUERY_BACKEND", "postgres"),
+		PersistedQueryAPQTTL:  getEnvAsDuration("PERSISTED_QUERY_APQ_TTL", 30*24*time.Hour),
+
+		PubSubBackend:        getEnv("PUBSUB_BACKEND", "memory"),
+		WebSocketInitTimeout: getEnvAsDuration("WEBSOCKET_INIT_TIMEOUT", 10*time.Second),
+		WebSocketKeepAlive:   getEnvAsDuration("WEBSOCKET_KEEPALIVE", 30*time.Second),
+
+		TrendingRefreshInterval: getEnvAsDuration("TRENDING_REFRESH_INTERVAL", 5*time.Minute),
+		TrendingMinAccounts:     getEnvAsInt("TRENDING_MIN_ACCOUNTS", 3),
+
+		TimelineFanoutMaxFollowers: getEnvAsInt("TIMELINE_FANOUT_MAX_FOLLOWERS", 10000),
+		TimelineBackfillLimit:      getEnvAsInt("TIMELINE_BACKFILL_LIMIT", 200),
+
+		StorageDriver: getEnv("STORAGE_DRIVER", "local"),
+		StorageDir:    getEnv("STORAGE_DIR", "tmp/storage"),
+		PublicBaseURL: getEnv("PUBLIC_BASE_URL", "http://localhost:8080"),
+
+		DataExportRetention: getEnvAsDuration("DATA_EXPORT_RETENTION", 7*24*time.Hour),
+		DataExportURLTTL:    getEnvAsDuration("DATA_EXPORT_URL_TTL", 15*time.Minute),
+
+		AccountDeactivationPeriod: getEnvAsDuration("ACCOUNT_DEACTIVATION_PERIOD", 30*24*time.Hour),
+		AccountPurgeInterval:      getEnvAsDuration("ACCOUNT_PURGE_INTERVAL", time.Hour),
+
+		JobBackend:        getEnv("JOB_BACKEND", "postgres"),
+		JobWorkers:        getEnvAsInt("JOB_WORKERS", 4),
+		JobPollInterval:   getEnvAsDuration("JOB_POLL_INTERVAL", time.Second),
+		JobMaxAttempts:    getEnvAsInt("JOB_MAX_ATTEMPTS", 5),
+		JobRetryBaseDelay: getEnvAsDuration("JOB_RETRY_BASE_DELAY", 30*time.Second),
+		JobRetryMaxDelay:  getEnvAsDuration("JOB_RETRY_MAX_DELAY", time.Hour),
+		JobLockTimeout:    getEnvAsDuration("JOB_LOCK_TIMEOUT", 10*time.Minute),
+		JobRetention:      getEnvAsDuration("JOB_RETENTION", 7*24*time.Hour),
+
+		IdempotencyKeyTTL:         getEnvAsDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		IdempotencyKeyLockTimeout: getEnvAsDuration("IDEMPOTENCY_KEY_LOCK_TIMEOUT", time.Minute),
+
+		RateLimitEnabled: getEnvAsBool("RATE_LIMIT_ENABLED", true),
+		RateLimitBackend: getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimits: map[string]RateLimit{
+			"login":                getEnvAsRateLimit("RATE_LIMIT_LOGIN", RateLimit{Requests: 10, Window: 15 * time.Minute}),
+			"register":             getEnvAsRateLimit("RATE_LIMIT_REGISTER", RateLimit{Requests: 5, Window: time.Hour}),
+			"requestPasswordReset": getEnvAsRateLimit("RATE_LIMIT_PASSWORD_RESET", RateLimit{Requests: 5, Window: time.Hour}),
+			"createPost":           getEnvAsRateLimit("RATE_LIMIT_CREATE_POST", RateLimit{Requests: 50, Window: time.Hour}),
+			"requestDataExport":    getEnvAsRateLimit("RATE_LIMIT_DATA_EXPORT", RateLimit{Requests: 3, Window: 24 * time.Hour}),
+			"*":                    getEnvAsRateLimit("RATE_LIMIT_MUTATION", RateLimit{Requests: 120, Window: time.Minute}),
+		},
+		TrustedProxies: getEnvAsCIDRs("TRUSTED_PROXIES"),
 	}
 
 	// 必須設定の検証
@@ -67,6 +234,9 @@ func LoadTest() *Config {
 	config.Port = getEnv("PORT", "8081")
 	config.DatabaseURL = config.TestDatabaseURL
 	config.LogLevel = getEnv("LOG_LEVEL", "debug")
+	config.MailerDriver = getEnv("MAILER_DRIVER", "memory")
+	config.StorageDriver = getEnv("STORAGE_DRIVER", "memory")
+	config.JobBackend = getEnv("JOB_BACKEND", "memory")
 
 	return config
 }
@@ -114,6 +284,63 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvAsDuration は環境変数を時間（例: "30m", "24h"）として取得します
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsRateLimit は環境変数を "回数/期間"（例: "10/15m"）形式のレート制限として取得します
+func getEnvAsRateLimit(key string, defaultValue RateLimit) RateLimit {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	count, window, ok := strings.Cut(value, "/")
+	if !ok {
+		log.Printf("Warning: invalid rate limit %s=%q, using default", key, value)
+		return defaultValue
+	}
+
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		log.Printf("Warning: invalid rate limit %s=%q, using default", key, value)
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		log.Printf("Warning: invalid rate limit %s=%q, using default", key, value)
+		return defaultValue
+	}
+
+	return RateLimit{Requests: requests, Window: duration}
+}
+
+// getEnvAsCIDRs は環境変数をカンマ区切りのCIDR（例: "10.0.0.0/8,192.168.1.10/32"）として取得します
+// 不正な値は警告して無視します
+func getEnvAsCIDRs(key string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			log.Printf("Warning: invalid CIDR in %s: %q", key, value)
+			continue
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
 // getCORSOrigins はCORS設定を取得します
 func getCORSOrigins() []string {
 	origins := getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:19000")
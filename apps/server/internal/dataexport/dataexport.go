@@ -0,0 +1,346 @@
+// dataexportはユーザーの個人データをJSONファイルのZIPにまとめて保存します
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/jobs"
+	"sns-server/internal/models"
+	"sns-server/internal/storage"
+)
+
+// Exporter はエクスポートを作成し、保持期間を過ぎたファイルを削除します
+type Exporter struct {
+	db        *gorm.DB
+	store     storage.Storage
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewExporter はExporterを作成します
+func NewExporter(db *gorm.DB, store storage.Storage, retention time.Duration) *Exporter {
+	return &Exporter{db: db, store: store, retention: retention, now: time.Now}
+}
+
+// バックグラウンドジョブの種類
+const (
+	JobType        = "data_export.build"   // エクスポートを作成する（ペイロードはJobPayload）
+	CleanupJobType = "data_export.cleanup" // 保持期間を過ぎたファイルを削除する
+)
+
+// JobPayload はエクスポートを作成するジョブのペイロードです
+type JobPayload struct {
+	ExportID uint `json:"exportId"`
+}
+
+// HandleJob はエクスポートを作成するジョブのハンドラーです
+// 最後の試行でも失敗した場合はエクスポートをFAILEDにします
+func (e *Exporter) HandleJob(ctx context.Context, job *models.Job) error {
+	var payload JobPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	err := e.Process(payload.ExportID)
+	if err != nil && (job.IsFinalAttempt() || errors.Is(err, gorm.ErrRecordNotFound)) {
+		e.db.Model(&models.DataExport{}).Where("id = ?", payload.ExportID).Updates(map[string]interface{}{
+			"status": models.DataExportStatusFailed,
+			"error":  err.Error(),
+		})
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// Process はエクスポートを作成します。作成待ちでも作成中（前回の試行が失敗した）でもないものは何もしません
+func (e *Exporter) Process(exportID uint) error {
+	var export models.DataExport
+	if err := e.db.First(&export, exportID).Error; err != nil {
+		return err
+	}
+
+	switch export.Status {
+	case models.DataExportStatusPending:
+		claimed, err := models.ClaimDataExport(e.db, &export)
+		if err != nil || !claimed {
+			return err // 他のワーカーが処理中
+		}
+	case models.DataExportStatusProcessing:
+		// 再試行（ジョブは同時に1つのワーカーしか実行しない）
+	default:
+		return nil
+	}
+
+	return e.process(&export)
+}
+
+// process はZIPを作成して保存し、エクスポートをダウンロード可能にします
+func (e *Exporter) process(export *models.DataExport) error {
+	data, err := e.Build(export.UserID)
+	if err != nil {
+		return err
+	}
+
+	key := archiveKey(export)
+	size, err := e.store.Put(key, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	now := e.now()
+	return e.db.Model(export).Updates(map[string]interface{}{
+		"status":       models.DataExportStatusCompleted,
+		"storage_key":  key,
+		"size_bytes":   size,
+		"completed_at": now,
+		"expires_at":   now.Add(e.retention),
+	}).Error
+}
+
+// CleanupExpired は保持期間を過ぎたファイルを削除してEXPIREDにし、件数を返します
+func (e *Exporter) CleanupExpired() (int, error) {
+	var expired []models.DataExport
+	err := e.db.Where("status = ? AND expires_at <= ?", models.DataExportStatusCompleted, e.now()).Find(&expired).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i, export := range expired {
+		if err := e.store.Delete(export.StorageKey); err != nil {
+			return i, fmt.Errorf("failed to delete archive of export %d: %w", export.ID, err)
+		}
+		if err := e.db.Model(&export).Update("status", models.DataExportStatusExpired).Error; err != nil {
+			return i, err
+		}
+	}
+	return len(expired), nil
+}
+
+// PurgeUser は退会したユーザーのエクスポートのファイルを削除します（models.PurgeDeactivatedUsersのフック）
+// エクスポートの行は個人情報の削除と一緒に削除される
+func (e *Exporter) PurgeUser(tx *gorm.DB, user *models.User) error {
+	var exports []models.DataExport
+	if err := tx.Where("user_id = ?", user.ID).Find(&exports).Error; err != nil {
+		return err
+	}
+
+	for _, export := range exports {
+		// 保存後に状態を更新できなかったファイルも残さないよう、保存先のキーに関わらず削除する
+		keys := []string{archiveKey(&export)}
+		if export.StorageKey != "" && export.StorageKey != keys[0] {
+			keys = append(keys, export.StorageKey)
+		}
+		for _, key := range keys {
+			if err := e.store.Delete(key); err != nil {
+				return fmt.Errorf("failed to delete archive of export %d: %w", export.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// archiveKey はエクスポートのZIPの保存先のキーを返します
+func archiveKey(export *models.DataExport) string {
+	return fmt.Sprintf("exports/%d/%d.zip", export.UserID, export.ID)
+}
+
+// HandleCleanupJob は保持期間を過ぎたファイルを削除するジョブのハンドラーです
+func (e *Exporter) HandleCleanupJob(ctx context.Context, job *models.Job) error {
+	cleaned, err := e.CleanupExpired()
+	if cleaned > 0 {
+		log.Printf("Cleaned up %d expired data exports", cleaned)
+	}
+	return err
+}
+
+// ZIP内のファイルの形式
+type profileFile struct {
+	ID            uint      `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	Bio           string    `json:"bio"`
+	Avatar        string    `json:"avatar"`
+	EmailVerified bool      `json:"emailVerified"`
+	IsPrivate     bool      `json:"isPrivate"`
+	Role          string    `json:"role"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type postRecord struct {
+	ID           uint      `json:"id"`
+	Content      string    `json:"content"`
+	ParentID     *uint     `json:"parentId"`
+	QuotedPostID *uint     `json:"quotedPostId"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type likeRecord struct {
+	PostID    uint      `json:"postId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type followRecord struct {
+	UserID    uint      `json:"userId"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type followsFile struct {
+	Following []followRecord `json:"following"`
+	Followers []followRecord `json:"followers"`
+}
+
+type notificationRecord struct {
+	ID         uint       `json:"id"`
+	Type       string     `json:"type"`
+	PostID     *uint      `json:"postId"`
+	Actors     []string   `json:"actors"` // 行為者のユーザー名（退会したユーザーを除く）
+	ActorCount int        `json:"actorCount"`
+	ReadAt     *time.Time `json:"readAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+// Build はユーザーのプロフィール・投稿・いいね・フォロー・通知をJSONファイルのZIPにまとめます
+func (e *Exporter) Build(userID uint) ([]byte, error) {
+	var user models.User
+	if err := e.db.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	files := []struct {
+		name  string
+		build func() (interface{}, error)
+	}{
+		{"profile.json", func() (interface{}, error) { return profileOf(&user), nil }},
+		{"posts.json", func() (interface{}, error) { return e.posts(userID) }},
+		{"likes.json", func() (interface{}, error) { return e.likes(userID) }},
+		{"follows.json", func() (interface{}, error) { return e.follows(userID) }},
+		{"notifications.json", func() (interface{}, error) { return e.notifications(userID) }},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, f := range files {
+		content, err := f.build()
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect %s: %w", f.name, err)
+		}
+
+		w, err := archive.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func profileOf(user *models.User) profileFile {
+	return profileFile{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Name:          user.Name,
+		Bio:           user.Bio,
+		Avatar:        user.Avatar,
+		EmailVerified: user.EmailVerified,
+		IsPrivate:     user.IsPrivate,
+		Role:          user.Role,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+func (e *Exporter) posts(userID uint) ([]postRecord, error) {
+	records := []postRecord{}
+	err := e.db.Model(&models.Post{}).
+		Select("id, content, parent_id, quoted_post_id, created_at, updated_at").
+		Where("author_id = ?", userID).
+		Order("id").
+		Scan(&records).Error
+	return records, err
+}
+
+func (e *Exporter) likes(userID uint) ([]likeRecord, error) {
+	records := []likeRecord{}
+	err := e.db.Model(&models.Like{}).
+		Select("post_id, created_at").
+		Where("user_id = ?", userID).
+		Order("id").
+		Scan(&records).Error
+	return records, err
+}
+
+func (e *Exporter) follows(userID uint) (followsFile, error) {
+	file := followsFile{Following: []followRecord{}, Followers: []followRecord{}}
+
+	err := e.db.Model(&models.Follow{}).
+		Select("users.id AS user_id, users.username, follows.created_at").
+		Joins("JOIN users ON users.id = follows.followee_id AND users.deleted_at IS NULL").
+		Where("follows.follower_id = ?", userID).
+		Order("follows.id").
+		Scan(&file.Following).Error
+	if err != nil {
+		return file, err
+	}
+
+	err = e.db.Model(&models.Follow{}).
+		Select("users.id AS user_id, users.username, follows.created_at").
+		Joins("JOIN users ON users.id = follows.follower_id AND users.deleted_at IS NULL").
+		Where("follows.followee_id = ?", userID).
+		Order("follows.id").
+		Scan(&file.Followers).Error
+	return file, err
+}
+
+func (e *Exporter) notifications(userID uint) ([]notificationRecord, error) {
+	var notifications []models.Notification
+	err := e.db.Preload("Actors", func(db *gorm.DB) *gorm.DB { return db.Order("created_at") }).
+		Preload("Actors.Actor").
+		Where("user_id = ?", userID).
+		Order("id").
+		Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]notificationRecord, 0, len(notifications))
+	for _, n := range notifications {
+		actors := []string{}
+		for _, a := range n.Actors {
+			if a.Actor.ID != 0 {
+				actors = append(actors, a.Actor.Username)
+			}
+		}
+		records = append(records, notificationRecord{
+			ID:         n.ID,
+			Type:       n.Type,
+			PostID:     n.PostID,
+			Actors:     actors,
+			ActorCount: n.ActorCount,
+			ReadAt:     n.ReadAt,
+			CreatedAt:  n.CreatedAt,
+			UpdatedAt:  n.UpdatedAt,
+		})
+	}
+	return records, nil
+}
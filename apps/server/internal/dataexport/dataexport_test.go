@@ -0,0 +1,223 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+	"sns-server/internal/storage"
+)
+
+func setupTestExporter(t *testing.T) (*Exporter, *gorm.DB, *storage.MemoryStorage) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Post{}, &models.Like{}, &models.Follow{}, &models.PostEntity{},
+		&models.Notification{}, &models.NotificationActor{}, &models.DataExport{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	store := storage.NewMemoryStorage()
+	return NewExporter(db, store, 7*24*time.Hour), db, store
+}
+
+func createUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+	user := &models.User{Username: username, Email: username + "@example.com", Password: "password", Name: username}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+// readArchive はZIP内のファイルを名前ごとに返します
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+
+	files := map[string][]byte{}
+	for _, f := range archive.File {
+		r, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(r)
+		r.Close()
+	}
+	return files
+}
+
+func TestExporter_Build(t *testing.T) {
+	exporter, db, _ := setupTestExporter(t)
+
+	alice := createUser(t, db, "alice")
+	bob := createUser(t, db, "bob")
+
+	post := &models.Post{Content: "Hello from alice", AuthorID: alice.ID}
+	db.Create(post)
+	deleted := &models.Post{Content: "Deleted post", AuthorID: alice.ID}
+	db.Create(deleted)
+	db.Delete(deleted)
+	bobPost := &models.Post{Content: "Hello from bob", AuthorID: bob.ID}
+	db.Create(bobPost)
+
+	db.Create(&models.Like{UserID: alice.ID, PostID: bobPost.ID})
+	db.Create(&models.Follow{FollowerID: alice.ID, FolloweeID: bob.ID})
+	models.RecordNotification(db, alice.ID, bob.ID, models.NotificationTypeFollow, nil)
+
+	data, err := exporter.Build(alice.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	files := readArchive(t, data)
+
+	for _, name := range []string{"profile.json", "posts.json", "likes.json", "follows.json", "notifications.json"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected %s in archive", name)
+		}
+	}
+
+	var profile profileFile
+	json.Unmarshal(files["profile.json"], &profile)
+	if profile.Username != "alice" || profile.Email != "alice@example.com" {
+		t.Errorf("Unexpected profile: %+v", profile)
+	}
+
+	var posts []postRecord
+	json.Unmarshal(files["posts.json"], &posts)
+	if len(posts) != 1 || posts[0].Content != "Hello from alice" {
+		t.Errorf("Expected only alice's remaining post, got %+v", posts)
+	}
+
+	var likes []likeRecord
+	json.Unmarshal(files["likes.json"], &likes)
+	if len(likes) != 1 || likes[0].PostID != bobPost.ID {
+		t.Errorf("Unexpected likes: %+v", likes)
+	}
+
+	var follows followsFile
+	json.Unmarshal(files["follows.json"], &follows)
+	if len(follows.Following) != 1 || follows.Following[0].Username != "bob" || len(follows.Followers) != 0 {
+		t.Errorf("Unexpected follows: %+v", follows)
+	}
+
+	var notifications []notificationRecord
+	json.Unmarshal(files["notifications.json"], &notifications)
+	if len(notifications) != 1 || len(notifications[0].Actors) != 1 || notifications[0].Actors[0] != "bob" {
+		t.Errorf("Unexpected notifications: %+v", notifications)
+	}
+}
+
+func TestExporter_HandleJob(t *testing.T) {
+	exporter, db, store := setupTestExporter(t)
+
+	alice := createUser(t, db, "alice")
+	export := &models.DataExport{UserID: alice.ID}
+	db.Create(export)
+	missing := &models.DataExport{UserID: 999}
+	db.Create(missing)
+
+	handle := func(exportID uint, attempts int) error {
+		payload, _ := json.Marshal(JobPayload{ExportID: exportID})
+		return exporter.HandleJob(context.Background(), &models.Job{Payload: string(payload), Attempts: attempts, MaxAttempts: 3})
+	}
+
+	if err := handle(export.ID, 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	db.First(export, export.ID)
+	if export.Status != models.DataExportStatusCompleted || export.SizeBytes == 0 || export.ExpiresAt == nil {
+		t.Fatalf("Expected completed export, got %+v", export)
+	}
+	if !export.IsDownloadable(time.Now()) {
+		t.Error("Expected export to be downloadable")
+	}
+	r, err := store.Open(export.StorageKey)
+	if err != nil {
+		t.Fatalf("Expected archive to be stored: %v", err)
+	}
+	r.Close()
+
+	t.Run("ユーザーが存在しない場合は再試行せずFAILEDにする", func(t *testing.T) {
+		if err := handle(missing.ID, 1); err == nil {
+			t.Fatal("Expected error for missing user")
+		}
+		db.First(missing, missing.ID)
+		if missing.Status != models.DataExportStatusFailed || missing.Error == "" {
+			t.Errorf("Expected export for missing user to fail, got %+v", missing)
+		}
+	})
+
+	t.Run("処理済みのエクスポートは再度処理しない", func(t *testing.T) {
+		completedAt := export.CompletedAt
+		if err := handle(export.ID, 1); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		db.First(export, export.ID)
+		if !export.CompletedAt.Equal(*completedAt) {
+			t.Errorf("Expected export not to be rebuilt")
+		}
+	})
+
+	t.Run("保持期間を過ぎたファイルを削除する", func(t *testing.T) {
+		exporter.now = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }
+
+		cleaned, err := exporter.CleanupExpired()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if cleaned != 1 {
+			t.Errorf("Expected 1 cleaned export, got %d", cleaned)
+		}
+		if store.Len() != 0 {
+			t.Errorf("Expected archive to be deleted, %d remain", store.Len())
+		}
+
+		db.First(export, export.ID)
+		if export.Status != models.DataExportStatusExpired {
+			t.Errorf("Expected EXPIRED, got %s", export.Status)
+		}
+	})
+}
+
+func TestExporter_PurgeUser(t *testing.T) {
+	exporter, db, store := setupTestExporter(t)
+
+	alice := createUser(t, db, "alice")
+	bob := createUser(t, db, "bob")
+	for _, user := range []*models.User{alice, bob} {
+		export := &models.DataExport{UserID: user.ID}
+		db.Create(export)
+		if err := exporter.Process(export.ID); err != nil {
+			t.Fatalf("Failed to build export: %v", err)
+		}
+	}
+	// 保存後に状態を更新できなかったエクスポート
+	processing := &models.DataExport{UserID: alice.ID}
+	db.Create(processing)
+	store.Put(archiveKey(processing), bytes.NewReader([]byte("partial")))
+
+	if err := exporter.PurgeUser(db, alice); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expected only bob's archive to remain, %d remain", store.Len())
+	}
+	var bobExport models.DataExport
+	db.Where("user_id = ?", bob.ID).First(&bobExport)
+	if r, err := store.Open(bobExport.StorageKey); err != nil {
+		t.Errorf("Expected bob's archive to remain: %v", err)
+	} else {
+		r.Close()
+	}
+}
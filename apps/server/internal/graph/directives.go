@@ -0,0 +1,110 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// 権限エラーのエラーコード
+const (
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	CodeForbidden       = "FORBIDDEN"
+)
+
+// HasRoleDirective はフィールドを指定の役割以上のユーザーに制限するディレクティブです
+const HasRoleDirective = "hasRole"
+
+// RoleRequirement はクエリが選択する@hasRoleで制限されたフィールドです
+type RoleRequirement struct {
+	Field string // 例: "Query.reports"
+	Role  string // 例: "MODERATOR"
+}
+
+// RequiredRoles はクエリが選択するフィールドのうち、@hasRoleで制限されたものを返します
+// フラグメント内のフィールドも含め、同じフィールドは1回だけ返します
+func RequiredRoles(query string) ([]RoleRequirement, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil, &QueryError{Code: CodeParseFailed, Message: fmt.Sprintf("Failed to parse query: %v", err)}
+	}
+
+	schema, err := Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &roleCollector{schema: schema, doc: doc, seen: map[string]bool{}}
+	for _, op := range doc.Operations {
+		var root *ast.Definition
+		switch op.Operation {
+		case ast.Mutation:
+			root = schema.Mutation
+		case ast.Subscription:
+			root = schema.Subscription
+		default:
+			root = schema.Query
+		}
+		c.selectionSet(op.SelectionSet, root, map[string]bool{})
+	}
+	return c.requirements, nil
+}
+
+type roleCollector struct {
+	schema       *ast.Schema
+	doc          *ast.QueryDocument
+	seen         map[string]bool
+	requirements []RoleRequirement
+}
+
+// selectionSet は選択セットを辿って制限されたフィールドを集めます
+// 循環するフラグメントや不明なフィールドはCheckQueryLimitsで弾くため、ここでは読み飛ばす
+func (c *roleCollector) selectionSet(set ast.SelectionSet, parent *ast.Definition, fragments map[string]bool) {
+	if parent == nil {
+		return
+	}
+
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			def := parent.Fields.ForName(sel.Name)
+			if def == nil {
+				continue
+			}
+			if directive := def.Directives.ForName(HasRoleDirective); directive != nil {
+				if arg := directive.Arguments.ForName("role"); arg != nil && arg.Value != nil {
+					c.add(parent.Name+"."+def.Name, arg.Value.Raw)
+				}
+			}
+			c.selectionSet(sel.SelectionSet, c.schema.Types[def.Type.Name()], fragments)
+
+		case *ast.FragmentSpread:
+			fragment := c.doc.Fragments.ForName(sel.Name)
+			if fragment == nil || fragments[sel.Name] {
+				continue
+			}
+			fragments[sel.Name] = true
+			c.selectionSet(fragment.SelectionSet, c.typeOrParent(fragment.TypeCondition, parent), fragments)
+			delete(fragments, sel.Name)
+
+		case *ast.InlineFragment:
+			c.selectionSet(sel.SelectionSet, c.typeOrParent(sel.TypeCondition, parent), fragments)
+		}
+	}
+}
+
+func (c *roleCollector) add(field, role string) {
+	if c.seen[field] {
+		return
+	}
+	c.seen[field] = true
+	c.requirements = append(c.requirements, RoleRequirement{Field: field, Role: role})
+}
+
+func (c *roleCollector) typeOrParent(name string, parent *ast.Definition) *ast.Definition {
+	if name == "" {
+		return parent
+	}
+	return c.schema.Types[name]
+}
@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRequiredRoles(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected []RoleRequirement
+	}{
+		{
+			name:     "制限のないフィールド",
+			query:    `{ me { id username role } posts { id } }`,
+			expected: nil,
+		},
+		{
+			name:     "モデレーターに制限されたクエリ",
+			query:    `{ reports { reports { id reason } } }`,
+			expected: []RoleRequirement{{Field: "Query.reports", Role: "MODERATOR"}},
+		},
+		{
+			name:     "管理者に制限されたミューテーション",
+			query:    `mutation { setUserRole(userId: "1", role: MODERATOR) { id role } }`,
+			expected: []RoleRequirement{{Field: "Mutation.setUserRole", Role: "ADMIN"}},
+		},
+		{
+			name:     "フラグメント内のフィールド",
+			query:    `query { ...Queue } fragment Queue on Query { moderationActions(targetType: POST, targetId: "1") { id } }`,
+			expected: []RoleRequirement{{Field: "Query.moderationActions", Role: "MODERATOR"}},
+		},
+		{
+			name:     "インラインフラグメント内のフィールド",
+			query:    `mutation { ... on Mutation { hidePost(postId: "1", reason: "spam") { id } } }`,
+			expected: []RoleRequirement{{Field: "Mutation.hidePost", Role: "MODERATOR"}},
+		},
+		{
+			name:     "エイリアスで複数回選択しても1回だけ返す",
+			query:    `{ open: reports(status: OPEN) { cursor } closed: reports(status: RESOLVED) { cursor } }`,
+			expected: []RoleRequirement{{Field: "Query.reports", Role: "MODERATOR"}},
+		},
+		{
+			name:  "複数の制限されたフィールド",
+			query: `mutation { suspendUser(userId: "2", reason: "spam") { id } setUserRole(userId: "3", role: USER) { id } }`,
+			expected: []RoleRequirement{
+				{Field: "Mutation.suspendUser", Role: "MODERATOR"},
+				{Field: "Mutation.setUserRole", Role: "ADMIN"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requirements, err := RequiredRoles(tt.query)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(requirements, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, requirements)
+			}
+		})
+	}
+}
+
+func TestRequiredRoles_ParseError(t *testing.T) {
+	_, err := RequiredRoles(`{ reports { `)
+
+	var queryErr *QueryError
+	if !errors.As(err, &queryErr) || queryErr.Code != CodeParseFailed {
+		t.Errorf("Expected parse error, got %v", err)
+	}
+}
@@ -0,0 +1,294 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// クエリ拒否時のエラーコード
+const (
+	CodeParseFailed     = "GRAPHQL_PARSE_FAILED"
+	CodeQueryTooDeep    = "QUERY_TOO_DEEP"
+	CodeQueryTooComplex = "QUERY_TOO_COMPLEX"
+)
+
+// QueryLimits はクエリの深さ・複雑度の上限です（0の場合は無制限）
+type QueryLimits struct {
+	MaxDepth        int
+	MaxComplexity   int
+	DefaultListSize int // limit引数がないリストフィールドの想定件数
+	MaxListSize     int // limit引数の上限（リゾルバーが返す件数の上限に合わせる）
+}
+
+// QueryStats はクエリの解析結果です
+type QueryStats struct {
+	Depth      int
+	Complexity int
+}
+
+// QueryError はクエリが拒否された理由です
+type QueryError struct {
+	Code       string
+	Message    string
+	Extensions map[string]interface{}
+}
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
+
+// CheckQueryLimits はクエリを解析し、上限を超える場合はQueryErrorを返します
+func CheckQueryLimits(query string, variables map[string]interface{}, limits QueryLimits) (*QueryStats, error) {
+	stats, err := AnalyzeQuery(query, variables, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.MaxDepth > 0 && stats.Depth > limits.MaxDepth {
+		return stats, &QueryError{
+			Code:    CodeQueryTooDeep,
+			Message: fmt.Sprintf("Query depth %d exceeds the maximum allowed depth of %d", stats.Depth, limits.MaxDepth),
+			Extensions: map[string]interface{}{
+				"depth":    stats.Depth,
+				"maxDepth": limits.MaxDepth,
+			},
+		}
+	}
+
+	if limits.MaxComplexity > 0 && stats.Complexity > limits.MaxComplexity {
+		return stats, &QueryError{
+			Code:    CodeQueryTooComplex,
+			Message: fmt.Sprintf("Query complexity %d exceeds the maximum allowed complexity of %d", stats.Complexity, limits.MaxComplexity),
+			Extensions: map[string]interface{}{
+				"complexity":    stats.Complexity,
+				"maxComplexity": limits.MaxComplexity,
+			},
+		}
+	}
+
+	return stats, nil
+}
+
+// AnalyzeQuery はクエリの深さと複雑度を計算します
+// 複雑度は各フィールドを1とし、リスト型のフィールドは子の複雑度にlimit引数（なければDefaultListSize）を掛けます
+// 複数の操作を含む場合は最も重い操作の値を返します
+// 複雑度がMaxComplexityを超えた時点で解析を打ち切るため、超えた場合の値は途中までの値です
+func AnalyzeQuery(query string, variables map[string]interface{}, limits QueryLimits) (*QueryStats, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return nil, &QueryError{Code: CodeParseFailed, Message: fmt.Sprintf("Failed to parse query: %v", err)}
+	}
+
+	schema, err := Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &analyzer{
+		schema:    schema,
+		doc:       doc,
+		variables: variables,
+		limits:    limits,
+		fragments: map[string]fragmentCost{},
+	}
+
+	stats := &QueryStats{}
+	for _, op := range doc.Operations {
+		var root *ast.Definition
+		switch op.Operation {
+		case ast.Mutation:
+			root = schema.Mutation
+		case ast.Subscription:
+			root = schema.Subscription
+		default:
+			root = schema.Query
+		}
+
+		depth, complexity, err := a.selectionSet(op.SelectionSet, root, 1, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+		if depth > stats.Depth {
+			stats.Depth = depth
+		}
+		if complexity > stats.Complexity {
+			stats.Complexity = complexity
+		}
+	}
+
+	return stats, nil
+}
+
+type analyzer struct {
+	schema    *ast.Schema
+	doc       *ast.QueryDocument
+	variables map[string]interface{}
+	limits    QueryLimits
+	fragments map[string]fragmentCost // 解析済みのフラグメント（使うたびに解析し直さない）
+}
+
+// fragmentCost はフラグメントの深さ（フラグメントを使った位置からの段数）と複雑度です
+type fragmentCost struct {
+	depth      int
+	complexity int
+}
+
+// exceeded は複雑度が上限を超えたかを返します（超えた後は解析を続けない）
+func (a *analyzer) exceeded(complexity int) bool {
+	return a.limits.MaxComplexity > 0 && complexity > a.limits.MaxComplexity
+}
+
+// selectionSet は選択セットの最大深さと複雑度を返します
+// スキーマにない型・フィールドも1として数えます（存在チェックは実行時に行う）
+func (a *analyzer) selectionSet(set ast.SelectionSet, parent *ast.Definition, depth int, fragments map[string]bool) (int, int, error) {
+	maxDepth, total := 0, 0
+
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			// __typenameなどのイントロスペクションは数えない
+			if strings.HasPrefix(sel.Name, "__") {
+				continue
+			}
+
+			var def *ast.FieldDefinition
+			var childType *ast.Definition
+			if parent != nil {
+				def = parent.Fields.ForName(sel.Name)
+			}
+			if def != nil {
+				childType = a.schema.Types[def.Type.Name()]
+			}
+
+			childDepth, childComplexity, err := a.selectionSet(sel.SelectionSet, childType, depth+1, fragments)
+			if err != nil {
+				return 0, 0, err
+			}
+
+			multiplier := 1
+			if def != nil && def.Type.Elem != nil {
+				multiplier = a.listSize(sel)
+			}
+
+			fieldDepth := depth
+			if childDepth > fieldDepth {
+				fieldDepth = childDepth
+			}
+			if fieldDepth > maxDepth {
+				maxDepth = fieldDepth
+			}
+			total = saturatingAdd(total, saturatingAdd(1, saturatingMul(multiplier, childComplexity)))
+
+		case *ast.FragmentSpread:
+			cost, err := a.fragmentSpread(sel, parent, fragments)
+			if err != nil {
+				return 0, 0, err
+			}
+			if cost.depth > 0 && depth+cost.depth-1 > maxDepth {
+				maxDepth = depth + cost.depth - 1
+			}
+			total = saturatingAdd(total, cost.complexity)
+
+		case *ast.InlineFragment:
+			fragDepth, fragComplexity, err := a.selectionSet(sel.SelectionSet, a.typeOrParent(sel.TypeCondition, parent), depth, fragments)
+			if err != nil {
+				return 0, 0, err
+			}
+			if fragDepth > maxDepth {
+				maxDepth = fragDepth
+			}
+			total = saturatingAdd(total, fragComplexity)
+		}
+
+		if a.exceeded(total) {
+			return maxDepth, total, nil
+		}
+	}
+
+	return maxDepth, total, nil
+}
+
+// fragmentSpread はフラグメントの深さと複雑度を返します
+// フラグメントの型は使う位置に関わらず型条件で決まるため、名前ごとに一度だけ解析する
+func (a *analyzer) fragmentSpread(sel *ast.FragmentSpread, parent *ast.Definition, fragments map[string]bool) (fragmentCost, error) {
+	if cost, ok := a.fragments[sel.Name]; ok {
+		return cost, nil
+	}
+	if fragments[sel.Name] {
+		return fragmentCost{}, &QueryError{Code: CodeParseFailed, Message: fmt.Sprintf("Fragment %q is cyclic", sel.Name)}
+	}
+	fragment := a.doc.Fragments.ForName(sel.Name)
+	if fragment == nil {
+		return fragmentCost{}, &QueryError{Code: CodeParseFailed, Message: fmt.Sprintf("Unknown fragment %q", sel.Name)}
+	}
+
+	// 深さ1から解析し、使う位置の深さに足す
+	fragments[sel.Name] = true
+	fragDepth, fragComplexity, err := a.selectionSet(fragment.SelectionSet, a.typeOrParent(fragment.TypeCondition, parent), 1, fragments)
+	delete(fragments, sel.Name)
+	if err != nil {
+		return fragmentCost{}, err
+	}
+
+	cost := fragmentCost{depth: fragDepth, complexity: fragComplexity}
+	a.fragments[sel.Name] = cost
+	return cost, nil
+}
+
+func (a *analyzer) typeOrParent(name string, parent *ast.Definition) *ast.Definition {
+	if name == "" {
+		return parent
+	}
+	return a.schema.Types[name]
+}
+
+// listSize はリストフィールドのlimit引数（リテラルまたは変数）をMaxListSize以下に収めて返します
+func (a *analyzer) listSize(field *ast.Field) int {
+	size := a.limits.DefaultListSize
+	if arg := field.Arguments.ForName("limit"); arg != nil && arg.Value != nil {
+		switch arg.Value.Kind {
+		case ast.IntValue:
+			// 範囲外の値はintの上限として扱う
+			if n, err := strconv.Atoi(arg.Value.Raw); err == nil || errors.Is(err, strconv.ErrRange) {
+				size = n
+			}
+		case ast.Variable:
+			switch n := a.variables[arg.Value.Raw].(type) {
+			case float64:
+				size = int(math.Min(n, math.MaxInt32))
+			case int:
+				size = n
+			}
+		}
+	}
+
+	if a.limits.MaxListSize > 0 && size > a.limits.MaxListSize {
+		size = a.limits.MaxListSize
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// saturatingAdd は0以上の値を足します（intの上限を超える場合は上限）
+func saturatingAdd(x, y int) int {
+	if x > math.MaxInt-y {
+		return math.MaxInt
+	}
+	return x + y
+}
+
+// saturatingMul は0以上の値を掛けます（intの上限を超える場合は上限）
+func saturatingMul(x, y int) int {
+	if x != 0 && y > math.MaxInt/x {
+		return math.MaxInt
+	}
+	return x * y
+}
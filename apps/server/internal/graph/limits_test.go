@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchema(t *testing.T) {
+	schema, err := Schema()
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	if schema.Query == nil || schema.Mutation == nil {
+		t.Fatal("Expected Query and Mutation types")
+	}
+}
+
+func TestAnalyzeQuery(t *testing.T) {
+	tests := []struct {
+		name               string
+		query              string
+		variables          map[string]interface{}
+		expectedDepth      int
+		expectedComplexity int
+	}{
+		{
+			name:               "スカラーのみ",
+			query:              `{ me { id username } }`,
+			expectedDepth:      2,
+			expectedComplexity: 3, // me(1) + id(1) + username(1)
+		},
+		{
+			name:               "limit引数のリストは件数を掛ける",
+			query:              `{ posts(limit: 5) { id content } }`,
+			expectedDepth:      2,
+			expectedComplexity: 11, // posts(1) + 5 * (id + content)
+		},
+		{
+			name:               "limitが変数の場合",
+			query:              `query ($n: Int) { users(limit: $n) { id } }`,
+			variables:          map[string]interface{}{"n": float64(3)},
+			expectedDepth:      2,
+			expectedComplexity: 4, // users(1) + 3 * id
+		},
+		{
+			name:               "limitなしのリストはデフォルト件数",
+			query:              `{ users { id } }`,
+			expectedDepth:      2,
+			expectedComplexity: 11, // users(1) + 10 * id
+		},
+		{
+			name:               "ネストしたリストは掛け算",
+			query:              `{ posts(limit: 2) { author { followers { id } } } }`,
+			expectedDepth:      4,
+			expectedComplexity: 1 + 2*(1+(1+10*1)),
+		},
+		{
+			name:               "フラグメントは深さを増やさない",
+			query:              `{ me { ...UserFields } } fragment UserFields on User { id name }`,
+			expectedDepth:      2,
+			expectedComplexity: 3,
+		},
+		{
+			name:               "limitは上限の件数に収める",
+			query:              `{ posts(limit: 1000) { id } }`,
+			expectedDepth:      2,
+			expectedComplexity: 51, // posts(1) + 50 * id
+		},
+		{
+			name:               "ネストしたフラグメントの深さ",
+			query:              `{ me { ...A } } fragment A on User { id posts { ...B } } fragment B on Post { id author { id } }`,
+			expectedDepth:      4,
+			expectedComplexity: 1 + (1 + (1 + 10*(1+(1+1)))),
+		},
+		{
+			name:               "__typenameは数えない",
+			query:              `{ me { __typename id } }`,
+			expectedDepth:      2,
+			expectedComplexity: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := AnalyzeQuery(tt.query, tt.variables, QueryLimits{DefaultListSize: 10, MaxListSize: 50})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if stats.Depth != tt.expectedDepth {
+				t.Errorf("Expected depth %d, got %d", tt.expectedDepth, stats.Depth)
+			}
+			if stats.Complexity != tt.expectedComplexity {
+				t.Errorf("Expected complexity %d, got %d", tt.expectedComplexity, stats.Complexity)
+			}
+		})
+	}
+}
+
+func TestCheckQueryLimits(t *testing.T) {
+	limits := QueryLimits{MaxDepth: 4, MaxComplexity: 100, DefaultListSize: 10, MaxListSize: 50}
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode string
+	}{
+		{
+			name:     "上限内のクエリ",
+			query:    `{ posts(limit: 5) { id author { username } } }`,
+			wantCode: "",
+		},
+		{
+			name:     "深すぎるクエリ",
+			query:    `{ posts { author { posts { replies { author { username } } } } } }`,
+			wantCode: CodeQueryTooDeep,
+		},
+		{
+			name:     "複雑すぎるクエリ",
+			query:    `{ users(limit: 100) { followers(limit: 100) { id } } }`,
+			wantCode: CodeQueryTooComplex,
+		},
+		{
+			name:     "limitが大きすぎても桁あふれしない",
+			query:    `{ posts(limit: 878416384462359601) { replies { id content } } }`,
+			wantCode: CodeQueryTooComplex,
+		},
+		{
+			name:     "変数のlimitが大きすぎても桁あふれしない",
+			query:    `query ($n: Int) { posts(limit: $n) { replies(limit: $n) { id content } } }`,
+			wantCode: CodeQueryTooComplex,
+		},
+		{
+			name:     "構文エラー",
+			query:    `{ posts { id `,
+			wantCode: CodeParseFailed,
+		},
+		{
+			name:     "循環するフラグメント",
+			query:    `{ me { ...A } } fragment A on User { ...B } fragment B on User { ...A }`,
+			wantCode: CodeParseFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CheckQueryLimits(tt.query, map[string]interface{}{"n": float64(1e300)}, limits)
+			if tt.wantCode == "" {
+				if err != nil {
+					t.Errorf("Expected no error but got: %v", err)
+				}
+				return
+			}
+
+			var queryErr *QueryError
+			if !errors.As(err, &queryErr) {
+				t.Fatalf("Expected QueryError, got %v", err)
+			}
+			if queryErr.Code != tt.wantCode {
+				t.Errorf("Expected code %s, got %s", tt.wantCode, queryErr.Code)
+			}
+		})
+	}
+}
+
+func TestCheckQueryLimits_NestedFragments(t *testing.T) {
+	// 各フラグメントが次のフラグメントを何度も使うクエリ（フラグメントを使うたびに解析すると指数時間かかる）
+	var query strings.Builder
+	query.WriteString(`{ me { ...F0 } }`)
+	const levels = 30
+	for i := 0; i < levels; i++ {
+		fmt.Fprintf(&query, " fragment F%d on User { id", i)
+		if i+1 < levels {
+			for j := 0; j < 5; j++ {
+				fmt.Fprintf(&query, " ...F%d", i+1)
+			}
+		}
+		query.WriteString(" }")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := CheckQueryLimits(query.String(), nil, QueryLimits{MaxDepth: 10, MaxComplexity: 1000, DefaultListSize: 10, MaxListSize: 50})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		var queryErr *QueryError
+		if !errors.As(err, &queryErr) || queryErr.Code != CodeQueryTooComplex {
+			t.Errorf("Expected %s, got %v", CodeQueryTooComplex, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected nested fragments to be analyzed quickly")
+	}
+
+	// 上限がなくても、フラグメントは名前ごとに一度だけ解析する
+	stats, err := AnalyzeQuery(query.String(), nil, QueryLimits{DefaultListSize: 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stats.Complexity != math.MaxInt {
+		t.Errorf("Expected saturated complexity, got %d", stats.Complexity)
+	}
+}
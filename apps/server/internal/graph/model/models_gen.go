@@ -3,6 +3,11 @@
 package model
 
 import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
 	"sns-server/internal/models"
 )
 
@@ -11,9 +16,17 @@ type AuthResponse struct {
 	User  *models.User `json:"user"`
 }
 
+type BookmarkList struct {
+	Bookmarks   []*models.Bookmark `json:"bookmarks"`
+	HasNextPage bool               `json:"hasNextPage"`
+	Cursor      *string            `json:"cursor,omitempty"`
+}
+
 type CreatePostInput struct {
-	Content  string  `json:"content"`
-	ParentID *string `json:"parentId,omitempty"`
+	Content      string     `json:"content"`
+	ParentID     *string    `json:"parentId,omitempty"`
+	QuotedPostID *string    `json:"quotedPostId,omitempty"`
+	Poll         *PollInput `json:"poll,omitempty"`
 }
 
 type LoginInput struct {
@@ -24,6 +37,22 @@ type LoginInput struct {
 type Mutation struct {
 }
 
+type NotificationList struct {
+	Notifications []*models.Notification `json:"notifications"`
+	HasNextPage   bool                   `json:"hasNextPage"`
+	Cursor        *string                `json:"cursor,omitempty"`
+}
+
+type PollInput struct {
+	Options   []string  `json:"options"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type PostLikeCount struct {
+	PostID    string `json:"postId"`
+	LikeCount int    `json:"likeCount"`
+}
+
 type Query struct {
 }
 
@@ -35,6 +64,34 @@ type RegisterInput struct {
 	Bio      *string `json:"bio,omitempty"`
 }
 
+type ReportList struct {
+	Reports     []*models.Report `json:"reports"`
+	HasNextPage bool             `json:"hasNextPage"`
+	Cursor      *string          `json:"cursor,omitempty"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
+
+type SaveDraftInput struct {
+	Content      string     `json:"content"`
+	ParentID     *string    `json:"parentId,omitempty"`
+	QuotedPostID *string    `json:"quotedPostId,omitempty"`
+	ScheduledAt  *time.Time `json:"scheduledAt,omitempty"`
+}
+
+type Subscription struct {
+}
+
+type Trending struct {
+	Window     TrendingWindow            `json:"window"`
+	Hashtags   []*models.TrendingHashtag `json:"hashtags"`
+	Posts      []*models.TrendingPost    `json:"posts"`
+	ComputedAt *time.Time                `json:"computedAt,omitempty"`
+}
+
 type Timeline struct {
 	Posts       []*models.Post `json:"posts"`
 	HasNextPage bool           `json:"hasNextPage"`
@@ -42,7 +99,360 @@ type Timeline struct {
 }
 
 type UpdateProfileInput struct {
-	Name   *string `json:"name,omitempty"`
-	Bio    *string `json:"bio,omitempty"`
-	Avatar *string `json:"avatar,omitempty"`
+	Name      *string `json:"name,omitempty"`
+	Bio       *string `json:"bio,omitempty"`
+	Avatar    *string `json:"avatar,omitempty"`
+	IsPrivate *bool   `json:"isPrivate,omitempty"`
+}
+
+type DataExportStatus string
+
+const (
+	DataExportStatusPending    DataExportStatus = "PENDING"
+	DataExportStatusProcessing DataExportStatus = "PROCESSING"
+	DataExportStatusCompleted  DataExportStatus = "COMPLETED"
+	DataExportStatusFailed     DataExportStatus = "FAILED"
+	DataExportStatusExpired    DataExportStatus = "EXPIRED"
+)
+
+var AllDataExportStatus = []DataExportStatus{
+	DataExportStatusPending,
+	DataExportStatusProcessing,
+	DataExportStatusCompleted,
+	DataExportStatusFailed,
+	DataExportStatusExpired,
+}
+
+func (e DataExportStatus) IsValid() bool {
+	switch e {
+	case DataExportStatusPending, DataExportStatusProcessing, DataExportStatusCompleted, DataExportStatusFailed, DataExportStatusExpired:
+		return true
+	}
+	return false
+}
+
+func (e DataExportStatus) String() string {
+	return string(e)
+}
+
+func (e *DataExportStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DataExportStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DataExportStatus", str)
+	}
+	return nil
+}
+
+func (e DataExportStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ModerationActionType string
+
+const (
+	ModerationActionTypeHidePost      ModerationActionType = "HIDE_POST"
+	ModerationActionTypeRestorePost   ModerationActionType = "RESTORE_POST"
+	ModerationActionTypeSuspendUser   ModerationActionType = "SUSPEND_USER"
+	ModerationActionTypeUnsuspendUser ModerationActionType = "UNSUSPEND_USER"
+)
+
+var AllModerationActionType = []ModerationActionType{
+	ModerationActionTypeHidePost,
+	ModerationActionTypeRestorePost,
+	ModerationActionTypeSuspendUser,
+	ModerationActionTypeUnsuspendUser,
+}
+
+func (e ModerationActionType) IsValid() bool {
+	switch e {
+	case ModerationActionTypeHidePost, ModerationActionTypeRestorePost, ModerationActionTypeSuspendUser, ModerationActionTypeUnsuspendUser:
+		return true
+	}
+	return false
+}
+
+func (e ModerationActionType) String() string {
+	return string(e)
+}
+
+func (e *ModerationActionType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ModerationActionType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ModerationActionType", str)
+	}
+	return nil
+}
+
+func (e ModerationActionType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type NotificationType string
+
+const (
+	NotificationTypeLike          NotificationType = "LIKE"
+	NotificationTypeFollow        NotificationType = "FOLLOW"
+	NotificationTypeReply         NotificationType = "REPLY"
+	NotificationTypeMention       NotificationType = "MENTION"
+	NotificationTypeFollowRequest NotificationType = "FOLLOW_REQUEST"
+)
+
+var AllNotificationType = []NotificationType{
+	NotificationTypeLike,
+	NotificationTypeFollow,
+	NotificationTypeReply,
+	NotificationTypeMention,
+	NotificationTypeFollowRequest,
+}
+
+func (e NotificationType) IsValid() bool {
+	switch e {
+	case NotificationTypeLike, NotificationTypeFollow, NotificationTypeReply, NotificationTypeMention, NotificationTypeFollowRequest:
+		return true
+	}
+	return false
+}
+
+func (e NotificationType) String() string {
+	return string(e)
+}
+
+func (e *NotificationType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = NotificationType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid NotificationType", str)
+	}
+	return nil
+}
+
+func (e NotificationType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PostEntityType string
+
+const (
+	PostEntityTypeMention PostEntityType = "MENTION"
+	PostEntityTypeHashtag PostEntityType = "HASHTAG"
+)
+
+var AllPostEntityType = []PostEntityType{
+	PostEntityTypeMention,
+	PostEntityTypeHashtag,
+}
+
+func (e PostEntityType) IsValid() bool {
+	switch e {
+	case PostEntityTypeMention, PostEntityTypeHashtag:
+		return true
+	}
+	return false
+}
+
+func (e PostEntityType) String() string {
+	return string(e)
+}
+
+func (e *PostEntityType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PostEntityType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PostEntityType", str)
+	}
+	return nil
+}
+
+func (e PostEntityType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen      ReportStatus = "OPEN"
+	ReportStatusReviewing ReportStatus = "REVIEWING"
+	ReportStatusResolved  ReportStatus = "RESOLVED"
+	ReportStatusDismissed ReportStatus = "DISMISSED"
+)
+
+var AllReportStatus = []ReportStatus{
+	ReportStatusOpen,
+	ReportStatusReviewing,
+	ReportStatusResolved,
+	ReportStatusDismissed,
+}
+
+func (e ReportStatus) IsValid() bool {
+	switch e {
+	case ReportStatusOpen, ReportStatusReviewing, ReportStatusResolved, ReportStatusDismissed:
+		return true
+	}
+	return false
+}
+
+func (e ReportStatus) String() string {
+	return string(e)
+}
+
+func (e *ReportStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportStatus", str)
+	}
+	return nil
+}
+
+func (e ReportStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportTargetType string
+
+const (
+	ReportTargetTypePost ReportTargetType = "POST"
+	ReportTargetTypeUser ReportTargetType = "USER"
+)
+
+var AllReportTargetType = []ReportTargetType{
+	ReportTargetTypePost,
+	ReportTargetTypeUser,
+}
+
+func (e ReportTargetType) IsValid() bool {
+	switch e {
+	case ReportTargetTypePost, ReportTargetTypeUser:
+		return true
+	}
+	return false
+}
+
+func (e ReportTargetType) String() string {
+	return string(e)
+}
+
+func (e *ReportTargetType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportTargetType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportTargetType", str)
+	}
+	return nil
+}
+
+func (e ReportTargetType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleUser      Role = "USER"
+	RoleModerator Role = "MODERATOR"
+	RoleAdmin     Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleUser,
+	RoleModerator,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TrendingWindow string
+
+const (
+	TrendingWindowHour TrendingWindow = "HOUR"
+	TrendingWindowDay  TrendingWindow = "DAY"
+	TrendingWindowWeek TrendingWindow = "WEEK"
+)
+
+var AllTrendingWindow = []TrendingWindow{
+	TrendingWindowHour,
+	TrendingWindowDay,
+	TrendingWindowWeek,
+}
+
+func (e TrendingWindow) IsValid() bool {
+	switch e {
+	case TrendingWindowHour, TrendingWindowDay, TrendingWindowWeek:
+		return true
+	}
+	return false
+}
+
+func (e TrendingWindow) String() string {
+	return string(e)
+}
+
+func (e *TrendingWindow) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TrendingWindow(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TrendingWindow", str)
+	}
+	return nil
+}
+
+func (e TrendingWindow) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -0,0 +1,23 @@
+package graph
+
+import (
+	_ "embed"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// SchemaSource はGraphQLスキーマの定義です
+//
+//go:embed schema.graphql
+var SchemaSource string
+
+var loadSchema = sync.OnceValues(func() (*ast.Schema, error) {
+	return gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: SchemaSource})
+})
+
+// Schema は解析済みのスキーマを返します（初回呼び出し時に一度だけ解析）
+func Schema() (*ast.Schema, error) {
+	return loadSchema()
+}
@@ -0,0 +1,163 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// HeaderName はキーを指定するリクエストヘッダーです（extensions.idempotencyKeyでも指定できる）
+const HeaderName = "Idempotency-Key"
+
+// ReplayedHeaderName は保存したレスポンスを返したことを示すレスポンスヘッダーです
+const ReplayedHeaderName = "Idempotent-Replayed"
+
+// MaxKeyLength はキーの最大長です
+const MaxKeyLength = 255
+
+// エラーコード
+const (
+	CodeInvalid    = "IDEMPOTENCY_KEY_INVALID"
+	CodeReused     = "IDEMPOTENCY_KEY_REUSED"
+	CodeInProgress = "IDEMPOTENCY_KEY_IN_PROGRESS"
+)
+
+// Error はキーを使えない場合のエラーです
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Policy はIdempotency-Keyの設定です
+type Policy struct {
+	TTL         time.Duration // 完了したレスポンスを保存する期間
+	LockTimeout time.Duration // 実行中のキーを放棄されたとみなすまでの時間
+}
+
+// PolicyFromConfig は設定からPolicyを作成します
+func PolicyFromConfig(cfg *config.Config) Policy {
+	return Policy{
+		TTL:         cfg.IdempotencyKeyTTL,
+		LockTimeout: cfg.IdempotencyKeyLockTimeout,
+	}
+}
+
+// Guard はミューテーションの結果をユーザーとキーごとに保存し、再送に同じ結果を返します
+type Guard struct {
+	db     *gorm.DB
+	policy Policy
+	now    func() time.Time
+}
+
+// NewGuard はGuardを作成します
+func NewGuard(db *gorm.DB, policy Policy) *Guard {
+	return &Guard{db: db, policy: policy, now: time.Now}
+}
+
+// ValidateKey はキーの形式を検証します（空白を含まない表示可能なASCII文字のみ）
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MaxKeyLength {
+		return &Error{Code: CodeInvalid, Message: "Idempotency key must be between 1 and 255 characters"}
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return &Error{Code: CodeInvalid, Message: "Idempotency key must contain only printable ASCII characters"}
+		}
+	}
+	return nil
+}
+
+// RequestHash はクエリ・操作名・変数から、キーの再利用を検出するためのハッシュを作成します
+func RequestHash(query, operationName string, variables map[string]interface{}) (string, error) {
+	// mapはキー順にエンコードされるため、変数の順序が違っても同じハッシュになる
+	data, err := json.Marshal(map[string]interface{}{
+		"query":         query,
+		"operationName": operationName,
+		"variables":     variables,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Begin はキーの使用を開始します
+// 初めてのキーの場合はnilを返し、呼び出し側はミューテーションを実行してCompleteまたはReleaseを呼びます
+// 完了済みのキーの場合は保存したレスポンスを返します
+// 別の内容で使用済み、または実行中の場合は*Errorを返します
+func (g *Guard) Begin(userID uint, key, requestHash string) ([]byte, error) {
+	// 期限切れのレコードを削除した後にもう一度だけ試す
+	for attempt := 0; attempt < 2; attempt++ {
+		now := g.now()
+		entry := models.IdempotencyKey{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: requestHash,
+			Status:      models.IdempotencyKeyInProgress,
+			ExpiresAt:   now.Add(g.policy.LockTimeout),
+		}
+		result := g.db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "key"}},
+			DoNothing: true,
+		}).Create(&entry)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil, nil
+		}
+
+		var existing models.IdempotencyKey
+		err := g.db.Where("user_id = ? AND key = ?", userID, key).First(&existing).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !existing.ExpiresAt.After(now) {
+			if err := g.db.Where("id = ? AND expires_at <= ?", existing.ID, now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, &Error{Code: CodeReused, Message: "Idempotency key was already used with a different request"}
+		}
+		if existing.Status != models.IdempotencyKeyCompleted {
+			return nil, &Error{Code: CodeInProgress, Message: "A request with this idempotency key is already in progress"}
+		}
+		return []byte(existing.Response), nil
+	}
+	return nil, &Error{Code: CodeInProgress, Message: "A request with this idempotency key is already in progress"}
+}
+
+// Complete は実行したミューテーションのレスポンスを保存し、TTLの間は再送に同じレスポンスを返します
+func (g *Guard) Complete(userID uint, key string, response []byte) error {
+	return g.db.Model(&models.IdempotencyKey{}).
+		Where("user_id = ? AND key = ? AND status = ?", userID, key, models.IdempotencyKeyInProgress).
+		Updates(map[string]interface{}{
+			"status":     models.IdempotencyKeyCompleted,
+			"response":   string(response),
+			"expires_at": g.now().Add(g.policy.TTL),
+		}).Error
+}
+
+// Release は実行中のキーを削除し、同じキーで再実行できるようにします（ミューテーションが失敗した場合）
+func (g *Guard) Release(userID uint, key string) error {
+	return g.db.Where("user_id = ? AND key = ? AND status = ?", userID, key, models.IdempotencyKeyInProgress).
+		Delete(&models.IdempotencyKey{}).Error
+}
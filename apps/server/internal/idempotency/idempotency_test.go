@@ -0,0 +1,161 @@
+package idempotency
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+func setupTestGuard(t *testing.T) (*Guard, *gorm.DB, *time.Time) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	current := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewGuard(db, Policy{TTL: time.Hour, LockTimeout: time.Minute})
+	guard.now = func() time.Time { return current }
+
+	return guard, db, &current
+}
+
+func errorCode(err error) string {
+	var idemErr *Error
+	if errors.As(err, &idemErr) {
+		return idemErr.Code
+	}
+	return ""
+}
+
+func TestGuard_Begin(t *testing.T) {
+	guard, _, current := setupTestGuard(t)
+	response := []byte(`{"data":{"createPost":{"id":1}}}`)
+
+	t.Run("初めてのキーは実行を許可する", func(t *testing.T) {
+		replay, err := guard.Begin(1, "key-1", "hash-a")
+		if err != nil || replay != nil {
+			t.Fatalf("Expected new key to be accepted, got %s, %v", replay, err)
+		}
+	})
+
+	t.Run("実行中のキーは拒否する", func(t *testing.T) {
+		_, err := guard.Begin(1, "key-1", "hash-a")
+		if code := errorCode(err); code != CodeInProgress {
+			t.Errorf("Expected %s, got %v", CodeInProgress, err)
+		}
+	})
+
+	t.Run("完了したキーは保存したレスポンスを返す", func(t *testing.T) {
+		if err := guard.Complete(1, "key-1", response); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		replay, err := guard.Begin(1, "key-1", "hash-a")
+		if err != nil || string(replay) != string(response) {
+			t.Errorf("Expected stored response, got %s, %v", replay, err)
+		}
+	})
+
+	t.Run("別の内容での再利用は拒否する", func(t *testing.T) {
+		_, err := guard.Begin(1, "key-1", "hash-b")
+		if code := errorCode(err); code != CodeReused {
+			t.Errorf("Expected %s, got %v", CodeReused, err)
+		}
+	})
+
+	t.Run("キーはユーザーごとに区別する", func(t *testing.T) {
+		replay, err := guard.Begin(2, "key-1", "hash-b")
+		if err != nil || replay != nil {
+			t.Errorf("Expected key to be accepted for another user, got %s, %v", replay, err)
+		}
+	})
+
+	t.Run("TTLを過ぎたキーは再び使える", func(t *testing.T) {
+		*current = current.Add(time.Hour)
+		replay, err := guard.Begin(1, "key-1", "hash-b")
+		if err != nil || replay != nil {
+			t.Errorf("Expected expired key to be accepted, got %s, %v", replay, err)
+		}
+	})
+
+	t.Run("応答しなかった実行中のキーはタイムアウト後に再実行できる", func(t *testing.T) {
+		guard.Begin(1, "key-2", "hash-a")
+		*current = current.Add(time.Minute)
+		replay, err := guard.Begin(1, "key-2", "hash-a")
+		if err != nil || replay != nil {
+			t.Errorf("Expected abandoned key to be accepted, got %s, %v", replay, err)
+		}
+	})
+}
+
+func TestGuard_Release(t *testing.T) {
+	guard, db, _ := setupTestGuard(t)
+
+	guard.Begin(1, "key-1", "hash-a")
+	if err := guard.Release(1, "key-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// 失敗した実行は保存せず、別の内容でも再試行できる
+	replay, err := guard.Begin(1, "key-1", "hash-b")
+	if err != nil || replay != nil {
+		t.Errorf("Expected released key to be accepted, got %s, %v", replay, err)
+	}
+
+	// 完了したキーは削除しない
+	guard.Complete(1, "key-1", []byte(`{}`))
+	guard.Release(1, "key-1")
+	var count int64
+	db.Model(&models.IdempotencyKey{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected completed key to remain, got %d", count)
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"UUID", "5f0c2a4e-8d1b-4c6f-9a7e-2b3d4e5f6a7b", false},
+		{"空のキー", "", true},
+		{"長すぎるキー", strings.Repeat("a", MaxKeyLength+1), true},
+		{"空白を含むキー", "my key", true},
+		{"ASCII以外の文字を含むキー", "キー", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && errorCode(err) != CodeInvalid {
+				t.Errorf("Expected %s, got %v", CodeInvalid, err)
+			}
+		})
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	query := `mutation { createPost(input: $input) { id } }`
+	a, _ := RequestHash(query, "", map[string]interface{}{"input": map[string]interface{}{"content": "hello", "visibility": "PUBLIC"}})
+	b, _ := RequestHash(query, "", map[string]interface{}{"input": map[string]interface{}{"visibility": "PUBLIC", "content": "hello"}})
+	c, _ := RequestHash(query, "", map[string]interface{}{"input": map[string]interface{}{"content": "bye"}})
+
+	if a != b {
+		t.Error("Expected same hash regardless of variable order")
+	}
+	if a == c {
+		t.Error("Expected different hash for different variables")
+	}
+}
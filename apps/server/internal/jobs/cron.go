@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Schedule は定期実行の予定です
+type Schedule interface {
+	// Next はafterより後の次の実行時刻を返します
+	Next(after time.Time) time.Time
+}
+
+// ParseSchedule は定期実行の予定を読み込みます
+//
+//	"@every 5m"      一定間隔（時刻を間隔で切り捨てた境界で実行するため、インスタンス間で揃う）
+//	"@hourly"        毎時0分
+//	"@daily"         毎日0時
+//	"*/15 * * * *"   cron形式（分 時 日 月 曜日、* / , - が使える）
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case strings.HasPrefix(spec, "@every "):
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid interval in schedule %q", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	case spec == "@hourly":
+		spec = "0 * * * *"
+	case spec == "@daily":
+		spec = "0 0 * * *"
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields", spec)
+	}
+
+	var s cronSchedule
+	var err error
+	bounds := []struct {
+		set      *uint64
+		min, max int
+	}{
+		{&s.minute, 0, 59},
+		{&s.hour, 0, 23},
+		{&s.dom, 1, 31},
+		{&s.month, 1, 12},
+		{&s.dow, 0, 7},
+	}
+	for i, b := range bounds {
+		if *b.set, err = parseField(fields[i], b.min, b.max); err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+	}
+
+	// 曜日の7は日曜日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return s, nil
+}
+
+// parseField はcron形式の1つの項目を、該当する値のビット集合にします
+func parseField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value %q out of range %d-%d", part, min, max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Truncate(s.interval).Add(s.interval)
+}
+
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+func (s cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0) // 該当する日付がない予定（2月30日など）で止まらないようにする
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches は日と曜日の条件を判定します（両方指定された場合はどちらかに該当すればよい）
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// scheduledJob は定期実行するジョブです
+type scheduledJob struct {
+	name     string
+	jobType  string
+	payload  interface{}
+	schedule Schedule
+	next     time.Time
+}
+
+// Scheduler は予定の時刻になったジョブをキューに登録します
+// 実行時刻ごとにUniqueKeyを付けるため、複数のインスタンスで動かしてもジョブは1つだけ登録されます
+type Scheduler struct {
+	queue *Queue
+	now   func() time.Time
+
+	mu   sync.Mutex
+	jobs []*scheduledJob
+}
+
+// NewScheduler はSchedulerを作成します
+func NewScheduler(queue *Queue) *Scheduler {
+	return &Scheduler{queue: queue, now: time.Now}
+}
+
+// Add は定期実行するジョブを追加します
+func (s *Scheduler) Add(name, spec, jobType string, payload interface{}) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, &scheduledJob{
+		name:     name,
+		jobType:  jobType,
+		payload:  payload,
+		schedule: schedule,
+		next:     schedule.Next(s.now()),
+	})
+	return nil
+}
+
+// Tick は実行時刻を過ぎたジョブを登録し、登録した件数を返します
+// 停止中に過ぎた実行時刻は、まとめて1回だけ実行します
+func (s *Scheduler) Tick() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	enqueued := 0
+	for _, job := range s.jobs {
+		if job.next.IsZero() || now.Before(job.next) {
+			continue
+		}
+
+		key := fmt.Sprintf("cron:%s:%d", job.name, job.next.Unix())
+		_, err := s.queue.Enqueue(job.jobType, job.payload, Unique(key), At(job.next))
+		if err != nil && !errors.Is(err, ErrDuplicate) {
+			return enqueued, fmt.Errorf("failed to enqueue scheduled job %s: %w", job.name, err)
+		}
+		if err == nil {
+			enqueued++
+		}
+		job.next = job.schedule.Next(now)
+	}
+	return enqueued, nil
+}
+
+// Run はctxが終了するまで毎秒Tickします
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Tick(); err != nil {
+				log.Printf("Scheduler error: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sns-server/internal/models"
+)
+
+// DatabaseStore はjobsテーブルに保存するStoreです
+// PostgreSQLでは `FOR UPDATE SKIP LOCKED` で複数のワーカー・インスタンスが同じジョブを取らないようにします
+// （SQLiteは書き込みが直列化されるため、ロック句なしで同じSQLが動作します）
+type DatabaseStore struct {
+	db *gorm.DB
+}
+
+// NewDatabaseStore はDatabaseStoreを作成します
+func NewDatabaseStore(db *gorm.DB) *DatabaseStore {
+	return &DatabaseStore{db: db}
+}
+
+func (s *DatabaseStore) Enqueue(job *models.Job) error {
+	if job.UniqueKey == nil {
+		return s.db.Create(job).Error
+	}
+
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "unique_key"}},
+		DoNothing: true,
+	}).Create(job)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrDuplicate
+	}
+	return nil
+}
+
+func (s *DatabaseStore) Claim(workerID string, now time.Time) (*models.Job, error) {
+	locking := ""
+	if s.db.Dialector.Name() == "postgres" {
+		locking = "FOR UPDATE SKIP LOCKED"
+	}
+
+	var job models.Job
+	err := s.db.Raw(fmt.Sprintf(`
+		UPDATE jobs SET status = ?, attempts = attempts + 1, locked_by = ?, locked_at = ?, updated_at = ?
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE status = ? AND run_at <= ?
+			ORDER BY run_at, id
+			LIMIT 1
+			%s
+		)
+		RETURNING *`, locking),
+		models.JobStatusRunning, workerID, now, now,
+		models.JobStatusQueued, now,
+	).Scan(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	if job.ID == 0 {
+		return nil, nil
+	}
+	return &job, nil
+}
+
+// finish は自分が実行中のジョブのみ更新します（期限切れで他のワーカーに渡ったジョブは更新しない）
+func (s *DatabaseStore) finish(job *models.Job, updates map[string]interface{}) error {
+	return s.db.Model(&models.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, models.JobStatusRunning, job.LockedBy).
+		Updates(updates).Error
+}
+
+func (s *DatabaseStore) Complete(job *models.Job, now time.Time) error {
+	return s.finish(job, map[string]interface{}{
+		"status":      models.JobStatusSucceeded,
+		"locked_by":   "",
+		"locked_at":   nil,
+		"finished_at": now,
+	})
+}
+
+func (s *DatabaseStore) Retry(job *models.Job, runAt time.Time, reason string) error {
+	return s.finish(job, map[string]interface{}{
+		"status":     models.JobStatusQueued,
+		"locked_by":  "",
+		"locked_at":  nil,
+		"run_at":     runAt,
+		"last_error": reason,
+	})
+}
+
+func (s *DatabaseStore) Bury(job *models.Job, reason string, now time.Time) error {
+	return s.finish(job, map[string]interface{}{
+		"status":      models.JobStatusDead,
+		"locked_by":   "",
+		"locked_at":   nil,
+		"last_error":  reason,
+		"finished_at": now,
+	})
+}
+
+func (s *DatabaseStore) RequeueStale(lockedBefore time.Time) (int, error) {
+	requeued := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		stale := func() *gorm.DB {
+			return tx.Model(&models.Job{}).Where("status = ? AND locked_at <= ?", models.JobStatusRunning, lockedBefore)
+		}
+
+		// 最後の試行中に止まったジョブはデッドレターにする
+		err := stale().Where("attempts >= max_attempts").Updates(map[string]interface{}{
+			"status":      models.JobStatusDead,
+			"locked_by":   "",
+			"locked_at":   nil,
+			"last_error":  "worker lock expired",
+			"finished_at": lockedBefore,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		result := stale().Updates(map[string]interface{}{
+			"status":     models.JobStatusQueued,
+			"locked_by":  "",
+			"locked_at":  nil,
+			"last_error": "worker lock expired",
+		})
+		requeued = int(result.RowsAffected)
+		return result.Error
+	})
+	return requeued, err
+}
+
+func (s *DatabaseStore) RequeueDead(jobType string, now time.Time) (int, error) {
+	query := s.db.Model(&models.Job{}).Where("status = ?", models.JobStatusDead)
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"status":      models.JobStatusQueued,
+		"attempts":    0,
+		"run_at":      now,
+		"finished_at": nil,
+	})
+	return int(result.RowsAffected), result.Error
+}
+
+func (s *DatabaseStore) DeleteFinished(before time.Time) (int, error) {
+	result := s.db.Where("status = ? AND finished_at <= ?", models.JobStatusSucceeded, before).Delete(&models.Job{})
+	return int(result.RowsAffected), result.Error
+}
@@ -0,0 +1,226 @@
+// jobsはデータベースに保存するバックグラウンドジョブのキューとワーカーです
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// ErrDuplicate は同じUniqueKeyのジョブが既に登録されている場合のエラーです
+var ErrDuplicate = errors.New("job already enqueued")
+
+// Handler はジョブを実行します
+// エラーを返すとバックオフ後に再試行し、最大試行回数に達するとデッドレターにします
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Store はジョブの保存先です
+type Store interface {
+	// Enqueue はジョブを登録します（UniqueKeyが重複する場合はErrDuplicate）
+	Enqueue(job *models.Job) error
+	// Claim は実行時刻を過ぎたジョブを1つ実行中にして返します（なければnil）
+	Claim(workerID string, now time.Time) (*models.Job, error)
+	// Complete は実行中のジョブを完了にします
+	Complete(job *models.Job, now time.Time) error
+	// Retry は実行中のジョブをrunAtに再実行するよう戻します
+	Retry(job *models.Job, runAt time.Time, reason string) error
+	// Bury は実行中のジョブをデッドレターにします
+	Bury(job *models.Job, reason string, now time.Time) error
+	// RequeueStale はlockedBefore以前から実行中のままのジョブ（停止したワーカーのもの）を戻し、件数を返します
+	RequeueStale(lockedBefore time.Time) (int, error)
+	// RequeueDead はデッドレターのジョブを再実行するよう戻し、件数を返します（jobTypeが空なら全て）
+	RequeueDead(jobType string, now time.Time) (int, error)
+	// DeleteFinished はbefore以前に完了したジョブを削除し、件数を返します
+	DeleteFinished(before time.Time) (int, error)
+}
+
+// NewStore は設定に応じたStoreを作成します
+func NewStore(cfg *config.Config, db *gorm.DB) (Store, error) {
+	switch cfg.JobBackend {
+	case "postgres", "":
+		return NewDatabaseStore(db), nil
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown job backend: %s", cfg.JobBackend)
+	}
+}
+
+// Policy は再試行の設定です
+type Policy struct {
+	MaxAttempts int           // ジョブごとの既定の最大試行回数
+	BaseDelay   time.Duration // 1回目の失敗後の待ち時間（失敗するたびに2倍にする）
+	MaxDelay    time.Duration // 待ち時間の上限
+}
+
+// PolicyFromConfig は設定からPolicyを作成します
+func PolicyFromConfig(cfg *config.Config) Policy {
+	return Policy{
+		MaxAttempts: cfg.JobMaxAttempts,
+		BaseDelay:   cfg.JobRetryBaseDelay,
+		MaxDelay:    cfg.JobRetryMaxDelay,
+	}
+}
+
+// Backoff はattempts回目の失敗後に再試行するまでの待ち時間を返します
+func (p Policy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// permanentError は再試行しても成功しないエラーです
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent はエラーを再試行せずにデッドレターにするエラーで包みます（不正なペイロードなど）
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Option はジョブの登録時の設定です
+type Option func(*models.Job)
+
+// At はジョブを指定した時刻以降に実行します
+func At(runAt time.Time) Option {
+	return func(job *models.Job) { job.RunAt = runAt }
+}
+
+// MaxAttempts はジョブの最大試行回数を指定します
+func MaxAttempts(n int) Option {
+	return func(job *models.Job) { job.MaxAttempts = n }
+}
+
+// Unique は同じキーのジョブを1つしか登録しないようにします
+func Unique(key string) Option {
+	return func(job *models.Job) { job.UniqueKey = &key }
+}
+
+// Queue はジョブの登録と、種類ごとのハンドラーでの実行を行います
+type Queue struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewQueue はQueueを作成します
+func NewQueue(store Store, policy Policy) *Queue {
+	return &Queue{store: store, policy: policy, now: time.Now, handlers: make(map[string]Handler)}
+}
+
+// Store はキューの保存先を返します
+func (q *Queue) Store() Store {
+	return q.store
+}
+
+// Register はジョブの種類のハンドラーを登録します
+func (q *Queue) Register(jobType string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+func (q *Queue) handler(jobType string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	h, ok := q.handlers[jobType]
+	return h, ok
+}
+
+// Enqueue はペイロードをJSONにしてジョブを登録します
+func (q *Queue) Enqueue(jobType string, payload interface{}, opts ...Option) (*models.Job, error) {
+	data := []byte("{}")
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("failed to encode job payload: %w", err)
+		}
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     string(data),
+		MaxAttempts: q.policy.MaxAttempts,
+		RunAt:       q.now(),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := q.store.Enqueue(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// RunNext は実行できるジョブを1つ実行します。実行するジョブがなければfalseを返します
+func (q *Queue) RunNext(ctx context.Context, workerID string) (bool, error) {
+	job, err := q.store.Claim(workerID, q.now())
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	handler, ok := q.handler(job.Type)
+	if !ok {
+		return true, q.store.Bury(job, fmt.Sprintf("no handler registered for %q", job.Type), q.now())
+	}
+
+	runErr := run(ctx, handler, job)
+	if runErr == nil {
+		return true, q.store.Complete(job, q.now())
+	}
+
+	var permanent *permanentError
+	if errors.As(runErr, &permanent) || job.IsFinalAttempt() {
+		log.Printf("Job %d (%s) failed permanently after %d attempts: %v", job.ID, job.Type, job.Attempts, runErr)
+		return true, q.store.Bury(job, runErr.Error(), q.now())
+	}
+
+	log.Printf("Job %d (%s) failed on attempt %d: %v", job.ID, job.Type, job.Attempts, runErr)
+	return true, q.store.Retry(job, q.now().Add(q.policy.Backoff(job.Attempts)), runErr.Error())
+}
+
+// RunPending は実行できるジョブがなくなるまで実行し、実行した件数を返します（テスト・管理コマンド用）
+func (q *Queue) RunPending(ctx context.Context) (int, error) {
+	ran := 0
+	for {
+		ok, err := q.RunNext(ctx, "inline")
+		if err != nil {
+			return ran, err
+		}
+		if !ok {
+			return ran, nil
+		}
+		ran++
+	}
+}
+
+// run はハンドラーを実行し、パニックをエラーにします
+func run(ctx context.Context, handler Handler, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
@@ -0,0 +1,441 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	return db
+}
+
+// testStore はStoreと、保存されたジョブを読み出す関数です
+type testStore struct {
+	Store
+	get func(id uint) models.Job
+}
+
+func testStores(t *testing.T) map[string]func(t *testing.T) testStore {
+	return map[string]func(t *testing.T) testStore{
+		"memory": func(t *testing.T) testStore {
+			s := NewMemoryStore()
+			return testStore{Store: s, get: func(id uint) models.Job {
+				for _, job := range s.Jobs() {
+					if job.ID == id {
+						return job
+					}
+				}
+				return models.Job{}
+			}}
+		},
+		"database": func(t *testing.T) testStore {
+			db := setupTestDB(t)
+			return testStore{Store: NewDatabaseStore(db), get: func(id uint) models.Job {
+				var job models.Job
+				db.Where("id = ?", id).Find(&job)
+				return job
+			}}
+		},
+	}
+}
+
+func TestStores(t *testing.T) {
+	now := time.Now()
+
+	for name, newStore := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Run("実行時刻が早いものから取り出す", func(t *testing.T) {
+				s := newStore(t)
+				later := &models.Job{Type: "test", MaxAttempts: 3, RunAt: now.Add(-time.Minute)}
+				earlier := &models.Job{Type: "test", MaxAttempts: 3, RunAt: now.Add(-time.Hour)}
+				future := &models.Job{Type: "test", MaxAttempts: 3, RunAt: now.Add(time.Hour)}
+				for _, job := range []*models.Job{later, earlier, future} {
+					if err := s.Enqueue(job); err != nil {
+						t.Fatalf("Failed to enqueue: %v", err)
+					}
+				}
+
+				for _, want := range []*models.Job{earlier, later} {
+					job, err := s.Claim("worker-1", now)
+					if err != nil || job == nil {
+						t.Fatalf("Expected a job, got %v, %v", job, err)
+					}
+					if job.ID != want.ID || job.Status != models.JobStatusRunning || job.Attempts != 1 || job.LockedBy != "worker-1" {
+						t.Errorf("Expected running job %d, got %+v", want.ID, job)
+					}
+				}
+
+				// 実行中のジョブと実行時刻前のジョブは取り出さない
+				if job, err := s.Claim("worker-2", now); job != nil || err != nil {
+					t.Errorf("Expected no job, got %+v, %v", job, err)
+				}
+			})
+
+			t.Run("同じUniqueKeyのジョブは登録しない", func(t *testing.T) {
+				s := newStore(t)
+				key := "cron:test:1"
+				if err := s.Enqueue(&models.Job{Type: "test", MaxAttempts: 3, UniqueKey: &key}); err != nil {
+					t.Fatalf("Failed to enqueue: %v", err)
+				}
+				if err := s.Enqueue(&models.Job{Type: "test", MaxAttempts: 3, UniqueKey: &key}); !errors.Is(err, ErrDuplicate) {
+					t.Errorf("Expected ErrDuplicate, got %v", err)
+				}
+			})
+
+			t.Run("完了・再試行・デッドレター", func(t *testing.T) {
+				s := newStore(t)
+				for i := 0; i < 3; i++ {
+					s.Enqueue(&models.Job{Type: "test", MaxAttempts: 3, RunAt: now.Add(-time.Minute)})
+				}
+
+				completed, _ := s.Claim("worker-1", now)
+				s.Complete(completed, now)
+				if job := s.get(completed.ID); job.Status != models.JobStatusSucceeded || job.FinishedAt == nil || job.LockedBy != "" {
+					t.Errorf("Expected succeeded job, got %+v", job)
+				}
+
+				retried, _ := s.Claim("worker-1", now)
+				s.Retry(retried, now.Add(time.Minute), "temporary")
+				if job := s.get(retried.ID); job.Status != models.JobStatusQueued || job.LastError != "temporary" || !job.RunAt.After(now) {
+					t.Errorf("Expected requeued job, got %+v", job)
+				}
+
+				buried, _ := s.Claim("worker-1", now)
+				s.Bury(buried, "broken", now)
+				if job := s.get(buried.ID); job.Status != models.JobStatusDead || job.LastError != "broken" {
+					t.Errorf("Expected dead job, got %+v", job)
+				}
+
+				if job, _ := s.Claim("worker-1", now); job != nil {
+					t.Errorf("Expected retried job to wait, got %+v", job)
+				}
+				if job, _ := s.Claim("worker-1", now.Add(time.Minute)); job == nil || job.ID != retried.ID || job.Attempts != 2 {
+					t.Errorf("Expected retried job on second attempt, got %+v", job)
+				}
+			})
+
+			t.Run("他のワーカーに渡ったジョブは更新しない", func(t *testing.T) {
+				s := newStore(t)
+				s.Enqueue(&models.Job{Type: "test", MaxAttempts: 3, RunAt: now.Add(-time.Minute)})
+
+				stale, _ := s.Claim("worker-1", now)
+				s.RequeueStale(now)
+				current, _ := s.Claim("worker-2", now)
+
+				s.Complete(stale, now)
+				if job := s.get(current.ID); job.Status != models.JobStatusRunning || job.LockedBy != "worker-2" {
+					t.Errorf("Expected job to stay with worker-2, got %+v", job)
+				}
+			})
+
+			t.Run("停止したワーカーのジョブを戻す", func(t *testing.T) {
+				s := newStore(t)
+				retryable := &models.Job{Type: "test", MaxAttempts: 3, RunAt: now.Add(-time.Hour)}
+				exhausted := &models.Job{Type: "test", MaxAttempts: 1, RunAt: now.Add(-time.Hour)}
+				s.Enqueue(retryable)
+				s.Enqueue(exhausted)
+				s.Claim("worker-1", now.Add(-10*time.Minute))
+				s.Claim("worker-1", now.Add(-10*time.Minute))
+
+				requeued, err := s.RequeueStale(now.Add(-5 * time.Minute))
+				if err != nil || requeued != 1 {
+					t.Errorf("Expected 1 requeued job, got %d, %v", requeued, err)
+				}
+				if job := s.get(retryable.ID); job.Status != models.JobStatusQueued {
+					t.Errorf("Expected retryable job to be queued, got %s", job.Status)
+				}
+				if job := s.get(exhausted.ID); job.Status != models.JobStatusDead {
+					t.Errorf("Expected exhausted job to be dead, got %s", job.Status)
+				}
+			})
+
+			t.Run("デッドレターの再実行と完了済みの削除", func(t *testing.T) {
+				s := newStore(t)
+				for _, jobType := range []string{"a", "b", "a"} {
+					s.Enqueue(&models.Job{Type: jobType, MaxAttempts: 1, RunAt: now.Add(-time.Minute)})
+				}
+				for i := 0; i < 2; i++ {
+					job, _ := s.Claim("worker-1", now)
+					s.Bury(job, "broken", now)
+				}
+				last, _ := s.Claim("worker-1", now)
+				s.Complete(last, now)
+
+				requeued, err := s.RequeueDead("a", now)
+				if err != nil || requeued != 1 {
+					t.Errorf("Expected 1 requeued job, got %d, %v", requeued, err)
+				}
+				if job, _ := s.Claim("worker-1", now); job == nil || job.Type != "a" || job.Attempts != 1 {
+					t.Errorf("Expected requeued job of type a, got %+v", job)
+				}
+
+				deleted, err := s.DeleteFinished(now.Add(time.Minute))
+				if err != nil || deleted != 1 {
+					t.Errorf("Expected 1 deleted job, got %d, %v", deleted, err)
+				}
+				if job := s.get(last.ID); job.ID != 0 {
+					t.Errorf("Expected finished job to be deleted, got %+v", job)
+				}
+			})
+		})
+	}
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	policy := Policy{MaxAttempts: 10, BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{4, 4 * time.Minute},
+		{5, 5 * time.Minute},
+		{50, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := policy.Backoff(tt.attempts); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestQueue_RunNext(t *testing.T) {
+	now := time.Now()
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+
+	setup := func() (*Queue, *MemoryStore) {
+		store := NewMemoryStore()
+		q := NewQueue(store, policy)
+		q.now = func() time.Time { return now }
+		return q, store
+	}
+	runAt := func(q *Queue, at time.Time) bool {
+		q.now = func() time.Time { return at }
+		ran, err := q.RunNext(context.Background(), "worker-1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return ran
+	}
+
+	t.Run("ペイロードを渡してハンドラーを実行する", func(t *testing.T) {
+		q, store := setup()
+		var got string
+		q.Register("greet", func(ctx context.Context, job *models.Job) error {
+			var payload struct{ Name string }
+			job.DecodePayload(&payload)
+			got = payload.Name
+			return nil
+		})
+		q.Enqueue("greet", map[string]string{"Name": "alice"})
+
+		if !runAt(q, now) || got != "alice" {
+			t.Errorf("Expected handler to receive payload, got %q", got)
+		}
+		if job := store.Jobs()[0]; job.Status != models.JobStatusSucceeded {
+			t.Errorf("Expected SUCCEEDED, got %s", job.Status)
+		}
+		if runAt(q, now) {
+			t.Error("Expected no more jobs")
+		}
+	})
+
+	t.Run("失敗するとバックオフ後に再試行し、最大試行回数でデッドレターにする", func(t *testing.T) {
+		q, store := setup()
+		calls := 0
+		q.Register("flaky", func(ctx context.Context, job *models.Job) error {
+			calls++
+			return errors.New("unavailable")
+		})
+		q.Enqueue("flaky", nil)
+
+		runAt(q, now)
+		if job := store.Jobs()[0]; job.Status != models.JobStatusQueued || !job.RunAt.Equal(now.Add(time.Minute)) {
+			t.Fatalf("Expected retry after 1m, got %+v", job)
+		}
+		if runAt(q, now.Add(30*time.Second)) {
+			t.Error("Expected job to wait for backoff")
+		}
+
+		runAt(q, now.Add(time.Minute))
+		if job := store.Jobs()[0]; !job.RunAt.Equal(now.Add(3 * time.Minute)) {
+			t.Fatalf("Expected retry after another 2m, got %v", job.RunAt)
+		}
+
+		runAt(q, now.Add(3*time.Minute))
+		job := store.Jobs()[0]
+		if job.Status != models.JobStatusDead || job.LastError != "unavailable" || calls != 3 {
+			t.Errorf("Expected dead job after 3 calls, got %+v after %d calls", job, calls)
+		}
+	})
+
+	t.Run("再試行しないジョブ", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler Handler
+		}{
+			{name: "Permanentエラー", handler: func(ctx context.Context, job *models.Job) error { return Permanent(errors.New("invalid")) }},
+			{name: "ハンドラー未登録", handler: nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				q, store := setup()
+				if tt.handler != nil {
+					q.Register("job", tt.handler)
+				}
+				q.Enqueue("job", nil)
+
+				runAt(q, now)
+				if job := store.Jobs()[0]; job.Status != models.JobStatusDead || job.Attempts != 1 {
+					t.Errorf("Expected dead job after 1 attempt, got %+v", job)
+				}
+			})
+		}
+	})
+
+	t.Run("パニックは失敗として再試行する", func(t *testing.T) {
+		q, store := setup()
+		q.Register("panic", func(ctx context.Context, job *models.Job) error { panic("boom") })
+		q.Enqueue("panic", nil)
+
+		runAt(q, now)
+		if job := store.Jobs()[0]; job.Status != models.JobStatusQueued || job.LastError != "panic: boom" {
+			t.Errorf("Expected queued job with panic error, got %+v", job)
+		}
+	})
+}
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC) // 水曜日
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"@every 5m", time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"* * * * *", time.Date(2024, 1, 31, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"0,45 9-17 * * *", time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 2, 1, 3, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2024, 2, 4, 12, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 5", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)}, // 15日または金曜日
+		{"0 0 30 2 *", time.Time{}},                                 // 存在しない日付
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := schedule.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("不正な予定", func(t *testing.T) {
+		for _, spec := range []string{"", "@every", "@every -1m", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+			if _, err := ParseSchedule(spec); err == nil {
+				t.Errorf("Expected error for %q", spec)
+			}
+		}
+	})
+}
+
+func TestScheduler_Tick(t *testing.T) {
+	store := NewMemoryStore()
+	q := NewQueue(store, Policy{MaxAttempts: 1})
+	now := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC)
+
+	// 複数のインスタンスで同じ予定を動かしても1回だけ登録される
+	schedulers := []*Scheduler{NewScheduler(q), NewScheduler(q)}
+	for _, s := range schedulers {
+		s.now = func() time.Time { return now }
+		if err := s.Add("cleanup", "@every 5m", "cleanup", nil); err != nil {
+			t.Fatalf("Failed to add schedule: %v", err)
+		}
+	}
+
+	tick := func(at time.Time) int {
+		total := 0
+		for _, s := range schedulers {
+			s.now = func() time.Time { return at }
+			n, err := s.Tick()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			total += n
+		}
+		return total
+	}
+
+	if n := tick(now.Add(time.Minute)); n != 0 {
+		t.Errorf("Expected nothing before 10:20, got %d", n)
+	}
+	if n := tick(time.Date(2024, 1, 31, 10, 20, 1, 0, time.UTC)); n != 1 {
+		t.Errorf("Expected 1 job at 10:20, got %d", n)
+	}
+	// 停止中に過ぎた実行時刻はまとめて1回
+	if n := tick(time.Date(2024, 1, 31, 11, 3, 0, 0, time.UTC)); n != 1 {
+		t.Errorf("Expected 1 job after downtime, got %d", n)
+	}
+
+	jobs := store.Jobs()
+	if len(jobs) != 2 || !jobs[0].RunAt.Equal(time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected scheduled jobs: %+v", jobs)
+	}
+}
+
+func TestPool(t *testing.T) {
+	store := NewMemoryStore()
+	q := NewQueue(store, Policy{MaxAttempts: 1})
+
+	var ran int32
+	q.Register("count", func(ctx context.Context, job *models.Job) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	})
+	for i := 0; i < 20; i++ {
+		q.Enqueue("count", nil)
+	}
+
+	pool := q.Start(PoolConfig{Workers: 4, PollInterval: 10 * time.Millisecond, LockTimeout: time.Minute})
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&ran) < 20 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	pool.Stop()
+
+	if got := atomic.LoadInt32(&ran); got != 20 {
+		t.Errorf("Expected 20 jobs to run exactly once, got %d", got)
+	}
+	for _, job := range store.Jobs() {
+		if job.Status != models.JobStatusSucceeded {
+			t.Errorf("Expected job %d to succeed, got %s", job.ID, job.Status)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package jobs
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"sns-server/internal/models"
+)
+
+// MemoryStore はプロセス内に保持するStoreです（テスト用、再起動するとジョブは失われます）
+type MemoryStore struct {
+	mu     sync.Mutex
+	jobs   map[uint]*models.Job
+	nextID uint
+}
+
+// NewMemoryStore はMemoryStoreを作成します
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[uint]*models.Job)}
+}
+
+func (s *MemoryStore) Enqueue(job *models.Job) error {
+	if err := job.BeforeCreate(nil); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job.UniqueKey != nil {
+		for _, existing := range s.jobs {
+			if existing.UniqueKey != nil && *existing.UniqueKey == *job.UniqueKey {
+				return ErrDuplicate
+			}
+		}
+	}
+
+	s.nextID++
+	now := time.Now()
+	job.ID = s.nextID
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	stored := *job
+	s.jobs[job.ID] = &stored
+	return nil
+}
+
+func (s *MemoryStore) Claim(workerID string, now time.Time) (*models.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next *models.Job
+	for _, job := range s.jobs {
+		if job.Status != models.JobStatusQueued || job.RunAt.After(now) {
+			continue
+		}
+		if next == nil || job.RunAt.Before(next.RunAt) || (job.RunAt.Equal(next.RunAt) && job.ID < next.ID) {
+			next = job
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	lockedAt := now
+	next.Status = models.JobStatusRunning
+	next.Attempts++
+	next.LockedBy = workerID
+	next.LockedAt = &lockedAt
+	next.UpdatedAt = now
+
+	claimed := *next
+	return &claimed, nil
+}
+
+// finish は自分が実行中のジョブのみ更新します
+func (s *MemoryStore) finish(job *models.Job, update func(stored *models.Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.jobs[job.ID]
+	if !ok || stored.Status != models.JobStatusRunning || stored.LockedBy != job.LockedBy {
+		return nil
+	}
+	stored.LockedBy = ""
+	stored.LockedAt = nil
+	stored.UpdatedAt = time.Now()
+	update(stored)
+	return nil
+}
+
+func (s *MemoryStore) Complete(job *models.Job, now time.Time) error {
+	return s.finish(job, func(stored *models.Job) {
+		stored.Status = models.JobStatusSucceeded
+		stored.FinishedAt = &now
+	})
+}
+
+func (s *MemoryStore) Retry(job *models.Job, runAt time.Time, reason string) error {
+	return s.finish(job, func(stored *models.Job) {
+		stored.Status = models.JobStatusQueued
+		stored.RunAt = runAt
+		stored.LastError = reason
+	})
+}
+
+func (s *MemoryStore) Bury(job *models.Job, reason string, now time.Time) error {
+	return s.finish(job, func(stored *models.Job) {
+		stored.Status = models.JobStatusDead
+		stored.LastError = reason
+		stored.FinishedAt = &now
+	})
+}
+
+func (s *MemoryStore) RequeueStale(lockedBefore time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requeued := 0
+	for _, job := range s.jobs {
+		if job.Status != models.JobStatusRunning || job.LockedAt == nil || job.LockedAt.After(lockedBefore) {
+			continue
+		}
+
+		job.LockedBy = ""
+		job.LockedAt = nil
+		job.LastError = "worker lock expired"
+		if job.Attempts >= job.MaxAttempts {
+			finishedAt := lockedBefore
+			job.Status = models.JobStatusDead
+			job.FinishedAt = &finishedAt
+			continue
+		}
+		job.Status = models.JobStatusQueued
+		requeued++
+	}
+	return requeued, nil
+}
+
+func (s *MemoryStore) RequeueDead(jobType string, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requeued := 0
+	for _, job := range s.jobs {
+		if job.Status != models.JobStatusDead || (jobType != "" && job.Type != jobType) {
+			continue
+		}
+		job.Status = models.JobStatusQueued
+		job.Attempts = 0
+		job.RunAt = now
+		job.FinishedAt = nil
+		requeued++
+	}
+	return requeued, nil
+}
+
+func (s *MemoryStore) DeleteFinished(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for id, job := range s.jobs {
+		if job.Status == models.JobStatusSucceeded && job.FinishedAt != nil && !job.FinishedAt.After(before) {
+			delete(s.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Jobs は保存しているジョブをID順に返します（テスト用）
+func (s *MemoryStore) Jobs() []models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]models.Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+	return jobs
+}
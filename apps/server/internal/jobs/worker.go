@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// PoolConfig はワーカープールの設定です
+type PoolConfig struct {
+	Workers      int           // 同時に実行するジョブの数
+	PollInterval time.Duration // 実行できるジョブがないときに待つ時間
+	LockTimeout  time.Duration // これ以上実行中のままのジョブは停止したワーカーのものとして戻す
+}
+
+// Pool はキューのジョブを並行して実行するワーカーの集まりです
+type Pool struct {
+	queue  *Queue
+	config PoolConfig
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start はワーカーを起動します。Stopで停止します
+func (q *Queue) Start(cfg PoolConfig) *Pool {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{queue: q, config: cfg, cancel: cancel}
+
+	host, _ := os.Hostname()
+	for i := 0; i < cfg.Workers; i++ {
+		workerID := fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i)
+		p.wg.Add(1)
+		go p.work(ctx, workerID)
+	}
+
+	p.wg.Add(1)
+	go p.recoverStale(ctx)
+
+	return p
+}
+
+// Stop は新しいジョブの実行をやめ、実行中のジョブの終了を待ちます
+func (p *Pool) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *Pool) work(ctx context.Context, workerID string) {
+	defer p.wg.Done()
+
+	for {
+		ran, err := p.runNext(ctx, workerID)
+		if err != nil {
+			log.Printf("Job worker %s error: %v", workerID, err)
+		}
+		if ran && err == nil {
+			continue // 続けて次のジョブを取る
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.config.PollInterval):
+		}
+	}
+}
+
+// runNext はジョブを1つ実行します。実行時間はロックの期限までに制限します
+func (p *Pool) runNext(ctx context.Context, workerID string) (bool, error) {
+	if ctx.Err() != nil {
+		return false, nil
+	}
+
+	jobCtx := context.Background()
+	if p.config.LockTimeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(jobCtx, p.config.LockTimeout)
+		defer cancel()
+	}
+	return p.queue.RunNext(jobCtx, workerID)
+}
+
+// recoverStale はロックの期限を過ぎて実行中のままのジョブを定期的に戻します
+func (p *Pool) recoverStale(ctx context.Context) {
+	defer p.wg.Done()
+	if p.config.LockTimeout <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.config.LockTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			requeued, err := p.queue.store.RequeueStale(p.queue.now().Add(-p.config.LockTimeout))
+			if err != nil {
+				log.Printf("Failed to requeue stale jobs: %v", err)
+			}
+			if requeued > 0 {
+				log.Printf("Requeued %d stale jobs", requeued)
+			}
+		}
+	}
+}
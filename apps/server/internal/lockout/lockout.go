@@ -0,0 +1,205 @@
+package lockout
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// 拒否理由
+const (
+	ReasonAccountLocked = "account_locked"
+	ReasonIPLocked      = "ip_locked"
+	ReasonThrottled     = "throttled"
+)
+
+// Policy はログイン失敗時の遅延・ロックアウトの設定です
+type Policy struct {
+	MaxAccountFailures int           // この回数失敗するとアカウントをロック
+	MaxIPFailures      int           // この回数失敗するとIPをロック
+	FailureWindow      time.Duration // 失敗回数を数える期間
+	LockoutDuration    time.Duration // ロック期間
+	DelayAfter         int           // この回数以降の失敗から次の試行までの待機を課す
+	BaseDelay          time.Duration // 待機時間の初期値（失敗ごとに倍増）
+	MaxDelay           time.Duration // 待機時間の上限
+}
+
+// PolicyFromConfig は設定からPolicyを作成します
+func PolicyFromConfig(cfg *config.Config) Policy {
+	return Policy{
+		MaxAccountFailures: cfg.LoginMaxFailures,
+		MaxIPFailures:      cfg.LoginIPMaxFailures,
+		FailureWindow:      cfg.LoginFailureWindow,
+		LockoutDuration:    cfg.LoginLockoutDuration,
+		DelayAfter:         3,
+		BaseDelay:          time.Second,
+		MaxDelay:           30 * time.Second,
+	}
+}
+
+// Decision はログイン試行を受け付けるかどうかの判定結果です
+type Decision struct {
+	Allowed    bool
+	Reason     string
+	RetryAfter time.Duration
+}
+
+// Guard はログイン試行を記録し、総当たり攻撃を遅延・ロックアウトで防ぎます
+type Guard struct {
+	db     *gorm.DB
+	policy Policy
+	now    func() time.Time
+}
+
+// NewGuard はGuardを作成します
+func NewGuard(db *gorm.DB, policy Policy) *Guard {
+	return &Guard{db: db, policy: policy, now: time.Now}
+}
+
+// Check はメールアドレスとIPに対してログイン試行を受け付けるかを判定します
+// アカウントの存在有無に関わらず同じ判定を行います
+func (g *Guard) Check(email, ip string) (Decision, error) {
+	now := g.now()
+
+	// 有効なロックアウトがあるか
+	var event models.LockoutEvent
+	err := g.db.
+		Where("((scope = ? AND subject = ?) OR (scope = ? AND subject = ?)) AND locked_until > ?",
+			models.LockoutScopeAccount, email, models.LockoutScopeIP, ip, now).
+		Order("locked_until DESC").
+		First(&event).Error
+	if err == nil {
+		reason := ReasonAccountLocked
+		if event.Scope == models.LockoutScopeIP {
+			reason = ReasonIPLocked
+		}
+		return Decision{Allowed: false, Reason: reason, RetryAfter: event.LockedUntil.Sub(now)}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return Decision{}, err
+	}
+
+	// 連続失敗に応じた段階的な待機
+	failures, lastFailure, err := g.accountFailures(email, now)
+	if err != nil {
+		return Decision{}, err
+	}
+	if delay := g.delayFor(failures); delay > 0 {
+		if next := lastFailure.Add(delay); next.After(now) {
+			return Decision{Allowed: false, Reason: ReasonThrottled, RetryAfter: next.Sub(now)}, nil
+		}
+	}
+
+	return Decision{Allowed: true}, nil
+}
+
+// RecordFailure は失敗した試行を記録し、しきい値を超えた場合はロックアウトします
+func (g *Guard) RecordFailure(email string, userID *uint, ip string) error {
+	now := g.now()
+
+	if err := g.db.Create(&models.LoginAttempt{Email: email, UserID: userID, IP: ip, Success: false, CreatedAt: now}).Error; err != nil {
+		return err
+	}
+
+	failures, _, err := g.accountFailures(email, now)
+	if err != nil {
+		return err
+	}
+	if failures >= g.policy.MaxAccountFailures {
+		if err := g.lock(models.LockoutScopeAccount, email, userID, ip, failures, now); err != nil {
+			return err
+		}
+	}
+
+	var ipFailures int64
+	if err := g.db.Model(&models.LoginAttempt{}).
+		Where("ip = ? AND success = ? AND created_at > ?", ip, false, now.Add(-g.policy.FailureWindow)).
+		Count(&ipFailures).Error; err != nil {
+		return err
+	}
+	if int(ipFailures) >= g.policy.MaxIPFailures {
+		if err := g.lock(models.LockoutScopeIP, ip, nil, ip, int(ipFailures), now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess は成功した試行を記録します（以降のアカウントの失敗回数はリセットされる）
+func (g *Guard) RecordSuccess(email string, userID *uint, ip string) error {
+	return g.db.Create(&models.LoginAttempt{Email: email, UserID: userID, IP: ip, Success: true, CreatedAt: g.now()}).Error
+}
+
+// accountFailures は期間内かつ最後の成功以降の失敗回数と、最後の失敗時刻を返します
+func (g *Guard) accountFailures(email string, now time.Time) (int, time.Time, error) {
+	since := now.Add(-g.policy.FailureWindow)
+
+	var lastSuccess models.LoginAttempt
+	err := g.db.Where("email = ? AND success = ? AND created_at > ?", email, true, since).
+		Order("created_at DESC").First(&lastSuccess).Error
+	if err == nil {
+		since = lastSuccess.CreatedAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, time.Time{}, err
+	}
+
+	var attempts []models.LoginAttempt
+	if err := g.db.Where("email = ? AND success = ? AND created_at > ?", email, false, since).
+		Order("created_at DESC").Find(&attempts).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+	if len(attempts) == 0 {
+		return 0, time.Time{}, nil
+	}
+
+	return len(attempts), attempts[0].CreatedAt, nil
+}
+
+// delayFor は失敗回数に応じた待機時間を返します（DelayAfter回目から倍増）
+func (g *Guard) delayFor(failures int) time.Duration {
+	if failures < g.policy.DelayAfter {
+		return 0
+	}
+
+	delay := g.policy.BaseDelay
+	for i := g.policy.DelayAfter; i < failures && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > g.policy.MaxDelay {
+		delay = g.policy.MaxDelay
+	}
+	return delay
+}
+
+// lock は有効なロックがなければロックアウトを記録します
+func (g *Guard) lock(scope, subject string, userID *uint, ip string, failures int, now time.Time) error {
+	var active int64
+	if err := g.db.Model(&models.LockoutEvent{}).
+		Where("scope = ? AND subject = ? AND locked_until > ?", scope, subject, now).
+		Count(&active).Error; err != nil {
+		return err
+	}
+	if active > 0 {
+		return nil
+	}
+
+	event := models.LockoutEvent{
+		Scope:          scope,
+		Subject:        subject,
+		UserID:         userID,
+		IP:             ip,
+		FailedAttempts: failures,
+		LockedUntil:    now.Add(g.policy.LockoutDuration),
+	}
+	if err := g.db.Create(&event).Error; err != nil {
+		return err
+	}
+
+	log.Printf("Login lockout: scope=%s subject=%s failures=%d until=%s", scope, subject, failures, event.LockedUntil.Format(time.RFC3339))
+	return nil
+}
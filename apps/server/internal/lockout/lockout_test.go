@@ -0,0 +1,143 @@
+package lockout
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+func setupTestGuard(t *testing.T) (*Guard, *gorm.DB, *time.Time) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.LoginAttempt{}, &models.LockoutEvent{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	current := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewGuard(db, Policy{
+		MaxAccountFailures: 5,
+		MaxIPFailures:      8,
+		FailureWindow:      15 * time.Minute,
+		LockoutDuration:    15 * time.Minute,
+		DelayAfter:         3,
+		BaseDelay:          time.Second,
+		MaxDelay:           4 * time.Second,
+	})
+	guard.now = func() time.Time { return current }
+
+	return guard, db, &current
+}
+
+func TestGuard_ProgressiveDelay(t *testing.T) {
+	guard, _, current := setupTestGuard(t)
+
+	// 2回までは待機なし
+	for i := 0; i < 2; i++ {
+		guard.RecordFailure("alice@example.com", nil, "203.0.113.1")
+		*current = current.Add(100 * time.Millisecond)
+		if d, _ := guard.Check("alice@example.com", "203.0.113.1"); !d.Allowed {
+			t.Fatalf("Attempt after %d failures should be allowed", i+1)
+		}
+	}
+
+	// 3回目の失敗から待機が必要（1秒、2秒、4秒...）
+	expected := []time.Duration{time.Second, 2 * time.Second}
+	for _, delay := range expected {
+		guard.RecordFailure("alice@example.com", nil, "203.0.113.1")
+
+		d, err := guard.Check("alice@example.com", "203.0.113.1")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if d.Allowed || d.Reason != ReasonThrottled {
+			t.Fatalf("Expected throttled, got %+v", d)
+		}
+		if d.RetryAfter != delay {
+			t.Errorf("Expected retry after %v, got %v", delay, d.RetryAfter)
+		}
+
+		*current = current.Add(delay)
+		if d, _ := guard.Check("alice@example.com", "203.0.113.1"); !d.Allowed {
+			t.Errorf("Expected attempt to be allowed after waiting %v", delay)
+		}
+	}
+}
+
+func TestGuard_AccountLockout(t *testing.T) {
+	guard, db, current := setupTestGuard(t)
+
+	for i := 0; i < 5; i++ {
+		if err := guard.RecordFailure("alice@example.com", nil, "203.0.113.1"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		*current = current.Add(time.Minute)
+	}
+
+	d, err := guard.Check("alice@example.com", "203.0.113.9")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if d.Allowed || d.Reason != ReasonAccountLocked {
+		t.Fatalf("Expected account to be locked, got %+v", d)
+	}
+
+	// 監査記録が1件残る
+	var events []models.LockoutEvent
+	db.Find(&events)
+	if len(events) != 1 || events[0].Scope != models.LockoutScopeAccount || events[0].FailedAttempts != 5 {
+		t.Errorf("Expected one account lockout event, got %+v", events)
+	}
+
+	// 他のアカウントは影響を受けない
+	if d, _ := guard.Check("bob@example.com", "203.0.113.9"); !d.Allowed {
+		t.Errorf("Other account should not be locked, got %+v", d)
+	}
+
+	// ロック期間が過ぎると解除される
+	*current = current.Add(15 * time.Minute)
+	if d, _ := guard.Check("alice@example.com", "203.0.113.9"); d.Reason == ReasonAccountLocked {
+		t.Errorf("Lock should expire, got %+v", d)
+	}
+}
+
+func TestGuard_IPLockout(t *testing.T) {
+	guard, _, current := setupTestGuard(t)
+
+	// 同じIPから複数のアカウントを試す（パスワードスプレー）
+	for i := 0; i < 8; i++ {
+		guard.RecordFailure("user"+string(rune('a'+i))+"@example.com", nil, "203.0.113.1")
+		*current = current.Add(time.Second)
+	}
+
+	d, _ := guard.Check("new@example.com", "203.0.113.1")
+	if d.Allowed || d.Reason != ReasonIPLocked {
+		t.Fatalf("Expected IP to be locked, got %+v", d)
+	}
+
+	if d, _ := guard.Check("new@example.com", "203.0.113.2"); !d.Allowed {
+		t.Errorf("Other IP should not be locked, got %+v", d)
+	}
+}
+
+func TestGuard_SuccessResetsFailures(t *testing.T) {
+	guard, _, current := setupTestGuard(t)
+
+	for i := 0; i < 4; i++ {
+		guard.RecordFailure("alice@example.com", nil, "203.0.113.1")
+		*current = current.Add(time.Minute)
+	}
+	guard.RecordSuccess("alice@example.com", nil, "203.0.113.1")
+	*current = current.Add(time.Second)
+
+	// 成功後の失敗は1回目から数え直す
+	guard.RecordFailure("alice@example.com", nil, "203.0.113.1")
+	if d, _ := guard.Check("alice@example.com", "203.0.113.1"); !d.Allowed {
+		t.Errorf("Expected failures to be reset after success, got %+v", d)
+	}
+}
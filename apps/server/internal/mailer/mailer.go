@@ -0,0 +1,58 @@
+package mailer
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"sns-server/internal/config"
+)
+
+// Message は送信するメール1通を表します
+type Message struct {
+	To      string
+	Subject string
+	Body    string // text/plain（UTF-8）
+}
+
+// Mailer はメール送信の抽象インターフェースです
+// 本番ではSMTP、開発・テストではファイルやメモリのアウトボックスを使います
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New は設定に応じたMailerを作成します
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailerDriver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}, nil
+	case "file":
+		return NewFileOutbox(cfg.MailOutboxDir, cfg.MailFrom)
+	case "memory", "":
+		return NewMemoryOutbox(), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", cfg.MailerDriver)
+	}
+}
+
+// buildRFC822 はヘッダー付きのメール本文を組み立てます
+func buildRFC822(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
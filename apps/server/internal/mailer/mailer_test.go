@@ -0,0 +1,113 @@
+package mailer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"sns-server/internal/config"
+)
+
+func TestMemoryOutbox_Send(t *testing.T) {
+	outbox := NewMemoryOutbox()
+
+	if err := outbox.Send(Message{To: "alice@example.com", Subject: "1通目", Body: "hello"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := outbox.Send(Message{To: "bob@example.com", Subject: "2通目", Body: "hello"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := outbox.Send(Message{To: "alice@example.com", Subject: "3通目", Body: "hello"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(outbox.Messages()) != 3 {
+		t.Errorf("Expected 3 messages, got %d", len(outbox.Messages()))
+	}
+
+	last, ok := outbox.Last("alice@example.com")
+	if !ok {
+		t.Fatal("Expected message for alice")
+	}
+	if last.Subject != "3通目" {
+		t.Errorf("Expected last subject '3通目', got %q", last.Subject)
+	}
+
+	if _, ok := outbox.Last("carol@example.com"); ok {
+		t.Error("Expected no message for carol")
+	}
+
+	outbox.Reset()
+	if len(outbox.Messages()) != 0 {
+		t.Errorf("Expected empty outbox after reset")
+	}
+
+	if err := outbox.Send(Message{Subject: "宛先なし"}); err == nil {
+		t.Error("Expected error for missing recipient")
+	}
+}
+
+func TestFileOutbox_Send(t *testing.T) {
+	dir := t.TempDir()
+
+	outbox, err := NewFileOutbox(dir, "noreply@sns.local")
+	if err != nil {
+		t.Fatalf("Failed to create outbox: %v", err)
+	}
+
+	msg := Message{To: "alice@example.com", Subject: "メール確認", Body: "本文です\nhttps://example.com"}
+	if err := outbox.Send(msg); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to read outbox: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 file, got %d", len(entries))
+	}
+
+	content, err := os.ReadFile(dir + "/" + entries[0].Name())
+	if err != nil {
+		t.Fatalf("Failed to read mail: %v", err)
+	}
+
+	for _, want := range []string{"From: noreply@sns.local", "To: alice@example.com", "Content-Type: text/plain; charset=UTF-8", "本文です\r\nhttps://example.com"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Expected mail to contain %q", want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		driver  string
+		wantErr bool
+	}{
+		{name: "memoryドライバー", driver: "memory", wantErr: false},
+		{name: "smtpドライバー", driver: "smtp", wantErr: false},
+		{name: "fileドライバー", driver: "file", wantErr: false},
+		{name: "未知のドライバーはエラー", driver: "carrier-pigeon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{MailerDriver: tt.driver, MailOutboxDir: t.TempDir()}
+			m, err := New(cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if m == nil {
+				t.Error("Expected mailer")
+			}
+		})
+	}
+}
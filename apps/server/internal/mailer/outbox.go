@@ -0,0 +1,101 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryOutbox は送信したメールをメモリに保持します（テスト用）
+type MemoryOutbox struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemoryOutbox は空のMemoryOutboxを作成します
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+// Send はメールをアウトボックスに追加します
+func (o *MemoryOutbox) Send(msg Message) error {
+	if msg.To == "" {
+		return errors.New("recipient is required")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages は送信済みメールのコピーを返します
+func (o *MemoryOutbox) Messages() []Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]Message(nil), o.messages...)
+}
+
+// Last は指定した宛先に最後に送信したメールを返します
+func (o *MemoryOutbox) Last(to string) (Message, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To == to {
+			return o.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset はアウトボックスを空にします
+func (o *MemoryOutbox) Reset() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = nil
+}
+
+// FileOutbox は送信したメールを.emlファイルとしてディレクトリに書き出します（開発用）
+type FileOutbox struct {
+	Dir  string
+	From string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewFileOutbox は書き込み先ディレクトリを作成してFileOutboxを返します
+func NewFileOutbox(dir, from string) (*FileOutbox, error) {
+	if dir == "" {
+		return nil, errors.New("outbox directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outbox directory: %w", err)
+	}
+	return &FileOutbox{Dir: dir, From: from}, nil
+}
+
+// Send はメールを1通1ファイルで書き出します
+func (o *FileOutbox) Send(msg Message) error {
+	if msg.To == "" {
+		return errors.New("recipient is required")
+	}
+
+	o.mu.Lock()
+	o.seq++
+	seq := o.seq
+	o.mu.Unlock()
+
+	now := time.Now()
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(msg.To)
+	name := fmt.Sprintf("%s-%04d-%s.eml", now.Format("20060102T150405"), seq, recipient)
+
+	if err := os.WriteFile(filepath.Join(o.Dir, name), buildRFC822(o.From, msg, now), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer はSMTPサーバー経由でメールを送信します
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send はメールをSMTPで送信します
+func (m *SMTPMailer) Send(msg Message) error {
+	if m.Host == "" {
+		return errors.New("SMTP host is not configured")
+	}
+	if msg.To == "" {
+		return errors.New("recipient is required")
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	if err := smtp.SendMail(addr, auth, m.From, []string{msg.To}, buildRFC822(m.From, msg, time.Now())); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
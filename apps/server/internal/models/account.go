@@ -0,0 +1,247 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeletedUserName は個人情報を削除したユーザーの表示名です
+const DeletedUserName = "Deleted user"
+
+// PurgedUsername は個人情報を削除したユーザーのユーザー名です
+// 登録済みのユーザー名と重なって削除が失敗しないよう、ユーザー名に使えない文字（-）を含める
+func PurgedUsername(userID uint) string {
+	return fmt.Sprintf("deleted-%d", userID)
+}
+
+var (
+	ErrSuspensionExpired  = errors.New("suspension expiry must be in the future")
+	ErrAlreadyDeactivated = errors.New("account is already deactivated")
+)
+
+// IsDeactivated は本人が退会しているかを返します（猶予期間中も含む）
+func (u *User) IsDeactivated() bool {
+	return u.DeletedAt.Valid
+}
+
+// CanReactivate は退会から猶予期間内で、まだ個人情報を削除していないかを返します
+func (u *User) CanReactivate(period time.Duration, now time.Time) bool {
+	return u.IsDeactivated() && u.PurgedAt == nil && now.Before(u.DeletedAt.Time.Add(period))
+}
+
+// SuspendUser はユーザーを利用停止します（untilがnilの場合は無期限）
+func SuspendUser(db *gorm.DB, user *User, reason string, until *time.Time) error {
+	now := time.Now()
+	if until != nil && !until.After(now) {
+		return ErrSuspensionExpired
+	}
+
+	updates := map[string]interface{}{
+		"suspended_at":      now,
+		"suspended_until":   until,
+		"suspension_reason": strings.TrimSpace(reason),
+	}
+	if err := db.Model(user).Updates(updates).Error; err != nil {
+		return err
+	}
+	user.SuspendedAt = &now
+	user.SuspendedUntil = until
+	user.SuspensionReason = strings.TrimSpace(reason)
+	return nil
+}
+
+// UnsuspendUser はユーザーの利用停止を解除します
+func UnsuspendUser(db *gorm.DB, user *User) error {
+	updates := map[string]interface{}{
+		"suspended_at":      nil,
+		"suspended_until":   nil,
+		"suspension_reason": "",
+	}
+	if err := db.Model(user).Updates(updates).Error; err != nil {
+		return err
+	}
+	user.SuspendedAt = nil
+	user.SuspendedUntil = nil
+	user.SuspensionReason = ""
+	return nil
+}
+
+// DeactivateUser はユーザーを退会させます（ソフトデリート）
+// 猶予期間中はReactivateUserで元に戻せます
+func DeactivateUser(db *gorm.DB, user *User) error {
+	if user.IsDeactivated() {
+		return ErrAlreadyDeactivated
+	}
+	if err := db.Delete(user).Error; err != nil {
+		return err
+	}
+	user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	return nil
+}
+
+// ReactivateUser は退会したユーザーを元に戻します
+func ReactivateUser(db *gorm.DB, user *User) error {
+	if err := db.Unscoped().Model(user).Update("deleted_at", nil).Error; err != nil {
+		return err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// ExcludeDeactivated はcolumnのユーザーが退会している行を除きます
+func ExcludeDeactivated(db *gorm.DB, column string) *gorm.DB {
+	deactivated := db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&User{}).Select("id").Where("deleted_at IS NOT NULL")
+	return db.Where(column+" NOT IN (?)", deactivated)
+}
+
+// PurgeHook はユーザーの個人情報の削除と同じトランザクションで先に実行する処理です（保存したファイルの削除など）
+// エラーを返すとそのユーザーの削除を取り消し、次回に再度削除する
+type PurgeHook func(tx *gorm.DB, user *User) error
+
+// PurgeDeactivatedUsers はcutoffより前に退会したユーザーの個人情報を削除し、件数を返します
+// 削除に失敗したユーザーは記録して飛ばし、残りのユーザーを削除してからまとめてエラーを返す
+func PurgeDeactivatedUsers(db *gorm.DB, cutoff time.Time, hooks ...PurgeHook) (int, error) {
+	var users []User
+	err := db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ? AND purged_at IS NULL", cutoff).
+		Order("id").
+		Find(&users).Error
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for i := range users {
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			for _, hook := range hooks {
+				if err := hook(tx, &users[i]); err != nil {
+					return err
+				}
+			}
+			return purgeUser(tx, &users[i])
+		}); err != nil {
+			log.Printf("Failed to purge user %d: %v", users[i].ID, err)
+			errs = append(errs, fmt.Errorf("failed to purge user %d: %w", users[i].ID, err))
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+// purgeUser はユーザーの関係・反応を削除し、投稿とプロフィールを匿名化します
+// リプライや引用のつながりを保つため、投稿とユーザーの行自体は残します
+func purgeUser(tx *gorm.DB, user *User) error {
+	posts := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&Post{}).Select("id").Where("author_id = ?", user.ID)
+	notifications := tx.Session(&gorm.Session{NewDB: true}).Model(&Notification{}).Select("id").Where("user_id = ? OR post_id IN (?)", user.ID, posts)
+
+	var actedNotificationIDs []uint
+	if err := tx.Model(&NotificationActor{}).Where("actor_id = ?", user.ID).Pluck("notification_id", &actedNotificationIDs).Error; err != nil {
+		return err
+	}
+
+	// いいね・リポスト・リプライした投稿、フォローの相手と投票した選択肢の件数は削除した後で数え直す
+	var countedPostIDs, countedUserIDs []uint
+	err := tx.Raw(`
+		SELECT post_id FROM likes WHERE user_id = @user
+		UNION SELECT post_id FROM reposts WHERE user_id = @user
+		UNION SELECT parent_id FROM posts WHERE author_id = @user AND parent_id IS NOT NULL`,
+		map[string]interface{}{"user": user.ID}).Scan(&countedPostIDs).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Raw(`
+		SELECT followee_id FROM follows WHERE follower_id = @user
+		UNION SELECT follower_id FROM follows WHERE followee_id = @user`,
+		map[string]interface{}{"user": user.ID}).Scan(&countedUserIDs).Error
+	if err != nil {
+		return err
+	}
+	var countedOptionIDs []uint
+	if err := tx.Model(&PollVote{}).Where("user_id = ?", user.ID).Pluck("option_id", &countedOptionIDs).Error; err != nil {
+		return err
+	}
+
+	deletes := []struct {
+		model interface{}
+		query string
+		args  []interface{}
+	}{
+		{&Like{}, "user_id = ? OR post_id IN (?)", []interface{}{user.ID, posts}},
+		{&Repost{}, "user_id = ? OR post_id IN (?)", []interface{}{user.ID, posts}},
+		{&Bookmark{}, "user_id = ? OR post_id IN (?)", []interface{}{user.ID, posts}},
+		{&BookmarkCollection{}, "user_id = ?", []interface{}{user.ID}},
+		{&Draft{}, "author_id = ?", []interface{}{user.ID}},
+		{&PollVote{}, "user_id = ?", []interface{}{user.ID}},
+		{&Follow{}, "follower_id = ? OR followee_id = ?", []interface{}{user.ID, user.ID}},
+		{&FollowRequest{}, "requester_id = ? OR target_id = ?", []interface{}{user.ID, user.ID}},
+		{&Block{}, "blocker_id = ? OR blocked_id = ?", []interface{}{user.ID, user.ID}},
+		{&Mute{}, "muter_id = ? OR muted_id = ?", []interface{}{user.ID, user.ID}},
+		{&NotificationActor{}, "actor_id = ? OR notification_id IN (?)", []interface{}{user.ID, notifications}},
+		{&Notification{}, "user_id = ? OR post_id IN (?)", []interface{}{user.ID, posts}},
+		{&UserToken{}, "user_id = ?", []interface{}{user.ID}},
+		{&LoginAttempt{}, "user_id = ?", []interface{}{user.ID}},
+		{&LockoutEvent{}, "user_id = ?", []interface{}{user.ID}},
+		{&DataExport{}, "user_id = ?", []interface{}{user.ID}}, // ファイルはPurgeHookで削除する
+		{&PostEntity{}, "post_id IN (?)", []interface{}{posts}},
+		{&TimelineEntry{}, "user_id = ? OR actor_id = ? OR post_id IN (?)", []interface{}{user.ID, user.ID, posts}},
+	}
+	for _, d := range deletes {
+		if err := tx.Where(d.query, d.args...).Delete(d.model).Error; err != nil {
+			return err
+		}
+	}
+
+	// このユーザーだけがまとめられていた他のユーザーへの通知は削除する
+	if len(actedNotificationIDs) > 0 {
+		remaining := tx.Session(&gorm.Session{NewDB: true}).Model(&NotificationActor{}).Select("notification_id")
+		err := tx.Where("id IN ? AND id NOT IN (?)", actedNotificationIDs, remaining).Delete(&Notification{}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// 他のユーザーの投稿でのメンションはユーザーへのリンクだけを外す
+	if err := tx.Model(&PostEntity{}).Where("user_id = ?", user.ID).Update("user_id", nil).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Unscoped().Model(&Post{}).Where("author_id = ?", user.ID).UpdateColumn("content", "").Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&Post{}).Where("author_id = ?", user.ID).UpdateColumn("deleted_at", now).Error; err != nil {
+		return err
+	}
+	if _, err := RecountPostCounters(tx, countedPostIDs); err != nil {
+		return err
+	}
+	if _, err := RecountUserCounters(tx, append(countedUserIDs, user.ID)); err != nil {
+		return err
+	}
+	if _, err := RecountPollOptionCounters(tx, countedOptionIDs); err != nil {
+		return err
+	}
+
+	return tx.Unscoped().Model(user).UpdateColumns(map[string]interface{}{
+		"username":          PurgedUsername(user.ID),
+		"email":             fmt.Sprintf("deleted-%d@invalid", user.ID),
+		"password":          "",
+		"name":              DeletedUserName,
+		"bio":               "",
+		"avatar":            "",
+		"email_verified":    false,
+		"is_private":        false,
+		"role":              RoleUser,
+		"suspended_at":      nil,
+		"suspended_until":   nil,
+		"suspension_reason": "",
+		"purged_at":         now,
+	}).Error
+}
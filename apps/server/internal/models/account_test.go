@@ -0,0 +1,299 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestUser_IsSuspended(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		user     User
+		expected bool
+	}{
+		{name: "利用停止されていない", user: User{}, expected: false},
+		{name: "無期限の利用停止", user: User{SuspendedAt: &past}, expected: true},
+		{name: "期限前の利用停止", user: User{SuspendedAt: &past, SuspendedUntil: &future}, expected: true},
+		{name: "期限を過ぎた利用停止", user: User{SuspendedAt: &past, SuspendedUntil: &past}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.IsSuspended(); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUser_CanReactivate(t *testing.T) {
+	now := time.Now()
+	period := 30 * 24 * time.Hour
+	purgedAt := now
+
+	tests := []struct {
+		name     string
+		user     User
+		expected bool
+	}{
+		{name: "退会していない", user: User{}, expected: false},
+		{name: "猶予期間中", user: User{DeletedAt: gorm.DeletedAt{Time: now.Add(-24 * time.Hour), Valid: true}}, expected: true},
+		{name: "猶予期間を過ぎた", user: User{DeletedAt: gorm.DeletedAt{Time: now.Add(-period - time.Hour), Valid: true}}, expected: false},
+		{name: "個人情報を削除済み", user: User{DeletedAt: gorm.DeletedAt{Time: now.Add(-time.Hour), Valid: true}, PurgedAt: &purgedAt}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.user.CanReactivate(period, now); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	db.Create(user)
+
+	past := time.Now().Add(-time.Minute)
+	if err := SuspendUser(db, user, "spam", &past); err != ErrSuspensionExpired {
+		t.Errorf("Expected ErrSuspensionExpired, got %v", err)
+	}
+
+	until := time.Now().Add(time.Hour)
+	if err := SuspendUser(db, user, "  spam  ", &until); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+
+	var saved User
+	db.First(&saved, user.ID)
+	if !saved.IsSuspended() || saved.SuspensionReason != "spam" || saved.SuspendedUntil == nil {
+		t.Errorf("Expected suspension to be saved, got %+v", saved)
+	}
+
+	if err := UnsuspendUser(db, user); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	saved = User{}
+	db.First(&saved, user.ID)
+	if saved.IsSuspended() || saved.SuspensionReason != "" || saved.SuspendedUntil != nil {
+		t.Errorf("Expected suspension to be cleared, got %+v", saved)
+	}
+}
+
+func TestDeactivateUser(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	db.Create(user)
+
+	if err := DeactivateUser(db, user); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := DeactivateUser(db, user); err != ErrAlreadyDeactivated {
+		t.Errorf("Expected ErrAlreadyDeactivated, got %v", err)
+	}
+	if err := db.First(&User{}, user.ID).Error; err == nil {
+		t.Error("Expected deactivated user to be hidden from default queries")
+	}
+
+	if err := ReactivateUser(db, user); err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if err := db.First(&User{}, user.ID).Error; err != nil {
+		t.Errorf("Expected reactivated user to be found, got %v", err)
+	}
+}
+
+func TestPurgeDeactivatedUsers(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice", Bio: "hello"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	carol := &User{Username: "carol", Email: "carol@example.com", Password: "password", Name: "Carol"}
+	for _, u := range []*User{alice, bob, carol} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	alicePost := &Post{Content: "Hello from alice", AuthorID: alice.ID}
+	bobPost := &Post{Content: "Hi @alice", AuthorID: bob.ID} // 作成時にaliceへのメンションを解析する
+	db.Create(alicePost)
+	db.Create(bobPost)
+	reply := &Post{Content: "Reply to alice", AuthorID: bob.ID, ParentID: &alicePost.ID}
+	db.Create(reply)
+
+	db.Create(&Like{UserID: alice.ID, PostID: bobPost.ID})
+	db.Create(&Like{UserID: bob.ID, PostID: alicePost.ID})
+	db.Create(&Follow{FollowerID: alice.ID, FolloweeID: bob.ID})
+	db.Create(&Follow{FollowerID: bob.ID, FolloweeID: alice.ID})
+	db.Create(&Follow{FollowerID: bob.ID, FolloweeID: carol.ID})
+	RecordNotification(db, bob.ID, alice.ID, NotificationTypeLike, &bobPost.ID)
+	RecordNotification(db, bob.ID, carol.ID, NotificationTypeFollow, nil)
+	db.Create(&Draft{Content: "alice's draft", AuthorID: alice.ID})
+	db.Create(&DataExport{UserID: alice.ID})
+	poll, _ := NewPoll([]string{"yes", "no"}, time.Now().Add(time.Hour), time.Now())
+	db.Create(&Post{Content: "vote", AuthorID: bob.ID, Poll: poll})
+	CastPollVote(db, alice.ID, poll.ID, poll.Options[0].ID, time.Now())
+
+	// 猶予期間を過ぎたaliceと、猶予期間中のcarol
+	db.Delete(alice)
+	db.Unscoped().Model(alice).Update("deleted_at", time.Now().Add(-31*24*time.Hour))
+	db.Delete(carol)
+
+	var hooked []uint
+	purged, err := PurgeDeactivatedUsers(db, time.Now().Add(-30*24*time.Hour), func(tx *gorm.DB, user *User) error {
+		hooked = append(hooked, user.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error but got: %v", err)
+	}
+	if len(hooked) != 1 || hooked[0] != alice.ID {
+		t.Errorf("Expected hook to run for alice, got %v", hooked)
+	}
+	if purged != 1 {
+		t.Fatalf("Expected 1 purged user, got %d", purged)
+	}
+
+	var anonymized User
+	db.Unscoped().First(&anonymized, alice.ID)
+	if anonymized.PurgedAt == nil || anonymized.Email == "alice@example.com" || anonymized.Username == "alice" ||
+		anonymized.Name != DeletedUserName || anonymized.Bio != "" || anonymized.Password != "" {
+		t.Errorf("Expected user to be anonymized, got %+v", anonymized)
+	}
+
+	var post Post
+	db.Unscoped().First(&post, alicePost.ID)
+	if post.Content != "" || !post.DeletedAt.Valid {
+		t.Errorf("Expected post to be anonymized and deleted, got %+v", post)
+	}
+	if err := db.First(&Post{}, reply.ID).Error; err != nil {
+		t.Errorf("Expected replies by other users to remain, got %v", err)
+	}
+
+	counts := []struct {
+		name     string
+		model    interface{}
+		query    string
+		args     []interface{}
+		expected int64
+	}{
+		{name: "aliceのいいね・aliceの投稿へのいいね", model: &Like{}, query: "1 = 1", expected: 0},
+		{name: "aliceのフォロー関係", model: &Follow{}, query: "follower_id = ? OR followee_id = ?", args: []interface{}{alice.ID, alice.ID}, expected: 0},
+		{name: "他のユーザー同士のフォロー", model: &Follow{}, query: "follower_id = ? AND followee_id = ?", args: []interface{}{bob.ID, carol.ID}, expected: 1},
+		{name: "aliceだけが行った通知", model: &Notification{}, query: "type = ?", args: []interface{}{NotificationTypeLike}, expected: 0},
+		{name: "他のユーザーの通知", model: &Notification{}, query: "type = ?", args: []interface{}{NotificationTypeFollow}, expected: 1},
+		{name: "aliceへのメンションのリンク", model: &PostEntity{}, query: "user_id = ?", args: []interface{}{alice.ID}, expected: 0},
+		{name: "aliceの下書き", model: &Draft{}, query: "author_id = ?", args: []interface{}{alice.ID}, expected: 0},
+		{name: "aliceの投票", model: &PollVote{}, query: "user_id = ?", args: []interface{}{alice.ID}, expected: 0},
+		{name: "aliceのデータエクスポート", model: &DataExport{}, query: "user_id = ?", args: []interface{}{alice.ID}, expected: 0},
+		{name: "メンション自体は残る", model: &PostEntity{}, query: "post_id = ?", args: []interface{}{bobPost.ID}, expected: 1},
+	}
+	for _, c := range counts {
+		t.Run(c.name, func(t *testing.T) {
+			var count int64
+			db.Model(c.model).Where(c.query, c.args...).Count(&count)
+			if count != c.expected {
+				t.Errorf("Expected %d, got %d", c.expected, count)
+			}
+		})
+	}
+
+	t.Run("いいね・フォローの相手の件数を数え直す", func(t *testing.T) {
+		var savedPost Post
+		var savedBob User
+		var savedOption PollOption
+		db.First(&savedPost, bobPost.ID)
+		db.First(&savedBob, bob.ID)
+		db.First(&savedOption, poll.Options[0].ID)
+		if savedPost.LikeCount != 0 || savedBob.FollowerCount != 0 || savedBob.FollowingCount != 1 {
+			t.Errorf("Expected counters to be recounted, got likes %d, followers %d, following %d",
+				savedPost.LikeCount, savedBob.FollowerCount, savedBob.FollowingCount)
+		}
+		if savedOption.VoteCount != 0 {
+			t.Errorf("Expected poll votes to be recounted, got %d", savedOption.VoteCount)
+		}
+	})
+
+	t.Run("猶予期間中のユーザーは削除しない", func(t *testing.T) {
+		var saved User
+		db.Unscoped().First(&saved, carol.ID)
+		if saved.PurgedAt != nil || saved.Email != "carol@example.com" {
+			t.Errorf("Expected carol to be kept, got %+v", saved)
+		}
+	})
+
+	t.Run("削除済みのユーザーは再度処理しない", func(t *testing.T) {
+		purged, err := PurgeDeactivatedUsers(db, time.Now().Add(-30*24*time.Hour))
+		if err != nil || purged != 0 {
+			t.Errorf("Expected nothing to purge, got %d, %v", purged, err)
+		}
+	})
+}
+
+func TestPurgeDeactivatedUsers_SkipsFailures(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	carol := &User{Username: "carol", Email: "carol@example.com", Password: "password", Name: "Carol"}
+	for _, u := range []*User{alice, bob, carol} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	t.Run("削除後のユーザー名は登録できない", func(t *testing.T) {
+		if err := ValidateUsername(PurgedUsername(alice.ID)); err == nil {
+			t.Errorf("Expected %q to be rejected", PurgedUsername(alice.ID))
+		}
+		if err := db.Create(&User{Username: PurgedUsername(alice.ID), Email: "x@example.com", Password: "password", Name: "X"}).Error; err == nil {
+			t.Error("Expected registration with the placeholder username to fail")
+		}
+	})
+
+	// 旧形式のプレースホルダー（deleted_<id>）を登録済みでも削除できる
+	if err := db.Create(&User{Username: fmt.Sprintf("deleted_%d", alice.ID), Email: "squatter@example.com", Password: "password", Name: "Squatter"}).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// bobのプレースホルダーは（直接書き換えて）既に使われていることにする
+	db.Create(&User{Username: "squatter2", Email: "squatter2@example.com", Password: "password", Name: "Squatter"})
+	db.Model(&User{}).Where("username = ?", "squatter2").UpdateColumn("username", PurgedUsername(bob.ID))
+
+	past := time.Now().Add(-31 * 24 * time.Hour)
+	for _, u := range []*User{alice, bob, carol} {
+		db.Delete(u)
+		db.Unscoped().Model(u).Update("deleted_at", past)
+	}
+
+	purged, err := PurgeDeactivatedUsers(db, time.Now().Add(-30*24*time.Hour))
+	if err == nil {
+		t.Error("Expected error for the user that could not be purged")
+	}
+	if purged != 2 {
+		t.Errorf("Expected 2 purged users, got %d", purged)
+	}
+
+	for _, tc := range []struct {
+		user   *User
+		purged bool
+	}{{alice, true}, {bob, false}, {carol, true}} {
+		var saved User
+		db.Unscoped().First(&saved, tc.user.ID)
+		if (saved.PurgedAt != nil) != tc.purged {
+			t.Errorf("Expected %s purged = %v, got %+v", tc.user.Username, tc.purged, saved)
+		}
+	}
+}
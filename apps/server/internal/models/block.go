@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Block はユーザーのブロックです
+// ブロックした側・された側のどちらからも、フォロー・いいね・リプライなどの操作や互いの投稿の表示ができなくなる
+type Block struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	BlockerID uint      `json:"blockerId" gorm:"not null;uniqueIndex:idx_blocker_blocked"`       // ブロックする人
+	BlockedID uint      `json:"blockedId" gorm:"not null;uniqueIndex:idx_blocker_blocked;index"` // ブロックされる人
+	CreatedAt time.Time `json:"createdAt"`
+
+	// リレーション
+	Blocker User `json:"blocker" gorm:"foreignKey:BlockerID"`
+	Blocked User `json:"blocked" gorm:"foreignKey:BlockedID"`
+}
+
+// 複合ユニークキー（同じユーザーを複数回ブロックできないように）
+func (Block) TableName() string {
+	return "blocks"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (b *Block) BeforeCreate(tx *gorm.DB) error {
+	if b.BlockerID == 0 {
+		return errors.New("blocker ID is required")
+	}
+	if b.BlockedID == 0 {
+		return errors.New("blocked ID is required")
+	}
+	if b.BlockerID == b.BlockedID {
+		return errors.New("cannot block yourself")
+	}
+	return nil
+}
+
+// Mute はユーザーのミュートです
+// ミュートした側のタイムラインにだけ影響し、相手からは操作も表示もこれまで通りできる
+type Mute struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	MuterID   uint      `json:"muterId" gorm:"not null;uniqueIndex:idx_muter_muted"` // ミュートする人
+	MutedID   uint      `json:"mutedId" gorm:"not null;uniqueIndex:idx_muter_muted"` // ミュートされる人
+	CreatedAt time.Time `json:"createdAt"`
+
+	// リレーション
+	Muter User `json:"muter" gorm:"foreignKey:MuterID"`
+	Muted User `json:"muted" gorm:"foreignKey:MutedID"`
+}
+
+// 複合ユニークキー（同じユーザーを複数回ミュートできないように）
+func (Mute) TableName() string {
+	return "mutes"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (m *Mute) BeforeCreate(tx *gorm.DB) error {
+	if m.MuterID == 0 {
+		return errors.New("muter ID is required")
+	}
+	if m.MutedID == 0 {
+		return errors.New("muted ID is required")
+	}
+	if m.MuterID == m.MutedID {
+		return errors.New("cannot mute yourself")
+	}
+	return nil
+}
+
+// BlockUser はユーザーをブロックし、互いのフォローとフォローリクエストを解除して、互いのタイムラインから投稿を取り除きます
+// （既にブロック済みの場合は何もしない）
+func BlockUser(db *gorm.DB, blockerID, blockedID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		block := Block{BlockerID: blockerID, BlockedID: blockedID}
+		if err := tx.Where(block).FirstOrCreate(&block).Error; err != nil {
+			return err
+		}
+		for _, pair := range [][2]uint{{blockerID, blockedID}, {blockedID, blockerID}} {
+			if _, err := Unfollow(tx, pair[0], pair[1]); err != nil {
+				return err
+			}
+		}
+		if err := RemoveBlockFromTimelines(tx, blockerID, blockedID); err != nil {
+			return err
+		}
+		return tx.Where("(requester_id = ? AND target_id = ?) OR (requester_id = ? AND target_id = ?)",
+			blockerID, blockedID, blockedID, blockerID).
+			Delete(&FollowRequest{}).Error
+	})
+}
+
+// IsBlockedBetween は2人のユーザーのどちらかがもう一方をブロックしているかチェックします
+func IsBlockedBetween(db *gorm.DB, userID, otherID uint) bool {
+	if userID == 0 || otherID == 0 || userID == otherID {
+		return false
+	}
+	var count int64
+	db.Model(&Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&count)
+	return count > 0
+}
+
+// IsMuted はmuterIDのユーザーがmutedIDのユーザーをミュートしているかチェックします
+func IsMuted(db *gorm.DB, muterID, mutedID uint) bool {
+	if muterID == 0 || mutedID == 0 {
+		return false
+	}
+	var count int64
+	db.Model(&Mute{}).Where("muter_id = ? AND muted_id = ?", muterID, mutedID).Count(&count)
+	return count > 0
+}
+
+// ExcludeBlocked はcolumnのユーザーがuserIDのユーザーとブロックの関係にある行を除きます（userIDが0の場合は何もしない）
+func ExcludeBlocked(db *gorm.DB, column string, userID uint) *gorm.DB {
+	if userID == 0 {
+		return db
+	}
+	return db.
+		Where(column+" NOT IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&Block{}).Select("blocked_id").Where("blocker_id = ?", userID)).
+		Where(column+" NOT IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&Block{}).Select("blocker_id").Where("blocked_id = ?", userID))
+}
+
+// ExcludeMuted はcolumnのユーザーをuserIDのユーザーがミュートしている行を除きます（userIDが0の場合は何もしない）
+func ExcludeMuted(db *gorm.DB, column string, userID uint) *gorm.DB {
+	if userID == 0 {
+		return db
+	}
+	return db.Where(column+" NOT IN (?)", db.Session(&gorm.Session{NewDB: true}).Model(&Mute{}).Select("muted_id").Where("muter_id = ?", userID))
+}
@@ -0,0 +1,141 @@
+package models
+
+import "testing"
+
+func TestBlock_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	db.Create(alice)
+	db.Create(bob)
+
+	tests := []struct {
+		name    string
+		block   Block
+		wantErr bool
+	}{
+		{name: "有効なブロックの作成", block: Block{BlockerID: alice.ID, BlockedID: bob.ID}, wantErr: false},
+		{name: "同じユーザーの重複したブロック", block: Block{BlockerID: alice.ID, BlockedID: bob.ID}, wantErr: true},
+		{name: "自分自身のブロック", block: Block{BlockerID: alice.ID, BlockedID: alice.ID}, wantErr: true},
+		{name: "ブロックする人のIDが空", block: Block{BlockedID: bob.ID}, wantErr: true},
+		{name: "ブロックされる人のIDが空", block: Block{BlockerID: alice.ID}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.block).Error
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestMute_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	db.Create(alice)
+	db.Create(bob)
+
+	tests := []struct {
+		name    string
+		mute    Mute
+		wantErr bool
+	}{
+		{name: "有効なミュートの作成", mute: Mute{MuterID: alice.ID, MutedID: bob.ID}, wantErr: false},
+		{name: "同じユーザーの重複したミュート", mute: Mute{MuterID: alice.ID, MutedID: bob.ID}, wantErr: true},
+		{name: "自分自身のミュート", mute: Mute{MuterID: alice.ID, MutedID: alice.ID}, wantErr: true},
+		{name: "ミュートする人のIDが空", mute: Mute{MutedID: bob.ID}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.mute).Error
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+
+	t.Run("ミュートは一方向", func(t *testing.T) {
+		if !IsMuted(db, alice.ID, bob.ID) {
+			t.Error("Expected alice to mute bob")
+		}
+		if IsMuted(db, bob.ID, alice.ID) {
+			t.Error("Expected bob not to mute alice")
+		}
+	})
+}
+
+func TestBlockUser(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	carol := &User{Username: "carol", Email: "carol@example.com", Password: "password", Name: "Carol"}
+	db.Create(alice)
+	db.Create(bob)
+	db.Create(carol)
+
+	db.Create(&Follow{FollowerID: alice.ID, FolloweeID: bob.ID})
+	db.Create(&Follow{FollowerID: bob.ID, FolloweeID: alice.ID})
+	db.Create(&Follow{FollowerID: alice.ID, FolloweeID: carol.ID})
+
+	if err := BlockUser(db, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Failed to block user: %v", err)
+	}
+	// 既にブロック済みの場合もエラーにしない
+	if err := BlockUser(db, alice.ID, bob.ID); err != nil {
+		t.Fatalf("Expected block to be idempotent, got: %v", err)
+	}
+
+	// 互いのフォローは解除され、他のフォローは残る
+	if IsFollowing(db, alice.ID, bob.ID) || IsFollowing(db, bob.ID, alice.ID) {
+		t.Error("Expected follows between alice and bob to be removed")
+	}
+	if !IsFollowing(db, alice.ID, carol.ID) {
+		t.Error("Expected alice to still follow carol")
+	}
+
+	// ブロックはどちらの向きからも有効
+	if !IsBlockedBetween(db, alice.ID, bob.ID) || !IsBlockedBetween(db, bob.ID, alice.ID) {
+		t.Error("Expected block to apply in both directions")
+	}
+	if IsBlockedBetween(db, alice.ID, carol.ID) {
+		t.Error("Expected no block between alice and carol")
+	}
+
+	for _, user := range []*User{alice, bob} {
+		db.Create(&Post{Content: "hello from " + user.Username, AuthorID: user.ID})
+	}
+	db.Create(&Post{Content: "hello from carol", AuthorID: carol.ID})
+
+	var visible []Post
+	ExcludeBlocked(db.Model(&Post{}), "author_id", bob.ID).Find(&visible)
+	for _, post := range visible {
+		if post.AuthorID == alice.ID {
+			t.Errorf("Expected alice's posts to be hidden from bob, got %q", post.Content)
+		}
+	}
+	if len(visible) != 2 {
+		t.Errorf("Expected bob's and carol's posts, got %d posts", len(visible))
+	}
+
+	// ミュートはミュートした側にのみ影響する
+	db.Create(&Mute{MuterID: carol.ID, MutedID: alice.ID})
+	var forCarol, forAlice []Post
+	ExcludeMuted(db.Model(&Post{}), "author_id", carol.ID).Find(&forCarol)
+	ExcludeMuted(db.Model(&Post{}), "author_id", alice.ID).Find(&forAlice)
+	if len(forCarol) != 2 || len(forAlice) != 3 {
+		t.Errorf("Expected mute to hide only alice's post from carol, got %d and %d", len(forCarol), len(forAlice))
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// コレクション名の最大文字数
+const BookmarkCollectionNameMaxLength = 50
+
+// Bookmark はユーザーが非公開で保存した投稿です
+// コレクションは任意で、未指定のブックマークはどのコレクションにも属さない
+type Bookmark struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	UserID       uint      `json:"userId" gorm:"not null;uniqueIndex:idx_bookmark_user_post"`
+	PostID       uint      `json:"postId" gorm:"not null;uniqueIndex:idx_bookmark_user_post;index"`
+	CollectionID *uint     `json:"collectionId" gorm:"index"`
+	CreatedAt    time.Time `json:"createdAt" gorm:"index"`
+
+	// リレーション
+	Post       Post                `json:"post" gorm:"foreignKey:PostID"`
+	Collection *BookmarkCollection `json:"collection" gorm:"foreignKey:CollectionID"`
+}
+
+// 複合ユニークキー（同じユーザーが同じ投稿を複数回ブックマークできないように）
+func (Bookmark) TableName() string {
+	return "bookmarks"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (b *Bookmark) BeforeCreate(tx *gorm.DB) error {
+	if b.UserID == 0 {
+		return errors.New("user ID is required")
+	}
+	if b.PostID == 0 {
+		return errors.New("post ID is required")
+	}
+	return nil
+}
+
+// BookmarkCollection はブックマークを整理するための名前付きのコレクションです（本人のみ閲覧できる）
+type BookmarkCollection struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_bookmark_collection_user_name"`
+	Name      string    `json:"name" gorm:"not null;size:50;uniqueIndex:idx_bookmark_collection_user_name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (BookmarkCollection) TableName() string {
+	return "bookmark_collections"
+}
+
+// BeforeCreate はレコード作成前のバリデーション（コレクション名の前後の空白は取り除く）
+func (c *BookmarkCollection) BeforeCreate(tx *gorm.DB) error {
+	if c.UserID == 0 {
+		return errors.New("user ID is required")
+	}
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return errors.New("collection name is required")
+	}
+	if utf8.RuneCountInString(c.Name) > BookmarkCollectionNameMaxLength {
+		return errors.New("collection name must be at most 50 characters")
+	}
+	return nil
+}
+
+// BookmarkedPostIDs は指定した投稿のうちユーザーがブックマークしている投稿のIDを返します
+func BookmarkedPostIDs(db *gorm.DB, userID uint, postIDs []uint) (map[uint]bool, error) {
+	bookmarked := make(map[uint]bool)
+	if userID == 0 || len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	var ids []uint
+	err := db.Model(&Bookmark{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		bookmarked[id] = true
+	}
+	return bookmarked, nil
+}
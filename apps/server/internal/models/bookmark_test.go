@@ -0,0 +1,92 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBookmark_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "testuser", Email: "test@example.com", Password: "password", Name: "Test User"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("テスト用ユーザー作成に失敗: %v", err)
+	}
+	post := &Post{Content: "Test post content", AuthorID: user.ID}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("テスト用投稿作成に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		bookmark Bookmark
+		wantErr  bool
+	}{
+		{name: "有効なブックマークの作成", bookmark: Bookmark{UserID: user.ID, PostID: post.ID}, wantErr: false},
+		{name: "同じ投稿の重複したブックマーク", bookmark: Bookmark{UserID: user.ID, PostID: post.ID}, wantErr: true},
+		{name: "ユーザーIDが空", bookmark: Bookmark{PostID: post.ID}, wantErr: true},
+		{name: "投稿IDが空", bookmark: Bookmark{UserID: user.ID}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.bookmark).Error
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+
+	other := &Post{Content: "not bookmarked", AuthorID: user.ID}
+	db.Create(other)
+	bookmarked, err := BookmarkedPostIDs(db, user.ID, []uint{post.ID, other.ID})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bookmarked[post.ID] || bookmarked[other.ID] {
+		t.Errorf("Expected only post %d to be bookmarked, got %v", post.ID, bookmarked)
+	}
+}
+
+func TestBookmarkCollection_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "testuser", Email: "test@example.com", Password: "password", Name: "Test User"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("テスト用ユーザー作成に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		collection BookmarkCollection
+		wantName   string
+		wantErr    bool
+	}{
+		{name: "有効なコレクションの作成", collection: BookmarkCollection{UserID: user.ID, Name: "  あとで読む "}, wantName: "あとで読む"},
+		{name: "同じ名前の重複したコレクション", collection: BookmarkCollection{UserID: user.ID, Name: "あとで読む"}, wantErr: true},
+		{name: "名前が空白のみ", collection: BookmarkCollection{UserID: user.ID, Name: "   "}, wantErr: true},
+		{name: "名前が長すぎる", collection: BookmarkCollection{UserID: user.ID, Name: strings.Repeat("あ", 51)}, wantErr: true},
+		{name: "ユーザーIDが空", collection: BookmarkCollection{Name: "Go"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.collection).Error
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+			if tt.collection.Name != tt.wantName {
+				t.Errorf("Expected name %q, got %q", tt.wantName, tt.collection.Name)
+			}
+		})
+	}
+}
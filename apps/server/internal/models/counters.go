@@ -0,0 +1,121 @@
+package models
+
+import "gorm.io/gorm"
+
+// 投稿のいいね数・リプライ数・リポスト数、ユーザーのフォロワー数・フォロー数と投票の選択肢の票数は、毎回数えずに列に保存します
+// 作成時は各モデルのAfterCreateで、削除時は削除する関数の中で同じトランザクション内で増減します
+// まとめて削除した場合やずれた場合はRecount*・ReconcileCountersで元のテーブルから数え直します
+
+// adjustCounter は列の件数をdeltaだけ増減します（0未満にはしない）
+func adjustCounter(tx *gorm.DB, model interface{}, id uint, column string, delta int) error {
+	query := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(model).Where("id = ?", id)
+	if delta < 0 {
+		query = query.Where(column+" >= ?", -delta)
+	}
+	return query.UpdateColumn(column, gorm.Expr(column+" + ?", delta)).Error
+}
+
+// AdjustReplyCount はリプライを非表示にした・元に戻した時にリプライ元の件数を増減します（リプライでない場合は何もしない）
+func AdjustReplyCount(tx *gorm.DB, post *Post, delta int) error {
+	if post.ParentID == nil {
+		return nil
+	}
+	return adjustCounter(tx, &Post{}, *post.ParentID, "reply_count", delta)
+}
+
+// RecountPostCounters は投稿の件数を数え直し、修正した投稿の数を返します
+func RecountPostCounters(db *gorm.DB, postIDs []uint) (int64, error) {
+	if len(postIDs) == 0 {
+		return 0, nil
+	}
+	return recountPostCounters(db, "p.id IN ?", postIDs)
+}
+
+// RecountUserCounters はユーザーの件数を数え直し、修正したユーザーの数を返します
+func RecountUserCounters(db *gorm.DB, userIDs []uint) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+	return recountUserCounters(db, "u.id IN ?", userIDs)
+}
+
+// RecountPollOptionCounters は投票の選択肢の票数を数え直し、修正した選択肢の数を返します
+func RecountPollOptionCounters(db *gorm.DB, optionIDs []uint) (int64, error) {
+	if len(optionIDs) == 0 {
+		return 0, nil
+	}
+	return recountPollOptionCounters(db, "o.id IN ?", optionIDs)
+}
+
+// CounterReconciliation は数え直しで件数を修正した投稿・ユーザー・投票の選択肢の数です
+type CounterReconciliation struct {
+	Posts       int64
+	Users       int64
+	PollOptions int64
+}
+
+// ReconcileCounters は全ての投稿・ユーザー・投票の選択肢の件数を元のテーブルから数え直します
+func ReconcileCounters(db *gorm.DB) (CounterReconciliation, error) {
+	var result CounterReconciliation
+	var err error
+	if result.Posts, err = recountPostCounters(db, "1 = 1"); err != nil {
+		return result, err
+	}
+	if result.Users, err = recountUserCounters(db, "1 = 1"); err != nil {
+		return result, err
+	}
+	result.PollOptions, err = recountPollOptionCounters(db, "1 = 1")
+	return result, err
+}
+
+// 削除済みの投稿も元に戻した時のために数え直す（非表示のリプライは数えない）
+func recountPostCounters(db *gorm.DB, scope string, args ...interface{}) (int64, error) {
+	result := db.Exec(`
+		UPDATE posts
+		SET like_count = actual.like_count, reply_count = actual.reply_count, repost_count = actual.repost_count
+		FROM (
+			SELECT p.id,
+				(SELECT COUNT(*) FROM likes WHERE likes.post_id = p.id) AS like_count,
+				(SELECT COUNT(*) FROM posts AS replies WHERE replies.parent_id = p.id AND replies.deleted_at IS NULL) AS reply_count,
+				(SELECT COUNT(*) FROM reposts WHERE reposts.post_id = p.id) AS repost_count
+			FROM posts AS p
+			WHERE `+scope+`
+		) AS actual
+		WHERE posts.id = actual.id
+			AND (posts.like_count <> actual.like_count OR posts.reply_count <> actual.reply_count OR posts.repost_count <> actual.repost_count)`,
+		args...)
+	return result.RowsAffected, result.Error
+}
+
+func recountUserCounters(db *gorm.DB, scope string, args ...interface{}) (int64, error) {
+	result := db.Exec(`
+		UPDATE users
+		SET follower_count = actual.follower_count, following_count = actual.following_count
+		FROM (
+			SELECT u.id,
+				(SELECT COUNT(*) FROM follows WHERE follows.followee_id = u.id) AS follower_count,
+				(SELECT COUNT(*) FROM follows WHERE follows.follower_id = u.id) AS following_count
+			FROM users AS u
+			WHERE `+scope+`
+		) AS actual
+		WHERE users.id = actual.id
+			AND (users.follower_count <> actual.follower_count OR users.following_count <> actual.following_count)`,
+		args...)
+	return result.RowsAffected, result.Error
+}
+
+func recountPollOptionCounters(db *gorm.DB, scope string, args ...interface{}) (int64, error) {
+	result := db.Exec(`
+		UPDATE poll_options
+		SET vote_count = actual.vote_count
+		FROM (
+			SELECT o.id,
+				(SELECT COUNT(*) FROM poll_votes WHERE poll_votes.option_id = o.id) AS vote_count
+			FROM poll_options AS o
+			WHERE `+scope+`
+		) AS actual
+		WHERE poll_options.id = actual.id
+			AND poll_options.vote_count <> actual.vote_count`,
+		args...)
+	return result.RowsAffected, result.Error
+}
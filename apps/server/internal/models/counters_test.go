@@ -0,0 +1,129 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCounters(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	for _, u := range []*User{alice, bob} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	post := &Post{Content: "counted", AuthorID: alice.ID}
+	db.Create(post)
+
+	postCounts := func() (int64, int64, int64) {
+		var saved Post
+		db.Unscoped().First(&saved, post.ID)
+		return saved.LikeCount, saved.ReplyCount, saved.RepostCount
+	}
+	followCounts := func(user *User) (int64, int64) {
+		var saved User
+		db.First(&saved, user.ID)
+		return saved.FollowerCount, saved.FollowingCount
+	}
+
+	t.Run("作成時に増やす", func(t *testing.T) {
+		db.Create(&Like{UserID: bob.ID, PostID: post.ID})
+		db.Create(&Repost{UserID: bob.ID, PostID: post.ID})
+		db.Create(&Post{Content: "reply", AuthorID: bob.ID, ParentID: &post.ID})
+		db.Create(&Follow{FollowerID: bob.ID, FolloweeID: alice.ID})
+
+		if likes, replies, reposts := postCounts(); likes != 1 || replies != 1 || reposts != 1 {
+			t.Errorf("Expected 1 like, reply and repost, got %d, %d, %d", likes, replies, reposts)
+		}
+		if followers, following := followCounts(alice); followers != 1 || following != 0 {
+			t.Errorf("Expected alice to have 1 follower, got %d followers, %d following", followers, following)
+		}
+		if followers, following := followCounts(bob); followers != 0 || following != 1 {
+			t.Errorf("Expected bob to follow 1 user, got %d followers, %d following", followers, following)
+		}
+	})
+
+	t.Run("重複した作成は数えない", func(t *testing.T) {
+		if err := db.Create(&Like{UserID: bob.ID, PostID: post.ID}).Error; err == nil {
+			t.Fatal("Expected duplicate like to fail")
+		}
+		if likes, _, _ := postCounts(); likes != 1 {
+			t.Errorf("Expected 1 like after failed duplicate, got %d", likes)
+		}
+	})
+
+	t.Run("取り消すと減らす", func(t *testing.T) {
+		if removed, err := RemoveLike(db, bob.ID, post.ID); err != nil || !removed {
+			t.Errorf("Expected like to be removed, got %v, %v", removed, err)
+		}
+		if removed, err := RemoveLike(db, bob.ID, post.ID); err != nil || removed {
+			t.Errorf("Expected second removal to do nothing, got %v, %v", removed, err)
+		}
+		if removed, err := RemoveRepost(db, bob.ID, post.ID); err != nil || !removed {
+			t.Errorf("Expected repost to be removed, got %v, %v", removed, err)
+		}
+		if unfollowed, err := Unfollow(db, bob.ID, alice.ID); err != nil || !unfollowed {
+			t.Errorf("Expected unfollow, got %v, %v", unfollowed, err)
+		}
+
+		if likes, _, reposts := postCounts(); likes != 0 || reposts != 0 {
+			t.Errorf("Expected no likes and reposts, got %d, %d", likes, reposts)
+		}
+		if followers, _ := followCounts(alice); followers != 0 {
+			t.Errorf("Expected no followers, got %d", followers)
+		}
+		if _, following := followCounts(bob); following != 0 {
+			t.Errorf("Expected no following, got %d", following)
+		}
+	})
+
+	t.Run("ブロックでフォローを解除すると減らす", func(t *testing.T) {
+		db.Create(&Follow{FollowerID: alice.ID, FolloweeID: bob.ID})
+		db.Create(&Follow{FollowerID: bob.ID, FolloweeID: alice.ID})
+		if err := BlockUser(db, alice.ID, bob.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, user := range []*User{alice, bob} {
+			if followers, following := followCounts(user); followers != 0 || following != 0 {
+				t.Errorf("Expected %s to have no follows, got %d followers, %d following", user.Username, followers, following)
+			}
+		}
+	})
+
+	t.Run("数え直すとずれを修正する", func(t *testing.T) {
+		poll, _ := NewPoll([]string{"yes", "no"}, time.Now().Add(time.Hour), time.Now())
+		db.Create(&Post{Content: "vote", AuthorID: alice.ID, Poll: poll})
+		CastPollVote(db, bob.ID, poll.ID, poll.Options[0].ID, time.Now())
+
+		db.Model(&Post{}).Where("id = ?", post.ID).UpdateColumn("like_count", 42)
+		db.Model(&User{}).Where("id = ?", alice.ID).UpdateColumn("follower_count", 7)
+		db.Model(&PollOption{}).Where("id = ?", poll.Options[1].ID).UpdateColumn("vote_count", 3)
+
+		result, err := ReconcileCounters(db)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.Posts != 1 || result.Users != 1 || result.PollOptions != 1 {
+			t.Errorf("Expected 1 post, 1 user and 1 poll option to be fixed, got %+v", result)
+		}
+		if likes, replies, _ := postCounts(); likes != 0 || replies != 1 {
+			t.Errorf("Expected recounted like and reply counts, got %d, %d", likes, replies)
+		}
+		if followers, _ := followCounts(alice); followers != 0 {
+			t.Errorf("Expected recounted follower count, got %d", followers)
+		}
+		var options []PollOption
+		db.Where("poll_id = ?", poll.ID).Order("position").Find(&options)
+		if len(options) != 2 || options[0].VoteCount != 1 || options[1].VoteCount != 0 {
+			t.Errorf("Expected recounted vote counts, got %+v", options)
+		}
+
+		result, err = ReconcileCounters(db)
+		if err != nil || result != (CounterReconciliation{}) {
+			t.Errorf("Expected nothing to fix, got %+v, %v", result, err)
+		}
+	})
+}
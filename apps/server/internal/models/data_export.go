@@ -0,0 +1,78 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// データエクスポートの状態（GraphQLのDataExportStatusと同じ値）
+const (
+	DataExportStatusPending    = "PENDING"    // 作成待ち
+	DataExportStatusProcessing = "PROCESSING" // 作成中
+	DataExportStatusCompleted  = "COMPLETED"  // ダウンロード可能
+	DataExportStatusFailed     = "FAILED"
+	DataExportStatusExpired    = "EXPIRED" // 保持期間を過ぎてファイルを削除済み
+)
+
+// DataExport はユーザーが要求した個人データのエクスポート（JSONファイルのZIP）です
+type DataExport struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"-" gorm:"not null;index"`
+	Status      string     `json:"status" gorm:"not null;size:16;default:'PENDING';index"`
+	StorageKey  string     `json:"-"` // 作成したZIPの保存先のキー
+	SizeBytes   int64      `json:"sizeBytes" gorm:"not null;default:0"`
+	Error       string     `json:"-"` // 作成に失敗した理由（運用者向け）
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"` // この日時を過ぎるとファイルを削除する
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+
+	// リレーション
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+func (DataExport) TableName() string {
+	return "data_exports"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (e *DataExport) BeforeCreate(tx *gorm.DB) error {
+	if e.UserID == 0 {
+		return errors.New("user ID is required")
+	}
+	e.Status = DataExportStatusPending
+	return nil
+}
+
+// IsDownloadable はファイルをダウンロードできるかを返します
+func (e *DataExport) IsDownloadable(now time.Time) bool {
+	return e.Status == DataExportStatusCompleted && e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+}
+
+// ActiveDataExport は作成待ち・作成中のエクスポートを返します（ない場合はnil）
+func ActiveDataExport(db *gorm.DB, userID uint) (*DataExport, error) {
+	var export DataExport
+	err := db.Where("user_id = ? AND status IN ?", userID, []string{DataExportStatusPending, DataExportStatusProcessing}).
+		Order("id").
+		Limit(1).
+		Find(&export).Error
+	if err != nil || export.ID == 0 {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ClaimDataExport は作成待ちのエクスポートを作成中にします
+// 複数のワーカーが同じエクスポートを処理しないよう、状態を変更できた場合のみtrueを返します
+func ClaimDataExport(db *gorm.DB, export *DataExport) (bool, error) {
+	result := db.Model(&DataExport{}).
+		Where("id = ? AND status = ?", export.ID, DataExportStatusPending).
+		Update("status", DataExportStatusProcessing)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	export.Status = DataExportStatusProcessing
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDataExport(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	db.Create(user)
+
+	t.Run("ユーザーなしでは作成できない", func(t *testing.T) {
+		if err := db.Create(&DataExport{}).Error; err == nil {
+			t.Error("Expected error but got none")
+		}
+	})
+
+	export := &DataExport{UserID: user.ID, Status: DataExportStatusCompleted}
+	if err := db.Create(export).Error; err != nil {
+		t.Fatalf("Failed to create export: %v", err)
+	}
+	if export.Status != DataExportStatusPending {
+		t.Errorf("Expected new export to be PENDING, got %s", export.Status)
+	}
+
+	active, err := ActiveDataExport(db, user.ID)
+	if err != nil || active == nil || active.ID != export.ID {
+		t.Fatalf("Expected active export %d, got %+v, %v", export.ID, active, err)
+	}
+
+	t.Run("作成待ちのエクスポートは1回だけ確保できる", func(t *testing.T) {
+		claimed, err := ClaimDataExport(db, export)
+		if err != nil || !claimed {
+			t.Fatalf("Expected to claim export, got %v, %v", claimed, err)
+		}
+		stale := &DataExport{ID: export.ID, Status: DataExportStatusPending}
+		if claimed, _ := ClaimDataExport(db, stale); claimed {
+			t.Error("Expected second claim to fail")
+		}
+	})
+
+	t.Run("完了したエクスポートは作成中として扱わない", func(t *testing.T) {
+		db.Model(export).Update("status", DataExportStatusCompleted)
+		active, err := ActiveDataExport(db, user.ID)
+		if err != nil || active != nil {
+			t.Errorf("Expected no active export, got %+v, %v", active, err)
+		}
+	})
+
+	t.Run("ダウンロードできるか", func(t *testing.T) {
+		now := time.Now()
+		future := now.Add(time.Hour)
+		past := now.Add(-time.Hour)
+
+		tests := []struct {
+			name     string
+			export   DataExport
+			expected bool
+		}{
+			{name: "完了・期限内", export: DataExport{Status: DataExportStatusCompleted, ExpiresAt: &future}, expected: true},
+			{name: "完了・期限切れ", export: DataExport{Status: DataExportStatusCompleted, ExpiresAt: &past}, expected: false},
+			{name: "作成中", export: DataExport{Status: DataExportStatusProcessing}, expected: false},
+			{name: "削除済み", export: DataExport{Status: DataExportStatusExpired, ExpiresAt: &future}, expected: false},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := tt.export.IsDownloadable(now); got != tt.expected {
+					t.Errorf("Expected %v, got %v", tt.expected, got)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Draft は公開前の投稿です（本人にのみ見える）
+// 公開すると同じ内容の投稿を作成し、下書きは削除します
+// ScheduledAtを指定した下書きはその時刻以降にバックグラウンドジョブが公開します
+type Draft struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	AuthorID     uint       `json:"authorId" gorm:"not null;index"`
+	Content      string     `json:"content" gorm:"not null;size:280"`
+	ParentID     *uint      `json:"parentId"`                 // リプライの場合
+	QuotedPostID *uint      `json:"quotedPostId"`             // 引用投稿の場合
+	ScheduledAt  *time.Time `json:"scheduledAt" gorm:"index"` // 予約投稿の公開日時（NULLの場合は予約なし）
+	// 予約した時刻に公開できなかった理由（リプライ先が削除されたなど、予約は取り消す）
+	PublishError string    `json:"publishError"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func (Draft) TableName() string {
+	return "drafts"
+}
+
+// BeforeSave は作成・更新前のバリデーション
+func (d *Draft) BeforeSave(tx *gorm.DB) error {
+	if err := validatePostContent(d.Content); err != nil {
+		return err
+	}
+	if d.AuthorID == 0 {
+		return errors.New("author ID is required")
+	}
+	return nil
+}
+
+// IsDue は予約した公開日時を過ぎているかを返します
+func (d *Draft) IsDue(now time.Time) bool {
+	return d.ScheduledAt != nil && !d.ScheduledAt.After(now)
+}
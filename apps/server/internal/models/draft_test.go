@@ -0,0 +1,95 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDraft_Save(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		draft   Draft
+		wantErr bool
+	}{
+		{
+			name:    "有効な下書き",
+			draft:   Draft{Content: "あとで投稿", AuthorID: user.ID},
+			wantErr: false,
+		},
+		{
+			name:    "空の内容はエラー",
+			draft:   Draft{Content: "   ", AuthorID: user.ID},
+			wantErr: true,
+		},
+		{
+			name:    "281文字以上はエラー",
+			draft:   Draft{Content: strings.Repeat("あ", 281), AuthorID: user.ID},
+			wantErr: true,
+		},
+		{
+			name:    "作成者IDなしはエラー",
+			draft:   Draft{Content: "あとで投稿"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.draft).Error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("更新時もバリデーションする", func(t *testing.T) {
+		draft := Draft{Content: "あとで投稿", AuthorID: user.ID}
+		db.Create(&draft)
+
+		draft.Content = ""
+		if err := db.Save(&draft).Error; err == nil {
+			t.Error("Expected error when saving empty content")
+		}
+	})
+
+	// 下書きは投稿の一覧に含まれない
+	var posts int64
+	db.Model(&Post{}).Count(&posts)
+	if posts != 0 {
+		t.Errorf("Expected drafts not to create posts, got %d", posts)
+	}
+}
+
+func TestDraft_IsDue(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		scheduledAt *time.Time
+		want        bool
+	}{
+		{"予約なし", nil, false},
+		{"公開日時を過ぎた", &past, true},
+		{"公開日時ちょうど", &now, true},
+		{"公開日時前", &future, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			draft := Draft{ScheduledAt: tt.scheduledAt}
+			if got := draft.IsDue(now); got != tt.want {
+				t.Errorf("IsDue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -46,3 +46,39 @@ func (f *Follow) BeforeCreate(tx *gorm.DB) error {
 
 	return nil
 }
+
+// followerIDのユーザーがfolloweeIDのユーザーをフォローしているかチェック
+func IsFollowing(db *gorm.DB, followerID, followeeID uint) bool {
+	var count int64
+	db.Model(&Follow{}).Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Count(&count)
+	return count > 0
+}
+
+// AfterCreate はフォローされたユーザーのフォロワー数とフォローしたユーザーのフォロー数を増やします
+func (f *Follow) AfterCreate(tx *gorm.DB) error {
+	return adjustFollowCounters(tx, f.FollowerID, f.FolloweeID, 1)
+}
+
+// Unfollow はフォローを解除して件数を減らし、解除したユーザーの投稿・リポストをタイムラインから取り除きます（解除したかを返す）
+func Unfollow(db *gorm.DB, followerID, followeeID uint) (bool, error) {
+	removed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("follower_id = ? AND followee_id = ?", followerID, followeeID).Delete(&Follow{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+		if err := adjustFollowCounters(tx, followerID, followeeID, -1); err != nil {
+			return err
+		}
+		return RemoveFollowFromTimeline(tx, followerID, followeeID)
+	})
+	return removed, err
+}
+
+func adjustFollowCounters(tx *gorm.DB, followerID, followeeID uint, delta int) error {
+	if err := adjustCounter(tx, &User{}, followeeID, "follower_count", delta); err != nil {
+		return err
+	}
+	return adjustCounter(tx, &User{}, followerID, "following_count", delta)
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FollowRequest は非公開アカウントへの承認待ちのフォローです
+// 承認されるとFollowを作成してリクエストを削除し、拒否されると削除します
+type FollowRequest struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RequesterID uint      `json:"requesterId" gorm:"not null;uniqueIndex:idx_follow_request_requester_target"`    // フォローしたい人
+	TargetID    uint      `json:"targetId" gorm:"not null;uniqueIndex:idx_follow_request_requester_target;index"` // 非公開アカウント
+	CreatedAt   time.Time `json:"createdAt" gorm:"index"`
+
+	// リレーション
+	Requester User `json:"requester" gorm:"foreignKey:RequesterID"`
+	Target    User `json:"target" gorm:"foreignKey:TargetID"`
+}
+
+// 複合ユニークキー（同じユーザーに複数回リクエストできないように）
+func (FollowRequest) TableName() string {
+	return "follow_requests"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (r *FollowRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.RequesterID == 0 {
+		return errors.New("requester ID is required")
+	}
+	if r.TargetID == 0 {
+		return errors.New("target ID is required")
+	}
+	if r.RequesterID == r.TargetID {
+		return errors.New("cannot follow yourself")
+	}
+	return nil
+}
+
+// ApproveFollowRequests はリクエストを承認してフォローを作成します
+// requesterIDsを省略すると対象ユーザーへの全てのリクエストを承認し、承認した件数を返します
+func ApproveFollowRequests(db *gorm.DB, targetID uint, requesterIDs ...uint) (int, error) {
+	approved := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("target_id = ?", targetID)
+		if len(requesterIDs) > 0 {
+			query = query.Where("requester_id IN ?", requesterIDs)
+		}
+		var requests []FollowRequest
+		if err := query.Find(&requests).Error; err != nil {
+			return err
+		}
+
+		for _, request := range requests {
+			follow := Follow{FollowerID: request.RequesterID, FolloweeID: targetID}
+			if err := tx.Where(follow).FirstOrCreate(&follow).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&request).Error; err != nil {
+				return err
+			}
+			approved++
+		}
+		return nil
+	})
+	return approved, err
+}
+
+// CanViewUserContent は閲覧者がユーザーの投稿・フォロー・フォロワーを見られるかチェックします
+// 非公開アカウントは本人と承認済みのフォロワーのみ、ブロックの関係にある場合は誰も見られない
+func CanViewUserContent(db *gorm.DB, viewerID uint, user *User) bool {
+	if IsBlockedBetween(db, viewerID, user.ID) {
+		return false
+	}
+	if !user.IsPrivate || viewerID == user.ID {
+		return true
+	}
+	return viewerID != 0 && IsFollowing(db, viewerID, user.ID)
+}
+
+// ExcludePrivate はcolumnのユーザーが閲覧者から見られない非公開アカウントの行を除きます
+// （閲覧者本人と承認済みのフォロー先は除かない、未認証の場合は全ての非公開アカウントを除く）
+func ExcludePrivate(db *gorm.DB, column string, viewerID uint) *gorm.DB {
+	newDB := db.Session(&gorm.Session{NewDB: true})
+	private := newDB.Model(&User{}).Select("id").
+		Where("is_private = ? AND id <> ?", true, viewerID).
+		Where("id NOT IN (?)", newDB.Model(&Follow{}).Select("followee_id").Where("follower_id = ?", viewerID))
+	return db.Where(column+" NOT IN (?)", private)
+}
+
+// ExcludeHidden は閲覧者から見られないユーザー（ブロックの関係・非公開・退会済み）の行を除きます
+func ExcludeHidden(db *gorm.DB, column string, viewerID uint) *gorm.DB {
+	return ExcludeDeactivated(ExcludePrivate(ExcludeBlocked(db, column, viewerID), column, viewerID), column)
+}
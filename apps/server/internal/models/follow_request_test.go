@@ -0,0 +1,112 @@
+package models
+
+import "testing"
+
+func TestFollowRequest_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob", IsPrivate: true}
+	db.Create(alice)
+	db.Create(bob)
+
+	tests := []struct {
+		name    string
+		request FollowRequest
+		wantErr bool
+	}{
+		{name: "有効なリクエストの作成", request: FollowRequest{RequesterID: alice.ID, TargetID: bob.ID}, wantErr: false},
+		{name: "同じユーザーへの重複したリクエスト", request: FollowRequest{RequesterID: alice.ID, TargetID: bob.ID}, wantErr: true},
+		{name: "自分自身へのリクエスト", request: FollowRequest{RequesterID: bob.ID, TargetID: bob.ID}, wantErr: true},
+		{name: "リクエストする人のIDが空", request: FollowRequest{TargetID: bob.ID}, wantErr: true},
+		{name: "対象のIDが空", request: FollowRequest{RequesterID: alice.ID}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.request).Error
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestApproveFollowRequests(t *testing.T) {
+	db := setupTestDB(t)
+
+	target := &User{Username: "target", Email: "target@example.com", Password: "password", Name: "Target", IsPrivate: true}
+	db.Create(target)
+	var requesters []*User
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user := &User{Username: name, Email: name + "@example.com", Password: "password", Name: name}
+		db.Create(user)
+		db.Create(&FollowRequest{RequesterID: user.ID, TargetID: target.ID})
+		requesters = append(requesters, user)
+	}
+
+	approved, err := ApproveFollowRequests(db, target.ID, requesters[0].ID)
+	if err != nil || approved != 1 {
+		t.Fatalf("Expected 1 approved request, got %d (%v)", approved, err)
+	}
+	if !IsFollowing(db, requesters[0].ID, target.ID) || IsFollowing(db, requesters[1].ID, target.ID) {
+		t.Error("Expected only alice to follow target")
+	}
+
+	// 省略すると残りの全てのリクエストを承認する
+	approved, err = ApproveFollowRequests(db, target.ID)
+	if err != nil || approved != 2 {
+		t.Fatalf("Expected 2 approved requests, got %d (%v)", approved, err)
+	}
+	var remaining int64
+	db.Model(&FollowRequest{}).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected no remaining requests, got %d", remaining)
+	}
+}
+
+func TestCanViewUserContent(t *testing.T) {
+	db := setupTestDB(t)
+
+	private := &User{Username: "private", Email: "private@example.com", Password: "password", Name: "Private", IsPrivate: true}
+	public := &User{Username: "public", Email: "public@example.com", Password: "password", Name: "Public"}
+	follower := &User{Username: "follower", Email: "follower@example.com", Password: "password", Name: "Follower"}
+	stranger := &User{Username: "stranger", Email: "stranger@example.com", Password: "password", Name: "Stranger"}
+	for _, user := range []*User{private, public, follower, stranger} {
+		db.Create(user)
+		db.Create(&Post{Content: "post by " + user.Username, AuthorID: user.ID})
+	}
+	db.Create(&Follow{FollowerID: follower.ID, FolloweeID: private.ID})
+
+	tests := []struct {
+		name     string
+		viewerID uint
+		user     *User
+		expected bool
+	}{
+		{name: "公開アカウントは誰でも見られる", viewerID: stranger.ID, user: public, expected: true},
+		{name: "公開アカウントは未認証でも見られる", viewerID: 0, user: public, expected: true},
+		{name: "非公開アカウントは本人が見られる", viewerID: private.ID, user: private, expected: true},
+		{name: "非公開アカウントは承認済みのフォロワーが見られる", viewerID: follower.ID, user: private, expected: true},
+		{name: "非公開アカウントはフォロワー以外は見られない", viewerID: stranger.ID, user: private, expected: false},
+		{name: "非公開アカウントは未認証では見られない", viewerID: 0, user: private, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanViewUserContent(db, tt.viewerID, tt.user); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+
+			// ExcludePrivateも同じ規則で投稿を除く
+			var count int64
+			ExcludePrivate(db.Model(&Post{}), "author_id", tt.viewerID).Where("author_id = ?", tt.user.ID).Count(&count)
+			if (count == 1) != tt.expected {
+				t.Errorf("Expected visible=%v in query, got count %d", tt.expected, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Idempotency-Keyの処理状態
+const (
+	IdempotencyKeyInProgress = "IN_PROGRESS" // ミューテーションを実行中
+	IdempotencyKeyCompleted  = "COMPLETED"   // 実行が完了し、レスポンスを保存済み
+)
+
+// IdempotencyKey はミューテーションの再送に同じ結果を返すため、ユーザーとキーごとにレスポンスを保存したものです
+// ExpiresAtを過ぎたレコードは存在しないものとして扱います
+type IdempotencyKey struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"userId" gorm:"not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key         string    `json:"key" gorm:"not null;size:255;uniqueIndex:idx_idempotency_keys_user_key"`
+	RequestHash string    `json:"requestHash" gorm:"not null;size:64"` // クエリと変数のSHA-256（別の内容でのキーの再利用を検出する）
+	Status      string    `json:"status" gorm:"not null;size:16;default:'IN_PROGRESS'"`
+	Response    string    `json:"response" gorm:"type:text"` // 完了時に保存したレスポンスのJSON
+	ExpiresAt   time.Time `json:"expiresAt" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.UserID == 0 {
+		return errors.New("user ID is required")
+	}
+	if k.Key == "" {
+		return errors.New("key is required")
+	}
+	if k.RequestHash == "" {
+		return errors.New("request hash is required")
+	}
+	if k.Status == "" {
+		k.Status = IdempotencyKeyInProgress
+	}
+	if k.Status != IdempotencyKeyInProgress && k.Status != IdempotencyKeyCompleted {
+		return errors.New("invalid idempotency key status")
+	}
+	if k.ExpiresAt.IsZero() {
+		return errors.New("expires at is required")
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys は期限切れのキーを削除し、削除した件数を返します
+func DeleteExpiredIdempotencyKeys(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Where("expires_at <= ?", now).Delete(&IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKey_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	expiresAt := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		entry   IdempotencyKey
+		wantErr bool
+	}{
+		{
+			name:    "有効なキー",
+			entry:   IdempotencyKey{UserID: 1, Key: "key-1", RequestHash: "hash", ExpiresAt: expiresAt},
+			wantErr: false,
+		},
+		{
+			name:    "同じユーザーの同じキーはエラー",
+			entry:   IdempotencyKey{UserID: 1, Key: "key-1", RequestHash: "hash", ExpiresAt: expiresAt},
+			wantErr: true,
+		},
+		{
+			name:    "別のユーザーなら同じキーを使える",
+			entry:   IdempotencyKey{UserID: 2, Key: "key-1", RequestHash: "hash", ExpiresAt: expiresAt},
+			wantErr: false,
+		},
+		{
+			name:    "キーが空の場合はエラー",
+			entry:   IdempotencyKey{UserID: 1, RequestHash: "hash", ExpiresAt: expiresAt},
+			wantErr: true,
+		},
+		{
+			name:    "不正な状態の場合はエラー",
+			entry:   IdempotencyKey{UserID: 1, Key: "key-2", RequestHash: "hash", Status: "DONE", ExpiresAt: expiresAt},
+			wantErr: true,
+		},
+		{
+			name:    "期限がない場合はエラー",
+			entry:   IdempotencyKey{UserID: 1, Key: "key-3", RequestHash: "hash"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.entry).Error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && tt.entry.Status != IdempotencyKeyInProgress {
+				t.Errorf("Expected default status %s, got %s", IdempotencyKeyInProgress, tt.entry.Status)
+			}
+		})
+	}
+}
+
+func TestDeleteExpiredIdempotencyKeys(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	db.Create(&IdempotencyKey{UserID: 1, Key: "expired", RequestHash: "hash", ExpiresAt: now.Add(-time.Minute)})
+	db.Create(&IdempotencyKey{UserID: 1, Key: "active", RequestHash: "hash", ExpiresAt: now.Add(time.Minute)})
+
+	deleted, err := DeleteExpiredIdempotencyKeys(db, now)
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected 1 deleted key, got %d, %v", deleted, err)
+	}
+
+	var keys []string
+	db.Model(&IdempotencyKey{}).Pluck("key", &keys)
+	if len(keys) != 1 || keys[0] != "active" {
+		t.Errorf("Expected only the active key to remain, got %v", keys)
+	}
+}
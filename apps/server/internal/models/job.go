@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ジョブの状態
+const (
+	JobStatusQueued    = "QUEUED"    // 実行待ち（RunAtを過ぎたものから実行する）
+	JobStatusRunning   = "RUNNING"   // ワーカーが実行中
+	JobStatusSucceeded = "SUCCEEDED" // 完了
+	JobStatusDead      = "DEAD"      // 最大試行回数まで失敗した（デッドレター、手動で再実行する）
+)
+
+// Job はバックグラウンドで実行する処理です
+type Job struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Type        string     `json:"type" gorm:"not null;size:64;index"` // ハンドラーの種類（例: "data_export"）
+	Payload     string     `json:"payload" gorm:"not null"`            // JSON
+	Status      string     `json:"status" gorm:"not null;size:16;default:'QUEUED';index:idx_jobs_status_run_at"`
+	Attempts    int        `json:"attempts" gorm:"not null;default:0"`
+	MaxAttempts int        `json:"maxAttempts" gorm:"not null"`
+	RunAt       time.Time  `json:"runAt" gorm:"not null;index:idx_jobs_status_run_at"` // 次に実行できる時刻（リトライ時は遅らせる）
+	UniqueKey   *string    `json:"uniqueKey" gorm:"size:255;uniqueIndex"`              // 同じキーのジョブは1つしか登録しない（定期実行の重複防止）
+	LockedBy    string     `json:"lockedBy" gorm:"size:64"`                            // 実行中のワーカー
+	LockedAt    *time.Time `json:"lockedAt"`
+	LastError   string     `json:"lastError"`
+	FinishedAt  *time.Time `json:"finishedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (j *Job) BeforeCreate(tx *gorm.DB) error {
+	if j.Type == "" {
+		return errors.New("job type is required")
+	}
+	if j.MaxAttempts < 1 {
+		return errors.New("max attempts must be at least 1")
+	}
+	if j.Payload == "" {
+		j.Payload = "{}"
+	}
+	if j.RunAt.IsZero() {
+		j.RunAt = time.Now()
+	}
+	j.Status = JobStatusQueued
+	return nil
+}
+
+// DecodePayload はペイロードのJSONを読み込みます
+func (j *Job) DecodePayload(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// IsFinalAttempt は実行中の試行が最後の試行かを返します（失敗するとデッドレターになる）
+func (j *Job) IsFinalAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
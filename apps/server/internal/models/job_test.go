@@ -0,0 +1,52 @@
+package models
+
+import "testing"
+
+func TestJob(t *testing.T) {
+	db := setupTestDB(t)
+
+	tests := []struct {
+		name    string
+		job     Job
+		wantErr bool
+	}{
+		{name: "正常なジョブ", job: Job{Type: "test", MaxAttempts: 3}},
+		{name: "種類なし", job: Job{MaxAttempts: 3}, wantErr: true},
+		{name: "最大試行回数が0", job: Job{Type: "test"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.job).Error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("作成時は実行待ちで、ペイロードは空のオブジェクト", func(t *testing.T) {
+		job := &Job{Type: "test", MaxAttempts: 3, Status: JobStatusDead}
+		db.Create(job)
+
+		var saved Job
+		db.First(&saved, job.ID)
+		if saved.Status != JobStatusQueued || saved.Payload != "{}" || saved.RunAt.IsZero() {
+			t.Errorf("Unexpected defaults: %+v", saved)
+		}
+
+		var payload map[string]interface{}
+		if err := saved.DecodePayload(&payload); err != nil {
+			t.Errorf("Expected payload to decode: %v", err)
+		}
+	})
+
+	t.Run("最後の試行か", func(t *testing.T) {
+		job := Job{Attempts: 2, MaxAttempts: 3}
+		if job.IsFinalAttempt() {
+			t.Error("Expected attempt 2 of 3 not to be final")
+		}
+		job.Attempts = 3
+		if !job.IsFinalAttempt() {
+			t.Error("Expected attempt 3 of 3 to be final")
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Like struct {
@@ -33,3 +34,64 @@ func (l *Like) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// AfterCreate は投稿のいいね数を増やします
+func (l *Like) AfterCreate(tx *gorm.DB) error {
+	return adjustCounter(tx, &Post{}, l.PostID, "like_count", 1)
+}
+
+// LikePost は投稿にいいねして投稿のいいね数を増やし、新しくいいねしたかを返します
+// 既にいいね済みの場合は何もしない（同時に2回いいねしても一意制約のエラーにしない）
+func LikePost(db *gorm.DB, userID, postID uint) (bool, error) {
+	if userID == 0 || postID == 0 {
+		return false, errors.New("user ID and post ID are required")
+	}
+
+	liked := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// AfterCreateは挿入しなかった場合にも呼ばれるため、件数はここで増やす
+		result := tx.Session(&gorm.Session{SkipHooks: true}).
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "post_id"}}, DoNothing: true}).
+			Create(&Like{UserID: userID, PostID: postID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		liked = true
+		return adjustCounter(tx, &Post{}, postID, "like_count", 1)
+	})
+	return liked, err
+}
+
+// RemoveLike はいいねを取り消して投稿のいいね数を減らし、取り消したかを返します
+func RemoveLike(db *gorm.DB, userID, postID uint) (bool, error) {
+	removed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&Like{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+		return adjustCounter(tx, &Post{}, postID, "like_count", -1)
+	})
+	return removed, err
+}
+
+// LikedPostIDs は指定した投稿のうちユーザーがいいねしている投稿のIDを返します
+func LikedPostIDs(db *gorm.DB, userID uint, postIDs []uint) (map[uint]bool, error) {
+	liked := make(map[uint]bool)
+	if userID == 0 || len(postIDs) == 0 {
+		return liked, nil
+	}
+
+	var ids []uint
+	err := db.Model(&Like{}).
+		Where("user_id = ? AND post_id IN ?", userID, postIDs).
+		Pluck("post_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		liked[id] = true
+	}
+	return liked, nil
+}
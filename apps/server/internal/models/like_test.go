@@ -227,3 +227,53 @@ func TestLike_DeleteCascade(t *testing.T) {
 	// いいねは残る可能性がある
 	t.Logf("投稿削除後のいいね数: %d", likeCount)
 }
+
+func TestLikePost(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "testuser", Email: "test@example.com", Password: "password", Name: "Test User"}
+	db.Create(user)
+	post := &Post{Content: "Test post content", AuthorID: user.ID}
+	db.Create(post)
+
+	likeCount := func() int64 {
+		var saved Post
+		db.First(&saved, post.ID)
+		return saved.LikeCount
+	}
+
+	t.Run("いいねは何度行っても1件", func(t *testing.T) {
+		for i, expected := range []bool{true, false} {
+			liked, err := LikePost(db, user.ID, post.ID)
+			if err != nil || liked != expected {
+				t.Errorf("Attempt %d: expected liked %v, got %v, %v", i+1, expected, liked, err)
+			}
+		}
+		if count := likeCount(); count != 1 {
+			t.Errorf("Expected 1 like, got %d", count)
+		}
+
+		liked, err := LikedPostIDs(db, user.ID, []uint{post.ID})
+		if err != nil || !liked[post.ID] {
+			t.Errorf("Expected post to be liked, got %v, %v", liked, err)
+		}
+	})
+
+	t.Run("取り消しは何度行ってもエラーにしない", func(t *testing.T) {
+		for i, expected := range []bool{true, false} {
+			removed, err := RemoveLike(db, user.ID, post.ID)
+			if err != nil || removed != expected {
+				t.Errorf("Attempt %d: expected removed %v, got %v, %v", i+1, expected, removed, err)
+			}
+		}
+		if count := likeCount(); count != 0 {
+			t.Errorf("Expected no likes, got %d", count)
+		}
+	})
+
+	t.Run("IDなしはエラー", func(t *testing.T) {
+		if _, err := LikePost(db, 0, post.ID); err == nil {
+			t.Error("Expected error without user ID")
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ロックアウトの対象
+const (
+	LockoutScopeAccount = "account" // メールアドレス単位
+	LockoutScopeIP      = "ip"      // クライアントIP単位
+)
+
+// LoginAttempt はログイン試行の記録です
+// 存在しないメールアドレスへの試行も同じように記録します（アカウント有無を推測させないため）
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Email     string    `json:"email" gorm:"not null;index:idx_login_attempts_email_created"`
+	UserID    *uint     `json:"userId"` // 存在しないメールアドレスの場合はNULL
+	IP        string    `json:"ip" gorm:"not null;index:idx_login_attempts_ip_created"`
+	Success   bool      `json:"success" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index:idx_login_attempts_email_created;index:idx_login_attempts_ip_created"`
+}
+
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (a *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.Email == "" {
+		return errors.New("email is required")
+	}
+	if a.IP == "" {
+		return errors.New("IP is required")
+	}
+	return nil
+}
+
+// LockoutEvent はロックアウトの監査記録です
+// LockedUntilが未来のレコードが存在する間、対象はロックされています
+type LockoutEvent struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Scope          string    `json:"scope" gorm:"not null;size:16;index:idx_lockout_events_subject"`
+	Subject        string    `json:"subject" gorm:"not null;index:idx_lockout_events_subject"` // メールアドレスまたはIP
+	UserID         *uint     `json:"userId"`
+	IP             string    `json:"ip" gorm:"not null"` // ロックのきっかけになった試行のIP
+	FailedAttempts int       `json:"failedAttempts" gorm:"not null"`
+	LockedUntil    time.Time `json:"lockedUntil" gorm:"not null"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func (LockoutEvent) TableName() string {
+	return "lockout_events"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (e *LockoutEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.Scope != LockoutScopeAccount && e.Scope != LockoutScopeIP {
+		return errors.New("invalid lockout scope")
+	}
+	if e.Subject == "" {
+		return errors.New("subject is required")
+	}
+	if e.LockedUntil.IsZero() {
+		return errors.New("locked until is required")
+	}
+	return nil
+}
+
+// DeleteLoginHistory はcutoffより前のログイン試行と、cutoffより前に解除されたロックアウトを削除し、削除した件数を返します
+func DeleteLoginHistory(db *gorm.DB, cutoff time.Time) (int64, error) {
+	attempts := db.Where("created_at < ?", cutoff).Delete(&LoginAttempt{})
+	if attempts.Error != nil {
+		return 0, attempts.Error
+	}
+	events := db.Where("locked_until < ?", cutoff).Delete(&LockoutEvent{})
+	return attempts.RowsAffected + events.RowsAffected, events.Error
+}
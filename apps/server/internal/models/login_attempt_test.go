@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginAttempt_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	tests := []struct {
+		name    string
+		attempt LoginAttempt
+		wantErr bool
+	}{
+		{
+			name:    "有効な試行記録",
+			attempt: LoginAttempt{Email: "alice@example.com", IP: "203.0.113.1"},
+			wantErr: false,
+		},
+		{
+			name:    "メールアドレスが空の場合はエラー",
+			attempt: LoginAttempt{IP: "203.0.113.1"},
+			wantErr: true,
+		},
+		{
+			name:    "IPが空の場合はエラー",
+			attempt: LoginAttempt{Email: "alice@example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := db.Create(&tt.attempt)
+			if tt.wantErr {
+				if result.Error == nil {
+					t.Errorf("Expected error but got none")
+				}
+			} else if result.Error != nil {
+				t.Errorf("Expected no error but got: %v", result.Error)
+			}
+		})
+	}
+}
+
+func TestLockoutEvent_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	lockedUntil := time.Now().Add(15 * time.Minute)
+
+	tests := []struct {
+		name    string
+		event   LockoutEvent
+		wantErr bool
+	}{
+		{
+			name:    "アカウントのロック",
+			event:   LockoutEvent{Scope: LockoutScopeAccount, Subject: "alice@example.com", IP: "203.0.113.1", FailedAttempts: 10, LockedUntil: lockedUntil},
+			wantErr: false,
+		},
+		{
+			name:    "IPのロック",
+			event:   LockoutEvent{Scope: LockoutScopeIP, Subject: "203.0.113.1", IP: "203.0.113.1", FailedAttempts: 50, LockedUntil: lockedUntil},
+			wantErr: false,
+		},
+		{
+			name:    "不正なスコープはエラー",
+			event:   LockoutEvent{Scope: "planet", Subject: "earth", LockedUntil: lockedUntil},
+			wantErr: true,
+		},
+		{
+			name:    "ロック期限がない場合はエラー",
+			event:   LockoutEvent{Scope: LockoutScopeAccount, Subject: "alice@example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := db.Create(&tt.event)
+			if tt.wantErr {
+				if result.Error == nil {
+					t.Errorf("Expected error but got none")
+				}
+			} else if result.Error != nil {
+				t.Errorf("Expected no error but got: %v", result.Error)
+			}
+		})
+	}
+}
+
+func TestDeleteLoginHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	cutoff := now.Add(-24 * time.Hour)
+	db.Create(&LoginAttempt{Email: "old@example.com", IP: "203.0.113.1", CreatedAt: cutoff.Add(-time.Minute)})
+	db.Create(&LoginAttempt{Email: "recent@example.com", IP: "203.0.113.1", CreatedAt: now})
+	db.Create(&LockoutEvent{Scope: LockoutScopeAccount, Subject: "old@example.com", IP: "203.0.113.1", FailedAttempts: 10, LockedUntil: cutoff.Add(-time.Minute)})
+	db.Create(&LockoutEvent{Scope: LockoutScopeIP, Subject: "203.0.113.1", IP: "203.0.113.1", FailedAttempts: 50, LockedUntil: now.Add(time.Minute)})
+
+	deleted, err := DeleteLoginHistory(db, cutoff)
+	if err != nil || deleted != 2 {
+		t.Fatalf("Expected 2 deleted rows, got %d, %v", deleted, err)
+	}
+
+	var emails []string
+	db.Model(&LoginAttempt{}).Pluck("email", &emails)
+	if len(emails) != 1 || emails[0] != "recent@example.com" {
+		t.Errorf("Expected only the recent attempt to remain, got %v", emails)
+	}
+	var subjects []string
+	db.Model(&LockoutEvent{}).Pluck("subject", &subjects)
+	if len(subjects) != 1 || subjects[0] != "203.0.113.1" {
+		t.Errorf("Expected only the active lockout to remain, got %v", subjects)
+	}
+}
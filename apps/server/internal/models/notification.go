@@ -0,0 +1,187 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 通知の種類（GraphQLのNotificationTypeと同じ値）
+const (
+	NotificationTypeLike    = "LIKE"
+	NotificationTypeFollow  = "FOLLOW"
+	NotificationTypeReply   = "REPLY"
+	NotificationTypeMention = "MENTION"
+	// 非公開アカウントへのフォローリクエスト
+	NotificationTypeFollowRequest = "FOLLOW_REQUEST"
+)
+
+// Notification はユーザーへの通知です
+// 同じ投稿へのいいねなど、未読の間は同じGroupKeyの通知に行為者をまとめます
+// （「Aliceさんと他3人があなたの投稿にいいねしました」）
+type Notification struct {
+	ID uint `json:"id" gorm:"primaryKey"`
+	// 通知を受け取るユーザー
+	UserID uint   `json:"-" gorm:"not null;index:idx_notifications_user_updated;uniqueIndex:idx_notifications_unread_group,where:read_at IS NULL"`
+	Type   string `json:"type" gorm:"not null;size:16"`
+	// いいね・リプライ・メンションの対象投稿
+	PostID *uint `json:"-"`
+	// 通知をまとめる単位（例: "LIKE:12"）
+	GroupKey   string     `json:"-" gorm:"not null;size:64;uniqueIndex:idx_notifications_unread_group,where:read_at IS NULL"`
+	ActorCount int        `json:"actorCount" gorm:"not null;default:0"`
+	ReadAt     *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	// 最後に行為者が加わった時刻
+	UpdatedAt time.Time `json:"updatedAt" gorm:"index:idx_notifications_user_updated"`
+
+	// リレーション
+	User   User                `json:"-" gorm:"foreignKey:UserID"`
+	Post   *Post               `json:"post" gorm:"foreignKey:PostID"`
+	Actors []NotificationActor `json:"-" gorm:"foreignKey:NotificationID"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.UserID == 0 {
+		return errors.New("recipient is required")
+	}
+	switch n.Type {
+	case NotificationTypeLike, NotificationTypeReply, NotificationTypeMention:
+		if n.PostID == nil {
+			return errors.New("post is required for this notification type")
+		}
+	case NotificationTypeFollow, NotificationTypeFollowRequest:
+	default:
+		return errors.New("invalid notification type")
+	}
+	if n.GroupKey == "" {
+		n.GroupKey = NotificationGroupKey(n.Type, n.PostID)
+	}
+	return nil
+}
+
+// NotificationActor は通知の行為者（いいね・フォローしたユーザーなど）です
+type NotificationActor struct {
+	NotificationID uint      `json:"-" gorm:"primaryKey"`
+	ActorID        uint      `json:"-" gorm:"primaryKey"`
+	CreatedAt      time.Time `json:"createdAt" gorm:"index"`
+
+	Actor User `json:"actor" gorm:"foreignKey:ActorID"`
+}
+
+func (NotificationActor) TableName() string {
+	return "notification_actors"
+}
+
+// NotificationGroupKey は通知をまとめる単位のキーを返します
+// いいねは投稿ごと、フォローは受け取るユーザーごとにまとめ、リプライ・メンションは投稿ごとに別の通知になる
+func NotificationGroupKey(notificationType string, postID *uint) string {
+	if postID == nil {
+		return notificationType
+	}
+	return fmt.Sprintf("%s:%d", notificationType, *postID)
+}
+
+// RecordNotification は通知を記録します
+// 同じグループの未読の通知があれば行為者を追加し、なければ新しい通知を作成します
+// 同じ行為者が繰り返し行っても（いいね→取り消し→いいね）人数は増えません
+// 自分自身の行為と、ブロックの関係にあるユーザーの行為は通知しません
+func RecordNotification(db *gorm.DB, recipientID, actorID uint, notificationType string, postID *uint) error {
+	if recipientID == actorID || IsBlockedBetween(db, recipientID, actorID) {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		notification := Notification{UserID: recipientID, Type: notificationType, PostID: postID}
+		if err := notification.BeforeCreate(tx); err != nil {
+			return err
+		}
+
+		// 未読のグループがあれば更新時刻を進め、なければ作成する（部分ユニークインデックスで競合を防ぐ）
+		now := time.Now()
+		var notificationID uint
+		err := tx.Raw(`
+			INSERT INTO notifications (user_id, type, post_id, group_key, actor_count, created_at, updated_at)
+			VALUES (?, ?, ?, ?, 0, ?, ?)
+			ON CONFLICT (user_id, group_key) WHERE read_at IS NULL
+			DO UPDATE SET updated_at = excluded.updated_at
+			RETURNING id`,
+			recipientID, notificationType, postID, notification.GroupKey, now, now,
+		).Scan(&notificationID).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Exec(`
+			INSERT INTO notification_actors (notification_id, actor_id, created_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT DO NOTHING`,
+			notificationID, actorID, now,
+		)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return tx.Model(&Notification{}).Where("id = ?", notificationID).
+			UpdateColumn("actor_count", gorm.Expr("actor_count + 1")).Error
+	})
+}
+
+// UnreadNotificationCount は未読の通知の件数を返します
+func UnreadNotificationCount(db *gorm.DB, userID uint) int64 {
+	var count int64
+	db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&count)
+	return count
+}
+
+// MarkNotificationsRead は通知を既読にし、更新した件数を返します
+// idsが空の場合は全ての未読の通知を既読にします
+func MarkNotificationsRead(db *gorm.DB, userID uint, ids []uint) (int64, error) {
+	query := db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.UpdateColumn("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// NotificationMessage は通知の文面を作成します（例: "Alice and 3 others liked your post"）
+// actorNamesは新しい順の行為者の名前、actorCountは行為者の総数です
+func NotificationMessage(notificationType string, actorNames []string, actorCount int) string {
+	var actors string
+	switch {
+	case len(actorNames) == 0:
+		actors = "Someone"
+	case actorCount <= 1:
+		actors = actorNames[0]
+	case actorCount == 2 && len(actorNames) >= 2:
+		actors = actorNames[0] + " and " + actorNames[1]
+	case actorCount == 2:
+		actors = actorNames[0] + " and 1 other"
+	default:
+		actors = fmt.Sprintf("%s and %d others", actorNames[0], actorCount-1)
+	}
+
+	switch notificationType {
+	case NotificationTypeLike:
+		return actors + " liked your post"
+	case NotificationTypeFollow:
+		return actors + " followed you"
+	case NotificationTypeReply:
+		return actors + " replied to your post"
+	case NotificationTypeMention:
+		return actors + " mentioned you"
+	case NotificationTypeFollowRequest:
+		return actors + " requested to follow you"
+	}
+	return actors
+}
@@ -0,0 +1,135 @@
+package models
+
+import "testing"
+
+func TestRecordNotification_Aggregation(t *testing.T) {
+	db := setupTestDB(t)
+
+	author := User{Username: "author", Email: "author@example.com", Password: "password", Name: "Author"}
+	db.Create(&author)
+	post := Post{Content: "Hello", AuthorID: author.ID}
+	db.Create(&post)
+
+	var likers []User
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user := User{Username: name, Email: name + "@example.com", Password: "password", Name: name}
+		db.Create(&user)
+		likers = append(likers, user)
+	}
+
+	// 同じ投稿へのいいねは1件の通知にまとまる
+	for _, liker := range likers {
+		if err := RecordNotification(db, author.ID, liker.ID, NotificationTypeLike, &post.ID); err != nil {
+			t.Fatalf("Failed to record notification: %v", err)
+		}
+	}
+	// 同じ人の再度のいいねは人数に数えない
+	RecordNotification(db, author.ID, likers[0].ID, NotificationTypeLike, &post.ID)
+	// 自分自身のいいねは通知しない
+	RecordNotification(db, author.ID, author.ID, NotificationTypeLike, &post.ID)
+
+	var notifications []Notification
+	db.Where("user_id = ?", author.ID).Find(&notifications)
+	if len(notifications) != 1 {
+		t.Fatalf("Expected 1 aggregated notification, got %d", len(notifications))
+	}
+	if notifications[0].ActorCount != 3 {
+		t.Errorf("Expected 3 actors, got %d", notifications[0].ActorCount)
+	}
+	if count := UnreadNotificationCount(db, author.ID); count != 1 {
+		t.Errorf("Expected 1 unread notification, got %d", count)
+	}
+
+	// 既読にした後のいいねは新しい通知になる
+	if n, err := MarkNotificationsRead(db, author.ID, nil); err != nil || n != 1 {
+		t.Fatalf("Expected 1 notification to be marked read, got %d, %v", n, err)
+	}
+	dave := User{Username: "dave", Email: "dave@example.com", Password: "password", Name: "Dave"}
+	db.Create(&dave)
+	RecordNotification(db, author.ID, dave.ID, NotificationTypeLike, &post.ID)
+
+	db.Where("user_id = ?", author.ID).Order("id").Find(&notifications)
+	if len(notifications) != 2 || notifications[1].ActorCount != 1 {
+		t.Errorf("Expected a new notification after reading, got %+v", notifications)
+	}
+	if count := UnreadNotificationCount(db, author.ID); count != 1 {
+		t.Errorf("Expected 1 unread notification, got %d", count)
+	}
+}
+
+func TestNotification_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	postID := uint(1)
+
+	tests := []struct {
+		name         string
+		notification Notification
+		wantErr      bool
+	}{
+		{
+			name:         "フォロー通知",
+			notification: Notification{UserID: 1, Type: NotificationTypeFollow},
+			wantErr:      false,
+		},
+		{
+			name:         "いいね通知",
+			notification: Notification{UserID: 1, Type: NotificationTypeLike, PostID: &postID},
+			wantErr:      false,
+		},
+		{
+			name:         "投稿のないいいね通知はエラー",
+			notification: Notification{UserID: 1, Type: NotificationTypeLike},
+			wantErr:      true,
+		},
+		{
+			name:         "不正な種類はエラー",
+			notification: Notification{UserID: 1, Type: "POKE"},
+			wantErr:      true,
+		},
+		{
+			name:         "受信者がいない場合はエラー",
+			notification: Notification{Type: NotificationTypeFollow},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := db.Create(&tt.notification)
+			if tt.wantErr {
+				if result.Error == nil {
+					t.Errorf("Expected error but got none")
+				}
+			} else if result.Error != nil {
+				t.Errorf("Expected no error but got: %v", result.Error)
+			}
+		})
+	}
+}
+
+func TestNotificationMessage(t *testing.T) {
+	tests := []struct {
+		name       string
+		typ        string
+		actorNames []string
+		actorCount int
+		expected   string
+	}{
+		{name: "1人のいいね", typ: NotificationTypeLike, actorNames: []string{"Alice"}, actorCount: 1, expected: "Alice liked your post"},
+		{name: "2人のいいね", typ: NotificationTypeLike, actorNames: []string{"Alice", "Bob"}, actorCount: 2, expected: "Alice and Bob liked your post"},
+		{name: "4人のいいね", typ: NotificationTypeLike, actorNames: []string{"Alice", "Bob", "Carol"}, actorCount: 4, expected: "Alice and 3 others liked your post"},
+		{name: "フォロー", typ: NotificationTypeFollow, actorNames: []string{"Alice"}, actorCount: 1, expected: "Alice followed you"},
+		{name: "リプライ", typ: NotificationTypeReply, actorNames: []string{"Alice"}, actorCount: 1, expected: "Alice replied to your post"},
+		{name: "メンション", typ: NotificationTypeMention, actorNames: []string{"Alice"}, actorCount: 1, expected: "Alice mentioned you"},
+		{name: "2人のフォローリクエスト", typ: NotificationTypeFollowRequest, actorNames: []string{"Alice", "Bob"}, actorCount: 2, expected: "Alice and Bob requested to follow you"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NotificationMessage(tt.typ, tt.actorNames, tt.actorCount); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 永続化クエリの登録元
+const (
+	PersistedQuerySourceAPQ      = "apq"      // クライアントの自動登録（APQ）
+	PersistedQuerySourceManifest = "manifest" // ビルド時のマニフェストから登録（許可リスト）
+)
+
+// PersistedQuery はSHA-256ハッシュで参照できるように保存したクエリです
+type PersistedQuery struct {
+	Hash          string     `json:"hash" gorm:"primaryKey;size:64"` // クエリ本文のSHA-256（16進小文字）
+	Query         string     `json:"query" gorm:"type:text;not null"`
+	OperationName string     `json:"operationName"`
+	Source        string     `json:"source" gorm:"not null;size:16;index"`
+	ExpiresAt     *time.Time `json:"expiresAt" gorm:"index"` // APQで登録したクエリの有効期限（マニフェストは無期限）
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (PersistedQuery) TableName() string {
+	return "persisted_queries"
+}
+
+// HashQuery はクエリ本文のSHA-256ハッシュを返します
+func HashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsExpired は有効期限を過ぎているかを返します
+func (q *PersistedQuery) IsExpired(now time.Time) bool {
+	return q.ExpiresAt != nil && !now.Before(*q.ExpiresAt)
+}
+
+// DeleteExpiredPersistedQueries は有効期限を過ぎたAPQのクエリを削除し、削除した件数を返します
+func DeleteExpiredPersistedQueries(db *gorm.DB, now time.Time) (int64, error) {
+	result := db.Where("source = ? AND expires_at <= ?", PersistedQuerySourceAPQ, now).Delete(&PersistedQuery{})
+	return result.RowsAffected, result.Error
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (q *PersistedQuery) BeforeCreate(tx *gorm.DB) error {
+	if q.Query == "" {
+		return errors.New("query is required")
+	}
+	if q.Hash != HashQuery(q.Query) {
+		return errors.New("hash does not match query")
+	}
+	if q.Source != PersistedQuerySourceAPQ && q.Source != PersistedQuerySourceManifest {
+		return errors.New("invalid persisted query source")
+	}
+	return nil
+}
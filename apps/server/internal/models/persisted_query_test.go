@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPersistedQuery_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	query := `{ posts { id content } }`
+
+	tests := []struct {
+		name    string
+		entry   PersistedQuery
+		wantErr bool
+	}{
+		{
+			name:    "有効な永続化クエリ",
+			entry:   PersistedQuery{Hash: HashQuery(query), Query: query, Source: PersistedQuerySourceManifest},
+			wantErr: false,
+		},
+		{
+			name:    "ハッシュが一致しない場合はエラー",
+			entry:   PersistedQuery{Hash: HashQuery("{ users { id } }"), Query: query, Source: PersistedQuerySourceAPQ},
+			wantErr: true,
+		},
+		{
+			name:    "クエリが空の場合はエラー",
+			entry:   PersistedQuery{Hash: HashQuery(""), Source: PersistedQuerySourceAPQ},
+			wantErr: true,
+		},
+		{
+			name:    "不正な登録元はエラー",
+			entry:   PersistedQuery{Hash: HashQuery("{ me { id } }"), Query: "{ me { id } }", Source: "unknown"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := db.Create(&tt.entry)
+			if tt.wantErr {
+				if result.Error == nil {
+					t.Errorf("Expected error but got none")
+				}
+			} else if result.Error != nil {
+				t.Errorf("Expected no error but got: %v", result.Error)
+			}
+		})
+	}
+}
+
+func TestHashQuery(t *testing.T) {
+	expected := "c53d78fa4c9c65a93967d42316fcd207fd611c7cac40a103820a866c3e5dd8f5"
+	if got := HashQuery("{ me { id } }"); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestDeleteExpiredPersistedQueries(t *testing.T) {
+	db := setupTestDB(t)
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+	expired := `{ posts { id } }`
+	active := `{ users { id } }`
+	manifest := `{ me { id } }`
+	db.Create(&PersistedQuery{Hash: HashQuery(expired), Query: expired, Source: PersistedQuerySourceAPQ, ExpiresAt: &past})
+	db.Create(&PersistedQuery{Hash: HashQuery(active), Query: active, Source: PersistedQuerySourceAPQ, ExpiresAt: &future})
+	db.Create(&PersistedQuery{Hash: HashQuery(manifest), Query: manifest, Source: PersistedQuerySourceManifest})
+
+	deleted, err := DeleteExpiredPersistedQueries(db, now)
+	if err != nil || deleted != 1 {
+		t.Fatalf("Expected 1 deleted query, got %d, %v", deleted, err)
+	}
+
+	var queries []string
+	db.Model(&PersistedQuery{}).Order("query").Pluck("query", &queries)
+	if len(queries) != 2 || queries[0] != manifest || queries[1] != active {
+		t.Errorf("Expected active and manifest queries to remain, got %v", queries)
+	}
+}
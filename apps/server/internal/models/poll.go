@@ -0,0 +1,217 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 投票の選択肢の数・長さと期間の制限
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 4
+	MaxPollOptionLength = 25 // 文字数
+	MinPollDuration     = 5 * time.Minute
+	MaxPollDuration     = 7 * 24 * time.Hour
+)
+
+var (
+	ErrPollExpired       = errors.New("poll has expired")
+	ErrPollOptionInvalid = errors.New("option does not belong to the poll")
+)
+
+// Poll は投稿に付けた投票です（1つの投稿に1つまで）
+// 選択肢ごとの票数はPollOption.VoteCountに保存します
+type Poll struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"postId" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// リレーション
+	Options []PollOption `json:"options" gorm:"foreignKey:PollID"`
+}
+
+func (Poll) TableName() string {
+	return "polls"
+}
+
+// PollOption は投票の選択肢です
+type PollOption struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	PollID    uint   `json:"pollId" gorm:"not null;index"`
+	Position  int    `json:"position" gorm:"not null"` // 表示順（0から）
+	Text      string `json:"text" gorm:"not null;size:25"`
+	VoteCount int64  `json:"voteCount" gorm:"not null;default:0"` // 投票時に同じトランザクション内で増やす
+}
+
+func (PollOption) TableName() string {
+	return "poll_options"
+}
+
+// PollVote はユーザーの投票です（1つの投票に1人1回まで）
+type PollVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_poll_votes_user_poll"`
+	PollID    uint      `json:"pollId" gorm:"not null;uniqueIndex:idx_poll_votes_user_poll"`
+	OptionID  uint      `json:"optionId" gorm:"not null;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (PollVote) TableName() string {
+	return "poll_votes"
+}
+
+// NewPoll は選択肢と期限をチェックして投票を作成します（投稿と一緒に保存する）
+func NewPoll(options []string, expiresAt, now time.Time) (*Poll, error) {
+	duration := expiresAt.Sub(now)
+	if duration < MinPollDuration || duration > MaxPollDuration {
+		return nil, fmt.Errorf("poll must expire between %s and %s from now", MinPollDuration, MaxPollDuration)
+	}
+
+	poll := &Poll{ExpiresAt: expiresAt}
+	for i, text := range options {
+		poll.Options = append(poll.Options, PollOption{Position: i, Text: strings.TrimSpace(text)})
+	}
+	if err := poll.validateOptions(); err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// IsExpired は投票の期限を過ぎたかを返します
+func (p *Poll) IsExpired(now time.Time) bool {
+	return !p.ExpiresAt.After(now)
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (p *Poll) BeforeCreate(tx *gorm.DB) error {
+	if p.ExpiresAt.IsZero() {
+		return errors.New("poll expiry is required")
+	}
+	return p.validateOptions()
+}
+
+func (p *Poll) validateOptions() error {
+	if len(p.Options) < MinPollOptions || len(p.Options) > MaxPollOptions {
+		return fmt.Errorf("poll must have %d to %d options", MinPollOptions, MaxPollOptions)
+	}
+	seen := make(map[string]bool, len(p.Options))
+	for _, option := range p.Options {
+		if err := validatePollOptionText(option.Text); err != nil {
+			return err
+		}
+		if seen[option.Text] {
+			return errors.New("poll options must be unique")
+		}
+		seen[option.Text] = true
+	}
+	return nil
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (o *PollOption) BeforeCreate(tx *gorm.DB) error {
+	return validatePollOptionText(o.Text)
+}
+
+func validatePollOptionText(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return errors.New("poll option cannot be empty")
+	}
+	if len([]rune(text)) > MaxPollOptionLength {
+		return fmt.Errorf("poll option exceeds %d characters", MaxPollOptionLength)
+	}
+	return nil
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (v *PollVote) BeforeCreate(tx *gorm.DB) error {
+	if v.UserID == 0 || v.PollID == 0 || v.OptionID == 0 {
+		return errors.New("user ID, poll ID and option ID are required")
+	}
+	return nil
+}
+
+// AfterCreate は選択肢の票数を増やします
+func (v *PollVote) AfterCreate(tx *gorm.DB) error {
+	return adjustCounter(tx, &PollOption{}, v.OptionID, "vote_count", 1)
+}
+
+// CastPollVote は投票し、投票したかを返します（投票済みの場合はfalse）
+// 期限を過ぎた投票と、投票に含まれない選択肢はエラー
+func CastPollVote(db *gorm.DB, userID, pollID, optionID uint, now time.Time) (bool, error) {
+	if userID == 0 || pollID == 0 || optionID == 0 {
+		return false, errors.New("user ID, poll ID and option ID are required")
+	}
+
+	voted := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var poll Poll
+		if err := tx.First(&poll, pollID).Error; err != nil {
+			return err
+		}
+		if poll.IsExpired(now) {
+			return ErrPollExpired
+		}
+
+		var options int64
+		if err := tx.Model(&PollOption{}).Where("id = ? AND poll_id = ?", optionID, pollID).Count(&options).Error; err != nil {
+			return err
+		}
+		if options == 0 {
+			return ErrPollOptionInvalid
+		}
+
+		// AfterCreateは挿入しなかった場合にも呼ばれるため、票数はここで増やす
+		result := tx.Session(&gorm.Session{SkipHooks: true}).
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "poll_id"}}, DoNothing: true}).
+			Create(&PollVote{UserID: userID, PollID: pollID, OptionID: optionID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		voted = true
+		return adjustCounter(tx, &PollOption{}, optionID, "vote_count", 1)
+	})
+	return voted, err
+}
+
+// PollsForPosts は投稿に付けた投票を選択肢の順に読み込み、投稿IDごとに返します
+func PollsForPosts(db *gorm.DB, postIDs []uint) (map[uint]*Poll, error) {
+	polls := make(map[uint]*Poll)
+	if len(postIDs) == 0 {
+		return polls, nil
+	}
+
+	var rows []Poll
+	err := db.Preload("Options", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("post_id IN ?", postIDs).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		polls[rows[i].PostID] = &rows[i]
+	}
+	return polls, nil
+}
+
+// VotedOptionIDs は指定した投票のうちユーザーが投票した選択肢のIDを、投票IDごとに返します
+func VotedOptionIDs(db *gorm.DB, userID uint, pollIDs []uint) (map[uint]uint, error) {
+	voted := make(map[uint]uint)
+	if userID == 0 || len(pollIDs) == 0 {
+		return voted, nil
+	}
+
+	var votes []PollVote
+	if err := db.Where("user_id = ? AND poll_id IN ?", userID, pollIDs).Find(&votes).Error; err != nil {
+		return nil, err
+	}
+	for _, vote := range votes {
+		voted[vote.PollID] = vote.OptionID
+	}
+	return voted, nil
+}
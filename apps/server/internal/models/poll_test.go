@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPoll(t *testing.T) {
+	now := time.Now()
+	inOneDay := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		options   []string
+		expiresAt time.Time
+		wantErr   bool
+	}{
+		{name: "選択肢2個", options: []string{"はい", "いいえ"}, expiresAt: inOneDay},
+		{name: "選択肢4個", options: []string{"A", "B", "C", "D"}, expiresAt: inOneDay},
+		{name: "選択肢1個はエラー", options: []string{"はい"}, expiresAt: inOneDay, wantErr: true},
+		{name: "選択肢5個はエラー", options: []string{"A", "B", "C", "D", "E"}, expiresAt: inOneDay, wantErr: true},
+		{name: "空の選択肢はエラー", options: []string{"はい", "  "}, expiresAt: inOneDay, wantErr: true},
+		{name: "26文字以上の選択肢はエラー", options: []string{"はい", strings.Repeat("あ", 26)}, expiresAt: inOneDay, wantErr: true},
+		{name: "重複した選択肢はエラー", options: []string{"はい", " はい "}, expiresAt: inOneDay, wantErr: true},
+		{name: "期限が過去はエラー", options: []string{"はい", "いいえ"}, expiresAt: now.Add(-time.Minute), wantErr: true},
+		{name: "期限が5分未満はエラー", options: []string{"はい", "いいえ"}, expiresAt: now.Add(time.Minute), wantErr: true},
+		{name: "期限が7日を超えるとエラー", options: []string{"はい", "いいえ"}, expiresAt: now.Add(8 * 24 * time.Hour), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll, err := NewPoll(tt.options, tt.expiresAt, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewPoll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(poll.Options) != len(tt.options) {
+				t.Errorf("Expected %d options, got %d", len(tt.options), len(poll.Options))
+			}
+		})
+	}
+}
+
+func TestCastPollVote(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	for _, u := range []*User{alice, bob} {
+		if err := db.Create(u).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	now := time.Now()
+	poll, err := NewPoll([]string{"はい", "いいえ"}, now.Add(time.Hour), now)
+	if err != nil {
+		t.Fatalf("Failed to build poll: %v", err)
+	}
+	post := &Post{Content: "どちらにしますか", AuthorID: alice.ID, Poll: poll}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("Failed to create post with poll: %v", err)
+	}
+	yes, no := poll.Options[0].ID, poll.Options[1].ID
+
+	voteCounts := func() (int64, int64) {
+		polls, err := PollsForPosts(db, []uint{post.ID})
+		if err != nil || polls[post.ID] == nil {
+			t.Fatalf("Failed to load poll: %v", err)
+		}
+		options := polls[post.ID].Options
+		return options[0].VoteCount, options[1].VoteCount
+	}
+
+	t.Run("投稿と一緒に選択肢を順に作成する", func(t *testing.T) {
+		polls, err := PollsForPosts(db, []uint{post.ID})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		saved := polls[post.ID]
+		if saved == nil || len(saved.Options) != 2 || saved.Options[0].Text != "はい" || saved.Options[1].Text != "いいえ" {
+			t.Errorf("Expected poll with ordered options, got %+v", saved)
+		}
+	})
+
+	t.Run("投票すると票数を増やす", func(t *testing.T) {
+		voted, err := CastPollVote(db, bob.ID, poll.ID, yes, now)
+		if err != nil || !voted {
+			t.Fatalf("Expected vote to be cast, got %v, %v", voted, err)
+		}
+		if y, n := voteCounts(); y != 1 || n != 0 {
+			t.Errorf("Expected 1 and 0 votes, got %d, %d", y, n)
+		}
+	})
+
+	t.Run("同じ投票には1回しか投票できない", func(t *testing.T) {
+		voted, err := CastPollVote(db, bob.ID, poll.ID, no, now)
+		if err != nil || voted {
+			t.Errorf("Expected second vote to be ignored, got %v, %v", voted, err)
+		}
+		if y, n := voteCounts(); y != 1 || n != 0 {
+			t.Errorf("Expected vote counts to be unchanged, got %d, %d", y, n)
+		}
+	})
+
+	t.Run("他の投票の選択肢はエラー", func(t *testing.T) {
+		other, _ := NewPoll([]string{"A", "B"}, now.Add(time.Hour), now)
+		db.Create(&Post{Content: "別の投票", AuthorID: bob.ID, Poll: other})
+
+		if _, err := CastPollVote(db, alice.ID, poll.ID, other.Options[0].ID, now); !errors.Is(err, ErrPollOptionInvalid) {
+			t.Errorf("Expected ErrPollOptionInvalid, got %v", err)
+		}
+	})
+
+	t.Run("期限を過ぎた投票はエラー", func(t *testing.T) {
+		if _, err := CastPollVote(db, alice.ID, poll.ID, no, poll.ExpiresAt); !errors.Is(err, ErrPollExpired) {
+			t.Errorf("Expected ErrPollExpired, got %v", err)
+		}
+		if y, n := voteCounts(); y != 1 || n != 0 {
+			t.Errorf("Expected vote counts to be unchanged, got %d, %d", y, n)
+		}
+	})
+
+	t.Run("投票した選択肢を返す", func(t *testing.T) {
+		voted, err := VotedOptionIDs(db, bob.ID, []uint{poll.ID})
+		if err != nil || voted[poll.ID] != yes {
+			t.Errorf("Expected bob's choice, got %v, %v", voted, err)
+		}
+		voted, err = VotedOptionIDs(db, alice.ID, []uint{poll.ID})
+		if err != nil || len(voted) != 0 {
+			t.Errorf("Expected no choice for alice, got %v, %v", voted, err)
+		}
+	})
+}
@@ -8,33 +8,32 @@ import (
 )
 
 type Post struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Content   string         `json:"content" gorm:"not null;size:280"` // Twitter風の文字制限
-	AuthorID  uint           `json:"authorId" gorm:"not null"`
-	ParentID  *uint          `json:"parentId"` // リプライ用（NULLable）
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // ソフトデリート
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Content  string `json:"content" gorm:"not null;size:280"` // Twitter風の文字制限
+	AuthorID uint   `json:"authorId" gorm:"not null"`
+	ParentID *uint  `json:"parentId"` // リプライ用（NULLable）
+	// 引用投稿の場合の引用元（NULLable）
+	QuotedPostID *uint `json:"quotedPostId" gorm:"index"`
+	// 件数（作成・削除時に同じトランザクション内で更新する、counters.goを参照）
+	LikeCount   int64          `json:"likeCount" gorm:"not null;default:0"`
+	ReplyCount  int64          `json:"replyCount" gorm:"not null;default:0"` // 非表示のリプライは数えない
+	RepostCount int64          `json:"repostCount" gorm:"not null;default:0"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"` // ソフトデリート
 
 	// リレーション
 	Author  User   `json:"author" gorm:"foreignKey:AuthorID"`
 	Parent  *Post  `json:"parent" gorm:"foreignKey:ParentID"` // リプライ元
 	Replies []Post `json:"replies" gorm:"foreignKey:ParentID"`
 	Likes   []Like `json:"likes" gorm:"foreignKey:PostID"`
-}
-
-// いいね数を取得
-func (p *Post) LikeCount(db *gorm.DB) int64 {
-	var count int64
-	db.Model(&Like{}).Where("post_id = ?", p.ID).Count(&count)
-	return count
-}
+	// 引用元（削除済みの場合はnull）
+	QuotedPost *Post `json:"quotedPost" gorm:"foreignKey:QuotedPostID"`
 
-// リプライ数を取得
-func (p *Post) ReplyCount(db *gorm.DB) int64 {
-	var count int64
-	db.Model(&Post{}).Where("parent_id = ?", p.ID).Count(&count)
-	return count
+	// 本文中のメンション・ハッシュタグ（作成時に解析）
+	Entities []PostEntity `json:"entities" gorm:"foreignKey:PostID"`
+	// 投票（作成時に一緒に保存する、APIでは閲覧中のユーザーに応じてpostViewで返す）
+	Poll *Poll `json:"-" gorm:"foreignKey:PostID"`
 }
 
 // ユーザーがいいねしているかチェック
@@ -46,20 +45,51 @@ func (p *Post) IsLikedByUser(db *gorm.DB, userID uint) bool {
 
 // バリデーション
 func (p *Post) BeforeCreate(tx *gorm.DB) error {
+	if err := validatePostContent(p.Content); err != nil {
+		return err
+	}
+
+	// 作成者IDが設定されているかチェック
+	if p.AuthorID == 0 {
+		return errors.New("author ID is required")
+	}
+
+	return nil
+}
+
+// validatePostContent は投稿・下書きの本文をチェックします
+func validatePostContent(content string) error {
 	// 内容が空でないかチェック
-	if strings.TrimSpace(p.Content) == "" {
+	if strings.TrimSpace(content) == "" {
 		return errors.New("content cannot be empty")
 	}
 
 	// 280文字制限チェック
-	if len([]rune(p.Content)) > 280 {
+	if len([]rune(content)) > 280 {
 		return errors.New("content exceeds 280 characters")
 	}
 
-	// 作成者IDが設定されているかチェック
-	if p.AuthorID == 0 {
-		return errors.New("author ID is required")
+	return nil
+}
+
+// AfterCreate は本文を解析してメンション・ハッシュタグを保存し、リプライの場合はリプライ元の件数を増やします
+func (p *Post) AfterCreate(tx *gorm.DB) error {
+	if err := AdjustReplyCount(tx, p, 1); err != nil {
+		return err
 	}
 
+	entities, err := ResolvePostEntities(tx, p.Content)
+	if err != nil {
+		return err
+	}
+	if len(entities) > 0 {
+		for i := range entities {
+			entities[i].PostID = p.ID
+		}
+		if err := tx.Omit("User").Create(&entities).Error; err != nil {
+			return err
+		}
+	}
+	p.Entities = entities
 	return nil
 }
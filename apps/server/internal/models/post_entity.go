@@ -0,0 +1,177 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// 投稿本文中のエンティティの種類（GraphQLのPostEntityTypeと同じ値）
+const (
+	PostEntityTypeMention = "MENTION"
+	PostEntityTypeHashtag = "HASHTAG"
+)
+
+// ハッシュタグの最大文字数（#を除く）
+const HashtagMaxLength = 100
+
+var (
+	// @username 形式のメンション（直前が英数字の場合はメールアドレスなどとみなして除外する）
+	mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_])(@([A-Za-z0-9_]{3,15}))\b`)
+	// #tag 形式のハッシュタグ（全角の＃や日本語のタグにも対応する）
+	// 直前が文字・数字の場合やURLのフラグメント（/#foo）、文字参照（&#39;）は除外する
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{M}\p{N}_&/])([#＃]([\p{L}\p{M}\p{N}_]+))`)
+)
+
+// PostEntity は投稿本文中のメンション・ハッシュタグです
+// 位置は文字（rune）単位で、クライアントがリンクを描画するために使います
+type PostEntity struct {
+	ID     uint   `json:"-" gorm:"primaryKey"`
+	PostID uint   `json:"-" gorm:"not null;index"`
+	Type   string `json:"type" gorm:"not null;size:16"`
+	// 本文中の開始位置と終了位置（文字単位、終了位置は含まない）
+	Start int `json:"start" gorm:"column:start_offset;not null"`
+	End   int `json:"end" gorm:"column:end_offset;not null"`
+	// 本文中の表記（例: "@Alice"、"#東京"）
+	Text string `json:"text" gorm:"not null;size:300"`
+	// 正規化したハッシュタグ（ハッシュタグのみ、検索用）
+	Tag *string `json:"tag" gorm:"size:400;index"`
+	// メンションされたユーザー（メンションのみ）
+	UserID *uint `json:"-" gorm:"index"`
+
+	// リレーション
+	User *User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+func (PostEntity) TableName() string {
+	return "post_entities"
+}
+
+// NormalizeHashtag はハッシュタグをNFKC正規化し小文字に揃えます（先頭の#は取り除く）
+// 全角英数字（例: "＃ＧＯ"）は半角の"go"と同じタグになります
+func NormalizeHashtag(tag string) string {
+	tag = norm.NFKC.String(strings.TrimSpace(tag))
+	tag = strings.TrimPrefix(tag, "#")
+	return strings.ToLower(tag)
+}
+
+// ParseEntities は投稿本文からメンションとハッシュタグを本文中の順に取り出します
+// メンションはユーザーに解決する前の状態で返します
+func ParseEntities(content string) []PostEntity {
+	var entities []PostEntity
+
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(content, -1) {
+		entities = append(entities, newPostEntity(content, PostEntityTypeMention, match[2], match[3]))
+	}
+
+	for _, match := range hashtagPattern.FindAllStringSubmatchIndex(content, -1) {
+		name := content[match[4]:match[5]]
+		// 数字のみのタグ（#1など）は除外する
+		if !strings.ContainsFunc(name, func(r rune) bool { return !unicode.IsDigit(r) }) {
+			continue
+		}
+		if utf8.RuneCountInString(name) > HashtagMaxLength {
+			continue
+		}
+		entity := newPostEntity(content, PostEntityTypeHashtag, match[2], match[3])
+		tag := NormalizeHashtag(name)
+		entity.Tag = &tag
+		entities = append(entities, entity)
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].Start < entities[j].Start
+	})
+	return entities
+}
+
+// newPostEntity はバイト位置を文字位置に変換してエンティティを作成します
+func newPostEntity(content, entityType string, startByte, endByte int) PostEntity {
+	start := utf8.RuneCountInString(content[:startByte])
+	text := content[startByte:endByte]
+	return PostEntity{
+		Type:  entityType,
+		Start: start,
+		End:   start + utf8.RuneCountInString(text),
+		Text:  text,
+	}
+}
+
+// ResolvePostEntities は投稿本文のエンティティを取り出し、メンションをユーザーに解決します
+// 存在しないユーザーへのメンションは含めません
+func ResolvePostEntities(db *gorm.DB, content string) ([]PostEntity, error) {
+	parsed := ParseEntities(content)
+
+	var usernames []string
+	for _, entity := range parsed {
+		if entity.Type == PostEntityTypeMention {
+			usernames = append(usernames, mentionUsername(entity))
+		}
+	}
+	users, err := FindUsersByUsernames(db, usernames)
+	if err != nil {
+		return nil, err
+	}
+	usersByName := make(map[string]*User, len(users))
+	for i := range users {
+		usersByName[strings.ToLower(users[i].Username)] = &users[i]
+	}
+
+	entities := make([]PostEntity, 0, len(parsed))
+	for _, entity := range parsed {
+		if entity.Type == PostEntityTypeMention {
+			user, ok := usersByName[mentionUsername(entity)]
+			if !ok {
+				continue
+			}
+			entity.UserID = &user.ID
+			entity.User = user
+		}
+		entities = append(entities, entity)
+	}
+	return entities, nil
+}
+
+// mentionUsername はメンションのユーザー名を小文字で返します
+func mentionUsername(entity PostEntity) string {
+	return strings.ToLower(strings.TrimPrefix(entity.Text, "@"))
+}
+
+// MentionedUsernames は投稿本文でメンションされているユーザー名を重複なく返します（小文字に正規化）
+func MentionedUsernames(content string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, entity := range ParseEntities(content) {
+		if entity.Type != PostEntityTypeMention {
+			continue
+		}
+		username := mentionUsername(entity)
+		if !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames
+}
+
+// FindUsersByUsernames はユーザー名（大文字小文字を区別しない）に一致するユーザーを返します
+func FindUsersByUsernames(db *gorm.DB, usernames []string) ([]User, error) {
+	var users []User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := db.Where("LOWER(username) IN ?", usernames).Find(&users).Error
+	return users, err
+}
+
+// PreloadPostEntities は投稿のエンティティを本文中の順にプリロードします
+func PreloadPostEntities(db *gorm.DB) *gorm.DB {
+	return db.Preload("Entities", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("start_offset")
+	}).Preload("Entities.User")
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMentionedUsernames(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{name: "メンションなし", content: "Hello, world", expected: nil},
+		{name: "先頭のメンション", content: "@alice hi", expected: []string{"alice"}},
+		{name: "複数のメンション", content: "hi @Alice and @bob_2!", expected: []string{"alice", "bob_2"}},
+		{name: "重複は1回", content: "@alice @ALICE", expected: []string{"alice"}},
+		{name: "メールアドレスは除外", content: "mail me at bob@example.com", expected: nil},
+		{name: "日本語の直後", content: "こんにちは@alice さん", expected: []string{"alice"}},
+		{name: "短すぎるユーザー名は除外", content: "@ab", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MentionedUsernames(tt.content)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseEntities(t *testing.T) {
+	type entity struct {
+		Type       string
+		Start, End int
+		Text       string
+		Tag        string
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []entity
+	}{
+		{name: "エンティティなし", content: "Hello, world", expected: nil},
+		{
+			name:    "メンションとハッシュタグ",
+			content: "@alice check #golang",
+			expected: []entity{
+				{Type: PostEntityTypeMention, Start: 0, End: 6, Text: "@alice"},
+				{Type: PostEntityTypeHashtag, Start: 13, End: 20, Text: "#golang", Tag: "golang"},
+			},
+		},
+		{
+			name:    "日本語の本文は文字単位の位置",
+			content: "今日は @bob と #東京 に行った",
+			expected: []entity{
+				{Type: PostEntityTypeMention, Start: 4, End: 8, Text: "@bob"},
+				{Type: PostEntityTypeHashtag, Start: 11, End: 14, Text: "#東京", Tag: "東京"},
+			},
+		},
+		{
+			name:    "全角の＃と英数字は正規化",
+			content: "＃ＧＯ言語",
+			expected: []entity{
+				{Type: PostEntityTypeHashtag, Start: 0, End: 5, Text: "＃ＧＯ言語", Tag: "go言語"},
+			},
+		},
+		{
+			name:    "大文字小文字は同じタグ",
+			content: "#GoLang",
+			expected: []entity{
+				{Type: PostEntityTypeHashtag, Start: 0, End: 7, Text: "#GoLang", Tag: "golang"},
+			},
+		},
+		{name: "数字のみのタグは除外", content: "issue #123", expected: nil},
+		{name: "URLのフラグメントは除外", content: "https://example.com/#section", expected: nil},
+		{name: "文字参照は除外", content: "it&#39;s", expected: nil},
+		{name: "単語の途中の#は除外", content: "C#", expected: nil},
+		{
+			name:    "句読点で区切る",
+			content: "#go、#rust!",
+			expected: []entity{
+				{Type: PostEntityTypeHashtag, Start: 0, End: 3, Text: "#go", Tag: "go"},
+				{Type: PostEntityTypeHashtag, Start: 4, End: 9, Text: "#rust", Tag: "rust"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []entity
+			for _, e := range ParseEntities(tt.content) {
+				item := entity{Type: e.Type, Start: e.Start, End: e.End, Text: e.Text}
+				if e.Tag != nil {
+					item.Tag = *e.Tag
+				}
+				got = append(got, item)
+
+				// 位置が本文中の表記と一致すること
+				if text := string([]rune(tt.content)[e.Start:e.End]); text != e.Text {
+					t.Errorf("Offsets [%d:%d] point to %q, expected %q", e.Start, e.End, text, e.Text)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPost_Entities(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := User{Username: "Alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	db.Create(&alice)
+	bob := User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	db.Create(&bob)
+
+	post := Post{Content: "@alice @nobody #Go と #go", AuthorID: bob.ID}
+	if err := db.Create(&post).Error; err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	// 存在しないユーザーへのメンションは保存しない
+	if len(post.Entities) != 3 {
+		t.Fatalf("Expected 3 entities, got %d", len(post.Entities))
+	}
+	if mention := post.Entities[0]; mention.UserID == nil || *mention.UserID != alice.ID {
+		t.Errorf("Expected mention resolved to alice, got %+v", mention.UserID)
+	}
+
+	var loaded Post
+	if err := PreloadPostEntities(db).First(&loaded, post.ID).Error; err != nil {
+		t.Fatalf("Failed to load post: %v", err)
+	}
+	if len(loaded.Entities) != 3 {
+		t.Fatalf("Expected 3 saved entities, got %d", len(loaded.Entities))
+	}
+	if loaded.Entities[0].User == nil || loaded.Entities[0].User.ID != alice.ID {
+		t.Error("Expected mentioned user to be preloaded")
+	}
+	for _, entity := range loaded.Entities[1:] {
+		if entity.Type != PostEntityTypeHashtag || *entity.Tag != "go" {
+			t.Errorf("Expected hashtag go, got %+v", entity)
+		}
+	}
+}
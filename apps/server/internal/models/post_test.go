@@ -123,15 +123,17 @@ func TestPost_LikeCount(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// いいねデータをクリア
+			// いいねデータをクリア（まとめて削除した件数は数え直す）
 			db.Where("1 = 1").Delete(&Like{})
+			RecountPostCounters(db, []uint{post.ID})
 
 			// テストデータ作成
 			for _, like := range tt.likes {
 				db.Create(&like)
 			}
 
-			count := post.LikeCount(db)
+			db.First(&post, post.ID)
+			count := post.LikeCount
 			if count != tt.expectedCount {
 				t.Errorf("Expected %d likes, got %d", tt.expectedCount, count)
 			}
@@ -223,7 +225,8 @@ func TestPost_Reply(t *testing.T) {
 	}
 
 	// リプライ数をチェック
-	count := parentPost.ReplyCount(db)
+	db.First(&parentPost, parentPost.ID)
+	count := parentPost.ReplyCount
 	if count != 1 {
 		t.Errorf("Expected 1 reply, got %d", count)
 	}
@@ -0,0 +1,13 @@
+package models
+
+// RateLimitBucket は複数インスタンス間で共有するレート制限のカウンターです（固定ウィンドウ方式）
+type RateLimitBucket struct {
+	Key         string `json:"key" gorm:"primaryKey;size:255"` // 例: "login:ip:203.0.113.1"
+	WindowStart int64  `json:"windowStart" gorm:"not null"`    // ウィンドウ開始時刻（UNIX秒）
+	Count       int    `json:"count" gorm:"not null"`
+	ExpiresAt   int64  `json:"expiresAt" gorm:"not null;index"` // ウィンドウ終了時刻（UNIX秒）、掃除用
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
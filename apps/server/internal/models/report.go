@@ -0,0 +1,207 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// 通報の対象の種類（GraphQLのReportTargetTypeと同じ値）
+const (
+	ReportTargetPost = "POST"
+	ReportTargetUser = "USER"
+)
+
+// 通報の状態（GraphQLのReportStatusと同じ値）
+// OPEN → REVIEWING → RESOLVED / DISMISSED の順に進み、RESOLVED・DISMISSEDになった通報は変更できない
+const (
+	ReportStatusOpen      = "OPEN"
+	ReportStatusReviewing = "REVIEWING"
+	ReportStatusResolved  = "RESOLVED"
+	ReportStatusDismissed = "DISMISSED"
+)
+
+// モデレーターの操作の種類（GraphQLのModerationActionTypeと同じ値）
+const (
+	ModerationActionHidePost      = "HIDE_POST"
+	ModerationActionRestorePost   = "RESTORE_POST"
+	ModerationActionSuspendUser   = "SUSPEND_USER"
+	ModerationActionUnsuspendUser = "UNSUSPEND_USER"
+)
+
+// MaxReportReasonLength は通報・モデレーションの理由の最大文字数です
+const MaxReportReasonLength = 500
+
+var ErrInvalidReportTransition = errors.New("invalid report status transition")
+
+// Report はユーザーによる投稿・ユーザーの通報です
+type Report struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	ReporterID uint   `json:"reporterId" gorm:"not null;index"`
+	TargetType string `json:"targetType" gorm:"not null;size:16;index:idx_reports_target"`
+	TargetID   uint   `json:"targetId" gorm:"not null;index:idx_reports_target"`
+	Reason     string `json:"reason" gorm:"not null;size:500"`
+	Status     string `json:"status" gorm:"not null;size:16;default:'OPEN';index:idx_reports_status_created"`
+	// 対応したモデレーターと対応内容（RESOLVED・DISMISSEDの場合）
+	ResolvedByID   *uint      `json:"resolvedById"`
+	ResolutionNote string     `json:"resolutionNote"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+	CreatedAt      time.Time  `json:"createdAt" gorm:"index:idx_reports_status_created"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+
+	// リレーション
+	Reporter   User  `json:"reporter" gorm:"foreignKey:ReporterID"`
+	ResolvedBy *User `json:"resolvedBy" gorm:"foreignKey:ResolvedByID"`
+}
+
+func (Report) TableName() string {
+	return "reports"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (r *Report) BeforeCreate(tx *gorm.DB) error {
+	if r.ReporterID == 0 {
+		return errors.New("reporter ID is required")
+	}
+	if !IsValidReportTargetType(r.TargetType) {
+		return errors.New("invalid report target type")
+	}
+	if r.TargetID == 0 {
+		return errors.New("target ID is required")
+	}
+	reason, err := normalizeModerationReason(r.Reason)
+	if err != nil {
+		return err
+	}
+	r.Reason = reason
+	if r.Status == "" {
+		r.Status = ReportStatusOpen
+	}
+	if r.Status != ReportStatusOpen {
+		return errors.New("new reports must be open")
+	}
+	return nil
+}
+
+// IsValidReportTargetType は通報の対象の種類が有効かチェックします
+func IsValidReportTargetType(targetType string) bool {
+	return targetType == ReportTargetPost || targetType == ReportTargetUser
+}
+
+// IsValidReportStatus は通報の状態が有効かチェックします
+func IsValidReportStatus(status string) bool {
+	switch status {
+	case ReportStatusOpen, ReportStatusReviewing, ReportStatusResolved, ReportStatusDismissed:
+		return true
+	}
+	return false
+}
+
+// IsClosed は対応済み（RESOLVED・DISMISSED）の通報かどうかを返します
+func (r *Report) IsClosed() bool {
+	return r.Status == ReportStatusResolved || r.Status == ReportStatusDismissed
+}
+
+// CanTransitionTo は通報を指定の状態に変更できるかチェックします
+func (r *Report) CanTransitionTo(status string) bool {
+	switch status {
+	case ReportStatusReviewing:
+		return r.Status == ReportStatusOpen
+	case ReportStatusResolved, ReportStatusDismissed:
+		return !r.IsClosed()
+	}
+	return false
+}
+
+// Transition は通報の状態を変更します
+// RESOLVED・DISMISSEDにする場合は対応したモデレーターとメモを記録する
+func (r *Report) Transition(db *gorm.DB, status string, moderatorID uint, note string) error {
+	if !r.CanTransitionTo(status) {
+		return ErrInvalidReportTransition
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if status == ReportStatusResolved || status == ReportStatusDismissed {
+		now := time.Now()
+		updates["resolved_by_id"] = moderatorID
+		updates["resolution_note"] = strings.TrimSpace(note)
+		updates["resolved_at"] = now
+	}
+	return db.Model(r).Updates(updates).Error
+}
+
+// ResolveReportsForTarget は対象への未対応の通報を全て対応済みにし、件数を返します
+func ResolveReportsForTarget(db *gorm.DB, targetType string, targetID, moderatorID uint, note string) (int64, error) {
+	result := db.Model(&Report{}).
+		Where("target_type = ? AND target_id = ? AND status IN ?", targetType, targetID, []string{ReportStatusOpen, ReportStatusReviewing}).
+		Updates(map[string]interface{}{
+			"status":          ReportStatusResolved,
+			"resolved_by_id":  moderatorID,
+			"resolution_note": strings.TrimSpace(note),
+			"resolved_at":     time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// ModerationAction はモデレーターが行った操作の記録です（誰が・何に・なぜ）
+type ModerationAction struct {
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	ModeratorID uint   `json:"moderatorId" gorm:"not null;index"`
+	Action      string `json:"action" gorm:"not null;size:32"`
+	TargetType  string `json:"targetType" gorm:"not null;size:16;index:idx_moderation_actions_target"`
+	TargetID    uint   `json:"targetId" gorm:"not null;index:idx_moderation_actions_target"`
+	Reason      string `json:"reason" gorm:"not null;size:500"`
+	// 通報をきっかけにした操作の場合の通報
+	ReportID  *uint     `json:"reportId"`
+	CreatedAt time.Time `json:"createdAt"`
+
+	// リレーション
+	Moderator User `json:"moderator" gorm:"foreignKey:ModeratorID"`
+}
+
+func (ModerationAction) TableName() string {
+	return "moderation_actions"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (a *ModerationAction) BeforeCreate(tx *gorm.DB) error {
+	if a.ModeratorID == 0 {
+		return errors.New("moderator ID is required")
+	}
+	switch a.Action {
+	case ModerationActionHidePost, ModerationActionRestorePost:
+		if a.TargetType != ReportTargetPost {
+			return errors.New("post actions must target a post")
+		}
+	case ModerationActionSuspendUser, ModerationActionUnsuspendUser:
+		if a.TargetType != ReportTargetUser {
+			return errors.New("user actions must target a user")
+		}
+	default:
+		return errors.New("invalid moderation action")
+	}
+	if a.TargetID == 0 {
+		return errors.New("target ID is required")
+	}
+	reason, err := normalizeModerationReason(a.Reason)
+	if err != nil {
+		return err
+	}
+	a.Reason = reason
+	return nil
+}
+
+// normalizeModerationReason は理由の前後の空白を除き、必須・文字数をチェックします
+func normalizeModerationReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return "", errors.New("reason is required")
+	}
+	if utf8.RuneCountInString(reason) > MaxReportReasonLength {
+		return "", errors.New("reason must be 500 characters or less")
+	}
+	return reason, nil
+}
@@ -0,0 +1,186 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReport_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	db.Create(alice)
+
+	tests := []struct {
+		name    string
+		report  Report
+		wantErr bool
+	}{
+		{name: "有効な投稿の通報", report: Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 1, Reason: "spam"}, wantErr: false},
+		{name: "有効なユーザーの通報", report: Report{ReporterID: alice.ID, TargetType: ReportTargetUser, TargetID: 2, Reason: "harassment"}, wantErr: false},
+		{name: "通報者のIDが空", report: Report{TargetType: ReportTargetPost, TargetID: 1, Reason: "spam"}, wantErr: true},
+		{name: "無効な対象の種類", report: Report{ReporterID: alice.ID, TargetType: "COMMENT", TargetID: 1, Reason: "spam"}, wantErr: true},
+		{name: "対象のIDが空", report: Report{ReporterID: alice.ID, TargetType: ReportTargetPost, Reason: "spam"}, wantErr: true},
+		{name: "空白のみの理由", report: Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 1, Reason: "   "}, wantErr: true},
+		{name: "500文字を超える理由", report: Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 1, Reason: strings.Repeat("あ", 501)}, wantErr: true},
+		{name: "対応済みの状態での作成", report: Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 1, Reason: "spam", Status: ReportStatusResolved}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.report).Error
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+			if !tt.wantErr && tt.report.Status != ReportStatusOpen {
+				t.Errorf("Expected status OPEN, got %s", tt.report.Status)
+			}
+		})
+	}
+}
+
+func TestReport_Transition(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	moderator := &User{Username: "carol", Email: "carol@example.com", Password: "password", Name: "Carol"}
+	db.Create(alice)
+	db.Create(moderator)
+
+	tests := []struct {
+		name    string
+		steps   []string
+		wantErr bool
+	}{
+		{name: "確認中から対応済み", steps: []string{ReportStatusReviewing, ReportStatusResolved}, wantErr: false},
+		{name: "未対応から却下", steps: []string{ReportStatusDismissed}, wantErr: false},
+		{name: "対応済みから確認中に戻す", steps: []string{ReportStatusResolved, ReportStatusReviewing}, wantErr: true},
+		{name: "却下から対応済み", steps: []string{ReportStatusDismissed, ReportStatusResolved}, wantErr: true},
+		{name: "未対応に戻す", steps: []string{ReportStatusReviewing, ReportStatusOpen}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Report{ReporterID: alice.ID, TargetType: ReportTargetUser, TargetID: moderator.ID, Reason: "spam"}
+			if err := db.Create(&report).Error; err != nil {
+				t.Fatalf("Failed to create report: %v", err)
+			}
+
+			var err error
+			for _, status := range tt.steps {
+				if err = report.Transition(db, status, moderator.ID, "checked"); err != nil {
+					break
+				}
+				db.First(&report, report.ID)
+			}
+			if tt.wantErr && err != ErrInvalidReportTransition {
+				t.Errorf("Expected ErrInvalidReportTransition, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+
+	t.Run("対応済みにするとモデレーターとメモを記録する", func(t *testing.T) {
+		report := Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 1, Reason: "spam"}
+		db.Create(&report)
+		if err := report.Transition(db, ReportStatusResolved, moderator.ID, " removed "); err != nil {
+			t.Fatalf("Failed to resolve report: %v", err)
+		}
+
+		var saved Report
+		db.First(&saved, report.ID)
+		if saved.ResolvedByID == nil || *saved.ResolvedByID != moderator.ID {
+			t.Errorf("Expected resolved by %d, got %v", moderator.ID, saved.ResolvedByID)
+		}
+		if saved.ResolutionNote != "removed" || saved.ResolvedAt == nil {
+			t.Errorf("Expected note and resolved time, got %q %v", saved.ResolutionNote, saved.ResolvedAt)
+		}
+	})
+}
+
+func TestResolveReportsForTarget(t *testing.T) {
+	db := setupTestDB(t)
+
+	alice := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	bob := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob"}
+	moderator := &User{Username: "carol", Email: "carol@example.com", Password: "password", Name: "Carol"}
+	db.Create(alice)
+	db.Create(bob)
+	db.Create(moderator)
+
+	open := Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 10, Reason: "spam"}
+	reviewing := Report{ReporterID: bob.ID, TargetType: ReportTargetPost, TargetID: 10, Reason: "spam"}
+	dismissed := Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 10, Reason: "old"}
+	other := Report{ReporterID: alice.ID, TargetType: ReportTargetPost, TargetID: 11, Reason: "spam"}
+	for _, report := range []*Report{&open, &reviewing, &dismissed, &other} {
+		db.Create(report)
+	}
+	reviewing.Transition(db, ReportStatusReviewing, moderator.ID, "")
+	dismissed.Transition(db, ReportStatusDismissed, moderator.ID, "")
+
+	resolved, err := ResolveReportsForTarget(db, ReportTargetPost, 10, moderator.ID, "hidden")
+	if err != nil {
+		t.Fatalf("Failed to resolve reports: %v", err)
+	}
+	if resolved != 2 {
+		t.Errorf("Expected 2 resolved reports, got %d", resolved)
+	}
+
+	tests := []struct {
+		name   string
+		id     uint
+		status string
+	}{
+		{name: "未対応の通報", id: open.ID, status: ReportStatusResolved},
+		{name: "確認中の通報", id: reviewing.ID, status: ReportStatusResolved},
+		{name: "却下済みの通報は変更しない", id: dismissed.ID, status: ReportStatusDismissed},
+		{name: "他の対象への通報は変更しない", id: other.ID, status: ReportStatusOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report Report
+			db.First(&report, tt.id)
+			if report.Status != tt.status {
+				t.Errorf("Expected status %s, got %s", tt.status, report.Status)
+			}
+		})
+	}
+}
+
+func TestModerationAction_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	moderator := &User{Username: "carol", Email: "carol@example.com", Password: "password", Name: "Carol"}
+	db.Create(moderator)
+
+	tests := []struct {
+		name    string
+		action  ModerationAction
+		wantErr bool
+	}{
+		{name: "投稿の非表示", action: ModerationAction{ModeratorID: moderator.ID, Action: ModerationActionHidePost, TargetType: ReportTargetPost, TargetID: 1, Reason: "spam"}, wantErr: false},
+		{name: "ユーザーの利用停止", action: ModerationAction{ModeratorID: moderator.ID, Action: ModerationActionSuspendUser, TargetType: ReportTargetUser, TargetID: 1, Reason: "abuse"}, wantErr: false},
+		{name: "ユーザーを対象にした投稿の操作", action: ModerationAction{ModeratorID: moderator.ID, Action: ModerationActionHidePost, TargetType: ReportTargetUser, TargetID: 1, Reason: "spam"}, wantErr: true},
+		{name: "無効な操作", action: ModerationAction{ModeratorID: moderator.ID, Action: "DELETE_USER", TargetType: ReportTargetUser, TargetID: 1, Reason: "spam"}, wantErr: true},
+		{name: "理由が空", action: ModerationAction{ModeratorID: moderator.ID, Action: ModerationActionHidePost, TargetType: ReportTargetPost, TargetID: 1}, wantErr: true},
+		{name: "モデレーターのIDが空", action: ModerationAction{Action: ModerationActionHidePost, TargetType: ReportTargetPost, TargetID: 1, Reason: "spam"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.action).Error
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
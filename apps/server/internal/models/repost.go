@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repost はユーザーによる投稿の再共有です（コメント付きの再共有は引用投稿としてPostで表す）
+type Repost struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"not null;uniqueIndex:idx_repost_user_post"`
+	PostID    uint      `json:"postId" gorm:"not null;uniqueIndex:idx_repost_user_post;index"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index"`
+
+	// リレーション
+	User User `json:"user" gorm:"foreignKey:UserID"`
+	Post Post `json:"post" gorm:"foreignKey:PostID"`
+}
+
+// 複合ユニークキー（同じユーザーが同じ投稿を複数回リポストできないように）
+func (Repost) TableName() string {
+	return "reposts"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (r *Repost) BeforeCreate(tx *gorm.DB) error {
+	if r.UserID == 0 {
+		return errors.New("user ID is required")
+	}
+	if r.PostID == 0 {
+		return errors.New("post ID is required")
+	}
+	return nil
+}
+
+// AfterCreate は投稿のリポスト数を増やします
+func (r *Repost) AfterCreate(tx *gorm.DB) error {
+	return adjustCounter(tx, &Post{}, r.PostID, "repost_count", 1)
+}
+
+// RemoveRepost はリポストを取り消して投稿のリポスト数を減らし、タイムラインからも取り除きます（取り消したかを返す）
+func RemoveRepost(db *gorm.DB, userID, postID uint) (bool, error) {
+	removed := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND post_id = ?", userID, postID).Delete(&Repost{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		removed = true
+		if err := adjustCounter(tx, &Post{}, postID, "repost_count", -1); err != nil {
+			return err
+		}
+		return RemoveRepostFromTimelines(tx, postID, userID)
+	})
+	return removed, err
+}
@@ -0,0 +1,77 @@
+package models
+
+import "testing"
+
+func TestRepost_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "testuser", Email: "test@example.com", Password: "password", Name: "Test User"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("テスト用ユーザー作成に失敗: %v", err)
+	}
+	post := &Post{Content: "Test post content", AuthorID: user.ID}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("テスト用投稿作成に失敗: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		repost  Repost
+		wantErr bool
+	}{
+		{name: "有効なリポストの作成", repost: Repost{UserID: user.ID, PostID: post.ID}, wantErr: false},
+		{name: "同じ投稿の重複したリポスト", repost: Repost{UserID: user.ID, PostID: post.ID}, wantErr: true},
+		{name: "ユーザーIDが空", repost: Repost{PostID: post.ID}, wantErr: true},
+		{name: "投稿IDが空", repost: Repost{UserID: user.ID}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := db.Create(&tt.repost).Error
+			if tt.wantErr && err == nil {
+				t.Error("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+
+	db.First(post, post.ID)
+	if count := post.RepostCount; count != 1 {
+		t.Errorf("Expected 1 repost, got %d", count)
+	}
+}
+
+func TestPost_QuotedPost(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "testuser", Email: "test@example.com", Password: "password", Name: "Test User"}
+	db.Create(user)
+	original := &Post{Content: "original", AuthorID: user.ID}
+	db.Create(original)
+
+	quote := &Post{Content: "quoting this", AuthorID: user.ID, QuotedPostID: &original.ID}
+	if err := db.Create(quote).Error; err != nil {
+		t.Fatalf("引用投稿の作成に失敗: %v", err)
+	}
+
+	var loaded Post
+	db.Preload("QuotedPost").First(&loaded, quote.ID)
+	if loaded.QuotedPost == nil || loaded.QuotedPost.Content != "original" {
+		t.Errorf("Expected quoted post to be loaded, got %+v", loaded.QuotedPost)
+	}
+
+	db.Create(&Like{UserID: user.ID, PostID: original.ID})
+	db.Create(&Post{Content: "reply", AuthorID: user.ID, ParentID: &original.ID})
+	db.Create(&Repost{UserID: user.ID, PostID: original.ID})
+
+	db.First(original, original.ID)
+	if original.LikeCount != 1 || original.ReplyCount != 1 || original.RepostCount != 1 {
+		t.Errorf("Expected 1 like, reply and repost, got %d, %d, %d", original.LikeCount, original.ReplyCount, original.RepostCount)
+	}
+	db.First(quote, quote.ID)
+	if quote.LikeCount != 0 || quote.ReplyCount != 0 || quote.RepostCount != 0 {
+		t.Errorf("Expected no counts for quote, got %d, %d, %d", quote.LikeCount, quote.ReplyCount, quote.RepostCount)
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ユーザーの役割（GraphQLのRoleと同じ値）
+// 上位の役割は下位の役割の権限を全て持つ（ADMIN ⊃ MODERATOR ⊃ USER）
+const (
+	RoleUser      = "USER"
+	RoleModerator = "MODERATOR" // 通報の確認・投稿の非表示・利用停止
+	RoleAdmin     = "ADMIN"     // モデレーターの権限に加えて役割の変更
+)
+
+// roleRanks は役割の序列です
+var roleRanks = map[string]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+var ErrInvalidRole = errors.New("invalid role")
+
+// IsValidRole は役割が有効かチェックします
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRole はユーザーが指定の役割の権限を持つかチェックします（上位の役割も含む）
+func (u *User) HasRole(role string) bool {
+	required, ok := roleRanks[role]
+	if !ok {
+		return false
+	}
+	current, ok := roleRanks[u.Role]
+	return ok && current >= required
+}
+
+// Outranks はユーザーの役割が他のユーザーより上位かチェックします
+func (u *User) Outranks(other *User) bool {
+	current, ok := roleRanks[u.Role]
+	if !ok {
+		return false
+	}
+	return current > roleRanks[other.Role]
+}
+
+// SetUserRole はユーザーの役割を変更します
+func SetUserRole(db *gorm.DB, user *User, role string) error {
+	if !IsValidRole(role) {
+		return ErrInvalidRole
+	}
+	if err := db.Model(user).Update("role", role).Error; err != nil {
+		return err
+	}
+	user.Role = role
+	return nil
+}
@@ -0,0 +1,104 @@
+package models
+
+import "testing"
+
+func TestUser_HasRole(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		required string
+		expected bool
+	}{
+		{name: "一般ユーザーはUSERの権限を持つ", role: RoleUser, required: RoleUser, expected: true},
+		{name: "一般ユーザーはMODERATORの権限を持たない", role: RoleUser, required: RoleModerator, expected: false},
+		{name: "モデレーターはMODERATORの権限を持つ", role: RoleModerator, required: RoleModerator, expected: true},
+		{name: "モデレーターはADMINの権限を持たない", role: RoleModerator, required: RoleAdmin, expected: false},
+		{name: "管理者はMODERATORの権限も持つ", role: RoleAdmin, required: RoleModerator, expected: true},
+		{name: "存在しない役割が必要な場合", role: RoleAdmin, required: "OWNER", expected: false},
+		{name: "役割が空の場合", role: "", required: RoleUser, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{Role: tt.role}
+			if got := user.HasRole(tt.required); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUser_Outranks(t *testing.T) {
+	tests := []struct {
+		name     string
+		role     string
+		other    string
+		expected bool
+	}{
+		{name: "モデレーターは一般ユーザーより上位", role: RoleModerator, other: RoleUser, expected: true},
+		{name: "管理者はモデレーターより上位", role: RoleAdmin, other: RoleModerator, expected: true},
+		{name: "同じ役割は上位ではない", role: RoleModerator, other: RoleModerator, expected: false},
+		{name: "モデレーターは管理者より上位ではない", role: RoleModerator, other: RoleAdmin, expected: false},
+		{name: "存在しない役割は上位ではない", role: "OWNER", other: RoleUser, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := User{Role: tt.role}
+			if got := user.Outranks(&User{Role: tt.other}); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := &User{Username: "alice", Email: "alice@example.com", Password: "password", Name: "Alice"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if user.Role != RoleUser {
+		t.Errorf("Expected default role USER, got %s", user.Role)
+	}
+
+	tests := []struct {
+		name    string
+		role    string
+		wantErr bool
+	}{
+		{name: "モデレーターに変更", role: RoleModerator, wantErr: false},
+		{name: "管理者に変更", role: RoleAdmin, wantErr: false},
+		{name: "一般ユーザーに戻す", role: RoleUser, wantErr: false},
+		{name: "存在しない役割", role: "OWNER", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := SetUserRole(db, user, tt.role)
+			if tt.wantErr {
+				if err != ErrInvalidRole {
+					t.Errorf("Expected ErrInvalidRole, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error but got: %v", err)
+			}
+
+			var saved User
+			db.First(&saved, user.ID)
+			if saved.Role != tt.role {
+				t.Errorf("Expected role %s, got %s", tt.role, saved.Role)
+			}
+		})
+	}
+
+	t.Run("無効な役割での作成", func(t *testing.T) {
+		invalid := &User{Username: "bob", Email: "bob@example.com", Password: "password", Name: "Bob", Role: "OWNER"}
+		if err := db.Create(invalid).Error; err == nil {
+			t.Error("Expected error but got none")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// タイムラインに配信した活動の種類
+const (
+	TimelineEntryPost   = "POST"   // フォロー中のユーザーの投稿
+	TimelineEntryRepost = "REPOST" // フォロー中のユーザーのリポスト
+)
+
+// TimelineEntry はフォロワーのホームタイムラインに書き込み時に配信した投稿・リポストです
+// 同じ投稿が複数のフォロー中のユーザーから届いた場合は行為者ごとに1行になり、読み込み時に投稿ごとにまとめます
+// 自分の投稿・リポストと、フォロワーの多いアカウントの投稿・リポストは配信せず読み込み時に取得します
+type TimelineEntry struct {
+	UserID     uint      `json:"userId" gorm:"primaryKey;index:idx_timeline_entries_user_activity,priority:1"` // タイムラインの持ち主
+	PostID     uint      `json:"postId" gorm:"primaryKey;index"`
+	ActorID    uint      `json:"actorId" gorm:"primaryKey"` // 投稿者またはリポストしたユーザー
+	Kind       string    `json:"kind" gorm:"primaryKey;size:8"`
+	ActivityAt time.Time `json:"activityAt" gorm:"not null;index:idx_timeline_entries_user_activity,priority:2"` // 投稿・リポストした時刻
+}
+
+func (TimelineEntry) TableName() string {
+	return "timeline_entries"
+}
+
+// RemoveFollowFromTimeline はフォローを解除したユーザーの投稿・リポストをタイムラインから取り除きます
+func RemoveFollowFromTimeline(db *gorm.DB, userID, followeeID uint) error {
+	return db.Where("user_id = ? AND actor_id = ?", userID, followeeID).Delete(&TimelineEntry{}).Error
+}
+
+// RemoveBlockFromTimelines はブロックの関係になった2人のタイムラインから互いの投稿・リポストを取り除きます
+// 他のユーザーがリポストした相手の投稿も取り除きます
+func RemoveBlockFromTimelines(db *gorm.DB, userID, otherID uint) error {
+	for _, pair := range [][2]uint{{userID, otherID}, {otherID, userID}} {
+		posts := db.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&Post{}).Select("id").Where("author_id = ?", pair[1])
+		err := db.Where("user_id = ? AND (actor_id = ? OR post_id IN (?))", pair[0], pair[1], posts).Delete(&TimelineEntry{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemovePostFromTimelines は削除した投稿を全てのタイムラインから取り除きます
+func RemovePostFromTimelines(db *gorm.DB, postID uint) error {
+	return db.Where("post_id = ?", postID).Delete(&TimelineEntry{}).Error
+}
+
+// RemoveRepostFromTimelines は取り消したリポストを全てのタイムラインから取り除きます
+func RemoveRepostFromTimelines(db *gorm.DB, postID, userID uint) error {
+	return db.Where("post_id = ? AND actor_id = ? AND kind = ?", postID, userID, TimelineEntryRepost).Delete(&TimelineEntry{}).Error
+}
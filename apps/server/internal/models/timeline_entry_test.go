@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimelineEntry_Remove(t *testing.T) {
+	db := setupTestDB(t)
+
+	var users []User
+	for _, name := range []string{"alice", "bob", "carol"} {
+		user := User{Username: name, Email: name + "@example.com", Password: "password", Name: name}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		users = append(users, user)
+	}
+	alice, bob, carol := users[0], users[1], users[2]
+
+	bobPost := Post{Content: "bob", AuthorID: bob.ID}
+	carolPost := Post{Content: "carol", AuthorID: carol.ID}
+	db.Create(&bobPost)
+	db.Create(&carolPost)
+
+	// aliceのタイムライン: bobの投稿、carolの投稿、carolがリポストしたbobの投稿、bobがリポストしたcarolの投稿
+	seed := func() {
+		db.Where("1 = 1").Delete(&TimelineEntry{})
+		now := time.Now()
+		db.Create(&[]TimelineEntry{
+			{UserID: alice.ID, PostID: bobPost.ID, ActorID: bob.ID, Kind: TimelineEntryPost, ActivityAt: now},
+			{UserID: alice.ID, PostID: carolPost.ID, ActorID: carol.ID, Kind: TimelineEntryPost, ActivityAt: now},
+			{UserID: alice.ID, PostID: bobPost.ID, ActorID: carol.ID, Kind: TimelineEntryRepost, ActivityAt: now},
+			{UserID: alice.ID, PostID: carolPost.ID, ActorID: bob.ID, Kind: TimelineEntryRepost, ActivityAt: now},
+			{UserID: bob.ID, PostID: carolPost.ID, ActorID: carol.ID, Kind: TimelineEntryPost, ActivityAt: now},
+		})
+	}
+	count := func(query string, args ...interface{}) int64 {
+		var n int64
+		db.Model(&TimelineEntry{}).Where(query, args...).Count(&n)
+		return n
+	}
+
+	t.Run("フォロー解除", func(t *testing.T) {
+		seed()
+		if err := RemoveFollowFromTimeline(db, alice.ID, bob.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// bobが行為者のエントリーのみ取り除き、carolがリポストしたbobの投稿は残す
+		if n := count("user_id = ? AND actor_id = ?", alice.ID, bob.ID); n != 0 {
+			t.Errorf("Expected bob's entries to be removed, got %d", n)
+		}
+		if n := count("user_id = ?", alice.ID); n != 2 {
+			t.Errorf("Expected 2 remaining entries, got %d", n)
+		}
+	})
+
+	t.Run("ブロック", func(t *testing.T) {
+		seed()
+		if err := RemoveBlockFromTimelines(db, bob.ID, alice.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		// aliceのタイムラインからbobの投稿・リポストとcarolがリポストしたbobの投稿を取り除く
+		if n := count("user_id = ?", alice.ID); n != 1 {
+			t.Errorf("Expected 1 remaining entry for alice, got %d", n)
+		}
+		if n := count("user_id = ?", bob.ID); n != 1 {
+			t.Errorf("Expected bob's timeline to be unaffected, got %d", n)
+		}
+	})
+
+	t.Run("投稿の削除", func(t *testing.T) {
+		seed()
+		if err := RemovePostFromTimelines(db, carolPost.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := count("post_id = ?", carolPost.ID); n != 0 {
+			t.Errorf("Expected post to be removed from all timelines, got %d", n)
+		}
+	})
+
+	t.Run("リポストの取り消し", func(t *testing.T) {
+		seed()
+		if err := RemoveRepostFromTimelines(db, bobPost.ID, carol.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := count("post_id = ?", bobPost.ID); n != 1 {
+			t.Errorf("Expected only the original post entry to remain, got %d", n)
+		}
+	})
+
+	t.Run("ブロックするとタイムラインからも取り除く", func(t *testing.T) {
+		seed()
+		if err := BlockUser(db, alice.ID, carol.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if n := count("user_id = ? AND (actor_id = ? OR post_id = ?)", alice.ID, carol.ID, carolPost.ID); n != 0 {
+			t.Errorf("Expected carol's activity to be removed, got %d", n)
+		}
+	})
+}
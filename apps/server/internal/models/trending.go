@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// トレンドの集計期間（GraphQLのTrendingWindowと同じ値）
+const (
+	TrendingWindowHour = "HOUR"
+	TrendingWindowDay  = "DAY"
+	TrendingWindowWeek = "WEEK"
+)
+
+// TrendingWindows は集計する全ての期間です
+var TrendingWindows = []string{TrendingWindowHour, TrendingWindowDay, TrendingWindowWeek}
+
+// TrendingWindowDuration は集計期間の長さを返します（不明な期間の場合は0）
+func TrendingWindowDuration(window string) time.Duration {
+	switch window {
+	case TrendingWindowHour:
+		return time.Hour
+	case TrendingWindowDay:
+		return 24 * time.Hour
+	case TrendingWindowWeek:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+// TrendingHashtag は定期ジョブが集計したトレンドのハッシュタグです
+// 集計のたびに期間ごとに洗い替えます
+type TrendingHashtag struct {
+	// WINDOWはSQLの予約語のため列名を変える
+	Window string  `json:"window" gorm:"column:time_window;primaryKey;size:8"`
+	Tag    string  `json:"tag" gorm:"primaryKey;size:400"`
+	Rank   int     `json:"rank" gorm:"not null"`
+	Score  float64 `json:"score" gorm:"not null"`
+	// 期間内にタグを使った投稿数とアカウント数
+	PostCount    int       `json:"postCount" gorm:"not null"`
+	AccountCount int       `json:"accountCount" gorm:"not null"`
+	ComputedAt   time.Time `json:"computedAt" gorm:"not null"`
+}
+
+func (TrendingHashtag) TableName() string {
+	return "trending_hashtags"
+}
+
+// TrendingPost は定期ジョブが集計したトレンドの投稿です
+type TrendingPost struct {
+	Window     string    `json:"window" gorm:"column:time_window;primaryKey;size:8"`
+	PostID     uint      `json:"-" gorm:"primaryKey"`
+	Rank       int       `json:"rank" gorm:"not null"`
+	Score      float64   `json:"score" gorm:"not null"`
+	ComputedAt time.Time `json:"computedAt" gorm:"not null"`
+
+	// リレーション
+	Post Post `json:"post" gorm:"foreignKey:PostID"`
+}
+
+func (TrendingPost) TableName() string {
+	return "trending_posts"
+}
@@ -7,16 +7,25 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"uniqueIndex;not null"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"` // JSONに含めない
-	Name      string         `json:"name" gorm:"not null"`
-	Bio       string         `json:"bio"`
-	Avatar    string         `json:"avatar"`
-	CreatedAt time.Time      `json:"createdAt"`
-	UpdatedAt time.Time      `json:"updatedAt"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"` // ソフトデリート
+	ID               uint           `json:"id" gorm:"primaryKey"`
+	Username         string         `json:"username" gorm:"uniqueIndex;uniqueIndex:idx_users_username_lower,expression:LOWER(username);not null"`
+	Email            string         `json:"email" gorm:"uniqueIndex;not null"`
+	Password         string         `json:"-" gorm:"not null"` // JSONに含めない
+	Name             string         `json:"name" gorm:"not null"`
+	Bio              string         `json:"bio"`
+	Avatar           string         `json:"avatar"`
+	EmailVerified    bool           `json:"emailVerified" gorm:"not null;default:false"` // メールアドレス確認済みか
+	IsPrivate        bool           `json:"isPrivate" gorm:"not null;default:false"`     // 非公開アカウントか（フォローは承認制）
+	Role             string         `json:"role" gorm:"not null;size:16;default:'USER'"` // 役割（USER / MODERATOR / ADMIN）
+	SuspendedAt      *time.Time     `json:"-"`                                           // モデレーターによる利用停止（NULLの場合は利用可能）
+	SuspendedUntil   *time.Time     `json:"-"`                                           // 利用停止の期限（NULLの場合は無期限）
+	SuspensionReason string         `json:"-"`                                           // 利用停止の理由（本人に通知する）
+	PurgedAt         *time.Time     `json:"-"`                                           // 退会後の猶予期間が過ぎ、個人情報を削除した日時
+	FollowerCount    int64          `json:"followerCount" gorm:"not null;default:0"`     // フォロワー数（フォローの作成・削除時に更新する）
+	FollowingCount   int64          `json:"followingCount" gorm:"not null;default:0"`    // フォロー数
+	CreatedAt        time.Time      `json:"createdAt"`
+	UpdatedAt        time.Time      `json:"updatedAt"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"` // 退会（ソフトデリート、猶予期間中はログインで再開できる）
 
 	// リレーション
 	Posts     []Post   `json:"posts" gorm:"foreignKey:AuthorID"`
@@ -25,18 +34,9 @@ type User struct {
 	Followers []Follow `json:"followers" gorm:"foreignKey:FolloweeID"`
 }
 
-// フォロワー数を取得
-func (u *User) FollowerCount(db *gorm.DB) int64 {
-	var count int64
-	db.Model(&Follow{}).Where("followee_id = ?", u.ID).Count(&count)
-	return count
-}
-
-// フォロー数を取得
-func (u *User) FollowingCount(db *gorm.DB) int64 {
-	var count int64
-	db.Model(&Follow{}).Where("follower_id = ?", u.ID).Count(&count)
-	return count
+// IsSuspended はモデレーターに利用停止されているかを返します（期限を過ぎた利用停止は含まない）
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil && (u.SuspendedUntil == nil || time.Now().Before(*u.SuspendedUntil))
 }
 
 // 投稿数を取得
@@ -48,6 +48,9 @@ func (u *User) PostCount(db *gorm.DB) int64 {
 
 // バリデーション
 func (u *User) BeforeCreate(tx *gorm.DB) error {
+	u.Username = NormalizeUsername(u.Username)
+	u.Email = NormalizeEmail(u.Email)
+
 	if u.Username == "" {
 		return errors.New("username is required")
 	}
@@ -57,5 +60,17 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.Name == "" {
 		return errors.New("name is required")
 	}
+	if err := ValidateUsername(u.Username); err != nil {
+		return err
+	}
+	if err := ValidateEmail(u.Email); err != nil {
+		return err
+	}
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+	if !IsValidRole(u.Role) {
+		return ErrInvalidRole
+	}
 	return nil
 }
@@ -13,7 +13,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// テスト用テーブル作成
-	err = db.AutoMigrate(&User{}, &Post{}, &Like{}, &Follow{})
+	err = db.AutoMigrate(&User{}, &Post{}, &Draft{}, &Poll{}, &PollOption{}, &PollVote{}, &Like{}, &Follow{}, &Repost{}, &Block{}, &Mute{}, &FollowRequest{}, &BookmarkCollection{}, &Bookmark{}, &Report{}, &ModerationAction{}, &UserToken{}, &LoginAttempt{}, &LockoutEvent{}, &PersistedQuery{}, &IdempotencyKey{}, &Notification{}, &NotificationActor{}, &PostEntity{}, &DataExport{}, &Job{}, &TimelineEntry{})
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
 	}
@@ -125,15 +125,17 @@ func TestUser_FollowerCount(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// フォローデータをクリア
+			// フォローデータをクリア（まとめて削除した件数は数え直す）
 			db.Where("1 = 1").Delete(&Follow{})
+			RecountUserCounters(db, []uint{user1.ID, user2.ID, user3.ID})
 
 			// テストデータ作成
 			for _, follow := range tt.followers {
 				db.Create(&follow)
 			}
 
-			count := tt.targetUser.FollowerCount(db)
+			db.First(tt.targetUser, tt.targetUser.ID)
+			count := tt.targetUser.FollowerCount
 			if count != tt.expectedCount {
 				t.Errorf("Expected %d followers, got %d", tt.expectedCount, count)
 			}
@@ -186,15 +188,17 @@ func TestUser_FollowingCount(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// フォローデータをクリア
+			// フォローデータをクリア（まとめて削除した件数は数え直す）
 			db.Where("1 = 1").Delete(&Follow{})
+			RecountUserCounters(db, []uint{user1.ID, user2.ID, user3.ID})
 
 			// テストデータ作成
 			for _, follow := range tt.followings {
 				db.Create(&follow)
 			}
 
-			count := tt.targetUser.FollowingCount(db)
+			db.First(tt.targetUser, tt.targetUser.ID)
+			count := tt.targetUser.FollowingCount
 			if count != tt.expectedCount {
 				t.Errorf("Expected %d following, got %d", tt.expectedCount, count)
 			}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// トークンの用途
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+var ErrTokenAlreadyUsed = errors.New("token has already been used or revoked")
+
+// UserToken はメール確認・パスワードリセット用の使い捨てトークンです
+// トークン本体は保存せず、SHA-256ハッシュのみを保存します
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"userId" gorm:"not null;index"`
+	Purpose   string     `json:"purpose" gorm:"not null;size:32"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"not null"`
+	UsedAt    *time.Time `json:"usedAt"` // 使用済み・無効化済みの場合に設定
+	CreatedAt time.Time  `json:"createdAt"`
+
+	// リレーション
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+func (UserToken) TableName() string {
+	return "user_tokens"
+}
+
+// BeforeCreate はレコード作成前のバリデーション
+func (t *UserToken) BeforeCreate(tx *gorm.DB) error {
+	if t.UserID == 0 {
+		return errors.New("user ID is required")
+	}
+	if t.Purpose == "" {
+		return errors.New("purpose is required")
+	}
+	if t.TokenHash == "" {
+		return errors.New("token hash is required")
+	}
+	if t.ExpiresAt.IsZero() {
+		return errors.New("expiry is required")
+	}
+	return nil
+}
+
+// ConsumeUserToken は未使用かつ有効期限内のトークンを使用済みにして返します
+// UPDATE ... WHERE used_at IS NULL で更新するため、同時に使われても1回しか成功しません
+func ConsumeUserToken(db *gorm.DB, tokenHash, purpose string, now time.Time) (*UserToken, error) {
+	result := db.Model(&UserToken{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", tokenHash, purpose, now).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrTokenAlreadyUsed
+	}
+
+	var token UserToken
+	if err := db.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeUserTokens はユーザーの指定用途の未使用トークンをすべて無効化します
+func RevokeUserTokens(db *gorm.DB, userID uint, purpose string, now time.Time) error {
+	return db.Model(&UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
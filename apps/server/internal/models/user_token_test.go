@@ -0,0 +1,118 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUserToken_Creation(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := User{Username: "user1", Email: "user1@test.com", Password: "pass", Name: "User 1"}
+	db.Create(&user)
+
+	expiresAt := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		token   UserToken
+		wantErr bool
+	}{
+		{
+			name:    "有効なトークンの作成",
+			token:   UserToken{UserID: user.ID, Purpose: TokenPurposeEmailVerification, TokenHash: "hash1", ExpiresAt: expiresAt},
+			wantErr: false,
+		},
+		{
+			name:    "ユーザーIDが0の場合はエラー",
+			token:   UserToken{Purpose: TokenPurposeEmailVerification, TokenHash: "hash2", ExpiresAt: expiresAt},
+			wantErr: true,
+		},
+		{
+			name:    "用途が空の場合はエラー",
+			token:   UserToken{UserID: user.ID, TokenHash: "hash3", ExpiresAt: expiresAt},
+			wantErr: true,
+		},
+		{
+			name:    "有効期限がない場合はエラー",
+			token:   UserToken{UserID: user.ID, Purpose: TokenPurposePasswordReset, TokenHash: "hash4"},
+			wantErr: true,
+		},
+		{
+			name:    "ハッシュが重複する場合はエラー",
+			token:   UserToken{UserID: user.ID, Purpose: TokenPurposePasswordReset, TokenHash: "hash1", ExpiresAt: expiresAt},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := db.Create(&tt.token)
+			if tt.wantErr {
+				if result.Error == nil {
+					t.Errorf("Expected error but got none")
+				}
+			} else if result.Error != nil {
+				t.Errorf("Expected no error but got: %v", result.Error)
+			}
+		})
+	}
+}
+
+func TestConsumeUserToken(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := User{Username: "user1", Email: "user1@test.com", Password: "pass", Name: "User 1"}
+	db.Create(&user)
+
+	now := time.Now()
+	db.Create(&UserToken{UserID: user.ID, Purpose: TokenPurposePasswordReset, TokenHash: "valid", ExpiresAt: now.Add(time.Hour)})
+	db.Create(&UserToken{UserID: user.ID, Purpose: TokenPurposePasswordReset, TokenHash: "expired", ExpiresAt: now.Add(-time.Minute)})
+
+	// 1回目は成功する
+	token, err := ConsumeUserToken(db, "valid", TokenPurposePasswordReset, now)
+	if err != nil {
+		t.Fatalf("First consume should succeed: %v", err)
+	}
+	if token.UserID != user.ID || token.UsedAt == nil {
+		t.Errorf("Expected used token for user %d, got %+v", user.ID, token)
+	}
+
+	// 2回目は失敗する（使い捨て）
+	if _, err := ConsumeUserToken(db, "valid", TokenPurposePasswordReset, now); err != ErrTokenAlreadyUsed {
+		t.Errorf("Expected ErrTokenAlreadyUsed, got %v", err)
+	}
+
+	// 有効期限切れは失敗する
+	if _, err := ConsumeUserToken(db, "expired", TokenPurposePasswordReset, now); err != ErrTokenAlreadyUsed {
+		t.Errorf("Expected expired token to be rejected, got %v", err)
+	}
+
+	// 用途が異なる場合は失敗する
+	db.Create(&UserToken{UserID: user.ID, Purpose: TokenPurposeEmailVerification, TokenHash: "verify", ExpiresAt: now.Add(time.Hour)})
+	if _, err := ConsumeUserToken(db, "verify", TokenPurposePasswordReset, now); err != ErrTokenAlreadyUsed {
+		t.Errorf("Expected purpose mismatch to be rejected, got %v", err)
+	}
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	db := setupTestDB(t)
+
+	user := User{Username: "user1", Email: "user1@test.com", Password: "pass", Name: "User 1"}
+	db.Create(&user)
+
+	now := time.Now()
+	db.Create(&UserToken{UserID: user.ID, Purpose: TokenPurposePasswordReset, TokenHash: "reset1", ExpiresAt: now.Add(time.Hour)})
+	db.Create(&UserToken{UserID: user.ID, Purpose: TokenPurposePasswordReset, TokenHash: "reset2", ExpiresAt: now.Add(time.Hour)})
+	db.Create(&UserToken{UserID: user.ID, Purpose: TokenPurposeEmailVerification, TokenHash: "verify", ExpiresAt: now.Add(time.Hour)})
+
+	if err := RevokeUserTokens(db, user.ID, TokenPurposePasswordReset, now); err != nil {
+		t.Fatalf("Failed to revoke tokens: %v", err)
+	}
+
+	var active int64
+	db.Model(&UserToken{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&active)
+	if active != 1 {
+		t.Errorf("Expected only the verification token to remain active, got %d", active)
+	}
+}
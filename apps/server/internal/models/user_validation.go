@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// ユーザー名の長さ制限
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 15
+	EmailMaxLength    = 254
+)
+
+var (
+	ErrUsernameInvalid  = errors.New("username must be 3-15 characters of letters, numbers or underscores")
+	ErrUsernameReserved = errors.New("username is reserved")
+	ErrEmailInvalid     = errors.New("email is invalid")
+)
+
+// 英数字とアンダースコアのみ許可
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// 予約済みユーザー名（小文字で管理）
+var reservedUsernames = map[string]struct{}{
+	"about":         {},
+	"admin":         {},
+	"administrator": {},
+	"api":           {},
+	"auth":          {},
+	"explore":       {},
+	"graphql":       {},
+	"help":          {},
+	"home":          {},
+	"login":         {},
+	"logout":        {},
+	"me":            {},
+	"moderator":     {},
+	"notifications": {},
+	"null":          {},
+	"official":      {},
+	"query":         {},
+	"register":      {},
+	"root":          {},
+	"search":        {},
+	"settings":      {},
+	"signup":        {},
+	"staff":         {},
+	"support":       {},
+	"system":        {},
+	"undefined":     {},
+	"www":           {},
+}
+
+// NormalizeUsername はユーザー名をNFKC正規化し前後の空白を取り除きます
+// 全角英数字（例: "Ａｌｉｃｅ"）は半角に変換されます。大文字小文字は表示用に保持します
+func NormalizeUsername(username string) string {
+	return strings.TrimSpace(norm.NFKC.String(username))
+}
+
+// NormalizeEmail はメールアドレスをNFKC正規化し小文字に揃えます
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(norm.NFKC.String(email)))
+}
+
+// ValidateUsername は正規化済みのユーザー名の文字種・長さ・予約語をチェックします
+func ValidateUsername(username string) error {
+	if len(username) < UsernameMinLength || len(username) > UsernameMaxLength {
+		return ErrUsernameInvalid
+	}
+	if !usernamePattern.MatchString(username) {
+		return ErrUsernameInvalid
+	}
+	if IsReservedUsername(username) {
+		return ErrUsernameReserved
+	}
+	return nil
+}
+
+// IsReservedUsername は予約済みユーザー名かどうかを大文字小文字を区別せずに判定します
+func IsReservedUsername(username string) bool {
+	_, ok := reservedUsernames[strings.ToLower(username)]
+	return ok
+}
+
+// ValidateEmail は正規化済みのメールアドレスを構文的に検証します
+// 表示名付き（"Alice <alice@example.com>"）やドメインにドットを含まないものは拒否します
+func ValidateEmail(email string) error {
+	if email == "" || len(email) > EmailMaxLength {
+		return ErrEmailInvalid
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrEmailInvalid
+	}
+
+	at := strings.LastIndex(email, "@")
+	domain := email[at+1:]
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return ErrEmailInvalid
+	}
+
+	return nil
+}
+
+// IsUsernameAvailable はユーザー名が登録可能かどうかを判定します
+// 形式違反・予約語・大文字小文字を無視した重複のいずれかに該当する場合はfalseを返します
+func IsUsernameAvailable(db *gorm.DB, username string) (bool, error) {
+	username = NormalizeUsername(username)
+	if err := ValidateUsername(username); err != nil {
+		return false, nil
+	}
+
+	var count int64
+	if err := db.Model(&User{}).Unscoped().Where("LOWER(username) = ?", strings.ToLower(username)).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count == 0, nil
+}
+
+// IsEmailTaken はメールアドレスが既に登録済みかどうかを判定します
+func IsEmailTaken(db *gorm.DB, email string) (bool, error) {
+	var count int64
+	if err := db.Model(&User{}).Unscoped().Where("email = ?", NormalizeEmail(email)).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "通常のユーザー名はそのまま", input: "Alice", expected: "Alice"},
+		{name: "全角英数字は半角に変換", input: "Ａｌｉｃｅ１２３", expected: "Alice123"},
+		{name: "前後の空白を除去", input: "  alice  ", expected: "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NormalizeUsername(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "小文字に変換", input: "Alice@Example.COM", expected: "alice@example.com"},
+		{name: "全角文字は半角に変換", input: "ａｌｉｃｅ＠example.com", expected: "alice@example.com"},
+		{name: "前後の空白を除去", input: " alice@example.com ", expected: "alice@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NormalizeEmail(tt.input)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		wantErr  error
+	}{
+		{name: "有効なユーザー名", username: "alice_01", wantErr: nil},
+		{name: "3文字ちょうどは有効", username: "abc", wantErr: nil},
+		{name: "15文字ちょうどは有効", username: "abcdefghijklmno", wantErr: nil},
+		{name: "2文字はエラー", username: "ab", wantErr: ErrUsernameInvalid},
+		{name: "16文字はエラー", username: "abcdefghijklmnop", wantErr: ErrUsernameInvalid},
+		{name: "記号を含む場合はエラー", username: "alice-01", wantErr: ErrUsernameInvalid},
+		{name: "日本語を含む場合はエラー", username: "ありす", wantErr: ErrUsernameInvalid},
+		{name: "予約語はエラー", username: "admin", wantErr: ErrUsernameReserved},
+		{name: "大文字の予約語もエラー", username: "API", wantErr: ErrUsernameReserved},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUsername(tt.username)
+			if err != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		wantErr bool
+	}{
+		{name: "有効なメールアドレス", email: "alice@example.com", wantErr: false},
+		{name: "サブドメイン付き", email: "alice+sns@mail.example.co.jp", wantErr: false},
+		{name: "空文字はエラー", email: "", wantErr: true},
+		{name: "@がない場合はエラー", email: "alice.example.com", wantErr: true},
+		{name: "ドメインにドットがない場合はエラー", email: "alice@localhost", wantErr: true},
+		{name: "表示名付きはエラー", email: "Alice <alice@example.com>", wantErr: true},
+		{name: "ローカル部がない場合はエラー", email: "@example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEmail(tt.email)
+			if tt.wantErr && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestUser_CaseInsensitiveUniqueness(t *testing.T) {
+	db := setupTestDB(t)
+
+	user1 := User{Username: "Alice", Email: "Alice@Example.com", Password: "pass", Name: "Alice"}
+	if err := db.Create(&user1).Error; err != nil {
+		t.Fatalf("First user should succeed: %v", err)
+	}
+
+	if user1.Email != "alice@example.com" {
+		t.Errorf("Expected normalized email, got %q", user1.Email)
+	}
+
+	// 大文字小文字違いのユーザー名は登録できない
+	user2 := User{Username: "alice", Email: "other@example.com", Password: "pass", Name: "Alice 2"}
+	if err := db.Create(&user2).Error; err == nil {
+		t.Errorf("Username differing only in case should fail")
+	}
+
+	// 大文字小文字違いのメールアドレスは登録できない
+	user3 := User{Username: "alice2", Email: "ALICE@example.com", Password: "pass", Name: "Alice 3"}
+	if err := db.Create(&user3).Error; err == nil {
+		t.Errorf("Email differing only in case should fail")
+	}
+}
+
+func TestIsUsernameAvailable(t *testing.T) {
+	db := setupTestDB(t)
+
+	db.Create(&User{Username: "Alice", Email: "alice@example.com", Password: "pass", Name: "Alice"})
+
+	tests := []struct {
+		name     string
+		username string
+		expected bool
+	}{
+		{name: "未使用のユーザー名", username: "bob", expected: true},
+		{name: "登録済みのユーザー名", username: "Alice", expected: false},
+		{name: "大文字小文字違いも使用不可", username: "ALICE", expected: false},
+		{name: "全角表記も使用不可", username: "ａｌｉｃｅ", expected: false},
+		{name: "予約語は使用不可", username: "admin", expected: false},
+		{name: "形式違反は使用不可", username: "a", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			available, err := IsUsernameAvailable(db, tt.username)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if available != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, available)
+			}
+		})
+	}
+}
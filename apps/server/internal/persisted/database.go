@@ -0,0 +1,79 @@
+package persisted
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sns-server/internal/models"
+)
+
+// DBStore はpersisted_queriesテーブルにクエリを保存するStoreです
+// マニフェストを読み込んだ内容を全インスタンスで共有できます
+type DBStore struct {
+	db *gorm.DB
+}
+
+// NewDBStore はDBStoreを作成します
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+func (s *DBStore) Get(hash string) (*models.PersistedQuery, error) {
+	var entry models.PersistedQuery
+	// 有効期限を過ぎたAPQのクエリは削除前でも未登録として扱う
+	err := s.db.Where("hash = ? AND (expires_at IS NULL OR expires_at > ?)", hash, time.Now()).First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *DBStore) Save(entry *models.PersistedQuery) error {
+	// APQの登録は有効期限を延長する（マニフェストで登録済みのクエリは変更しない）
+	onConflict := clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "persisted_queries.source = ?", Vars: []interface{}{models.PersistedQuerySourceAPQ}}}},
+		DoUpdates: clause.AssignmentColumns([]string{"expires_at", "updated_at"}),
+	}
+	if entry.Source == models.PersistedQuerySourceManifest {
+		onConflict = clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.AssignmentColumns([]string{"source", "operation_name", "expires_at", "updated_at"}),
+		}
+	}
+	return s.db.Clauses(onConflict).Create(entry).Error
+}
+
+// ImportManifest はマニフェストの操作をまとめて登録します
+// pruneがtrueの場合、マニフェストに含まれない許可済みクエリを削除します（許可の取り消し）
+func (s *DBStore) ImportManifest(entries []models.PersistedQuery, prune bool) (removed int64, err error) {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		store := &DBStore{db: tx}
+		hashes := make([]string, 0, len(entries))
+		for i := range entries {
+			entries[i].Source = models.PersistedQuerySourceManifest
+			entries[i].ExpiresAt = nil
+			if err := store.Save(&entries[i]); err != nil {
+				return err
+			}
+			hashes = append(hashes, entries[i].Hash)
+		}
+
+		if !prune {
+			return nil
+		}
+		query := tx.Where("source = ?", models.PersistedQuerySourceManifest)
+		if len(hashes) > 0 {
+			query = query.Where("hash NOT IN ?", hashes)
+		}
+		result := query.Delete(&models.PersistedQuery{})
+		removed = result.RowsAffected
+		return result.Error
+	})
+	return removed, err
+}
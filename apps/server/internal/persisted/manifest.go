@@ -0,0 +1,74 @@
+package persisted
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sns-server/internal/models"
+)
+
+// manifestFormat はApolloの永続化クエリマニフェスト形式の識別子です
+const manifestFormat = "apollo-persisted-query-manifest"
+
+type apolloManifest struct {
+	Format     string `json:"format"`
+	Version    int    `json:"version"`
+	Operations []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+		Body string `json:"body"`
+	} `json:"operations"`
+}
+
+// ParseManifest はビルド時に生成したマニフェストを読み込みます
+// Apollo形式（{"format": "apollo-persisted-query-manifest", "operations": [...]}）と
+// ハッシュからクエリへの単純なマップ（{"<sha256>": "query ..."}）に対応します
+// ハッシュが省略されている場合は計算し、記載されている場合はクエリと一致するか検証します
+func ParseManifest(data []byte) ([]models.PersistedQuery, error) {
+	var probe struct {
+		Format string `json:"format"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	var entries []models.PersistedQuery
+	if probe.Format == manifestFormat {
+		var manifest apolloManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("invalid manifest: %w", err)
+		}
+		if manifest.Version != 1 {
+			return nil, fmt.Errorf("unsupported manifest version: %d", manifest.Version)
+		}
+		for _, op := range manifest.Operations {
+			entries = append(entries, models.PersistedQuery{Hash: op.ID, Query: op.Body, OperationName: op.Name})
+		}
+	} else {
+		var flat map[string]string
+		if err := json.Unmarshal(data, &flat); err != nil {
+			return nil, errors.New("invalid manifest: expected apollo manifest or a map of hash to query")
+		}
+		for hash, query := range flat {
+			entries = append(entries, models.PersistedQuery{Hash: hash, Query: query})
+		}
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		if entry.Query == "" {
+			return nil, fmt.Errorf("operation %q has an empty body", entry.OperationName)
+		}
+
+		hash := models.HashQuery(entry.Query)
+		if entry.Hash != "" && strings.ToLower(entry.Hash) != hash {
+			return nil, fmt.Errorf("hash %s does not match the body of operation %q", entry.Hash, entry.OperationName)
+		}
+		entry.Hash = hash
+		entry.Source = models.PersistedQuerySourceManifest
+	}
+	return entries, nil
+}
@@ -0,0 +1,50 @@
+package persisted
+
+import (
+	"sync"
+	"time"
+
+	"sns-server/internal/models"
+)
+
+// MemoryStore はプロセス内でクエリを保持するStoreです（単一インスタンス・テスト用）
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]models.PersistedQuery
+}
+
+// NewMemoryStore は空のMemoryStoreを作成します
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]models.PersistedQuery)}
+}
+
+func (s *MemoryStore) Get(hash string) (*models.PersistedQuery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if entry.IsExpired(time.Now()) {
+		delete(s.entries, hash)
+		return nil, ErrNotFound
+	}
+	return &entry, nil
+}
+
+func (s *MemoryStore) Save(entry *models.PersistedQuery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[entry.Hash]; ok && entry.Source != models.PersistedQuerySourceManifest {
+		// APQの登録は有効期限を延長する（マニフェストで登録済みのクエリは変更しない）
+		if existing.Source == models.PersistedQuerySourceAPQ {
+			existing.ExpiresAt = entry.ExpiresAt
+			s.entries[entry.Hash] = existing
+		}
+		return nil
+	}
+	s.entries[entry.Hash] = *entry
+	return nil
+}
@@ -0,0 +1,307 @@
+package persisted
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+const (
+	postsQuery = `query Posts { posts { id content } }`
+	usersQuery = `query Users { users { id } }`
+)
+
+func errorCode(err error) string {
+	var pqErr *Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code
+	}
+	return ""
+}
+
+func TestResolver_APQ(t *testing.T) {
+	resolver, _ := NewResolver(NewMemoryStore(), ModeAPQ)
+	hash := models.HashQuery(postsQuery)
+
+	// 未登録のハッシュのみ → PERSISTED_QUERY_NOT_FOUND
+	_, err := resolver.Resolve("", &Extension{Version: 1, SHA256Hash: hash})
+	if code := errorCode(err); code != CodeNotFound {
+		t.Fatalf("Expected %s, got %v", CodeNotFound, err)
+	}
+
+	// クエリ本文付きで再送 → 登録対象になる
+	res, err := resolver.Resolve(postsQuery, &Extension{Version: 1, SHA256Hash: hash})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !res.Register || res.Query != postsQuery {
+		t.Fatalf("Expected query to be registered, got %+v", res)
+	}
+	if err := resolver.Register(res, "Posts"); err != nil {
+		t.Fatalf("Failed to register: %v", err)
+	}
+
+	// 以降はハッシュのみで解決できる
+	res, err = resolver.Resolve("", &Extension{Version: 1, SHA256Hash: hash})
+	if err != nil || res.Query != postsQuery {
+		t.Fatalf("Expected registered query, got %+v, %v", res, err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		ext      *Extension
+		wantCode string
+	}{
+		{
+			name:     "ハッシュがクエリと一致しない",
+			query:    usersQuery,
+			ext:      &Extension{Version: 1, SHA256Hash: hash},
+			wantCode: CodeHashMismatch,
+		},
+		{
+			name:     "未対応のバージョン",
+			ext:      &Extension{Version: 2, SHA256Hash: hash},
+			wantCode: CodeVersionUnsupported,
+		},
+		{
+			name:     "extensionsなしのクエリはそのまま実行",
+			query:    usersQuery,
+			wantCode: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolver.Resolve(tt.query, tt.ext)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Errorf("Expected code %q, got %v", tt.wantCode, err)
+			}
+		})
+	}
+}
+
+func TestResolver_Allowlist(t *testing.T) {
+	store := NewMemoryStore()
+	store.Save(&models.PersistedQuery{Hash: models.HashQuery(postsQuery), Query: postsQuery, Source: models.PersistedQuerySourceManifest})
+	store.Save(&models.PersistedQuery{Hash: models.HashQuery(usersQuery), Query: usersQuery, Source: models.PersistedQuerySourceAPQ})
+	resolver, _ := NewResolver(store, ModeAllowlist)
+
+	tests := []struct {
+		name     string
+		query    string
+		ext      *Extension
+		wantCode string
+	}{
+		{
+			name:     "マニフェストのハッシュ",
+			ext:      &Extension{Version: 1, SHA256Hash: models.HashQuery(postsQuery)},
+			wantCode: "",
+		},
+		{
+			name:     "マニフェストと同じクエリ本文",
+			query:    postsQuery,
+			wantCode: "",
+		},
+		{
+			name:     "APQで登録されたクエリは許可しない",
+			ext:      &Extension{Version: 1, SHA256Hash: models.HashQuery(usersQuery)},
+			wantCode: CodeNotAllowed,
+		},
+		{
+			name:     "未登録のクエリ本文",
+			query:    `{ me { id } }`,
+			wantCode: CodeNotAllowed,
+		},
+		{
+			name:     "未登録のクエリは本文付きでも登録しない",
+			query:    `{ me { id } }`,
+			ext:      &Extension{Version: 1, SHA256Hash: models.HashQuery(`{ me { id } }`)},
+			wantCode: CodeNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := resolver.Resolve(tt.query, tt.ext)
+			if code := errorCode(err); code != tt.wantCode {
+				t.Fatalf("Expected code %q, got %v", tt.wantCode, err)
+			}
+			if res.Register {
+				t.Error("Allowlist mode must not register queries")
+			}
+		})
+	}
+}
+
+func TestResolver_Off(t *testing.T) {
+	var resolver *Resolver
+
+	if _, err := resolver.Resolve("", &Extension{Version: 1, SHA256Hash: models.HashQuery(postsQuery)}); errorCode(err) != CodeNotSupported {
+		t.Errorf("Expected %s, got %v", CodeNotSupported, err)
+	}
+	if res, err := resolver.Resolve(postsQuery, nil); err != nil || res.Query != postsQuery {
+		t.Errorf("Expected query to pass through, got %+v, %v", res, err)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifest  string
+		wantCount int
+		wantErr   bool
+	}{
+		{
+			name:      "Apollo形式",
+			manifest:  `{"format":"apollo-persisted-query-manifest","version":1,"operations":[{"id":"` + models.HashQuery(postsQuery) + `","name":"Posts","type":"query","body":"` + postsQuery + `"}]}`,
+			wantCount: 1,
+		},
+		{
+			name:      "ハッシュとクエリのマップ",
+			manifest:  `{"` + models.HashQuery(postsQuery) + `":"` + postsQuery + `","` + models.HashQuery(usersQuery) + `":"` + usersQuery + `"}`,
+			wantCount: 2,
+		},
+		{
+			name:     "ハッシュが一致しない",
+			manifest: `{"` + models.HashQuery(usersQuery) + `":"` + postsQuery + `"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "未対応のバージョン",
+			manifest: `{"format":"apollo-persisted-query-manifest","version":2,"operations":[]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "不正なJSON",
+			manifest: `[`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := ParseManifest([]byte(tt.manifest))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(entries) != tt.wantCount {
+				t.Errorf("Expected %d entries, got %d", tt.wantCount, len(entries))
+			}
+			for _, entry := range entries {
+				if entry.Source != models.PersistedQuerySourceManifest {
+					t.Errorf("Expected manifest source, got %s", entry.Source)
+				}
+			}
+		})
+	}
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.PersistedQuery{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+func TestStore_APQExpiry(t *testing.T) {
+	stores := map[string]func() Store{
+		"DBStore":     func() Store { return NewDBStore(setupTestDB(t)) },
+		"MemoryStore": func() Store { return NewMemoryStore() },
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore()
+			resolver, _ := NewResolver(store, ModeAPQ)
+			resolver.APQTTL = time.Hour
+			hash := models.HashQuery(postsQuery)
+			ext := &Extension{Version: 1, SHA256Hash: hash}
+			register := func() {
+				t.Helper()
+				res, err := resolver.Resolve(postsQuery, ext)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if err := resolver.Register(res, "Posts"); err != nil {
+					t.Fatalf("Failed to register: %v", err)
+				}
+			}
+
+			register()
+			entry, err := store.Get(hash)
+			if err != nil || entry.ExpiresAt == nil || entry.ExpiresAt.Before(time.Now().Add(59*time.Minute)) {
+				t.Fatalf("Expected query to expire after the TTL, got %+v, %v", entry, err)
+			}
+
+			// 有効期限を過ぎたクエリは未登録として扱い、再登録すると有効期限を延長する
+			past := time.Now().Add(-time.Minute)
+			store.Save(&models.PersistedQuery{Hash: hash, Query: postsQuery, Source: models.PersistedQuerySourceAPQ, ExpiresAt: &past})
+			if _, err := resolver.Resolve("", ext); errorCode(err) != CodeNotFound {
+				t.Fatalf("Expected %s for expired query, got %v", CodeNotFound, err)
+			}
+			resolver.APQTTL = time.Hour
+			register()
+			if res, err := resolver.Resolve("", ext); err != nil || res.Query != postsQuery {
+				t.Fatalf("Expected re-registered query, got %+v, %v", res, err)
+			}
+
+			// マニフェストで登録したクエリは無期限で、APQの登録で期限を付けない
+			store.Save(&models.PersistedQuery{Hash: models.HashQuery(usersQuery), Query: usersQuery, Source: models.PersistedQuerySourceManifest})
+			res, _ := resolver.Resolve(usersQuery, &Extension{Version: 1, SHA256Hash: models.HashQuery(usersQuery)})
+			resolver.Register(res, "Users")
+			entry, err = store.Get(models.HashQuery(usersQuery))
+			if err != nil || entry.ExpiresAt != nil || entry.Source != models.PersistedQuerySourceManifest {
+				t.Errorf("Expected manifest query to stay without expiry, got %+v, %v", entry, err)
+			}
+		})
+	}
+}
+
+func TestDBStore_ImportManifest(t *testing.T) {
+	store := NewDBStore(setupTestDB(t))
+
+	// APQで登録済みのクエリはマニフェストの読み込みで許可済みに昇格する
+	apq := models.PersistedQuery{Hash: models.HashQuery(postsQuery), Query: postsQuery, Source: models.PersistedQuerySourceAPQ}
+	if err := store.Save(&apq); err != nil {
+		t.Fatalf("Failed to save: %v", err)
+	}
+
+	entries, _ := ParseManifest([]byte(`{"` + models.HashQuery(postsQuery) + `":"` + postsQuery + `","` + models.HashQuery(usersQuery) + `":"` + usersQuery + `"}`))
+	if _, err := store.ImportManifest(entries, false); err != nil {
+		t.Fatalf("Failed to import manifest: %v", err)
+	}
+
+	entry, err := store.Get(models.HashQuery(postsQuery))
+	if err != nil || entry.Source != models.PersistedQuerySourceManifest {
+		t.Fatalf("Expected query to be promoted to manifest, got %+v, %v", entry, err)
+	}
+
+	// 再読み込み時にpruneするとマニフェストから外れたクエリを削除する
+	entries, _ = ParseManifest([]byte(`{"` + models.HashQuery(usersQuery) + `":"` + usersQuery + `"}`))
+	removed, err := store.ImportManifest(entries, true)
+	if err != nil {
+		t.Fatalf("Failed to import manifest: %v", err)
+	}
+	if removed != 1 {
+		t.Errorf("Expected 1 query to be removed, got %d", removed)
+	}
+	if _, err := store.Get(models.HashQuery(postsQuery)); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected pruned query to be gone, got %v", err)
+	}
+}
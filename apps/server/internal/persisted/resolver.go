@@ -0,0 +1,168 @@
+package persisted
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"sns-server/internal/models"
+)
+
+// 永続化クエリの動作モード
+const (
+	ModeOff       = "off"       // 永続化クエリを使わない
+	ModeAPQ       = "apq"       // ハッシュでの参照と、未登録時のクエリ本文による自動登録
+	ModeAllowlist = "allowlist" // マニフェストで登録したクエリのみ実行できる
+)
+
+// エラーコード（Apollo ClientのAPQ実装が参照するコードに合わせる）
+const (
+	CodeNotFound           = "PERSISTED_QUERY_NOT_FOUND"
+	CodeNotSupported       = "PERSISTED_QUERY_NOT_SUPPORTED"
+	CodeNotAllowed         = "PERSISTED_QUERY_NOT_ALLOWED"
+	CodeHashMismatch       = "PERSISTED_QUERY_HASH_MISMATCH"
+	CodeVersionUnsupported = "PERSISTED_QUERY_VERSION_NOT_SUPPORTED"
+)
+
+// Extension はリクエストのextensions.persistedQueryです
+type Extension struct {
+	Version    int    `json:"version"`
+	SHA256Hash string `json:"sha256Hash"`
+}
+
+// Error は永続化クエリの解決に失敗した場合のエラーです
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Resolution はリクエストから解決した実行対象のクエリです
+type Resolution struct {
+	Query string
+	Hash  string
+	// Registerがtrueの場合、クエリの検証に通った後でSaveすること（APQの自動登録）
+	Register bool
+}
+
+// Resolver はモードに応じてリクエストのクエリを解決します
+type Resolver struct {
+	Store Store
+	Mode  string
+	// APQTTL はAPQで登録したクエリの有効期限です（0の場合は無期限）
+	APQTTL time.Duration
+}
+
+// NewResolver はResolverを作成します
+func NewResolver(store Store, mode string) (*Resolver, error) {
+	switch mode {
+	case ModeOff, ModeAPQ, ModeAllowlist:
+		return &Resolver{Store: store, Mode: mode}, nil
+	default:
+		return nil, fmt.Errorf("unknown persisted query mode: %s", mode)
+	}
+}
+
+// Resolve はクエリ本文とextensionsから実行するクエリを決定します
+// Resolverがnilの場合はModeOffとして扱います
+func (r *Resolver) Resolve(query string, ext *Extension) (Resolution, error) {
+	mode := ModeOff
+	if r != nil {
+		mode = r.Mode
+	}
+
+	if ext == nil {
+		if mode != ModeAllowlist {
+			return Resolution{Query: query}, nil
+		}
+		// 許可リストモードではハッシュなしで送られたクエリも登録済みなら実行する
+		hash := models.HashQuery(query)
+		if err := r.checkAllowed(hash); err != nil {
+			return Resolution{}, err
+		}
+		return Resolution{Query: query, Hash: hash}, nil
+	}
+
+	if mode == ModeOff {
+		if query == "" {
+			return Resolution{}, &Error{Code: CodeNotSupported, Message: "PersistedQueryNotSupported"}
+		}
+		return Resolution{Query: query}, nil
+	}
+
+	if ext.Version != 1 {
+		return Resolution{}, &Error{Code: CodeVersionUnsupported, Message: fmt.Sprintf("Unsupported persisted query version: %d", ext.Version)}
+	}
+	hash := strings.ToLower(ext.SHA256Hash)
+
+	// ハッシュのみの場合は登録済みのクエリを探す
+	if query == "" {
+		entry, err := r.Store.Get(hash)
+		if errors.Is(err, ErrNotFound) {
+			if mode == ModeAllowlist {
+				return Resolution{}, notAllowed()
+			}
+			return Resolution{}, &Error{Code: CodeNotFound, Message: "PersistedQueryNotFound"}
+		}
+		if err != nil {
+			return Resolution{}, err
+		}
+		if mode == ModeAllowlist && entry.Source != models.PersistedQuerySourceManifest {
+			return Resolution{}, notAllowed()
+		}
+		return Resolution{Query: entry.Query, Hash: hash}, nil
+	}
+
+	if models.HashQuery(query) != hash {
+		return Resolution{}, &Error{Code: CodeHashMismatch, Message: "provided sha does not match query"}
+	}
+
+	if mode == ModeAllowlist {
+		if err := r.checkAllowed(hash); err != nil {
+			return Resolution{}, err
+		}
+		return Resolution{Query: query, Hash: hash}, nil
+	}
+	return Resolution{Query: query, Hash: hash, Register: true}, nil
+}
+
+// Register はAPQで送られたクエリを登録します
+func (r *Resolver) Register(res Resolution, operationName string) error {
+	if r == nil || !res.Register {
+		return nil
+	}
+	entry := &models.PersistedQuery{
+		Hash:          res.Hash,
+		Query:         res.Query,
+		OperationName: operationName,
+		Source:        models.PersistedQuerySourceAPQ,
+	}
+	if r.APQTTL > 0 {
+		expiresAt := time.Now().Add(r.APQTTL)
+		entry.ExpiresAt = &expiresAt
+	}
+	return r.Store.Save(entry)
+}
+
+// checkAllowed はハッシュがマニフェストで登録済みかを確認します
+func (r *Resolver) checkAllowed(hash string) error {
+	entry, err := r.Store.Get(hash)
+	if errors.Is(err, ErrNotFound) {
+		return notAllowed()
+	}
+	if err != nil {
+		return err
+	}
+	if entry.Source != models.PersistedQuerySourceManifest {
+		return notAllowed()
+	}
+	return nil
+}
+
+func notAllowed() error {
+	return &Error{Code: CodeNotAllowed, Message: "Only registered persisted queries are allowed"}
+}
@@ -0,0 +1,36 @@
+package persisted
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// ErrNotFound はハッシュに対応するクエリが登録されていない場合のエラーです
+var ErrNotFound = errors.New("persisted query not found")
+
+// Store はハッシュをキーにクエリを保存します
+type Store interface {
+	// Get はハッシュに対応するクエリを返します（なければErrNotFound）
+	Get(hash string) (*models.PersistedQuery, error)
+	// Save はクエリを登録します
+	// 既に登録済みの場合、マニフェストからの登録は登録元と操作名を上書きして無期限にし、
+	// APQからの登録はAPQで登録済みのクエリの有効期限のみ更新する
+	// 有効期限を過ぎたクエリはGetでErrNotFoundを返す
+	Save(entry *models.PersistedQuery) error
+}
+
+// NewStore は設定に応じたStoreを作成します
+func NewStore(cfg *config.Config, db *gorm.DB) (Store, error) {
+	switch cfg.PersistedQueryBackend {
+	case "postgres", "":
+		return NewDBStore(db), nil
+	case "memory":
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown persisted query backend: %s", cfg.PersistedQueryBackend)
+	}
+}
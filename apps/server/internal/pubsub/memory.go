@@ -0,0 +1,74 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker はプロセス内で配信するBrokerです（単一インスタンス・テスト用）
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+// NewMemoryBroker はMemoryBrokerを作成します
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{topics: make(map[string]map[*Subscription]struct{})}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.topics[topic] {
+		select {
+		case sub.ch <- payload:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(topic string) *Subscription {
+	sub := &Subscription{ch: make(chan []byte, subscriptionBuffer)}
+
+	var once sync.Once
+	sub.cancel = func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.topics[topic], sub)
+			if len(b.topics[topic]) == 0 {
+				delete(b.topics, topic)
+			}
+			close(sub.ch)
+		})
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.topics[topic] == nil {
+		b.topics[topic] = make(map[*Subscription]struct{})
+	}
+	b.topics[topic][sub] = struct{}{}
+
+	return sub
+}
+
+// Close は全ての購読を解除します
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	var subs []*Subscription
+	for _, topicSubs := range b.topics {
+		for sub := range topicSubs {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// NOTIFYに使うチャネル名
+const notifyChannel = "sns_events"
+
+// NOTIFYのペイロード上限（8000バイト）を超えないように、イベントにはIDなど最小限の情報だけを載せること
+
+type envelope struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// PostgresBroker はPostgresのLISTEN/NOTIFYで複数インスタンスにメッセージを配信するBrokerです
+// 受信したメッセージはプロセス内のMemoryBrokerで購読者に配ります
+type PostgresBroker struct {
+	db     *gorm.DB
+	dsn    string
+	local  *MemoryBroker
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPostgresBroker はPostgresBrokerを作成し、LISTENを開始します
+func NewPostgresBroker(db *gorm.DB, dsn string) *PostgresBroker {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &PostgresBroker{
+		db:     db,
+		dsn:    dsn,
+		local:  NewMemoryBroker(),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go b.listen(ctx)
+	return b
+}
+
+func (b *PostgresBroker) Publish(ctx context.Context, topic string, payload []byte) error {
+	data, err := json.Marshal(envelope{Topic: topic, Payload: payload})
+	if err != nil {
+		return err
+	}
+	return b.db.WithContext(ctx).Exec("SELECT pg_notify(?, ?)", notifyChannel, string(data)).Error
+}
+
+func (b *PostgresBroker) Subscribe(topic string) *Subscription {
+	return b.local.Subscribe(topic)
+}
+
+// Close はLISTENを停止し、全ての購読を解除します
+func (b *PostgresBroker) Close() error {
+	b.cancel()
+	<-b.done
+	return b.local.Close()
+}
+
+// listen は専用の接続でLISTENし、通知をローカルの購読者に配ります
+// 接続が切れた場合は待機時間を伸ばしながら再接続します
+func (b *PostgresBroker) listen(ctx context.Context) {
+	defer close(b.done)
+
+	backoff := time.Second
+	for ctx.Err() == nil {
+		err := b.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("PubSub listener disconnected, retrying in %v: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func (b *PostgresBroker) listenOnce(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var msg envelope
+		if err := json.Unmarshal([]byte(notification.Payload), &msg); err != nil {
+			log.Printf("PubSub received invalid payload: %v", err)
+			continue
+		}
+		b.local.Publish(ctx, msg.Topic, msg.Payload)
+	}
+}
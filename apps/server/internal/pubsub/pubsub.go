@@ -0,0 +1,49 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+)
+
+// 購読者ごとのバッファ（溢れた分は捨てる）
+const subscriptionBuffer = 64
+
+// Broker はトピック単位でメッセージを配信します
+// ペイロードはJSONであること（PostgresBrokerではNOTIFYのペイロードにそのまま埋め込むため）
+type Broker interface {
+	Publish(ctx context.Context, topic string, payload []byte) error
+	Subscribe(topic string) *Subscription
+	Close() error
+}
+
+// New は設定に応じたBrokerを作成します
+func New(cfg *config.Config, db *gorm.DB) (Broker, error) {
+	switch cfg.PubSubBackend {
+	case "memory", "":
+		return NewMemoryBroker(), nil
+	case "postgres":
+		return NewPostgresBroker(db, cfg.DatabaseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown pubsub backend: %s", cfg.PubSubBackend)
+	}
+}
+
+// Subscription は1つのトピックの購読です
+// 受信が追いつかない購読者へのメッセージは破棄されます（ライブ更新用途のため）
+type Subscription struct {
+	ch     chan []byte
+	cancel func()
+}
+
+// C は受信したペイロードのチャネルを返します（Close後に閉じられる）
+func (s *Subscription) C() <-chan []byte {
+	return s.ch
+}
+
+// Close は購読を解除します
+func (s *Subscription) Close() {
+	s.cancel()
+}
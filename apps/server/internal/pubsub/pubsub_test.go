@@ -0,0 +1,67 @@
+package pubsub
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, sub *Subscription) (string, bool) {
+	t.Helper()
+	select {
+	case payload, ok := <-sub.C():
+		return string(payload), ok
+	case <-time.After(100 * time.Millisecond):
+		return "", false
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	ctx := context.Background()
+	broker := NewMemoryBroker()
+
+	posts := broker.Subscribe("post.created")
+	posts2 := broker.Subscribe("post.created")
+	likes := broker.Subscribe("post.like_count")
+
+	broker.Publish(ctx, "post.created", []byte(`{"postId":1}`))
+
+	for _, sub := range []*Subscription{posts, posts2} {
+		if payload, ok := receive(t, sub); !ok || payload != `{"postId":1}` {
+			t.Errorf("Expected payload, got %q", payload)
+		}
+	}
+	if _, ok := receive(t, likes); ok {
+		t.Error("Subscriber of another topic should not receive the message")
+	}
+
+	// 解除後は配信されず、チャネルが閉じられる
+	posts.Close()
+	broker.Publish(ctx, "post.created", []byte(`{"postId":2}`))
+	if _, ok := <-posts.C(); ok {
+		t.Error("Expected channel to be closed after Close")
+	}
+	if payload, _ := receive(t, posts2); payload != `{"postId":2}` {
+		t.Errorf("Remaining subscriber should receive the message, got %q", payload)
+	}
+
+	// 二重のCloseでパニックしない
+	posts.Close()
+}
+
+func TestMemoryBroker_SlowSubscriber(t *testing.T) {
+	broker := NewMemoryBroker()
+	sub := broker.Subscribe("topic")
+
+	// バッファを超えても Publish はブロックしない
+	for i := 0; i < subscriptionBuffer*2; i++ {
+		broker.Publish(context.Background(), "topic", []byte(`{}`))
+	}
+	if len(sub.C()) != subscriptionBuffer {
+		t.Errorf("Expected %d buffered messages, got %d", subscriptionBuffer, len(sub.C()))
+	}
+
+	broker.Close()
+	for range sub.C() {
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"sns-server/internal/config"
+)
+
+// MemoryLimiter はプロセス内でカウンターを保持するLimiterです（単一インスタンス・テスト用）
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	now     func() time.Time
+	calls   int
+}
+
+type memoryBucket struct {
+	windowStart time.Time
+	windowEnd   time.Time
+	count       int
+}
+
+// 何回の呼び出しごとに期限切れのカウンターを掃除するか
+const memoryCleanupInterval = 1000
+
+// NewMemoryLimiter は空のMemoryLimiterを作成します
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow はキーのカウンターを1増やし、上限内かどうかを返します
+func (l *MemoryLimiter) Allow(key string, limit config.RateLimit) (Result, error) {
+	now := l.now()
+	start, end := window(now, limit)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls++
+	if l.calls%memoryCleanupInterval == 0 {
+		l.cleanup(now)
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok || !bucket.windowStart.Equal(start) {
+		bucket = &memoryBucket{windowStart: start, windowEnd: end}
+		l.buckets[key] = bucket
+	}
+	bucket.count++
+
+	return result(bucket.count, limit, now, end), nil
+}
+
+// cleanup はウィンドウが終了したカウンターを削除します
+func (l *MemoryLimiter) cleanup(now time.Time) {
+	for key, bucket := range l.buckets {
+		if !now.Before(bucket.windowEnd) {
+			delete(l.buckets, key)
+		}
+	}
+}
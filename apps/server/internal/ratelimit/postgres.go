@@ -0,0 +1,51 @@
+package ratelimit
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// PostgresLimiter はrate_limit_bucketsテーブルでカウンターを共有するLimiterです
+// 複数インスタンス構成でも上限が共有されます（UPSERTのみ使うためSQLiteでも動作します）
+type PostgresLimiter struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+// NewPostgresLimiter はPostgresLimiterを作成します
+func NewPostgresLimiter(db *gorm.DB) *PostgresLimiter {
+	return &PostgresLimiter{db: db, now: time.Now}
+}
+
+// Allow はキーのカウンターを原子的に1増やし、上限内かどうかを返します
+// ウィンドウが切り替わっていればカウンターを1からやり直します
+func (l *PostgresLimiter) Allow(key string, limit config.RateLimit) (Result, error) {
+	now := l.now()
+	start, end := window(now, limit)
+
+	var count int
+	err := l.db.Raw(`
+		INSERT INTO rate_limit_buckets (key, window_start, count, expires_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limit_buckets.window_start = excluded.window_start
+				THEN rate_limit_buckets.count + 1 ELSE 1 END,
+			window_start = excluded.window_start,
+			expires_at = excluded.expires_at
+		RETURNING count`,
+		key, start.Unix(), end.Unix(),
+	).Scan(&count).Error
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result(count, limit, now, end), nil
+}
+
+// Cleanup はウィンドウが終了したカウンターを削除します
+func (l *PostgresLimiter) Cleanup() error {
+	return l.db.Where("expires_at <= ?", l.now().Unix()).Delete(&models.RateLimitBucket{}).Error
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+)
+
+// Result はレート制限の判定結果です
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // 拒否された場合、次に許可されるまでの時間
+}
+
+// Limiter はキーごとのリクエスト数を数えて上限を判定します
+type Limiter interface {
+	Allow(key string, limit config.RateLimit) (Result, error)
+}
+
+// New は設定に応じたLimiterを作成します
+func New(cfg *config.Config, db *gorm.DB) (Limiter, error) {
+	switch cfg.RateLimitBackend {
+	case "memory", "":
+		return NewMemoryLimiter(), nil
+	case "postgres":
+		return NewPostgresLimiter(db), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend: %s", cfg.RateLimitBackend)
+	}
+}
+
+// window は固定ウィンドウの開始・終了時刻を計算します
+func window(now time.Time, limit config.RateLimit) (start, end time.Time) {
+	start = now.Truncate(limit.Window)
+	return start, start.Add(limit.Window)
+}
+
+func result(count int, limit config.RateLimit, now, end time.Time) Result {
+	if count > limit.Requests {
+		return Result{Allowed: false, Remaining: 0, RetryAfter: end.Sub(now)}
+	}
+	return Result{Allowed: true, Remaining: limit.Requests - count}
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	if err := db.AutoMigrate(&models.RateLimitBucket{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	return db
+}
+
+func TestLimiters(t *testing.T) {
+	limiters := map[string]func(t *testing.T, now func() time.Time) Limiter{
+		"memory": func(t *testing.T, now func() time.Time) Limiter {
+			l := NewMemoryLimiter()
+			l.now = now
+			return l
+		},
+		"postgres": func(t *testing.T, now func() time.Time) Limiter {
+			l := NewPostgresLimiter(setupTestDB(t))
+			l.now = now
+			return l
+		},
+	}
+
+	limit := config.RateLimit{Requests: 3, Window: time.Minute}
+
+	for name, newLimiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			current := time.Date(2025, 1, 1, 12, 0, 10, 0, time.UTC)
+			limiter := newLimiter(t, func() time.Time { return current })
+
+			// 上限までは許可される
+			for i := 1; i <= 3; i++ {
+				res, err := limiter.Allow("login:ip:203.0.113.1", limit)
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if !res.Allowed {
+					t.Fatalf("Request %d should be allowed", i)
+				}
+				if res.Remaining != 3-i {
+					t.Errorf("Expected remaining %d, got %d", 3-i, res.Remaining)
+				}
+			}
+
+			// 上限を超えると拒否され、ウィンドウ終了までの時間が返る
+			res, err := limiter.Allow("login:ip:203.0.113.1", limit)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if res.Allowed {
+				t.Error("Request over the limit should be rejected")
+			}
+			if res.RetryAfter != 50*time.Second {
+				t.Errorf("Expected retry after 50s, got %v", res.RetryAfter)
+			}
+
+			// 別のキーは影響を受けない
+			res, _ = limiter.Allow("login:ip:203.0.113.2", limit)
+			if !res.Allowed {
+				t.Error("Other key should be allowed")
+			}
+
+			// 次のウィンドウではリセットされる
+			current = current.Add(time.Minute)
+			res, _ = limiter.Allow("login:ip:203.0.113.1", limit)
+			if !res.Allowed || res.Remaining != 2 {
+				t.Errorf("Expected reset in next window, got %+v", res)
+			}
+		})
+	}
+}
+
+func TestPostgresLimiter_Cleanup(t *testing.T) {
+	db := setupTestDB(t)
+	current := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewPostgresLimiter(db)
+	limiter.now = func() time.Time { return current }
+
+	limiter.Allow("short", config.RateLimit{Requests: 1, Window: time.Minute})
+	limiter.Allow("long", config.RateLimit{Requests: 1, Window: time.Hour})
+
+	current = current.Add(2 * time.Minute)
+	if err := limiter.Cleanup(); err != nil {
+		t.Fatalf("Cleanup failed: %v", err)
+	}
+
+	var count int64
+	db.Model(&models.RateLimitBucket{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 bucket after cleanup, got %d", count)
+	}
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/auth"
+	"sns-server/internal/mailer"
+	"sns-server/internal/models"
+)
+
+// sendVerificationEmail はメールアドレス確認用のリンクを送信します
+func (s *Server) sendVerificationEmail(user *models.User) error {
+	token, err := s.issueUserToken(s.DB, user.ID, models.TokenPurposeEmailVerification, s.Config.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "メールアドレスの確認",
+		Body: fmt.Sprintf("%sさん\n\nSNSへのご登録ありがとうございます。\n以下のリンクからメールアドレスを確認してください（有効期限: %s）。\n\n%s\n",
+			user.Name, s.Config.EmailVerificationTTL, s.actionURL("/verify-email", token)),
+	})
+}
+
+// sendPasswordResetEmail はパスワード再設定用のリンクを送信します
+func (s *Server) sendPasswordResetEmail(user *models.User) error {
+	// 古いリセットリンクは無効化して最新のものだけを有効にする
+	if err := models.RevokeUserTokens(s.DB, user.ID, models.TokenPurposePasswordReset, time.Now()); err != nil {
+		return err
+	}
+
+	token, err := s.issueUserToken(s.DB, user.ID, models.TokenPurposePasswordReset, s.Config.PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "パスワードの再設定",
+		Body: fmt.Sprintf("%sさん\n\nパスワード再設定のリクエストを受け付けました。\n以下のリンクから新しいパスワードを設定してください（有効期限: %s）。\n\n%s\n\nお心当たりがない場合はこのメールを破棄してください。\n",
+			user.Name, s.Config.PasswordResetTTL, s.actionURL("/reset-password", token)),
+	})
+}
+
+// issueUserToken は署名付きトークンを発行し、使い捨て管理のためにハッシュをDBに保存します
+func (s *Server) issueUserToken(db *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	expiresAt := time.Now().Add(ttl)
+
+	token, err := auth.SignToken(s.Config.JWTSecret, purpose, userID, expiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	record := models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: auth.HashToken(token),
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Create(&record).Error; err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+
+	return token, nil
+}
+
+// consumeUserToken は署名・有効期限を検証し、トークンを使用済みにします
+func (s *Server) consumeUserToken(db *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	claims, err := auth.VerifyToken(s.Config.JWTSecret, token, purpose)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := models.ConsumeUserToken(db, auth.HashToken(token), purpose, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if record.UserID != claims.UserID {
+		return nil, auth.ErrInvalidToken
+	}
+
+	return record, nil
+}
+
+func (s *Server) actionURL(path, token string) string {
+	return s.Config.AppBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func (s *Server) handleVerifyEmailMutation(variables map[string]interface{}) GraphQLResponse {
+	token := getString(variables, "token")
+	if token == "" {
+		return errorResponse("Token is required")
+	}
+
+	var user models.User
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := s.consumeUserToken(tx, token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, record.UserID).Error; err != nil {
+			return err
+		}
+
+		user.EmailVerified = true
+		return tx.Model(&user).Update("email_verified", true).Error
+	})
+	if err != nil {
+		return errorResponse(tokenErrorMessage(err))
+	}
+
+	return dataResponse("verifyEmail", user)
+}
+
+func (s *Server) handleRequestPasswordResetMutation(variables map[string]interface{}) GraphQLResponse {
+	email := models.NormalizeEmail(getString(variables, "email"))
+	if email == "" {
+		return errorResponse("Email is required")
+	}
+
+	// 登録有無が推測されないよう、ユーザーが存在しなくても常にtrueを返す
+	var user models.User
+	if err := s.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errorResponse(fmt.Sprintf("Database error: %v", err))
+		}
+		return dataResponse("requestPasswordReset", true)
+	}
+
+	if err := s.sendPasswordResetEmail(&user); err != nil {
+		log.Printf("Failed to send password reset email to user %d: %v", user.ID, err)
+	}
+
+	return dataResponse("requestPasswordReset", true)
+}
+
+func (s *Server) handleResetPasswordMutation(variables map[string]interface{}) GraphQLResponse {
+	input, ok := variables["input"].(map[string]interface{})
+	if !ok {
+		return errorResponse("Invalid input format")
+	}
+
+	token := getString(input, "token")
+	if token == "" {
+		return errorResponse("Token is required")
+	}
+
+	newPassword := getString(input, "newPassword")
+	if err := auth.ValidatePassword(newPassword); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	hash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return errorResponse("Failed to hash password")
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		record, err := s.consumeUserToken(tx, token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", record.UserID).Update("password", hash).Error; err != nil {
+			return err
+		}
+
+		// 同時に発行されていた他のリセットトークンも無効化
+		return models.RevokeUserTokens(tx, record.UserID, models.TokenPurposePasswordReset, time.Now())
+	})
+	if err != nil {
+		return errorResponse(tokenErrorMessage(err))
+	}
+
+	return dataResponse("resetPassword", true)
+}
+
+// handleDeactivateAccountMutation は本人の確認のためパスワードを照合してから退会させます
+// 猶予期間中にログインすると退会を取り消せます
+func (s *Server) handleDeactivateAccountMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	if !auth.CheckPassword(user.Password, getString(variables, "password")) {
+		return errorResponse("Invalid password")
+	}
+
+	if err := models.DeactivateUser(s.DB, user); err != nil {
+		return errorResponse(fmt.Sprintf("Failed to deactivate account: %v", err))
+	}
+
+	return dataResponse("deactivateAccount", true)
+}
+
+// tokenErrorMessage はトークン検証エラーをクライアント向けのメッセージに変換します
+func tokenErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrTokenExpired):
+		return "Token has expired"
+	case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, models.ErrTokenAlreadyUsed), errors.Is(err, gorm.ErrRecordNotFound):
+		return "Invalid or already used token"
+	default:
+		return fmt.Sprintf("Database error: %v", err)
+	}
+}
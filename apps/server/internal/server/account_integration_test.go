@@ -0,0 +1,185 @@
+package server_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/lockout"
+	"sns-server/internal/mailer"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestAccountRecoveryIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	outbox := mailer.NewMemoryOutbox()
+
+	cfg := config.LoadTest()
+	srv := &server.Server{
+		DB:         db,
+		Config:     cfg,
+		Mailer:     outbox,
+		LoginGuard: lockout.NewGuard(db, lockout.PolicyFromConfig(cfg)),
+	}
+
+	register := GraphQLRequest{
+		Query: `mutation { register(input: $input) { token user { id emailVerified } } }`,
+		Variables: map[string]interface{}{
+			"input": map[string]interface{}{
+				"username": "alice",
+				"email":    "Alice@Example.com",
+				"password": "password123",
+				"name":     "Alice",
+			},
+		},
+	}
+	if resp := executeGraphQLRequest(t, srv, register); resp.Errors != nil {
+		t.Fatalf("Register failed: %v", resp.Errors)
+	}
+
+	t.Run("登録時にパスワードがハッシュ化される", func(t *testing.T) {
+		var user models.User
+		db.Where("email = ?", "alice@example.com").First(&user)
+
+		if user.Password == "password123" {
+			t.Error("Password should not be stored in plain text")
+		}
+		if !auth.CheckPassword(user.Password, "password123") {
+			t.Error("Stored hash should match the password")
+		}
+		if user.EmailVerified {
+			t.Error("Email should not be verified right after registration")
+		}
+	})
+
+	t.Run("ログイン", func(t *testing.T) {
+		login := func(email, password string) GraphQLResponse {
+			return executeGraphQLRequest(t, srv, GraphQLRequest{
+				Query: `mutation ($input: LoginInput!) { login(input: $input) { token user { id } } }`,
+				Variables: map[string]interface{}{
+					"input": map[string]interface{}{"email": email, "password": password},
+				},
+			})
+		}
+
+		resp := login("ALICE@example.com", "password123")
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		data := resp.Data.(map[string]interface{})
+		token := data["login"].(map[string]interface{})["token"].(string)
+		if _, err := auth.ParseSessionToken(srv.Config.JWTSecret, token); err != nil {
+			t.Errorf("Expected valid session token: %v", err)
+		}
+
+		if resp := login("alice@example.com", "wrong-password"); resp.Errors == nil {
+			t.Error("Expected error for wrong password")
+		}
+	})
+
+	t.Run("確認メールのトークンでメールアドレスを確認", func(t *testing.T) {
+		token := tokenFromMail(t, outbox, "alice@example.com")
+
+		req := GraphQLRequest{
+			Query:     `mutation ($token: String!) { verifyEmail(token: $token) { id emailVerified } }`,
+			Variables: map[string]interface{}{"token": token},
+		}
+
+		resp := executeGraphQLRequest(t, srv, req)
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		data := resp.Data.(map[string]interface{})
+		user := data["verifyEmail"].(map[string]interface{})
+		if user["emailVerified"] != true {
+			t.Errorf("Expected emailVerified to be true, got %v", user["emailVerified"])
+		}
+
+		// 同じトークンは再利用できない
+		resp = executeGraphQLRequest(t, srv, req)
+		if resp.Errors == nil {
+			t.Error("Expected error when reusing verification token")
+		}
+	})
+
+	t.Run("存在しないメールアドレスでもリセット要求は成功扱い", func(t *testing.T) {
+		outbox.Reset()
+
+		req := GraphQLRequest{
+			Query:     `mutation ($email: String!) { requestPasswordReset(email: $email) }`,
+			Variables: map[string]interface{}{"email": "nobody@example.com"},
+		}
+
+		resp := executeGraphQLRequest(t, srv, req)
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if len(outbox.Messages()) != 0 {
+			t.Error("No mail should be sent for unknown address")
+		}
+	})
+
+	t.Run("パスワードリセット", func(t *testing.T) {
+		req := GraphQLRequest{
+			Query:     `mutation ($email: String!) { requestPasswordReset(email: $email) }`,
+			Variables: map[string]interface{}{"email": "alice@example.com"},
+		}
+		if resp := executeGraphQLRequest(t, srv, req); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		token := tokenFromMail(t, outbox, "alice@example.com")
+
+		reset := GraphQLRequest{
+			Query: `mutation ($input: ResetPasswordInput!) { resetPassword(input: $input) }`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{"token": token, "newPassword": "new-password-456"},
+			},
+		}
+
+		resp := executeGraphQLRequest(t, srv, reset)
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		var user models.User
+		db.Where("email = ?", "alice@example.com").First(&user)
+		if !auth.CheckPassword(user.Password, "new-password-456") {
+			t.Error("Password should be updated")
+		}
+
+		// 同じトークンは再利用できない
+		resp = executeGraphQLRequest(t, srv, reset)
+		if resp.Errors == nil {
+			t.Error("Expected error when reusing reset token")
+		}
+	})
+}
+
+// tokenFromMail は最後に送信されたメール本文のリンクからトークンを取り出します
+func tokenFromMail(t *testing.T, outbox *mailer.MemoryOutbox, to string) string {
+	msg, ok := outbox.Last(to)
+	if !ok {
+		t.Fatalf("No mail sent to %s", to)
+	}
+
+	for _, line := range strings.Split(msg.Body, "\n") {
+		if !strings.Contains(line, "?token=") {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(line))
+		if err != nil {
+			t.Fatalf("Failed to parse link: %v", err)
+		}
+		return u.Query().Get("token")
+	}
+
+	t.Fatalf("No token link found in mail to %s", to)
+	return ""
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/models"
+)
+
+// ブロックの関係にあるユーザーへの操作（フォロー・いいね・リプライなど）のエラー
+var errBlocked = errors.New("Cannot interact with a blocked user")
+
+// checkNotBlocked はユーザー同士がブロックの関係にないかチェックします
+func (s *Server) checkNotBlocked(userID, otherID uint) error {
+	if models.IsBlockedBetween(s.DB, userID, otherID) {
+		return errBlocked
+	}
+	return nil
+}
+
+// viewerID は閲覧中のユーザーのIDを返します（未認証の場合は0）
+func viewerID(ctx context.Context) uint {
+	userID, _ := auth.UserIDFromContext(ctx)
+	return userID
+}
+
+func (s *Server) handleBlockUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, target, err := s.relationshipTarget(ctx, variables)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+	if user.ID == target.ID {
+		return errorResponse("Cannot block yourself")
+	}
+
+	if err := models.BlockUser(s.DB, user.ID, target.ID); err != nil {
+		return errorResponse(fmt.Sprintf("Failed to block user: %v", err))
+	}
+	return dataResponse("blockUser", target)
+}
+
+func (s *Server) handleUnblockUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, target, err := s.relationshipTarget(ctx, variables)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	result := s.DB.Where("blocker_id = ? AND blocked_id = ?", user.ID, target.ID).Delete(&models.Block{})
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Failed to unblock user: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errorResponse("Block not found")
+	}
+	return dataResponse("unblockUser", target)
+}
+
+func (s *Server) handleMuteUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, target, err := s.relationshipTarget(ctx, variables)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+	if user.ID == target.ID {
+		return errorResponse("Cannot mute yourself")
+	}
+
+	mute := models.Mute{MuterID: user.ID, MutedID: target.ID}
+	if err := s.DB.Where(mute).FirstOrCreate(&mute).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Failed to mute user: %v", err))
+	}
+	return dataResponse("muteUser", target)
+}
+
+func (s *Server) handleUnmuteUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, target, err := s.relationshipTarget(ctx, variables)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	result := s.DB.Where("muter_id = ? AND muted_id = ?", user.ID, target.ID).Delete(&models.Mute{})
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Failed to unmute user: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errorResponse("Mute not found")
+	}
+	return dataResponse("unmuteUser", target)
+}
+
+// relationshipTarget はブロック・ミュートの操作を行うユーザーと対象のユーザーを返します
+func (s *Server) relationshipTarget(ctx context.Context, variables map[string]interface{}) (*models.User, *models.User, error) {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	targetID := getUint(variables, "userId")
+	if targetID == 0 {
+		return nil, nil, errors.New("User ID is required")
+	}
+
+	var target models.User
+	if err := s.DB.First(&target, targetID).Error; err != nil {
+		return nil, nil, errors.New("User not found")
+	}
+	return user, &target, nil
+}
+
+func (s *Server) handleBlockedUsersQuery(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var users []models.User
+	err = s.DB.Where("id IN (?)", s.DB.Model(&models.Block{}).Select("blocked_id").Where("blocker_id = ?", user.ID)).
+		Order("username").
+		Find(&users).Error
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("blockedUsers", users)
+}
+
+func (s *Server) handleMutedUsersQuery(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var users []models.User
+	err = s.DB.Where("id IN (?)", s.DB.Model(&models.Mute{}).Select("muted_id").Where("muter_id = ?", user.ID)).
+		Order("username").
+		Find(&users).Error
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("mutedUsers", users)
+}
@@ -0,0 +1,246 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+	"sns-server/internal/timeline"
+)
+
+func TestBlockMuteIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+	fanout := timeline.NewFanout(db, timeline.PolicyFromConfig(cfg))
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	carol := testutil.CreateTestUser(t, db, "carol", "carol@example.com", "Carol")
+	dave := testutil.CreateTestUser(t, db, "dave", "dave@example.com", "Dave")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	decode := func(resp GraphQLResponse, field string, v interface{}) {
+		t.Helper()
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})[field])
+		json.Unmarshal(data, v)
+	}
+	userVars := func(user *models.User) map[string]interface{} {
+		return map[string]interface{}{"userId": fmt.Sprint(user.ID)}
+	}
+	contents := func(user *models.User, query, field string, variables map[string]interface{}) []string {
+		t.Helper()
+		var posts []struct {
+			Content string `json:"content"`
+		}
+		if field == "timeline" {
+			// フォロー・投稿を直接作成しているため、読み込む前にタイムラインを作り直す
+			if _, err := fanout.Rebuild(user.ID); err != nil {
+				t.Fatalf("Failed to rebuild timeline: %v", err)
+			}
+		}
+		resp := execute(user, query, variables)
+		if field == "postsByHashtag" || field == "timeline" {
+			var page struct {
+				Posts json.RawMessage `json:"posts"`
+			}
+			decode(resp, field, &page)
+			json.Unmarshal(page.Posts, &posts)
+		} else {
+			decode(resp, field, &posts)
+		}
+		var result []string
+		for _, post := range posts {
+			result = append(result, post.Content)
+		}
+		return result
+	}
+	usernames := func(user *models.User) map[string]bool {
+		t.Helper()
+		var users []struct {
+			Username string `json:"username"`
+		}
+		decode(execute(user, `query { users { id username } }`, nil), "users", &users)
+		result := map[string]bool{}
+		for _, u := range users {
+			result[u.Username] = true
+		}
+		return result
+	}
+
+	db.Create(&models.Follow{FollowerID: alice.ID, FolloweeID: bob.ID})
+	db.Create(&models.Follow{FollowerID: bob.ID, FolloweeID: alice.ID})
+	alicePost := testutil.CreateTestPost(t, db, alice.ID, "alice #blocktest")
+	bobPost := testutil.CreateTestPost(t, db, bob.ID, "bob #blocktest")
+
+	t.Run("ブロックすると互いのフォローを解除する", func(t *testing.T) {
+		if resp := execute(alice, `mutation { blockUser(userId: $userId) { id } }`, userVars(bob)); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if models.IsFollowing(db, alice.ID, bob.ID) || models.IsFollowing(db, bob.ID, alice.ID) {
+			t.Error("Expected follows to be removed in both directions")
+		}
+
+		if resp := execute(alice, `mutation { blockUser(userId: $userId) { id } }`, userVars(alice)); resp.Errors == nil {
+			t.Error("Expected error when blocking yourself")
+		}
+
+		var blocked []struct {
+			Username string `json:"username"`
+		}
+		decode(execute(alice, `query { blockedUsers { username } }`, nil), "blockedUsers", &blocked)
+		if len(blocked) != 1 || blocked[0].Username != "bob" {
+			t.Errorf("Expected bob to be blocked, got %+v", blocked)
+		}
+	})
+
+	t.Run("ブロックの関係にあるユーザーへの操作はできない", func(t *testing.T) {
+		// ブロックされた側・した側のどちらからも操作できない
+		cases := []struct {
+			name      string
+			user      *models.User
+			query     string
+			variables map[string]interface{}
+		}{
+			{"フォロー", bob, `mutation { followUser(userId: $userId) { id } }`, userVars(alice)},
+			{"フォロー（ブロックした側）", alice, `mutation { followUser(userId: $userId) { id } }`, userVars(bob)},
+			{"いいね", bob, `mutation { likePost(postId: $postId) { id } }`, map[string]interface{}{"postId": alicePost.ID}},
+			{"いいね（ブロックした側）", alice, `mutation { likePost(postId: $postId) { id } }`, map[string]interface{}{"postId": bobPost.ID}},
+			{"リプライ", bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{"input": map[string]interface{}{"content": "reply", "parentId": fmt.Sprint(alicePost.ID)}}},
+			{"引用", bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{"input": map[string]interface{}{"content": "quote", "quotedPostId": fmt.Sprint(alicePost.ID)}}},
+			{"リポスト", bob, `mutation { repost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(alicePost.ID)}},
+		}
+		for _, tc := range cases {
+			if resp := execute(tc.user, tc.query, tc.variables); resp.Errors == nil {
+				t.Errorf("%s: expected error between blocked users", tc.name)
+			}
+		}
+
+		// ブロックされたユーザーからのメンションは通知しない
+		execute(bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{"input": map[string]interface{}{"content": "hey @alice"}})
+		if count := models.UnreadNotificationCount(db, alice.ID); count != 0 {
+			t.Errorf("Expected no notifications from blocked user, got %d", count)
+		}
+	})
+
+	t.Run("ブロックの関係にあるユーザーの投稿とユーザーを表示しない", func(t *testing.T) {
+		if users := usernames(alice); users["bob"] || !users["carol"] {
+			t.Errorf("Expected bob to be hidden from alice, got %v", users)
+		}
+		if users := usernames(bob); users["alice"] {
+			t.Errorf("Expected alice to be hidden from bob, got %v", users)
+		}
+		if users := usernames(carol); !users["alice"] || !users["bob"] {
+			t.Errorf("Expected carol to see both, got %v", users)
+		}
+
+		for _, content := range contents(alice, `query { posts { id content } }`, "posts", nil) {
+			if content == "bob #blocktest" {
+				t.Error("Expected bob's post to be hidden from posts")
+			}
+		}
+
+		tagged := contents(alice, `query { postsByHashtag(tag: $tag) { posts { content } } }`, "postsByHashtag", map[string]interface{}{"tag": "blocktest"})
+		if len(tagged) != 1 || tagged[0] != "alice #blocktest" {
+			t.Errorf("Expected only alice's post in hashtag search, got %v", tagged)
+		}
+
+		// ブロックの関係にあるユーザーの投稿はnull、リプライからも除く
+		var post *struct {
+			Content string `json:"content"`
+		}
+		decode(execute(alice, `query { post(id: $id) { content } }`, map[string]interface{}{"id": fmt.Sprint(bobPost.ID)}), "post", &post)
+		if post != nil {
+			t.Errorf("Expected bob's post to be null for alice, got %+v", post)
+		}
+
+		carolPost := testutil.CreateTestPost(t, db, carol.ID, "carol")
+		db.Create(&models.Post{Content: "reply from bob", AuthorID: bob.ID, ParentID: &carolPost.ID})
+		db.Create(&models.Post{Content: "reply from dave", AuthorID: dave.ID, ParentID: &carolPost.ID})
+		var detail struct {
+			Replies []struct {
+				Content string `json:"content"`
+			} `json:"replies"`
+		}
+		decode(execute(alice, `query { post(id: $id) { content replies { content } } }`, map[string]interface{}{"id": fmt.Sprint(carolPost.ID)}), "post", &detail)
+		if len(detail.Replies) != 1 || detail.Replies[0].Content != "reply from dave" {
+			t.Errorf("Expected only dave's reply, got %+v", detail.Replies)
+		}
+		decode(execute(carol, `query { post(id: $id) { content replies { content } } }`, map[string]interface{}{"id": fmt.Sprint(carolPost.ID)}), "post", &detail)
+		if len(detail.Replies) != 2 {
+			t.Errorf("Expected carol to see both replies, got %+v", detail.Replies)
+		}
+	})
+
+	t.Run("ブロックを解除すると再びフォローできる", func(t *testing.T) {
+		if resp := execute(alice, `mutation { unblockUser(userId: $userId) { id } }`, userVars(bob)); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if resp := execute(bob, `mutation { followUser(userId: $userId) { id } }`, userVars(alice)); resp.Errors != nil {
+			t.Errorf("Expected follow after unblock, got %v", resp.Errors)
+		}
+		if resp := execute(alice, `mutation { unblockUser(userId: $userId) { id } }`, userVars(bob)); resp.Errors == nil {
+			t.Error("Expected error when block does not exist")
+		}
+	})
+
+	t.Run("ミュートはミュートした側のタイムラインのみから隠す", func(t *testing.T) {
+		db.Create(&models.Follow{FollowerID: carol.ID, FolloweeID: dave.ID})
+		db.Create(&models.Follow{FollowerID: carol.ID, FolloweeID: alice.ID})
+		db.Create(&models.Follow{FollowerID: dave.ID, FolloweeID: carol.ID})
+		testutil.CreateTestPost(t, db, dave.ID, "dave muted")
+		execute(dave, `mutation { repost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(bobPost.ID)})
+
+		if resp := execute(carol, `mutation { muteUser(userId: $userId) { id } }`, userVars(dave)); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		timelineQuery := `query { timeline { posts { content } } }`
+		for _, content := range contents(carol, timelineQuery, "timeline", nil) {
+			if content == "dave muted" || content == "bob #blocktest" {
+				t.Errorf("Expected dave's post and repost to be hidden from carol, got %q", content)
+			}
+		}
+
+		// ミュートされた側は操作も表示もできる
+		if resp := execute(dave, `mutation { likePost(postId: $postId) { id } }`, map[string]interface{}{"postId": alicePost.ID}); resp.Errors != nil {
+			t.Errorf("Expected muted user to still like posts, got %v", resp.Errors)
+		}
+		found := false
+		for _, content := range contents(dave, timelineQuery, "timeline", nil) {
+			found = found || content == "carol"
+		}
+		if !found {
+			t.Error("Expected dave to still see carol's posts")
+		}
+		// ミュートはタイムライン以外には影響しない
+		if users := usernames(carol); !users["dave"] {
+			t.Error("Expected muted user to remain in users")
+		}
+
+		if resp := execute(carol, `mutation { unmuteUser(userId: $userId) { id } }`, userVars(dave)); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		found = false
+		for _, content := range contents(carol, timelineQuery, "timeline", nil) {
+			found = found || content == "dave muted"
+		}
+		if !found {
+			t.Error("Expected dave's post to be visible after unmute")
+		}
+	})
+}
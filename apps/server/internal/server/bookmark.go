@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+var errBookmarkCollectionNotFound = errors.New("Collection not found")
+
+// bookmarkView はAPIで返すブックマークです
+type bookmarkView struct {
+	models.Bookmark
+	Post postView `json:"post"`
+}
+
+// bookmarkCollectionView はAPIで返すブックマークのコレクションです
+type bookmarkCollectionView struct {
+	models.BookmarkCollection
+	BookmarkCount int64 `json:"bookmarkCount"`
+}
+
+func (s *Server) handleBookmarkPostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	postID := getUint(variables, "postId")
+	if postID == 0 {
+		return errorResponse("Post ID is required")
+	}
+
+	var post models.Post
+	if err := s.postQuery().First(&post, postID).Error; err != nil {
+		return errorResponse("Post not found")
+	}
+	if err := s.checkPostAccess(user.ID, &post); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var collectionID *uint
+	if id := getUint(variables, "collectionId"); id != 0 {
+		collection, err := s.findBookmarkCollection(user.ID, id)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		collectionID = &collection.ID
+	}
+
+	// 既にブックマーク済みの場合はコレクションの指定があれば移動する
+	bookmark := models.Bookmark{UserID: user.ID, PostID: post.ID}
+	if err := s.DB.Where(bookmark).Attrs(models.Bookmark{CollectionID: collectionID}).FirstOrCreate(&bookmark).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Failed to bookmark post: %v", err))
+	}
+	if collectionID != nil && (bookmark.CollectionID == nil || *bookmark.CollectionID != *collectionID) {
+		if err := s.DB.Model(&bookmark).Update("collection_id", *collectionID).Error; err != nil {
+			return errorResponse(fmt.Sprintf("Failed to bookmark post: %v", err))
+		}
+	}
+
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("bookmarkPost", view)
+}
+
+func (s *Server) handleUnbookmarkPostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	postID := getUint(variables, "postId")
+	if postID == 0 {
+		return errorResponse("Post ID is required")
+	}
+
+	var post models.Post
+	if err := s.postQuery().First(&post, postID).Error; err != nil {
+		return errorResponse("Post not found")
+	}
+
+	result := s.DB.Where("user_id = ? AND post_id = ?", user.ID, post.ID).Delete(&models.Bookmark{})
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Failed to unbookmark post: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errorResponse("Bookmark not found")
+	}
+
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("unbookmarkPost", view)
+}
+
+func (s *Server) handleBookmarksQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	// 削除された投稿と見られなくなった投稿（ブロック・非公開）のブックマークは除く
+	limit := pageSize(variables)
+	query := models.ExcludeHidden(s.DB, "posts.author_id", user.ID).Preload("Collection").
+		Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL").
+		Where("bookmarks.user_id = ?", user.ID).
+		Order("bookmarks.created_at DESC, bookmarks.id DESC").
+		Limit(limit + 1)
+
+	if id := getUint(variables, "collectionId"); id != 0 {
+		collection, err := s.findBookmarkCollection(user.ID, id)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		query = query.Where("bookmarks.collection_id = ?", collection.ID)
+	}
+
+	if cursor := getString(variables, "cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		query = query.Where("bookmarks.created_at < ? OR (bookmarks.created_at = ? AND bookmarks.id < ?)", createdAt, createdAt, id)
+	}
+
+	var bookmarks []models.Bookmark
+	if err := query.Find(&bookmarks).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	hasNextPage := len(bookmarks) > limit
+	if hasNextPage {
+		bookmarks = bookmarks[:limit]
+	}
+
+	views, err := s.buildBookmarkViews(ctx, bookmarks)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	var nextCursor *string
+	if hasNextPage {
+		last := bookmarks[len(bookmarks)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	return dataResponse("bookmarks", map[string]interface{}{
+		"bookmarks":   views,
+		"hasNextPage": hasNextPage,
+		"cursor":      nextCursor,
+	})
+}
+
+// buildBookmarkViews はブックマークの順に投稿を読み込みます
+func (s *Server) buildBookmarkViews(ctx context.Context, bookmarks []models.Bookmark) ([]bookmarkView, error) {
+	ids := make([]uint, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		ids = append(ids, bookmark.PostID)
+	}
+
+	var posts []models.Post
+	if err := s.postQuery().Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	postViews, err := s.buildPostViews(ctx, posts)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]postView, len(postViews))
+	for _, view := range postViews {
+		byID[view.ID] = view
+	}
+
+	views := make([]bookmarkView, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		post, ok := byID[bookmark.PostID]
+		if !ok {
+			continue
+		}
+		views = append(views, bookmarkView{Bookmark: bookmark, Post: post})
+	}
+	return views, nil
+}
+
+func (s *Server) handleBookmarkCollectionsQuery(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var collections []models.BookmarkCollection
+	if err := s.DB.Where("user_id = ?", user.ID).Order("name").Find(&collections).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	// 削除された投稿のブックマークは数えない
+	var rows []struct {
+		CollectionID uint
+		Count        int64
+	}
+	err = s.DB.Model(&models.Bookmark{}).
+		Select("bookmarks.collection_id, COUNT(*) AS count").
+		Joins("JOIN posts ON posts.id = bookmarks.post_id AND posts.deleted_at IS NULL").
+		Where("bookmarks.user_id = ? AND bookmarks.collection_id IS NOT NULL", user.ID).
+		Group("bookmarks.collection_id").
+		Scan(&rows).Error
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CollectionID] = row.Count
+	}
+
+	views := make([]bookmarkCollectionView, 0, len(collections))
+	for _, collection := range collections {
+		views = append(views, bookmarkCollectionView{BookmarkCollection: collection, BookmarkCount: counts[collection.ID]})
+	}
+	return dataResponse("bookmarkCollections", views)
+}
+
+func (s *Server) handleCreateBookmarkCollectionMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	collection := models.BookmarkCollection{UserID: user.ID, Name: strings.TrimSpace(getString(variables, "name"))}
+	var count int64
+	s.DB.Model(&models.BookmarkCollection{}).Where("user_id = ? AND name = ?", user.ID, collection.Name).Count(&count)
+	if count > 0 {
+		return errorResponse("Collection already exists")
+	}
+
+	if err := s.DB.Create(&collection).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Failed to create collection: %v", err))
+	}
+	return dataResponse("createBookmarkCollection", bookmarkCollectionView{BookmarkCollection: collection})
+}
+
+func (s *Server) handleDeleteBookmarkCollectionMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	collection, err := s.findBookmarkCollection(user.ID, getUint(variables, "id"))
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	// コレクション内のブックマークは削除せず、コレクションなしに戻す
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Bookmark{}).Where("collection_id = ?", collection.ID).Update("collection_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(collection).Error
+	})
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to delete collection: %v", err))
+	}
+	return dataResponse("deleteBookmarkCollection", true)
+}
+
+// findBookmarkCollection はユーザー本人のコレクションを返します（他人のコレクションは存在しないものとして扱う）
+func (s *Server) findBookmarkCollection(userID, id uint) (*models.BookmarkCollection, error) {
+	var collection models.BookmarkCollection
+	if err := s.DB.Where("id = ? AND user_id = ?", id, userID).First(&collection).Error; err != nil {
+		return nil, errBookmarkCollectionNotFound
+	}
+	return &collection, nil
+}
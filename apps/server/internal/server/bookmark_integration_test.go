@@ -0,0 +1,211 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+type bookmarkResponse struct {
+	ID   json.Number `json:"id"`
+	Post struct {
+		ID           json.Number `json:"id"`
+		Content      string      `json:"content"`
+		IsBookmarked bool        `json:"isBookmarked"`
+	} `json:"post"`
+	Collection *struct {
+		Name string `json:"name"`
+	} `json:"collection"`
+}
+
+func TestBookmarkIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	decode := func(resp GraphQLResponse, field string, v interface{}) {
+		t.Helper()
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})[field])
+		json.Unmarshal(data, v)
+	}
+	bookmark := func(user *models.User, variables map[string]interface{}) GraphQLResponse {
+		return execute(user, `mutation { bookmarkPost(postId: $postId, collectionId: $collectionId) { id isBookmarked } }`, variables)
+	}
+	bookmarks := func(user *models.User, variables map[string]interface{}) ([]bookmarkResponse, bool, *string) {
+		t.Helper()
+		var result struct {
+			Bookmarks   []bookmarkResponse `json:"bookmarks"`
+			HasNextPage bool               `json:"hasNextPage"`
+			Cursor      *string            `json:"cursor"`
+		}
+		resp := execute(user, `query { bookmarks(collectionId: $collectionId, limit: $limit, cursor: $cursor) { bookmarks { id post { id content isBookmarked } collection { name } } hasNextPage cursor } }`, variables)
+		decode(resp, "bookmarks", &result)
+		return result.Bookmarks, result.HasNextPage, result.Cursor
+	}
+
+	first := testutil.CreateTestPost(t, db, bob.ID, "first")
+	second := testutil.CreateTestPost(t, db, bob.ID, "second")
+	third := testutil.CreateTestPost(t, db, bob.ID, "third")
+
+	var collectionID string
+
+	t.Run("ブックマークとisBookmarked", func(t *testing.T) {
+		var post struct {
+			IsBookmarked bool `json:"isBookmarked"`
+		}
+		decode(bookmark(alice, map[string]interface{}{"postId": fmt.Sprint(first.ID)}), "bookmarkPost", &post)
+		if !post.IsBookmarked {
+			t.Error("Expected post to be bookmarked")
+		}
+
+		// 重複したブックマークはエラーにしない
+		if resp := bookmark(alice, map[string]interface{}{"postId": fmt.Sprint(first.ID)}); resp.Errors != nil {
+			t.Errorf("Expected bookmark to be idempotent, got %v", resp.Errors)
+		}
+
+		// 他のユーザーにはブックマークが見えない
+		var posts []struct {
+			ID           json.Number `json:"id"`
+			IsBookmarked bool        `json:"isBookmarked"`
+		}
+		decode(execute(bob, `query { posts { id isBookmarked } }`, nil), "posts", &posts)
+		for _, p := range posts {
+			if p.IsBookmarked {
+				t.Errorf("Expected post %s not to be bookmarked for bob", p.ID)
+			}
+		}
+
+		if resp := bookmark(alice, map[string]interface{}{"postId": "99999"}); resp.Errors == nil {
+			t.Error("Expected error for missing post")
+		}
+	})
+
+	t.Run("コレクション", func(t *testing.T) {
+		var collection struct {
+			ID   json.Number `json:"id"`
+			Name string      `json:"name"`
+		}
+		resp := execute(alice, `mutation { createBookmarkCollection(name: $name) { id name } }`, map[string]interface{}{"name": " Go "})
+		decode(resp, "createBookmarkCollection", &collection)
+		if collection.Name != "Go" {
+			t.Errorf("Expected trimmed name, got %q", collection.Name)
+		}
+		collectionID = collection.ID.String()
+
+		resp = execute(alice, `mutation { createBookmarkCollection(name: $name) { id } }`, map[string]interface{}{"name": "Go"})
+		if resp.Errors == nil {
+			t.Error("Expected error for duplicate collection name")
+		}
+
+		// ブックマーク済みの投稿はコレクションに移動する
+		bookmark(alice, map[string]interface{}{"postId": fmt.Sprint(first.ID), "collectionId": collectionID})
+		bookmark(alice, map[string]interface{}{"postId": fmt.Sprint(second.ID), "collectionId": collectionID})
+		bookmark(alice, map[string]interface{}{"postId": fmt.Sprint(third.ID)})
+
+		items, _, _ := bookmarks(alice, map[string]interface{}{"collectionId": collectionID})
+		if len(items) != 2 || items[0].Post.Content != "second" || items[1].Post.Content != "first" {
+			t.Fatalf("Expected second and first in collection, got %+v", items)
+		}
+		if items[0].Collection == nil || items[0].Collection.Name != "Go" || !items[0].Post.IsBookmarked {
+			t.Errorf("Expected bookmark in collection Go, got %+v", items[0])
+		}
+
+		// 他のユーザーのコレクションは使えない
+		if resp := bookmark(bob, map[string]interface{}{"postId": fmt.Sprint(first.ID), "collectionId": collectionID}); resp.Errors == nil {
+			t.Error("Expected error for another user's collection")
+		}
+		resp = execute(bob, `query { bookmarks(collectionId: $collectionId) { bookmarks { id } } }`, map[string]interface{}{"collectionId": collectionID})
+		if resp.Errors == nil {
+			t.Error("Expected error when listing another user's collection")
+		}
+
+		var collections []struct {
+			Name          string `json:"name"`
+			BookmarkCount int    `json:"bookmarkCount"`
+		}
+		decode(execute(alice, `query { bookmarkCollections { id name bookmarkCount } }`, nil), "bookmarkCollections", &collections)
+		if len(collections) != 1 || collections[0].BookmarkCount != 2 {
+			t.Errorf("Expected 1 collection with 2 bookmarks, got %+v", collections)
+		}
+	})
+
+	t.Run("削除された投稿は一覧から除く", func(t *testing.T) {
+		db.Delete(&models.Post{}, second.ID)
+
+		items, _, _ := bookmarks(alice, nil)
+		if len(items) != 2 || items[0].Post.Content != "third" || items[1].Post.Content != "first" {
+			t.Errorf("Expected third and first, got %+v", items)
+		}
+	})
+
+	t.Run("ページング", func(t *testing.T) {
+		items, hasNext, cursor := bookmarks(alice, map[string]interface{}{"limit": 1})
+		if len(items) != 1 || !hasNext || cursor == nil {
+			t.Fatalf("Expected first page with next cursor, got %+v %v", items, hasNext)
+		}
+		next, hasNext, _ := bookmarks(alice, map[string]interface{}{"limit": 1, "cursor": *cursor})
+		if len(next) != 1 || hasNext || next[0].ID == items[0].ID {
+			t.Errorf("Expected a different last page, got %+v %v", next, hasNext)
+		}
+	})
+
+	t.Run("ブックマークの取り消しとコレクションの削除", func(t *testing.T) {
+		var post struct {
+			IsBookmarked bool `json:"isBookmarked"`
+		}
+		resp := execute(alice, `mutation { unbookmarkPost(postId: $postId) { id isBookmarked } }`, map[string]interface{}{"postId": fmt.Sprint(third.ID)})
+		decode(resp, "unbookmarkPost", &post)
+		if post.IsBookmarked {
+			t.Error("Expected post not to be bookmarked")
+		}
+
+		resp = execute(alice, `mutation { unbookmarkPost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(third.ID)})
+		if resp.Errors == nil {
+			t.Error("Expected error when bookmark does not exist")
+		}
+
+		// コレクションを削除してもブックマークは残る
+		var deleted bool
+		decode(execute(alice, `mutation { deleteBookmarkCollection(id: $id) }`, map[string]interface{}{"id": collectionID}), "deleteBookmarkCollection", &deleted)
+		if !deleted {
+			t.Error("Expected collection to be deleted")
+		}
+		items, _, _ := bookmarks(alice, nil)
+		if len(items) != 1 || items[0].Post.Content != "first" || items[0].Collection != nil {
+			t.Errorf("Expected first without collection, got %+v", items)
+		}
+	})
+
+	t.Run("未認証のブックマークはエラー", func(t *testing.T) {
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { bookmarks { bookmarks { id } } }`})
+		if resp.Errors == nil {
+			t.Error("Expected error for unauthenticated bookmarks")
+		}
+		resp = executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query:     `mutation { bookmarkPost(postId: $postId) { id } }`,
+			Variables: map[string]interface{}{"postId": fmt.Sprint(first.ID)},
+		})
+		if resp.Errors == nil {
+			t.Error("Expected error for unauthenticated bookmarkPost")
+		}
+	})
+}
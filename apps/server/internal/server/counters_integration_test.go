@@ -0,0 +1,99 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestCountersIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	post := testutil.CreateTestPost(t, db, alice.ID, "count me")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		resp := executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		return resp.Data.(map[string]interface{})
+	}
+	decode := func(data map[string]interface{}, field string, v interface{}) {
+		raw, _ := json.Marshal(data[field])
+		json.Unmarshal(raw, v)
+	}
+	userVars := map[string]interface{}{"userId": fmt.Sprint(alice.ID)}
+	postVars := map[string]interface{}{"id": fmt.Sprint(post.ID)}
+
+	type userCounts struct {
+		FollowerCount  int `json:"followerCount"`
+		FollowingCount int `json:"followingCount"`
+	}
+	type postCounts struct {
+		LikeCount   int `json:"likeCount"`
+		ReplyCount  int `json:"replyCount"`
+		RepostCount int `json:"repostCount"`
+	}
+	readPost := func() postCounts {
+		t.Helper()
+		var counts postCounts
+		decode(execute(bob, `query { post(id: $id) { likeCount replyCount repostCount } }`, postVars), "post", &counts)
+		return counts
+	}
+
+	t.Run("フォロー・フォロー解除でフォロワー数を更新する", func(t *testing.T) {
+		var followed userCounts
+		decode(execute(bob, `mutation { followUser(userId: $userId) { followerCount } }`, userVars), "followUser", &followed)
+		if followed.FollowerCount != 1 {
+			t.Errorf("Expected 1 follower after follow, got %d", followed.FollowerCount)
+		}
+
+		var me userCounts
+		decode(execute(bob, `query { me { followerCount followingCount } }`, nil), "me", &me)
+		if me.FollowingCount != 1 {
+			t.Errorf("Expected bob to follow 1 user, got %d", me.FollowingCount)
+		}
+
+		var unfollowed userCounts
+		decode(execute(bob, `mutation { unfollowUser(userId: $userId) { followerCount } }`, userVars), "unfollowUser", &unfollowed)
+		if unfollowed.FollowerCount != 0 {
+			t.Errorf("Expected no followers after unfollow, got %d", unfollowed.FollowerCount)
+		}
+	})
+
+	t.Run("いいね・リプライ・リポストで投稿の件数を更新する", func(t *testing.T) {
+		execute(bob, `mutation { likePost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(post.ID)})
+		execute(bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+			"input": map[string]interface{}{"content": "a reply", "parentId": fmt.Sprint(post.ID)},
+		})
+		var reposted postCounts
+		decode(execute(bob, `mutation { repost(postId: $postId) { repostCount } }`, map[string]interface{}{"postId": fmt.Sprint(post.ID)}), "repost", &reposted)
+		if reposted.RepostCount != 1 {
+			t.Errorf("Expected repost to return the updated count, got %d", reposted.RepostCount)
+		}
+
+		if counts := readPost(); counts != (postCounts{LikeCount: 1, ReplyCount: 1, RepostCount: 1}) {
+			t.Errorf("Expected 1 like, reply and repost, got %+v", counts)
+		}
+
+		execute(bob, `mutation { unlikePost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(post.ID)})
+		execute(bob, `mutation { unrepost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(post.ID)})
+		if counts := readPost(); counts != (postCounts{ReplyCount: 1}) {
+			t.Errorf("Expected only the reply to remain, got %+v", counts)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ページングのデフォルト件数と上限
+const (
+	defaultPageSize = 20
+	maxPageSize     = 50
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor は並び順の基準時刻とIDからカーソルを作成します（クライアントには不透明な文字列）
+func encodeCursor(t time.Time, id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", t.UnixNano(), id)))
+}
+
+// decodeCursor はカーソルから基準時刻とIDを取り出します
+func decodeCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+
+	var nanos int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &id); err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+	return time.Unix(0, nanos), id, nil
+}
+
+// pageSize はlimit引数をデフォルト値と上限に収めます
+func pageSize(variables map[string]interface{}) int {
+	limit := int(getUint(variables, "limit"))
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	return min(limit, maxPageSize)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/dataexport"
+	"sns-server/internal/models"
+	"sns-server/internal/storage"
+)
+
+// dataExportDownloadPath はデータエクスポートのダウンロードURLのパスの接頭辞です
+const dataExportDownloadPath = "/exports/"
+
+// dataExportView はAPIで返すデータエクスポートです
+// ダウンロードURLは作成済みで保持期間内の場合のみ、問い合わせのたびに署名して返します
+type dataExportView struct {
+	models.DataExport
+	DownloadURL *string `json:"downloadUrl"`
+}
+
+func (s *Server) handleRequestDataExportMutation(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	// 作成待ち・作成中のエクスポートがあれば新しく作らずにそれを返す
+	export, err := models.ActiveDataExport(s.DB, user.ID)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	if export == nil {
+		export = &models.DataExport{UserID: user.ID}
+		if err := s.DB.Create(export).Error; err != nil {
+			return errorResponse(fmt.Sprintf("Failed to request data export: %v", err))
+		}
+
+		// 作成はバックグラウンドジョブで行う（登録できなければ作成待ちのまま残さない）
+		if _, err := s.Jobs.Enqueue(dataexport.JobType, dataexport.JobPayload{ExportID: export.ID}); err != nil {
+			s.DB.Delete(export)
+			return errorResponse(fmt.Sprintf("Failed to request data export: %v", err))
+		}
+	}
+
+	return dataResponse("requestDataExport", s.buildDataExportView(*export))
+}
+
+func (s *Server) handleDataExportsQuery(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var exports []models.DataExport
+	if err := s.DB.Where("user_id = ?", user.ID).Order("id DESC").Find(&exports).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	views := make([]dataExportView, 0, len(exports))
+	for _, export := range exports {
+		views = append(views, s.buildDataExportView(export))
+	}
+	return dataResponse("dataExports", views)
+}
+
+func (s *Server) buildDataExportView(export models.DataExport) dataExportView {
+	view := dataExportView{DataExport: export}
+	now := time.Now()
+	if export.IsDownloadable(now) {
+		// URLの有効期限はファイルの保持期間を超えない
+		expiresAt := now.Add(s.Config.DataExportURLTTL)
+		if expiresAt.After(*export.ExpiresAt) {
+			expiresAt = *export.ExpiresAt
+		}
+		url := s.Config.PublicBaseURL + auth.SignURL(s.Config.JWTSecret, dataExportDownloadPath+strconv.FormatUint(uint64(export.ID), 10), expiresAt)
+		view.DownloadURL = &url
+	}
+	return view
+}
+
+// HandleDataExportDownload は署名付きURLでデータエクスポートのZIPを返します
+// ブラウザから直接ダウンロードできるよう、Authorizationヘッダーではなく署名で認可します
+func (s *Server) HandleDataExportDownload(w http.ResponseWriter, r *http.Request) {
+	if err := auth.VerifySignedURL(s.Config.JWTSecret, r.URL.Path, r.URL.Query()); err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, auth.ErrTokenExpired) {
+			status = http.StatusGone
+		}
+		http.Error(w, "Invalid or expired download link", status)
+		return
+	}
+
+	id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, dataExportDownloadPath), 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var export models.DataExport
+	if err := s.DB.First(&export, id).Error; err != nil || !export.IsDownloadable(time.Now()) {
+		http.NotFound(w, r)
+		return
+	}
+	// 退会済み・利用停止中のユーザーのエクスポートは返さない
+	if user, ok := s.sessionUser(export.UserID); !ok || user.IsSuspended() {
+		http.NotFound(w, r)
+		return
+	}
+
+	file, err := s.Storage.Open(export.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to open data export %d: %v", export.ID, err)
+		http.Error(w, "Failed to read data export", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="sns-data-export-%d.zip"`, export.ID))
+	w.Header().Set("Content-Length", strconv.FormatInt(export.SizeBytes, 10))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := io.Copy(w, file); err != nil {
+		log.Printf("Failed to send data export %d: %v", export.ID, err)
+	}
+}
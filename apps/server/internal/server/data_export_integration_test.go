@@ -0,0 +1,168 @@
+package server_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/dataexport"
+	"sns-server/internal/jobs"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/storage"
+	"sns-server/internal/testutil"
+)
+
+func TestDataExportIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	store := storage.NewMemoryStorage()
+	queue := jobs.NewQueue(jobs.NewMemoryStore(), jobs.PolicyFromConfig(cfg))
+	srv := &server.Server{DB: db, Config: cfg, Storage: store, Jobs: queue}
+	exporter := dataexport.NewExporter(db, store, cfg.DataExportRetention)
+	queue.Register(dataexport.JobType, exporter.HandleJob)
+
+	owner := testutil.CreateTestUser(t, db, "exporter", "exporter@example.com", "Exporter")
+	other := testutil.CreateTestUser(t, db, "bystander", "bystander@example.com", "Bystander")
+	testutil.CreateTestPost(t, db, owner.ID, "My first post")
+
+	type exportResult struct {
+		ID          string  `json:"id"`
+		Status      string  `json:"status"`
+		SizeBytes   int64   `json:"sizeBytes"`
+		DownloadURL *string `json:"downloadUrl"`
+	}
+	execute := func(user *models.User, query string) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query}, token)
+	}
+	decode := func(resp GraphQLResponse, key string, v interface{}) {
+		t.Helper()
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})[key])
+		json.Unmarshal(data, v)
+	}
+	download := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		srv.HandleDataExportDownload(recorder, httptest.NewRequest("GET", path, nil))
+		return recorder
+	}
+
+	requestExport := `mutation { requestDataExport { id status downloadUrl } }`
+	var requested exportResult
+
+	t.Run("エクスポートを要求すると作成待ちになる", func(t *testing.T) {
+		decode(execute(owner, requestExport), "requestDataExport", &requested)
+		if requested.Status != "PENDING" || requested.DownloadURL != nil {
+			t.Errorf("Expected pending export without URL, got %+v", requested)
+		}
+
+		// 作成待ちの間は同じエクスポートを返す
+		var again exportResult
+		decode(execute(owner, requestExport), "requestDataExport", &again)
+		if again.ID != requested.ID {
+			t.Errorf("Expected the same export %s, got %s", requested.ID, again.ID)
+		}
+	})
+
+	t.Run("未認証の場合はエラー", func(t *testing.T) {
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: requestExport})
+		if len(resp.Errors) == 0 {
+			t.Error("Expected authentication error")
+		}
+	})
+
+	if ran, err := queue.RunPending(context.Background()); err != nil || ran != 1 {
+		t.Fatalf("Expected one export job to run, got %d: %v", ran, err)
+	}
+
+	var downloadPath string
+	t.Run("作成後は署名付きURLでダウンロードできる", func(t *testing.T) {
+		var exports []exportResult
+		decode(execute(owner, `query { dataExports { id status sizeBytes downloadUrl } }`), "dataExports", &exports)
+		if len(exports) != 1 || exports[0].Status != "COMPLETED" || exports[0].DownloadURL == nil {
+			t.Fatalf("Expected completed export with URL, got %+v", exports)
+		}
+
+		parsed, err := url.Parse(*exports[0].DownloadURL)
+		if err != nil {
+			t.Fatalf("Invalid download URL: %v", err)
+		}
+		downloadPath = parsed.RequestURI()
+
+		rec := download(downloadPath)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec.Header().Get("Content-Type") != "application/zip" {
+			t.Errorf("Expected application/zip, got %s", rec.Header().Get("Content-Type"))
+		}
+
+		body := rec.Body.Bytes()
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("Expected a ZIP archive: %v", err)
+		}
+		if len(archive.File) != 5 || int64(len(body)) != exports[0].SizeBytes {
+			t.Errorf("Expected 5 files of %d bytes, got %d files of %d bytes", exports[0].SizeBytes, len(archive.File), len(body))
+		}
+	})
+
+	t.Run("他のユーザーには表示しない", func(t *testing.T) {
+		var exports []exportResult
+		decode(execute(other, `query { dataExports { id } }`), "dataExports", &exports)
+		if len(exports) != 0 {
+			t.Errorf("Expected no exports for other user, got %+v", exports)
+		}
+	})
+
+	t.Run("無効なダウンロードURL", func(t *testing.T) {
+		parsed, _ := url.Parse(downloadPath)
+		expired := auth.SignURL(cfg.JWTSecret, parsed.Path, time.Now().Add(-time.Minute))
+
+		tests := []struct {
+			name string
+			path string
+			code int
+		}{
+			{name: "署名なし", path: parsed.Path, code: http.StatusForbidden},
+			{name: "別のエクスポートのID", path: parsed.Path + "0?" + parsed.RawQuery, code: http.StatusForbidden},
+			{name: "期限切れ", path: expired, code: http.StatusGone},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if rec := download(tt.path); rec.Code != tt.code {
+					t.Errorf("Expected %d, got %d", tt.code, rec.Code)
+				}
+			})
+		}
+	})
+
+	t.Run("保持期間を過ぎると削除される", func(t *testing.T) {
+		db.Model(&models.DataExport{}).Where("user_id = ?", owner.ID).Update("expires_at", time.Now().Add(-time.Minute))
+		if _, err := exporter.CleanupExpired(); err != nil {
+			t.Fatalf("Failed to clean up exports: %v", err)
+		}
+
+		var exports []exportResult
+		decode(execute(owner, `query { dataExports { id status downloadUrl } }`), "dataExports", &exports)
+		if len(exports) != 1 || exports[0].Status != "EXPIRED" || exports[0].DownloadURL != nil {
+			t.Errorf("Expected expired export without URL, got %+v", exports)
+		}
+		if rec := download(downloadPath); rec.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 after expiry, got %d", rec.Code)
+		}
+	})
+}
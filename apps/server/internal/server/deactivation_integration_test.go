@@ -0,0 +1,159 @@
+package server_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/lockout"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestAccountDeactivationIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg, LoginGuard: lockout.NewGuard(db, lockout.PolicyFromConfig(cfg))}
+
+	leaver := testutil.CreateTestUser(t, db, "leaver", "leaver@example.com", "Leaver")
+	viewer := testutil.CreateTestUser(t, db, "viewer", "viewer@example.com", "Viewer")
+	hash, _ := auth.HashPassword("password123")
+	db.Model(leaver).Update("password", hash)
+	post := testutil.CreateTestPost(t, db, leaver.ID, "Goodbye for now")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	login := func() GraphQLResponse {
+		t.Helper()
+		return executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query: `mutation { login(input: $input) { token } }`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{"email": "leaver@example.com", "password": "password123"},
+			},
+		})
+	}
+	postVisible := func() bool {
+		t.Helper()
+		resp := execute(viewer, `query { post(id: $id) { id } }`, map[string]interface{}{"id": fmt.Sprint(post.ID)})
+		return resp.Errors == nil && resp.Data.(map[string]interface{})["post"] != nil
+	}
+
+	t.Run("パスワードが違う場合は退会できない", func(t *testing.T) {
+		resp := execute(leaver, `mutation { deactivateAccount(password: $password) }`, map[string]interface{}{"password": "wrong"})
+		if len(resp.Errors) == 0 || resp.Errors[0].Message != "Invalid password" {
+			t.Errorf("Expected invalid password error, got %v", resp.Errors)
+		}
+	})
+
+	t.Run("退会すると未認証になり投稿も表示されない", func(t *testing.T) {
+		resp := execute(leaver, `mutation { deactivateAccount(password: $password) }`, map[string]interface{}{"password": "password123"})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		resp = execute(leaver, `query { me { id } }`, nil)
+		if me := resp.Data.(map[string]interface{})["me"]; me != nil {
+			t.Errorf("Expected deactivated user to be unauthenticated, got %v", me)
+		}
+		if postVisible() {
+			t.Error("Expected post by deactivated user to be hidden")
+		}
+	})
+
+	t.Run("猶予期間中にログインすると退会を取り消す", func(t *testing.T) {
+		if resp := login(); resp.Errors != nil {
+			t.Fatalf("Expected login to reactivate the account, got %v", resp.Errors)
+		}
+		if err := db.First(&models.User{}, leaver.ID).Error; err != nil {
+			t.Errorf("Expected user to be reactivated, got %v", err)
+		}
+		if !postVisible() {
+			t.Error("Expected post to be visible again")
+		}
+	})
+
+	t.Run("猶予期間を過ぎるとログインできない", func(t *testing.T) {
+		db.Delete(&models.User{}, leaver.ID)
+		db.Unscoped().Model(&models.User{}).Where("id = ?", leaver.ID).
+			Update("deleted_at", time.Now().Add(-cfg.AccountDeactivationPeriod-time.Hour))
+
+		resp := login()
+		if len(resp.Errors) == 0 || resp.Errors[0].Message != "Invalid email or password" {
+			t.Errorf("Expected login to fail, got %v", resp.Errors)
+		}
+	})
+}
+
+func TestAccountSuspensionIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg, LoginGuard: lockout.NewGuard(db, lockout.PolicyFromConfig(cfg))}
+
+	moderator := testutil.CreateTestUser(t, db, "suspender", "suspender@example.com", "Suspender")
+	models.SetUserRole(db, moderator, models.RoleModerator)
+	target := testutil.CreateTestUser(t, db, "spammer", "spammer@example.com", "Spammer")
+	hash, _ := auth.HashPassword("password123")
+	db.Model(target).Update("password", hash)
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	suspend := func(until string) GraphQLResponse {
+		t.Helper()
+		variables := map[string]interface{}{"userId": fmt.Sprint(target.ID), "reason": "spam links"}
+		if until != "" {
+			variables["until"] = until
+		}
+		return execute(moderator, `mutation { suspendUser(userId: $userId, reason: $reason, until: $until) { id action } }`, variables)
+	}
+	expectSuspended := func(resp GraphQLResponse) {
+		t.Helper()
+		if len(resp.Errors) == 0 {
+			t.Fatalf("Expected ACCOUNT_SUSPENDED, got data %v", resp.Data)
+		}
+		ext := resp.Errors[0].Extensions
+		if ext["code"] != "ACCOUNT_SUSPENDED" || ext["reason"] != "spam links" || ext["suspendedUntil"] == nil {
+			t.Errorf("Expected ACCOUNT_SUSPENDED with reason and expiry, got %v", resp.Errors[0])
+		}
+	}
+
+	t.Run("過去の期限では利用停止できない", func(t *testing.T) {
+		resp := suspend(time.Now().Add(-time.Hour).Format(time.RFC3339))
+		if len(resp.Errors) == 0 {
+			t.Error("Expected error for past expiry")
+		}
+	})
+
+	t.Run("期限付きの利用停止中はリクエストとログインを拒否する", func(t *testing.T) {
+		if resp := suspend(time.Now().Add(time.Hour).Format(time.RFC3339)); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		expectSuspended(execute(target, `query { me { id } }`, nil))
+		expectSuspended(executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query: `mutation { login(input: $input) { token } }`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{"email": "spammer@example.com", "password": "password123"},
+			},
+		}))
+	})
+
+	t.Run("期限を過ぎると利用できる", func(t *testing.T) {
+		db.Model(&models.User{}).Where("id = ?", target.ID).Update("suspended_until", time.Now().Add(-time.Minute))
+
+		resp := execute(target, `query { me { id } }`, nil)
+		if resp.Errors != nil || resp.Data.(map[string]interface{})["me"] == nil {
+			t.Errorf("Expected suspension to have expired, got %v", resp.Errors)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/jobs"
+	"sns-server/internal/models"
+)
+
+// PublishDraftJobType は予約した下書きを公開するジョブの種類です
+const PublishDraftJobType = "drafts.publish"
+
+// PublishDraftPayload は予約した下書きを公開するジョブのペイロードです
+type PublishDraftPayload struct {
+	DraftID uint `json:"draftId"`
+}
+
+var (
+	errDraftNotFound  = errors.New("Draft not found")
+	errAuthorInactive = errors.New("Account is suspended or deactivated")
+)
+
+func (s *Server) handleDraftsQuery(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var drafts []models.Draft
+	if err := s.DB.Where("author_id = ?", user.ID).Order("updated_at DESC, id DESC").Find(&drafts).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("drafts", drafts)
+}
+
+func (s *Server) handleSaveDraftMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	input, ok := variables["input"].(map[string]interface{})
+	if !ok {
+		return errorResponse("Invalid input format - variables required")
+	}
+
+	content := getString(input, "content")
+	if content == "" {
+		return errorResponse("Content is required")
+	}
+
+	scheduledAt, err := getTime(input, "scheduledAt")
+	if err != nil {
+		return errorResponse("Invalid scheduledAt: must be an RFC 3339 timestamp")
+	}
+	if scheduledAt != nil {
+		if !scheduledAt.After(time.Now()) {
+			return errorResponse("Scheduled time must be in the future")
+		}
+		if s.Jobs == nil {
+			return errorResponse("Scheduled posts are not available")
+		}
+	}
+
+	// リプライ先・引用元は保存時にもチェックする（公開時にも改めてチェックする）
+	post, _, err := s.preparePost(user.ID, content, getUint(input, "parentId"), getUint(input, "quotedPostId"))
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	draft := models.Draft{AuthorID: user.ID}
+	if id := getUint(variables, "id"); id != 0 {
+		if err := s.DB.Where("id = ? AND author_id = ?", id, user.ID).First(&draft).Error; err != nil {
+			return errorResponse(errDraftNotFound.Error())
+		}
+	}
+	draft.Content = post.Content
+	draft.ParentID = post.ParentID
+	draft.QuotedPostID = post.QuotedPostID
+	draft.ScheduledAt = scheduledAt
+	draft.PublishError = ""
+
+	if err := s.DB.Save(&draft).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Failed to save draft: %v", err))
+	}
+
+	// 予約を変更する前のジョブは、実行時に下書きの公開日時を確認して何もしない
+	if scheduledAt != nil {
+		if _, err := s.Jobs.Enqueue(PublishDraftJobType, PublishDraftPayload{DraftID: draft.ID}, jobs.At(*scheduledAt)); err != nil {
+			s.DB.Model(&draft).Update("scheduled_at", nil)
+			return errorResponse(fmt.Sprintf("Failed to schedule draft: %v", err))
+		}
+	}
+
+	return dataResponse("saveDraft", draft)
+}
+
+func (s *Server) handlePublishDraftMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	draftID := getUint(variables, "id")
+	if draftID == 0 {
+		return errorResponse("Draft ID is required")
+	}
+
+	var draft models.Draft
+	if err := s.DB.Where("id = ? AND author_id = ?", draftID, user.ID).First(&draft).Error; err != nil {
+		return errorResponse(errDraftNotFound.Error())
+	}
+
+	post, err := s.publishDraft(ctx, &draft)
+	if err != nil {
+		var rejected *draftRejectedError
+		if errors.As(err, &rejected) || errors.Is(err, errDraftNotFound) {
+			return errorResponse(err.Error())
+		}
+		return errorResponse(fmt.Sprintf("Failed to publish draft: %v", err))
+	}
+
+	view, err := s.buildPostView(ctx, *post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("publishDraft", view)
+}
+
+func (s *Server) handleDeleteDraftMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	draftID := getUint(variables, "id")
+	if draftID == 0 {
+		return errorResponse("Draft ID is required")
+	}
+
+	result := s.DB.Where("id = ? AND author_id = ?", draftID, user.ID).Delete(&models.Draft{})
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Failed to delete draft: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errorResponse(errDraftNotFound.Error())
+	}
+	return dataResponse("deleteDraft", true)
+}
+
+// draftRejectedError は下書きの内容が公開できない状態になった場合のエラーです（リプライ先が削除されたなど）
+type draftRejectedError struct {
+	err error
+}
+
+func (e *draftRejectedError) Error() string { return e.err.Error() }
+func (e *draftRejectedError) Unwrap() error { return e.err }
+
+// publishDraft は下書きを投稿として公開し、下書きを削除します
+// 同時に公開された場合に二重に投稿しないよう、下書きの削除と投稿の作成は同じトランザクションで行う
+func (s *Server) publishDraft(ctx context.Context, draft *models.Draft) (*models.Post, error) {
+	post, parent, err := s.preparePost(draft.AuthorID, draft.Content, uintValue(draft.ParentID), uintValue(draft.QuotedPostID))
+	if err != nil {
+		return nil, &draftRejectedError{err: err}
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Draft{}, draft.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errDraftNotFound
+		}
+		return tx.Create(&post).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.postCreated(ctx, &post, parent)
+	return &post, nil
+}
+
+// HandlePublishDraftJob は予約した時刻を過ぎた下書きを公開します
+// 公開済み・削除済み・予約を取り消した下書きは何もせず、公開できない下書きは理由を記録して予約を取り消す
+func (s *Server) HandlePublishDraftJob(ctx context.Context, job *models.Job) error {
+	var payload PublishDraftPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	var draft models.Draft
+	if err := s.DB.First(&draft, payload.DraftID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// 予約を遅らせた場合は変更後の時刻のジョブが公開する
+	if !draft.IsDue(time.Now()) {
+		return nil
+	}
+
+	// 退会したユーザーも取得して予約を取り消す
+	var author models.User
+	if err := s.DB.Unscoped().First(&author, draft.AuthorID).Error; err != nil {
+		return err
+	}
+	if author.IsSuspended() || author.IsDeactivated() {
+		return s.cancelScheduledDraft(&draft, errAuthorInactive)
+	}
+
+	_, err := s.publishDraft(ctx, &draft)
+	var rejected *draftRejectedError
+	switch {
+	case errors.As(err, &rejected):
+		return s.cancelScheduledDraft(&draft, rejected.err)
+	case errors.Is(err, errDraftNotFound):
+		return nil
+	}
+	return err
+}
+
+// cancelScheduledDraft は公開できなかった下書きの予約を取り消し、理由を記録します
+func (s *Server) cancelScheduledDraft(draft *models.Draft, reason error) error {
+	log.Printf("Failed to publish scheduled draft %d: %v", draft.ID, reason)
+	return s.DB.Model(draft).Updates(map[string]interface{}{
+		"scheduled_at":  nil,
+		"publish_error": reason.Error(),
+	}).Error
+}
+
+// uintValue はポインタの値を返します（nilの場合は0）
+func uintValue(p *uint) uint {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
@@ -0,0 +1,275 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/jobs"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+	"sns-server/internal/timeline"
+)
+
+func TestDraftsIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	queue := jobs.NewQueue(jobs.NewDatabaseStore(db), jobs.PolicyFromConfig(cfg))
+	srv := &server.Server{DB: db, Config: cfg, Jobs: queue}
+	fanout := timeline.NewFanout(db, timeline.PolicyFromConfig(cfg))
+	queue.Register(timeline.FanoutJobType, fanout.HandleFanoutJob)
+	queue.Register(timeline.BackfillJobType, fanout.HandleBackfillJob)
+	queue.Register(server.PublishDraftJobType, srv.HandlePublishDraftJob)
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	db.Create(&models.Follow{FollowerID: bob.ID, FolloweeID: alice.ID})
+	bobPost := testutil.CreateTestPost(t, db, bob.ID, "bob's post")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	saveDraft := func(user *models.User, id uint, input map[string]interface{}) (models.Draft, GraphQLResponse) {
+		t.Helper()
+		variables := map[string]interface{}{"input": input}
+		if id != 0 {
+			variables["id"] = fmt.Sprint(id)
+		}
+		resp := execute(user, `mutation { saveDraft(id: $id, input: $input) { id content scheduledAt } }`, variables)
+		var draft models.Draft
+		if resp.Errors == nil {
+			data, _ := json.Marshal(resp.Data.(map[string]interface{})["saveDraft"])
+			json.Unmarshal(data, &draft)
+		}
+		return draft, resp
+	}
+	drafts := func(user *models.User) []models.Draft {
+		t.Helper()
+		resp := execute(user, `query { drafts { id content scheduledAt publishError } }`, nil)
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		var list []models.Draft
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})["drafts"])
+		json.Unmarshal(data, &list)
+		return list
+	}
+	postsByAlice := func() int64 {
+		var count int64
+		db.Model(&models.Post{}).Where("author_id = ?", alice.ID).Count(&count)
+		return count
+	}
+	runJobs := func() {
+		t.Helper()
+		if _, err := queue.RunPending(context.Background()); err != nil {
+			t.Fatalf("Failed to run jobs: %v", err)
+		}
+	}
+	// 予約した時刻を過ぎたことにする
+	makeDue := func(draftID uint) {
+		past := time.Now().Add(-time.Second)
+		db.Model(&models.Draft{}).Where("id = ?", draftID).UpdateColumn("scheduled_at", past)
+		db.Model(&models.Job{}).Where("type = ?", server.PublishDraftJobType).Update("run_at", past)
+	}
+
+	var draftID uint
+	t.Run("下書きを保存し、本人にのみ見える", func(t *testing.T) {
+		draft, resp := saveDraft(alice, 0, map[string]interface{}{"content": "draft v1"})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		draftID = draft.ID
+
+		if list := drafts(alice); len(list) != 1 || list[0].Content != "draft v1" {
+			t.Errorf("Expected alice's draft, got %+v", list)
+		}
+		if list := drafts(bob); len(list) != 0 {
+			t.Errorf("Expected no drafts for bob, got %+v", list)
+		}
+		if n := postsByAlice(); n != 0 {
+			t.Errorf("Expected draft not to be posted, got %d posts", n)
+		}
+		if resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { drafts { id } }`}); resp.Errors == nil {
+			t.Error("Expected error for unauthenticated request")
+		}
+	})
+
+	t.Run("下書きを上書きする", func(t *testing.T) {
+		_, resp := saveDraft(alice, draftID, map[string]interface{}{"content": "draft v2", "parentId": fmt.Sprint(bobPost.ID)})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if list := drafts(alice); len(list) != 1 || list[0].Content != "draft v2" {
+			t.Errorf("Expected updated draft, got %+v", list)
+		}
+
+		if _, resp := saveDraft(bob, draftID, map[string]interface{}{"content": "hijack"}); resp.Errors == nil {
+			t.Error("Expected error when updating another user's draft")
+		}
+	})
+
+	t.Run("過去の日時には予約できない", func(t *testing.T) {
+		_, resp := saveDraft(alice, 0, map[string]interface{}{
+			"content":     "too late",
+			"scheduledAt": time.Now().Add(-time.Minute).Format(time.RFC3339),
+		})
+		if resp.Errors == nil {
+			t.Error("Expected error for past scheduledAt")
+		}
+	})
+
+	t.Run("下書きを公開すると投稿して通知する", func(t *testing.T) {
+		resp := execute(alice, `mutation { publishDraft(id: $id) { id content parentId } }`, map[string]interface{}{"id": fmt.Sprint(draftID)})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if n := postsByAlice(); n != 1 {
+			t.Errorf("Expected 1 post, got %d", n)
+		}
+		if list := drafts(alice); len(list) != 0 {
+			t.Errorf("Expected draft to be removed, got %+v", list)
+		}
+		var notifications int64
+		db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", bob.ID, models.NotificationTypeReply).Count(&notifications)
+		if notifications != 1 {
+			t.Errorf("Expected reply notification, got %d", notifications)
+		}
+
+		resp = execute(alice, `mutation { publishDraft(id: $id) { id } }`, map[string]interface{}{"id": fmt.Sprint(draftID)})
+		if resp.Errors == nil {
+			t.Error("Expected error when publishing the same draft twice")
+		}
+	})
+
+	t.Run("予約した時刻を過ぎるとジョブが公開してタイムラインに配信する", func(t *testing.T) {
+		draft, resp := saveDraft(alice, 0, map[string]interface{}{
+			"content":     "scheduled hello @bob",
+			"scheduledAt": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		runJobs()
+		if n := postsByAlice(); n != 1 {
+			t.Fatalf("Expected draft not to be published before scheduledAt, got %d posts", n)
+		}
+
+		makeDue(draft.ID)
+		runJobs()
+		if n := postsByAlice(); n != 2 {
+			t.Fatalf("Expected scheduled draft to be published, got %d posts", n)
+		}
+		if list := drafts(alice); len(list) != 0 {
+			t.Errorf("Expected draft to be removed, got %+v", list)
+		}
+
+		var entries int64
+		db.Model(&models.TimelineEntry{}).
+			Where("user_id = ? AND post_id IN (?)", bob.ID, db.Model(&models.Post{}).Select("id").Where("content = ?", "scheduled hello @bob")).
+			Count(&entries)
+		if entries != 1 {
+			t.Errorf("Expected post to be fanned out to followers, got %d entries", entries)
+		}
+		var mentions int64
+		db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", bob.ID, models.NotificationTypeMention).Count(&mentions)
+		if mentions != 1 {
+			t.Errorf("Expected mention notification, got %d", mentions)
+		}
+	})
+
+	t.Run("予約を取り消すとジョブは何もしない", func(t *testing.T) {
+		draft, _ := saveDraft(alice, 0, map[string]interface{}{
+			"content":     "cancelled",
+			"scheduledAt": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+		saveDraft(alice, draft.ID, map[string]interface{}{"content": "cancelled"})
+
+		db.Model(&models.Job{}).Where("type = ?", server.PublishDraftJobType).Update("run_at", time.Now().Add(-time.Second))
+		runJobs()
+		if n := postsByAlice(); n != 2 {
+			t.Errorf("Expected cancelled draft not to be published, got %d posts", n)
+		}
+		execute(alice, `mutation { deleteDraft(id: $id) }`, map[string]interface{}{"id": fmt.Sprint(draft.ID)})
+	})
+
+	t.Run("公開できない下書きは理由を記録して予約を取り消す", func(t *testing.T) {
+		parent := testutil.CreateTestPost(t, db, bob.ID, "to be deleted")
+		draft, resp := saveDraft(alice, 0, map[string]interface{}{
+			"content":     "reply later",
+			"parentId":    fmt.Sprint(parent.ID),
+			"scheduledAt": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		db.Delete(parent)
+
+		makeDue(draft.ID)
+		runJobs()
+		list := drafts(alice)
+		if len(list) != 1 || list[0].ScheduledAt != nil || list[0].PublishError == "" {
+			t.Errorf("Expected schedule to be cancelled with an error, got %+v", list)
+		}
+		if n := postsByAlice(); n != 2 {
+			t.Errorf("Expected no new post, got %d posts", n)
+		}
+	})
+
+	t.Run("下書きを削除する", func(t *testing.T) {
+		list := drafts(alice)
+		if len(list) != 1 {
+			t.Fatalf("Expected 1 draft, got %d", len(list))
+		}
+		variables := map[string]interface{}{"id": fmt.Sprint(list[0].ID)}
+
+		if resp := execute(bob, `mutation { deleteDraft(id: $id) }`, variables); resp.Errors == nil {
+			t.Error("Expected error when deleting another user's draft")
+		}
+		if resp := execute(alice, `mutation { deleteDraft(id: $id) }`, variables); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if list := drafts(alice); len(list) != 0 {
+			t.Errorf("Expected draft to be deleted, got %+v", list)
+		}
+	})
+	t.Run("退会したユーザーの予約した下書きは公開せずに予約を取り消す", func(t *testing.T) {
+		carol := testutil.CreateTestUser(t, db, "carol", "carol@example.com", "Carol")
+		draft, resp := saveDraft(carol, 0, map[string]interface{}{
+			"content":     "after leaving",
+			"scheduledAt": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if err := models.DeactivateUser(db, carol); err != nil {
+			t.Fatalf("Failed to deactivate: %v", err)
+		}
+
+		makeDue(draft.ID)
+		runJobs()
+		var failed int64
+		db.Model(&models.Job{}).Where("type = ? AND last_error <> ''", server.PublishDraftJobType).Count(&failed)
+		if failed != 0 {
+			t.Errorf("Expected job to finish without error, got %d failed jobs", failed)
+		}
+		var saved models.Draft
+		db.First(&saved, draft.ID)
+		if saved.ScheduledAt != nil || saved.PublishError == "" {
+			t.Errorf("Expected schedule to be cancelled with an error, got %+v", saved)
+		}
+		var posts int64
+		db.Model(&models.Post{}).Where("author_id = ?", carol.ID).Count(&posts)
+		if posts != 0 {
+			t.Errorf("Expected no post by deactivated user, got %d", posts)
+		}
+	})
+}
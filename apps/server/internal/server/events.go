@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"sns-server/internal/models"
+)
+
+// サブスクリプション用のイベントのトピック
+// イベントにはIDなど最小限の情報だけを載せ、購読側で必要なデータを読み込む
+const (
+	topicPostCreated   = "post.created"
+	topicPostLikeCount = "post.like_count"
+)
+
+type postCreatedEvent struct {
+	PostID   uint `json:"postId"`
+	AuthorID uint `json:"authorId"`
+}
+
+type postLikeCountEvent struct {
+	PostID    uint  `json:"postId"`
+	LikeCount int64 `json:"likeCount"`
+}
+
+// publish はイベントを配信します（PubSubが未設定の場合は何もしない）
+// 配信の失敗でミューテーション自体は失敗させない
+func (s *Server) publish(ctx context.Context, topic string, event interface{}) {
+	if s.PubSub == nil {
+		return
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", topic, err)
+		return
+	}
+	if err := s.PubSub.Publish(ctx, topic, payload); err != nil {
+		log.Printf("Failed to publish %s event: %v", topic, err)
+	}
+}
+
+func (s *Server) publishPostCreated(ctx context.Context, post *models.Post) {
+	s.publish(ctx, topicPostCreated, postCreatedEvent{PostID: post.ID, AuthorID: post.AuthorID})
+}
+
+func (s *Server) publishLikeCountChanged(ctx context.Context, postID uint) {
+	var post models.Post
+	if err := s.DB.Select("like_count").First(&post, postID).Error; err != nil {
+		log.Printf("Failed to load like count of post %d: %v", postID, err)
+		return
+	}
+	s.publish(ctx, topicPostLikeCount, postLikeCountEvent{PostID: postID, LikeCount: post.LikeCount})
+}
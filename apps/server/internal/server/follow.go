@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+	"sns-server/internal/timeline"
+)
+
+var errPrivateAccount = errors.New("This account is private")
+
+// relationshipView はフォロー操作の対象ユーザーと、操作したユーザーとの関係です
+type relationshipView struct {
+	models.User
+	IsFollowing     bool `json:"isFollowing"`
+	FollowRequested bool `json:"followRequested"` // 非公開アカウントへのフォローリクエストが承認待ちか
+}
+
+// followRequestView はAPIで返すフォローリクエストです
+type followRequestView struct {
+	ID        uint        `json:"id"`
+	Requester models.User `json:"requester"`
+	CreatedAt time.Time   `json:"createdAt"`
+}
+
+func (s *Server) handleFollowUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	followeeID := getUint(variables, "userId")
+	if followeeID == 0 {
+		return errorResponse("User ID is required")
+	}
+
+	var followee models.User
+	if err := s.DB.First(&followee, followeeID).Error; err != nil {
+		return errorResponse("User not found")
+	}
+	if err := s.checkNotBlocked(user.ID, followee.ID); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	// 非公開アカウントへのフォローは承認待ちのリクエストにする
+	if followee.IsPrivate && !models.IsFollowing(s.DB, user.ID, followee.ID) {
+		request := models.FollowRequest{RequesterID: user.ID, TargetID: followee.ID}
+		result := s.DB.Where(request).FirstOrCreate(&request)
+		if result.Error != nil {
+			return errorResponse(fmt.Sprintf("Failed to follow user: %v", result.Error))
+		}
+		if result.RowsAffected > 0 {
+			if err := models.RecordNotification(s.DB, followee.ID, user.ID, models.NotificationTypeFollowRequest, nil); err != nil {
+				log.Printf("Failed to record follow request notification: %v", err)
+			}
+		}
+		return dataResponse("followUser", relationshipView{User: followee, FollowRequested: true})
+	}
+
+	follow := models.Follow{FollowerID: user.ID, FolloweeID: followee.ID}
+	result := s.DB.Where(follow).FirstOrCreate(&follow)
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Failed to follow user: %v", result.Error))
+	}
+
+	// 新しくフォローした場合のみ通知し、最近の投稿をタイムラインに追加する
+	if result.RowsAffected > 0 {
+		if err := models.RecordNotification(s.DB, followee.ID, user.ID, models.NotificationTypeFollow, nil); err != nil {
+			log.Printf("Failed to record follow notification: %v", err)
+		}
+		s.enqueueTimelineJob(timeline.BackfillJobType, timeline.BackfillPayload{UserID: user.ID, FolloweeID: followee.ID})
+	}
+
+	// 更新したフォロワー数を返す
+	s.DB.First(&followee, followee.ID)
+	return dataResponse("followUser", relationshipView{User: followee, IsFollowing: true})
+}
+
+func (s *Server) handleUnfollowUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	followeeID := getUint(variables, "userId")
+	if followeeID == 0 {
+		return errorResponse("User ID is required")
+	}
+
+	var followee models.User
+	if err := s.DB.First(&followee, followeeID).Error; err != nil {
+		return errorResponse("User not found")
+	}
+
+	// 承認待ちのフォローリクエストも取り消す
+	var deleted int64
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		unfollowed, err := models.Unfollow(tx, user.ID, followee.ID)
+		if err != nil {
+			return err
+		}
+		if unfollowed {
+			deleted++
+		}
+
+		result := tx.Where("requester_id = ? AND target_id = ?", user.ID, followee.ID).Delete(&models.FollowRequest{})
+		deleted += result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to unfollow user: %v", err))
+	}
+	if deleted == 0 {
+		return errorResponse("Follow not found")
+	}
+
+	s.DB.First(&followee, followee.ID)
+	return dataResponse("unfollowUser", relationshipView{User: followee})
+}
+
+func (s *Server) handleFollowRequestsQuery(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var requests []models.FollowRequest
+	err = s.DB.Preload("Requester").
+		Where("target_id = ?", user.ID).
+		Order("created_at DESC, id DESC").
+		Find(&requests).Error
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	views := make([]followRequestView, 0, len(requests))
+	for _, request := range requests {
+		views = append(views, followRequestView{ID: request.ID, Requester: request.Requester, CreatedAt: request.CreatedAt})
+	}
+	return dataResponse("followRequests", views)
+}
+
+func (s *Server) handleApproveFollowRequestMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, requester, err := s.relationshipTarget(ctx, variables)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	approved, err := models.ApproveFollowRequests(s.DB, user.ID, requester.ID)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to approve follow request: %v", err))
+	}
+	if approved == 0 {
+		return errorResponse("Follow request not found")
+	}
+	s.enqueueTimelineJob(timeline.BackfillJobType, timeline.BackfillPayload{UserID: requester.ID, FolloweeID: user.ID})
+	return dataResponse("approveFollowRequest", requester)
+}
+
+func (s *Server) handleRejectFollowRequestMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, requester, err := s.relationshipTarget(ctx, variables)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	result := s.DB.Where("requester_id = ? AND target_id = ?", requester.ID, user.ID).Delete(&models.FollowRequest{})
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Failed to reject follow request: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return errorResponse("Follow request not found")
+	}
+	return dataResponse("rejectFollowRequest", requester)
+}
+
+func (s *Server) handleUpdateProfileMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	input, ok := variables["input"].(map[string]interface{})
+	if !ok {
+		return errorResponse("Invalid input format - variables required")
+	}
+
+	// 指定されたフィールドのみ更新する
+	updates := map[string]interface{}{}
+	if name, ok := input["name"].(string); ok {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return errorResponse("Name is required")
+		}
+		updates["name"] = name
+	}
+	if bio, ok := input["bio"].(string); ok {
+		updates["bio"] = bio
+	}
+	if avatar, ok := input["avatar"].(string); ok {
+		updates["avatar"] = avatar
+	}
+	isPrivate, privacyChanged := input["isPrivate"].(bool)
+	if privacyChanged {
+		updates["is_private"] = isPrivate
+	}
+
+	var approvedIDs []uint
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(user).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		// 公開アカウントに戻した場合は承認待ちのリクエストを全て承認する
+		if privacyChanged && !isPrivate {
+			if err := tx.Model(&models.FollowRequest{}).Where("target_id = ?", user.ID).Pluck("requester_id", &approvedIDs).Error; err != nil {
+				return err
+			}
+			if _, err := models.ApproveFollowRequests(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to update profile: %v", err))
+	}
+	for _, requesterID := range approvedIDs {
+		s.enqueueTimelineJob(timeline.BackfillJobType, timeline.BackfillPayload{UserID: requesterID, FolloweeID: user.ID})
+	}
+
+	s.DB.First(user, user.ID)
+	return dataResponse("updateProfile", user)
+}
+
+func (s *Server) handleFollowersQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	return s.followList(ctx, variables, "followers", "follower_id", "followee_id")
+}
+
+func (s *Server) handleFollowingQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	return s.followList(ctx, variables, "following", "followee_id", "follower_id")
+}
+
+// followList はユーザーのフォロワーまたはフォロー中のユーザーを返します
+// 非公開アカウントの一覧は本人と承認済みのフォロワーのみ見られる
+func (s *Server) followList(ctx context.Context, variables map[string]interface{}, key, selectColumn, userColumn string) GraphQLResponse {
+	userID := getUint(variables, "userId")
+	if userID == 0 {
+		return errorResponse("User ID is required")
+	}
+
+	viewer := viewerID(ctx)
+	var user models.User
+	if err := s.DB.First(&user, userID).Error; err != nil || models.IsBlockedBetween(s.DB, viewer, user.ID) {
+		return errorResponse("User not found")
+	}
+	if !models.CanViewUserContent(s.DB, viewer, &user) {
+		return errorResponse(errPrivateAccount.Error())
+	}
+
+	var users []models.User
+	err := models.ExcludeBlocked(s.DB, "id", viewer).
+		Where("id IN (?)", s.DB.Model(&models.Follow{}).Select(selectColumn).Where(userColumn+" = ?", user.ID)).
+		Order("username").
+		Limit(pageSize(variables)).
+		Offset(int(getUint(variables, "offset"))).
+		Find(&users).Error
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse(key, users)
+}
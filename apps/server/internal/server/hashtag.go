@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"sns-server/internal/models"
+)
+
+func (s *Server) handlePostsByHashtagQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	tag := models.NormalizeHashtag(getString(variables, "tag"))
+	if tag == "" {
+		return errorResponse("Tag is required")
+	}
+
+	limit := pageSize(variables)
+	// ブロックの関係にあるユーザーと見られない非公開アカウントの投稿は含めない
+	query := models.ExcludeHidden(s.postQuery(), "author_id", viewerID(ctx)).
+		Where("id IN (?)", s.DB.Model(&models.PostEntity{}).
+			Select("post_id").
+			Where("type = ? AND tag = ?", models.PostEntityTypeHashtag, tag)).
+		Order("created_at DESC, id DESC").
+		Limit(limit + 1)
+
+	if cursor := getString(variables, "cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		query = query.Where("created_at < ? OR (created_at = ? AND id < ?)", createdAt, createdAt, id)
+	}
+
+	var posts []models.Post
+	if err := query.Find(&posts).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	hasNextPage := len(posts) > limit
+	if hasNextPage {
+		posts = posts[:limit]
+	}
+
+	var nextCursor *string
+	if hasNextPage {
+		last := posts[len(posts)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	views, err := s.buildPostViews(ctx, posts)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	return dataResponse("postsByHashtag", map[string]interface{}{
+		"posts":       views,
+		"hasNextPage": hasNextPage,
+		"cursor":      nextCursor,
+	})
+}
@@ -0,0 +1,141 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+type postEntityResponse struct {
+	Type  string  `json:"type"`
+	Start int     `json:"start"`
+	End   int     `json:"end"`
+	Text  string  `json:"text"`
+	Tag   *string `json:"tag"`
+	User  *struct {
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+type timelineResponse struct {
+	Posts []struct {
+		ID       json.Number          `json:"id"`
+		Content  string               `json:"content"`
+		Entities []postEntityResponse `json:"entities"`
+	} `json:"posts"`
+	HasNextPage bool    `json:"hasNextPage"`
+	Cursor      *string `json:"cursor"`
+}
+
+func TestHashtagIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	token, _ := auth.IssueSessionToken(cfg.JWTSecret, bob.ID, time.Hour)
+
+	postsByHashtag := func(variables map[string]interface{}) timelineResponse {
+		t.Helper()
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query:     `query { postsByHashtag(tag: $tag, limit: $limit, cursor: $cursor) { posts { id content entities { type start end text tag user { username } } } hasNextPage cursor } }`,
+			Variables: variables,
+		})
+		if resp.Errors != nil {
+			t.Fatalf("Failed to query postsByHashtag: %v", resp.Errors)
+		}
+		var result struct {
+			PostsByHashtag timelineResponse `json:"postsByHashtag"`
+		}
+		data, _ := json.Marshal(resp.Data)
+		json.Unmarshal(data, &result)
+		return result.PostsByHashtag
+	}
+
+	t.Run("作成した投稿にエンティティが含まれる", func(t *testing.T) {
+		resp := executeAuthenticatedRequest(t, srv, GraphQLRequest{
+			Query: `mutation { createPost(input: $input) { id entities { type start end text tag user { username } } } }`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{"content": "こんにちは @Alice #東京 で会おう"},
+			},
+		}, token)
+		if resp.Errors != nil {
+			t.Fatalf("Failed to create post: %v", resp.Errors)
+		}
+
+		var result struct {
+			CreatePost struct {
+				Entities []postEntityResponse `json:"entities"`
+			} `json:"createPost"`
+		}
+		data, _ := json.Marshal(resp.Data)
+		json.Unmarshal(data, &result)
+
+		entities := result.CreatePost.Entities
+		if len(entities) != 2 {
+			t.Fatalf("Expected 2 entities, got %d", len(entities))
+		}
+		if m := entities[0]; m.Type != "MENTION" || m.Start != 6 || m.End != 12 || m.User == nil || m.User.Username != alice.Username {
+			t.Errorf("Unexpected mention: %+v", m)
+		}
+		if h := entities[1]; h.Type != "HASHTAG" || h.Start != 13 || h.End != 16 || h.Tag == nil || *h.Tag != "東京" {
+			t.Errorf("Unexpected hashtag: %+v", h)
+		}
+	})
+
+	t.Run("ハッシュタグで投稿を検索できる", func(t *testing.T) {
+		testutil.CreateTestPost(t, db, alice.ID, "#Go is fun")
+		testutil.CreateTestPost(t, db, bob.ID, "Learning #golang")
+		deleted := testutil.CreateTestPost(t, db, bob.ID, "deleted #go post")
+		db.Delete(deleted)
+		testutil.CreateTestPost(t, db, bob.ID, "#go と #GO")
+
+		// #の有無・大文字小文字を問わない
+		for _, tag := range []string{"go", "#GO", "＃ｇｏ"} {
+			result := postsByHashtag(map[string]interface{}{"tag": tag})
+			if len(result.Posts) != 2 {
+				t.Errorf("Expected 2 posts for %q, got %d", tag, len(result.Posts))
+				continue
+			}
+			if result.Posts[0].Content != "#go と #GO" || result.Posts[1].Content != "#Go is fun" {
+				t.Errorf("Expected newest first, got %q and %q", result.Posts[0].Content, result.Posts[1].Content)
+			}
+		}
+	})
+
+	t.Run("カーソルでページングできる", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			testutil.CreateTestPost(t, db, alice.ID, fmt.Sprintf("post %d #paging", i))
+		}
+
+		first := postsByHashtag(map[string]interface{}{"tag": "paging", "limit": 2})
+		if len(first.Posts) != 2 || !first.HasNextPage || first.Cursor == nil {
+			t.Fatalf("Expected first page with next cursor, got %+v", first)
+		}
+		second := postsByHashtag(map[string]interface{}{"tag": "paging", "limit": 2, "cursor": *first.Cursor})
+		if len(second.Posts) != 1 || second.HasNextPage {
+			t.Errorf("Expected last page with 1 post, got %+v", second)
+		}
+		if second.Posts[0].Content != "post 0 #paging" {
+			t.Errorf("Expected oldest post on last page, got %q", second.Posts[0].Content)
+		}
+	})
+
+	t.Run("タグが空の場合はエラー", func(t *testing.T) {
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query:     `query { postsByHashtag(tag: $tag) { hasNextPage } }`,
+			Variables: map[string]interface{}{"tag": "#"},
+		})
+		if resp.Errors == nil {
+			t.Error("Expected error for empty tag")
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"sns-server/internal/idempotency"
+)
+
+// idempotencyKey はリクエストに指定されたIdempotency-Keyを返します（ヘッダーを優先する）
+func (req *GraphQLRequest) idempotencyKey(r *http.Request) string {
+	if key := r.Header.Get(idempotency.HeaderName); key != "" {
+		return key
+	}
+	if req.Extensions == nil {
+		return ""
+	}
+	return req.Extensions.IdempotencyKey
+}
+
+// executeIdempotent はIdempotency-Keyを指定したミューテーションを実行します
+// 同じユーザー・キー・内容の再送には保存したレスポンスを返し、別の内容での再利用は拒否する
+// エラーになったレスポンスは保存せず、同じキーで再試行できるようにする
+func (s *Server) executeIdempotent(ctx context.Context, w http.ResponseWriter, key, query string, req *GraphQLRequest) {
+	userID := viewerID(ctx)
+	if userID == 0 {
+		s.sendIdempotencyError(w, &idempotency.Error{Code: idempotency.CodeInvalid, Message: "Authentication required to use an idempotency key"})
+		return
+	}
+	if err := idempotency.ValidateKey(key); err != nil {
+		s.sendIdempotencyError(w, err)
+		return
+	}
+
+	hash, err := idempotency.RequestHash(query, req.OperationName, req.Variables)
+	if err != nil {
+		s.sendIdempotencyError(w, err)
+		return
+	}
+
+	replay, err := s.Idempotency.Begin(userID, key, hash)
+	if err != nil {
+		s.sendIdempotencyError(w, err)
+		return
+	}
+	if replay != nil {
+		w.Header().Set(idempotency.ReplayedHeaderName, "true")
+		w.Write(replay)
+		return
+	}
+
+	response := s.executeQuery(ctx, query, req.Variables)
+	body, err := json.Marshal(response)
+	if err != nil {
+		log.Printf("Failed to encode response for idempotency key: %v", err)
+		s.Idempotency.Release(userID, key)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(errorResponse("Failed to encode response"))
+		return
+	}
+
+	if len(response.Errors) > 0 {
+		err = s.Idempotency.Release(userID, key)
+	} else {
+		err = s.Idempotency.Complete(userID, key, body)
+	}
+	if err != nil {
+		log.Printf("Failed to save idempotency key: %v", err)
+	}
+	w.Write(body)
+}
+
+// sendIdempotencyError はIdempotency-Keyを使えない場合のエラーを返します
+func (s *Server) sendIdempotencyError(w http.ResponseWriter, err error) {
+	var idemErr *idempotency.Error
+	if !errors.As(err, &idemErr) {
+		log.Printf("Failed to check idempotency key: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(errorResponse("Failed to check idempotency key"))
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch idemErr.Code {
+	case idempotency.CodeReused:
+		status = http.StatusUnprocessableEntity
+	case idempotency.CodeInProgress:
+		status = http.StatusConflict
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(GraphQLResponse{
+		Errors: []GraphQLError{{
+			Message:    idemErr.Message,
+			Extensions: map[string]interface{}{"code": idemErr.Code},
+		}},
+	})
+}
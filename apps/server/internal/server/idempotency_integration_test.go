@@ -0,0 +1,155 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/idempotency"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+// executeIdempotentRequest はIdempotency-Keyヘッダーを付けてリクエストを実行し、レスポンスのヘッダーも返します
+func executeIdempotentRequest(t *testing.T, srv *server.Server, req GraphQLRequest, token, key string) (*httptest.ResponseRecorder, GraphQLResponse) {
+	t.Helper()
+	reqBody, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Failed to marshal request: %v", err)
+	}
+
+	httpReq := httptest.NewRequest("POST", "/query", bytes.NewBuffer(reqBody))
+	httpReq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	if key != "" {
+		httpReq.Header.Set(idempotency.HeaderName, key)
+	}
+
+	recorder := httptest.NewRecorder()
+	srv.AuthMiddleware(http.HandlerFunc(srv.HandleGraphQL)).ServeHTTP(recorder, httpReq)
+
+	var resp GraphQLResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	return recorder, resp
+}
+
+func TestIdempotencyKeyIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg, Idempotency: idempotency.NewGuard(db, idempotency.PolicyFromConfig(cfg))}
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	aliceToken, _ := auth.IssueSessionToken(cfg.JWTSecret, alice.ID, time.Hour)
+	bobToken, _ := auth.IssueSessionToken(cfg.JWTSecret, bob.ID, time.Hour)
+
+	createPost := func(content string) GraphQLRequest {
+		return GraphQLRequest{
+			Query:     `mutation { createPost(input: $input) { id content } }`,
+			Variables: map[string]interface{}{"input": map[string]interface{}{"content": content}},
+		}
+	}
+	postCount := func(userID uint) int64 {
+		var count int64
+		db.Model(&models.Post{}).Where("author_id = ?", userID).Count(&count)
+		return count
+	}
+
+	t.Run("再送すると最初の結果を返し、二重に投稿しない", func(t *testing.T) {
+		first, firstResp := executeIdempotentRequest(t, srv, createPost("hello"), aliceToken, "post-1")
+		if firstResp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", firstResp.Errors)
+		}
+		if first.Header().Get(idempotency.ReplayedHeaderName) != "" {
+			t.Error("Expected first response not to be marked as replayed")
+		}
+
+		retry, retryResp := executeIdempotentRequest(t, srv, createPost("hello"), aliceToken, "post-1")
+		if retryResp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", retryResp.Errors)
+		}
+		if retry.Header().Get(idempotency.ReplayedHeaderName) != "true" {
+			t.Error("Expected retried response to be marked as replayed")
+		}
+		if first.Body.String() != retry.Body.String() {
+			t.Errorf("Expected same response, got %s and %s", first.Body.String(), retry.Body.String())
+		}
+		if n := postCount(alice.ID); n != 1 {
+			t.Errorf("Expected 1 post, got %d", n)
+		}
+	})
+
+	t.Run("別の内容でキーを再利用すると拒否する", func(t *testing.T) {
+		recorder, resp := executeIdempotentRequest(t, srv, createPost("different"), aliceToken, "post-1")
+		if recorder.Code != http.StatusUnprocessableEntity || errorCodeOf(resp) != idempotency.CodeReused {
+			t.Errorf("Expected %s with 422, got %d %+v", idempotency.CodeReused, recorder.Code, resp.Errors)
+		}
+		if n := postCount(alice.ID); n != 1 {
+			t.Errorf("Expected 1 post, got %d", n)
+		}
+	})
+
+	t.Run("キーはユーザーごとに区別する", func(t *testing.T) {
+		_, resp := executeIdempotentRequest(t, srv, createPost("hello"), bobToken, "post-1")
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if n := postCount(bob.ID); n != 1 {
+			t.Errorf("Expected 1 post for bob, got %d", n)
+		}
+	})
+
+	t.Run("extensionsでもキーを指定できる", func(t *testing.T) {
+		req := createPost("from extensions")
+		req.Extensions = map[string]interface{}{"idempotencyKey": "post-2"}
+		executeIdempotentRequest(t, srv, req, aliceToken, "")
+		recorder, _ := executeIdempotentRequest(t, srv, req, aliceToken, "")
+		if recorder.Header().Get(idempotency.ReplayedHeaderName) != "true" {
+			t.Error("Expected retried response to be marked as replayed")
+		}
+		if n := postCount(alice.ID); n != 2 {
+			t.Errorf("Expected 2 posts, got %d", n)
+		}
+	})
+
+	t.Run("エラーになった結果は保存せず再試行できる", func(t *testing.T) {
+		_, resp := executeIdempotentRequest(t, srv, createPost(""), aliceToken, "post-3")
+		if resp.Errors == nil {
+			t.Fatal("Expected error for empty content")
+		}
+		recorder, _ := executeIdempotentRequest(t, srv, createPost("retried"), aliceToken, "post-3")
+		if recorder.Header().Get(idempotency.ReplayedHeaderName) != "" {
+			t.Error("Expected failed request not to be replayed")
+		}
+		if n := postCount(alice.ID); n != 3 {
+			t.Errorf("Expected 3 posts, got %d", n)
+		}
+	})
+
+	t.Run("未認証の場合は拒否する", func(t *testing.T) {
+		recorder, resp := executeIdempotentRequest(t, srv, createPost("anonymous"), "", "post-4")
+		if recorder.Code != http.StatusBadRequest || errorCodeOf(resp) != idempotency.CodeInvalid {
+			t.Errorf("Expected %s with 400, got %d %+v", idempotency.CodeInvalid, recorder.Code, resp.Errors)
+		}
+	})
+
+	t.Run("クエリではキーを無視する", func(t *testing.T) {
+		req := GraphQLRequest{Query: `query { posts { id } }`}
+		executeIdempotentRequest(t, srv, req, aliceToken, "query-1")
+		recorder, _ := executeIdempotentRequest(t, srv, req, aliceToken, "query-1")
+		if recorder.Header().Get(idempotency.ReplayedHeaderName) != "" {
+			t.Error("Expected query not to be replayed")
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sns-server/internal/config"
+	"sns-server/internal/server"
+)
+
+func TestHandleGraphQL_QueryLimits(t *testing.T) {
+	srv := &server.Server{
+		Config: &config.Config{
+			MaxQueryDepth:       4,
+			MaxQueryComplexity:  100,
+			DefaultListSize:     20,
+			MaxRequestBodyBytes: 1024,
+		},
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:         "深すぎるクエリは拒否",
+			query:        `{ posts { author { posts { replies { author { username } } } } } }`,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "QUERY_TOO_DEEP",
+		},
+		{
+			name:         "複雑すぎるクエリは拒否",
+			query:        `{ users(limit: 50) { followers(limit: 50) { id } } }`,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "QUERY_TOO_COMPLEX",
+		},
+		{
+			name:         "構文エラー",
+			query:        `{ posts { id `,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "GRAPHQL_PARSE_FAILED",
+		},
+		{
+			name:         "ボディサイズの上限を超える",
+			query:        `{ posts { id } } # ` + strings.Repeat("x", 2048),
+			expectedCode: http.StatusRequestEntityTooLarge,
+			expectedErr:  "REQUEST_TOO_LARGE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(GraphQLRequest{Query: tt.query})
+			req := httptest.NewRequest("POST", "/query", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			recorder := httptest.NewRecorder()
+			srv.HandleGraphQL(recorder, req)
+
+			if recorder.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, recorder.Code)
+			}
+
+			var resp GraphQLResponse
+			json.Unmarshal(recorder.Body.Bytes(), &resp)
+			if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != tt.expectedErr {
+				t.Errorf("Expected %s error, got %+v", tt.expectedErr, resp.Errors)
+			}
+		})
+	}
+}
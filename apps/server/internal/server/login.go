@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/lockout"
+	"sns-server/internal/models"
+)
+
+// ログイン失敗時のメッセージ（アカウントの有無に関わらず同じ文言を返す）
+const invalidCredentialsMessage = "Invalid email or password"
+
+func (s *Server) handleLoginMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	input, ok := variables["input"].(map[string]interface{})
+	if !ok {
+		return errorResponse("Invalid input format")
+	}
+
+	email := models.NormalizeEmail(getString(input, "email"))
+	password := getString(input, "password")
+	if email == "" || password == "" {
+		return errorResponse("Email and password are required")
+	}
+
+	ip := clientIPFromContext(ctx)
+
+	// ロックアウト・段階的な待機の判定（アカウントの有無に関わらず同じ判定）
+	decision, err := s.LoginGuard.Check(email, ip)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	if !decision.Allowed {
+		return loginRejectedResponse(decision)
+	}
+
+	// ユーザーが存在しない場合もダミーのハッシュで照合し、応答時間を揃える
+	// 退会済みのユーザーは猶予期間中のみログインでき、ログインすると退会を取り消す
+	var user models.User
+	found := s.DB.Unscoped().Where("email = ?", email).First(&user).Error == nil
+	if found && user.IsDeactivated() && !user.CanReactivate(s.Config.AccountDeactivationPeriod, time.Now()) {
+		found = false
+	}
+
+	hash := auth.DummyPasswordHash()
+	var userID *uint
+	if found {
+		hash = user.Password
+		userID = &user.ID
+	}
+
+	if !auth.CheckPassword(hash, password) || !found {
+		if err := s.LoginGuard.RecordFailure(email, userID, ip); err != nil {
+			log.Printf("Failed to record login failure: %v", err)
+		}
+		return errorResponse(invalidCredentialsMessage)
+	}
+
+	if err := s.LoginGuard.RecordSuccess(email, userID, ip); err != nil {
+		log.Printf("Failed to record login success: %v", err)
+	}
+
+	if user.IsSuspended() {
+		return GraphQLResponse{Errors: []GraphQLError{accountSuspendedError(&user)}}
+	}
+
+	if user.IsDeactivated() {
+		if err := models.ReactivateUser(s.DB, &user); err != nil {
+			return errorResponse(fmt.Sprintf("Failed to reactivate account: %v", err))
+		}
+	}
+
+	token, err := auth.IssueSessionToken(s.Config.JWTSecret, user.ID, s.Config.SessionTTL)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to issue token: %v", err))
+	}
+
+	return dataResponse("login", map[string]interface{}{
+		"token": token,
+		"user":  user,
+	})
+}
+
+// loginRejectedResponse はロックアウト・待機中のエラーレスポンスを作成します
+func loginRejectedResponse(decision lockout.Decision) GraphQLResponse {
+	seconds := int(math.Ceil(decision.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	code := "LOGIN_THROTTLED"
+	message := fmt.Sprintf("Too many failed login attempts, retry after %d seconds", seconds)
+	if decision.Reason != lockout.ReasonThrottled {
+		code = "ACCOUNT_LOCKED"
+		message = fmt.Sprintf("Login is temporarily locked due to too many failed attempts, retry after %d seconds", seconds)
+	}
+
+	return GraphQLResponse{
+		Errors: []GraphQLError{{
+			Message: message,
+			Extensions: map[string]interface{}{
+				"code":       code,
+				"retryAfter": seconds,
+			},
+		}},
+	}
+}
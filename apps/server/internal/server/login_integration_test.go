@@ -0,0 +1,82 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"sns-server/internal/config"
+	"sns-server/internal/lockout"
+	"sns-server/internal/mailer"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestLoginLockoutIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	srv := &server.Server{
+		DB:     db,
+		Config: config.LoadTest(),
+		Mailer: mailer.NewMemoryOutbox(),
+		LoginGuard: lockout.NewGuard(db, lockout.Policy{
+			MaxAccountFailures: 3,
+			MaxIPFailures:      100,
+			FailureWindow:      15 * time.Minute,
+			LockoutDuration:    15 * time.Minute,
+			DelayAfter:         100, // このテストでは待機を課さない
+			BaseDelay:          time.Second,
+			MaxDelay:           time.Second,
+		}),
+	}
+
+	register := GraphQLRequest{
+		Query: `mutation { register(input: $input) { token } }`,
+		Variables: map[string]interface{}{
+			"input": map[string]interface{}{
+				"username": "alice",
+				"email":    "alice@example.com",
+				"password": "password123",
+				"name":     "Alice",
+			},
+		},
+	}
+	if resp := executeGraphQLRequest(t, srv, register); resp.Errors != nil {
+		t.Fatalf("Register failed: %v", resp.Errors)
+	}
+
+	login := func(email, password string) GraphQLResponse {
+		return executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query: `mutation ($input: LoginInput!) { login(input: $input) { token } }`,
+			Variables: map[string]interface{}{
+				"input": map[string]interface{}{"email": email, "password": password},
+			},
+		})
+	}
+
+	// 実在・非実在のアカウントで同じ応答になることを確認する
+	for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+		t.Run(email, func(t *testing.T) {
+			for i := 0; i < 3; i++ {
+				resp := login(email, "wrong-password")
+				if len(resp.Errors) != 1 || resp.Errors[0].Message != "Invalid email or password" {
+					t.Fatalf("Attempt %d: expected invalid credentials, got %+v", i+1, resp.Errors)
+				}
+			}
+
+			// しきい値を超えるとロックされ、正しいパスワードでもログインできない
+			resp := login(email, "password123")
+			if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "ACCOUNT_LOCKED" {
+				t.Fatalf("Expected ACCOUNT_LOCKED, got %+v", resp.Errors)
+			}
+			if _, ok := resp.Errors[0].Extensions["retryAfter"].(float64); !ok {
+				t.Error("Expected retryAfter extension")
+			}
+		})
+	}
+
+	var events int64
+	db.Table("lockout_events").Count(&events)
+	if events != 2 {
+		t.Errorf("Expected 2 lockout events, got %d", events)
+	}
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// AuthMiddleware はAuthorizationヘッダーのBearerトークンを検証し、ユーザーIDをコンテキストに設定します
+// トークンがない・無効な場合、退会済みのユーザーの場合は未認証として次のハンドラーに渡します
+// 利用停止中のユーザーのリクエストは403で拒否します
+func (s *Server) AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, err := auth.ParseSessionToken(s.Config.JWTSecret, token)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, ok := s.sessionUser(userID)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if user.IsSuspended() {
+			s.sendAccountSuspended(w, user)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+	})
+}
+
+// sessionUser はセッションのユーザーを読み込みます
+// 退会済み・存在しないユーザーの場合はfalseを返します（未認証として扱う）
+func (s *Server) sessionUser(userID uint) (*models.User, bool) {
+	var user models.User
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		return nil, false
+	}
+	return &user, true
+}
+
+// RateLimitMiddleware はミューテーションごとのレート制限を適用します
+// 認証済みの場合はユーザー単位、未認証の場合はクライアントIP単位で数えます
+// AuthMiddlewareより後に登録してください
+func (s *Server) RateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.RateLimiter == nil || !s.Config.RateLimitEnabled || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// 操作名を判定するためにボディを読み、後続のハンドラー用に戻しておく
+		if s.Config.MaxRequestBodyBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, int64(s.Config.MaxRequestBodyBytes))
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				s.sendRequestTooLarge(w, maxBytesErr.Limit)
+				return
+			}
+			s.sendError(w, "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var req GraphQLRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// ハッシュのみのリクエストも登録済みのクエリで操作を判定する
+		// 解決に失敗した場合はHandleGraphQLがエラーを返す
+		query := req.Query
+		if resolved, err := s.PersistedQueries.Resolve(req.Query, req.persistedQuery()); err == nil {
+			query = resolved.Query
+		}
+		if !contains(query, "mutation") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		operation := detectOperation(query)
+		if operation == "" {
+			operation = "unknown"
+		}
+		limit, ok := s.rateLimitFor(operation)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := operation + ":" + rateLimitSubject(r)
+		res, err := s.RateLimiter.Allow(key, limit)
+		if err != nil {
+			// レート制限のバックエンド障害ではリクエストを止めない
+			log.Printf("Rate limiter error: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+
+		if !res.Allowed {
+			s.sendRateLimited(w, operation, res.RetryAfter.Seconds())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitFor は操作名に対応する上限を返します（個別設定がなければ "*" を使う）
+func (s *Server) rateLimitFor(operation string) (config.RateLimit, bool) {
+	if limit, ok := s.Config.RateLimits[operation]; ok {
+		return limit, true
+	}
+	limit, ok := s.Config.RateLimits["*"]
+	return limit, ok
+}
+
+// rateLimitSubject はレート制限のキーとなる利用者を返します
+func rateLimitSubject(r *http.Request) string {
+	if userID, ok := auth.UserIDFromContext(r.Context()); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return "ip:" + clientIP(r)
+}
+
+type clientIPKey struct{}
+
+// withClientIP はクライアントIPをコンテキストに設定します
+func withClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// clientIPFromContext はコンテキストからクライアントIPを取得します
+func clientIPFromContext(ctx context.Context) string {
+	if ip, ok := ctx.Value(clientIPKey{}).(string); ok && ip != "" {
+		return ip
+	}
+	return "unknown"
+}
+
+// RealIPMiddleware は信頼するプロキシ（TrustedProxies）からのリクエストに限り、
+// 転送ヘッダーのクライアントIPをRemoteAddrに設定します
+// それ以外の接続の転送ヘッダーはクライアントが自由に設定できるため無視する（レート制限・ロックアウトを回避できないように）
+func (s *Server) RealIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := s.forwardedClientIP(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedClientIP は信頼するプロキシが転送したクライアントIPを返します（使えない場合は空文字）
+// X-Forwarded-Forは右から順に信頼するプロキシを除き、最初に見つかったアドレスを使う（左側はクライアントが偽装できる）
+func (s *Server) forwardedClientIP(r *http.Request) string {
+	if !s.isTrustedProxy(clientIP(r)) {
+		return ""
+	}
+
+	if header := r.Header.Get("X-Forwarded-For"); header != "" {
+		addrs := strings.Split(header, ",")
+		for i := len(addrs) - 1; i >= 0; i-- {
+			addr := strings.TrimSpace(addrs[i])
+			if net.ParseIP(addr) == nil {
+				return ""
+			}
+			if i == 0 || !s.isTrustedProxy(addr) {
+				return addr
+			}
+		}
+	}
+	if addr := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(addr) != nil {
+		return addr
+	}
+	return ""
+}
+
+// isTrustedProxy は転送ヘッダーを信頼するプロキシのアドレスかを返します
+func (s *Server) isTrustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range s.Config.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP はリクエスト元のIPアドレスを返します
+// プロキシ配下ではRealIPMiddlewareで信頼するプロキシが転送したアドレスに書き換えておく
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (s *Server) sendAccountSuspended(w http.ResponseWriter, user *models.User) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(GraphQLResponse{
+		Errors: []GraphQLError{accountSuspendedError(user)},
+	})
+}
+
+// accountSuspendedError は利用停止中のエラーを作成します（期限がない場合、suspendedUntilはnull）
+func accountSuspendedError(user *models.User) GraphQLError {
+	return GraphQLError{
+		Message: errAccountSuspended.Error(),
+		Extensions: map[string]interface{}{
+			"code":           "ACCOUNT_SUSPENDED",
+			"reason":         user.SuspensionReason,
+			"suspendedUntil": user.SuspendedUntil,
+		},
+	}
+}
+
+func (s *Server) sendRateLimited(w http.ResponseWriter, operation string, retryAfter float64) {
+	seconds := int(math.Ceil(retryAfter))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(GraphQLResponse{
+		Errors: []GraphQLError{{
+			Message: fmt.Sprintf("Too many %s requests, retry after %d seconds", operation, seconds),
+			Extensions: map[string]interface{}{
+				"code":       "RATE_LIMITED",
+				"retryAfter": seconds,
+			},
+		}},
+	})
+}
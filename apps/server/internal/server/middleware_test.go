@@ -0,0 +1,274 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/persisted"
+	"sns-server/internal/ratelimit"
+	"sns-server/internal/server"
+)
+
+// newSessionDB はトークンのユーザーの状態を確認するためのインメモリDBを作成します（ユーザーID 1, 2）
+func newSessionDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	for _, name := range []string{"alice", "bob"} {
+		user := models.User{Username: name, Email: name + "@example.com", Password: "password", Name: name}
+		if err := db.Create(&user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	return db
+}
+
+func newRateLimitedServer(t *testing.T) *server.Server {
+	return &server.Server{
+		DB: newSessionDB(t),
+		Config: &config.Config{
+			JWTSecret:        "test-secret",
+			RateLimitEnabled: true,
+			RateLimits: map[string]config.RateLimit{
+				"login": {Requests: 2, Window: time.Minute},
+				"*":     {Requests: 100, Window: time.Minute},
+			},
+		},
+		RateLimiter: ratelimit.NewMemoryLimiter(),
+	}
+}
+
+func sendThroughMiddleware(t *testing.T, srv *server.Server, query, remoteAddr, token string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(GraphQLRequest{Query: query})
+	req := httptest.NewRequest("POST", "/query", bytes.NewBuffer(body))
+	req.RemoteAddr = remoteAddr
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	// 後続のハンドラーではボディが読めることを確認する
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var decoded GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&decoded); err != nil || decoded.Query != query {
+			t.Errorf("Body was not restored for the next handler")
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	srv.AuthMiddleware(srv.RateLimitMiddleware(next)).ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Run("IP単位で上限を超えるとRATE_LIMITED", func(t *testing.T) {
+		srv := newRateLimitedServer(t)
+		login := `mutation { login(input: $input) { token } }`
+
+		for i := 0; i < 2; i++ {
+			if rec := sendThroughMiddleware(t, srv, login, "203.0.113.1:1234", ""); rec.Code != http.StatusOK {
+				t.Fatalf("Request %d should pass, got %d", i+1, rec.Code)
+			}
+		}
+
+		rec := sendThroughMiddleware(t, srv, login, "203.0.113.1:5678", "")
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("Expected 429, got %d", rec.Code)
+		}
+		if rec.Header().Get("Retry-After") == "" {
+			t.Error("Expected Retry-After header")
+		}
+
+		var resp GraphQLResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		if len(resp.Errors) != 1 || resp.Errors[0].Extensions["code"] != "RATE_LIMITED" {
+			t.Errorf("Expected RATE_LIMITED error, got %+v", resp.Errors)
+		}
+		if _, ok := resp.Errors[0].Extensions["retryAfter"].(float64); !ok {
+			t.Error("Expected retryAfter extension")
+		}
+
+		// 別のIPは影響を受けない
+		if rec := sendThroughMiddleware(t, srv, login, "203.0.113.2:1234", ""); rec.Code != http.StatusOK {
+			t.Errorf("Other IP should pass, got %d", rec.Code)
+		}
+	})
+
+	t.Run("認証済みの場合はユーザー単位で数える", func(t *testing.T) {
+		srv := newRateLimitedServer(t)
+		login := `mutation { login(input: $input) { token } }`
+		token1, _ := auth.IssueSessionToken("test-secret", 1, time.Hour)
+		token2, _ := auth.IssueSessionToken("test-secret", 2, time.Hour)
+
+		// 同じIPでもユーザーが異なれば別カウント
+		for i := 0; i < 2; i++ {
+			sendThroughMiddleware(t, srv, login, "203.0.113.1:1234", token1)
+		}
+		if rec := sendThroughMiddleware(t, srv, login, "203.0.113.1:1234", token1); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected user 1 to be limited, got %d", rec.Code)
+		}
+		if rec := sendThroughMiddleware(t, srv, login, "203.0.113.1:1234", token2); rec.Code != http.StatusOK {
+			t.Errorf("Expected user 2 to pass, got %d", rec.Code)
+		}
+	})
+
+	t.Run("クエリは制限しない", func(t *testing.T) {
+		srv := newRateLimitedServer(t)
+		srv.Config.RateLimits["*"] = config.RateLimit{Requests: 1, Window: time.Minute}
+
+		for i := 0; i < 3; i++ {
+			if rec := sendThroughMiddleware(t, srv, `{ posts { id } }`, "203.0.113.1:1234", ""); rec.Code != http.StatusOK {
+				t.Errorf("Query should not be limited, got %d", rec.Code)
+			}
+		}
+	})
+
+	t.Run("ハッシュのみのミューテーションも制限する", func(t *testing.T) {
+		srv := newRateLimitedServer(t)
+		login := `mutation { login(input: $input) { token } }`
+		store := persisted.NewMemoryStore()
+		store.Save(&models.PersistedQuery{Hash: models.HashQuery(login), Query: login, Source: models.PersistedQuerySourceAPQ})
+		srv.PersistedQueries, _ = persisted.NewResolver(store, persisted.ModeAPQ)
+
+		send := func() *httptest.ResponseRecorder {
+			body, _ := json.Marshal(GraphQLRequest{Extensions: map[string]interface{}{
+				"persistedQuery": map[string]interface{}{"version": 1, "sha256Hash": models.HashQuery(login)},
+			}})
+			req := httptest.NewRequest("POST", "/query", bytes.NewBuffer(body))
+			req.RemoteAddr = "203.0.113.1:1234"
+			recorder := httptest.NewRecorder()
+			srv.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})).ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		for i := 0; i < 2; i++ {
+			send()
+		}
+		if rec := send(); rec.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected persisted mutation to be limited, got %d", rec.Code)
+		}
+	})
+}
+
+func TestRealIPMiddleware(t *testing.T) {
+	login := `mutation { login(input: $input) { token } }`
+	send := func(srv *server.Server, remoteAddr, forwardedFor string) int {
+		body, _ := json.Marshal(GraphQLRequest{Query: login})
+		req := httptest.NewRequest("POST", "/query", bytes.NewBuffer(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		recorder := httptest.NewRecorder()
+		srv.RealIPMiddleware(srv.AuthMiddleware(srv.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))).ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	t.Run("偽装したX-Forwarded-Forでは上限がリセットされない", func(t *testing.T) {
+		srv := newRateLimitedServer(t)
+		for i, spoofed := range []string{"198.51.100.1", "198.51.100.2"} {
+			if code := send(srv, "203.0.113.1:1234", spoofed); code != http.StatusOK {
+				t.Fatalf("Request %d should pass, got %d", i+1, code)
+			}
+		}
+		if code := send(srv, "203.0.113.1:1234", "198.51.100.3"); code != http.StatusTooManyRequests {
+			t.Errorf("Expected spoofed header to be ignored, got %d", code)
+		}
+	})
+
+	t.Run("信頼するプロキシの転送したIPで数える", func(t *testing.T) {
+		srv := newRateLimitedServer(t)
+		_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+		srv.Config.TrustedProxies = []*net.IPNet{proxies}
+
+		// 左側はクライアントが付けられるため、プロキシが追加した右端のアドレスを使う
+		for i := 0; i < 2; i++ {
+			send(srv, "10.0.0.1:1234", fmt.Sprintf("198.51.100.%d, 203.0.113.1", i))
+		}
+		if code := send(srv, "10.0.0.1:1234", "198.51.100.9, 203.0.113.1"); code != http.StatusTooManyRequests {
+			t.Errorf("Expected client IP forwarded by the proxy to be limited, got %d", code)
+		}
+		if code := send(srv, "10.0.0.1:1234", "10.0.0.2, 203.0.113.2"); code != http.StatusOK {
+			t.Errorf("Expected other client to pass, got %d", code)
+		}
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	srv := &server.Server{DB: newSessionDB(t), Config: &config.Config{JWTSecret: "test-secret"}}
+	token, _ := auth.IssueSessionToken("test-secret", 1, time.Hour)
+
+	send := func() (*httptest.ResponseRecorder, bool) {
+		var authenticated bool
+		req := httptest.NewRequest("POST", "/query", bytes.NewBufferString(`{"query":"{ me { id } }"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		srv.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, authenticated = auth.UserIDFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+		})).ServeHTTP(recorder, req)
+		return recorder, authenticated
+	}
+	setUser := func(updates map[string]interface{}) {
+		t.Helper()
+		if err := srv.DB.Unscoped().Model(&models.User{}).Where("id = ?", 1).Updates(updates).Error; err != nil {
+			t.Fatalf("Failed to update user: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		updates       map[string]interface{}
+		code          int
+		authenticated bool
+	}{
+		{name: "有効なユーザーは認証済み", updates: map[string]interface{}{"suspended_at": nil, "deleted_at": nil}, code: http.StatusOK, authenticated: true},
+		{name: "利用停止中のユーザーは403", updates: map[string]interface{}{"suspended_at": time.Now(), "suspended_until": time.Now().Add(time.Hour), "suspension_reason": "spam"}, code: http.StatusForbidden},
+		{name: "期限を過ぎた利用停止は認証済み", updates: map[string]interface{}{"suspended_until": time.Now().Add(-time.Minute)}, code: http.StatusOK, authenticated: true},
+		{name: "退会済みのユーザーは未認証", updates: map[string]interface{}{"suspended_at": nil, "deleted_at": time.Now()}, code: http.StatusOK, authenticated: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setUser(tt.updates)
+			rec, authenticated := send()
+			if rec.Code != tt.code {
+				t.Fatalf("Expected %d, got %d", tt.code, rec.Code)
+			}
+			if authenticated != tt.authenticated {
+				t.Errorf("Expected authenticated=%v, got %v", tt.authenticated, authenticated)
+			}
+			if tt.code != http.StatusForbidden {
+				return
+			}
+
+			var resp GraphQLResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != "ACCOUNT_SUSPENDED" || resp.Errors[0].Extensions["reason"] != "spam" {
+				t.Errorf("Expected ACCOUNT_SUSPENDED with reason, got %v", resp.Errors)
+			}
+			if resp.Errors[0].Extensions["suspendedUntil"] == nil {
+				t.Error("Expected suspendedUntil to be set")
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+	"sns-server/internal/timeline"
+)
+
+var errReportNotFound = errors.New("Report not found")
+
+// reportView はAPIで返す通報です（対象の投稿・ユーザーを含む）
+type reportView struct {
+	models.Report
+	Post *postView    `json:"post"` // 対象が投稿の場合（非表示にした投稿も含む）
+	User *models.User `json:"user"` // 対象がユーザーの場合
+}
+
+func (s *Server) handleReportContentMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	targetType := getString(variables, "targetType")
+	if !models.IsValidReportTargetType(targetType) {
+		return errorResponse("Invalid target type")
+	}
+	targetID := getUint(variables, "targetId")
+	if targetID == 0 {
+		return errorResponse("Target ID is required")
+	}
+
+	// ブロックした相手も通報できるよう、ブロックの関係はチェックしない
+	switch targetType {
+	case models.ReportTargetPost:
+		var post models.Post
+		if err := s.DB.Preload("Author").First(&post, targetID).Error; err != nil {
+			return errorResponse(errPostNotFound.Error())
+		}
+		if post.AuthorID == user.ID {
+			return errorResponse("Cannot report your own post")
+		}
+		if post.Author.IsPrivate && !models.IsFollowing(s.DB, user.ID, post.AuthorID) {
+			return errorResponse(errPostNotFound.Error())
+		}
+	case models.ReportTargetUser:
+		var target models.User
+		if err := s.DB.First(&target, targetID).Error; err != nil {
+			return errorResponse("User not found")
+		}
+		if target.ID == user.ID {
+			return errorResponse("Cannot report yourself")
+		}
+	}
+
+	// 同じ対象への未対応の通報がある場合はそれを返す
+	report := models.Report{ReporterID: user.ID, TargetType: targetType, TargetID: targetID, Reason: getString(variables, "reason")}
+	var existing models.Report
+	err = s.DB.Where("reporter_id = ? AND target_type = ? AND target_id = ? AND status IN ?",
+		user.ID, targetType, targetID, []string{models.ReportStatusOpen, models.ReportStatusReviewing}).
+		First(&existing).Error
+	if err == nil {
+		report = existing
+	} else if err := s.DB.Create(&report).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Failed to report content: %v", err))
+	}
+
+	report.Reporter = *user
+	return dataResponse("reportContent", report)
+}
+
+func (s *Server) handleReportsQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	if _, err := s.requireRole(ctx, models.RoleModerator); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	status := getString(variables, "status")
+	if status == "" {
+		status = models.ReportStatusOpen
+	}
+	if !models.IsValidReportStatus(status) {
+		return errorResponse("Invalid report status")
+	}
+
+	// 古い通報から順に対応する
+	limit := pageSize(variables)
+	query := s.DB.Preload("Reporter").Preload("ResolvedBy").
+		Where("status = ?", status).
+		Order("created_at, id").
+		Limit(limit + 1)
+
+	if targetType := getString(variables, "targetType"); targetType != "" {
+		if !models.IsValidReportTargetType(targetType) {
+			return errorResponse("Invalid target type")
+		}
+		query = query.Where("target_type = ?", targetType)
+	}
+
+	if cursor := getString(variables, "cursor"); cursor != "" {
+		createdAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		query = query.Where("created_at > ? OR (created_at = ? AND id > ?)", createdAt, createdAt, id)
+	}
+
+	var reports []models.Report
+	if err := query.Find(&reports).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	hasNextPage := len(reports) > limit
+	if hasNextPage {
+		reports = reports[:limit]
+	}
+
+	views, err := s.buildReportViews(ctx, reports)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	var nextCursor *string
+	if hasNextPage {
+		last := reports[len(reports)-1]
+		cursor := encodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	return dataResponse("reports", map[string]interface{}{
+		"reports":     views,
+		"hasNextPage": hasNextPage,
+		"cursor":      nextCursor,
+	})
+}
+
+// buildReportViews は通報の対象の投稿・ユーザーをまとめて読み込みます
+// 非表示にした投稿もモデレーターが確認できるよう含める
+func (s *Server) buildReportViews(ctx context.Context, reports []models.Report) ([]reportView, error) {
+	var postIDs, userIDs []uint
+	for _, report := range reports {
+		if report.TargetType == models.ReportTargetPost {
+			postIDs = append(postIDs, report.TargetID)
+		} else {
+			userIDs = append(userIDs, report.TargetID)
+		}
+	}
+
+	postsByID := map[uint]postView{}
+	if len(postIDs) > 0 {
+		var posts []models.Post
+		if err := s.postQuery().Unscoped().Where("id IN ?", postIDs).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		postViews, err := s.buildPostViews(ctx, posts)
+		if err != nil {
+			return nil, err
+		}
+		for _, view := range postViews {
+			postsByID[view.ID] = view
+		}
+	}
+
+	usersByID := map[uint]models.User{}
+	if len(userIDs) > 0 {
+		var users []models.User
+		if err := s.DB.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			usersByID[user.ID] = user
+		}
+	}
+
+	views := make([]reportView, 0, len(reports))
+	for _, report := range reports {
+		view := reportView{Report: report}
+		if post, ok := postsByID[report.TargetID]; ok && report.TargetType == models.ReportTargetPost {
+			view.Post = &post
+		}
+		if user, ok := usersByID[report.TargetID]; ok && report.TargetType == models.ReportTargetUser {
+			view.User = &user
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+func (s *Server) handleUpdateReportStatusMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	moderator, err := s.requireRole(ctx, models.RoleModerator)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var report models.Report
+	if err := s.DB.First(&report, getUint(variables, "id")).Error; err != nil {
+		return errorResponse(errReportNotFound.Error())
+	}
+
+	status := getString(variables, "status")
+	if !models.IsValidReportStatus(status) {
+		return errorResponse("Invalid report status")
+	}
+	if err := report.Transition(s.DB, status, moderator.ID, getString(variables, "note")); err != nil {
+		if errors.Is(err, models.ErrInvalidReportTransition) {
+			return errorResponse(fmt.Sprintf("Cannot change report status from %s to %s", report.Status, status))
+		}
+		return errorResponse(fmt.Sprintf("Failed to update report: %v", err))
+	}
+
+	s.DB.Preload("Reporter").Preload("ResolvedBy").First(&report, report.ID)
+	views, err := s.buildReportViews(ctx, []models.Report{report})
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("updateReportStatus", views[0])
+}
+
+func (s *Server) handleHidePostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	return s.moderatePost(ctx, variables, "hidePost", models.ModerationActionHidePost)
+}
+
+func (s *Server) handleRestorePostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	return s.moderatePost(ctx, variables, "restorePost", models.ModerationActionRestorePost)
+}
+
+// moderatePost は投稿を非表示にする・元に戻す操作を行い、操作を記録します
+// 非表示にした投稿は削除と同じ扱いになり、全ての一覧・詳細から表示されなくなる
+func (s *Server) moderatePost(ctx context.Context, variables map[string]interface{}, key, action string) GraphQLResponse {
+	moderator, err := s.requireRole(ctx, models.RoleModerator)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var post models.Post
+	if err := s.DB.Unscoped().First(&post, getUint(variables, "postId")).Error; err != nil {
+		return errorResponse(errPostNotFound.Error())
+	}
+
+	hidden := post.DeletedAt.Valid
+	if action == models.ModerationActionHidePost && hidden {
+		return errorResponse("Post is already hidden")
+	}
+	// 投稿者自身が削除した投稿は元に戻さない
+	if action == models.ModerationActionRestorePost && (!hidden || s.lastModerationAction(models.ReportTargetPost, post.ID) != models.ModerationActionHidePost) {
+		return errorResponse("Post is not hidden")
+	}
+
+	record, err := s.recordModerationAction(moderator, models.ReportTargetPost, post.ID, action, variables, func(tx *gorm.DB) error {
+		if action == models.ModerationActionHidePost {
+			if err := tx.Delete(&post).Error; err != nil {
+				return err
+			}
+			if err := models.AdjustReplyCount(tx, &post, -1); err != nil {
+				return err
+			}
+			return models.RemovePostFromTimelines(tx, post.ID)
+		}
+		if err := tx.Unscoped().Model(&post).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return models.AdjustReplyCount(tx, &post, 1)
+	})
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+	// 元に戻した投稿はフォロワーのタイムラインに配信し直す
+	if action == models.ModerationActionRestorePost {
+		s.enqueueTimelineJob(timeline.FanoutJobType, timeline.FanoutPayload{PostID: post.ID})
+	}
+	return dataResponse(key, record)
+}
+
+func (s *Server) handleSuspendUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	return s.moderateUser(ctx, variables, "suspendUser", models.ModerationActionSuspendUser)
+}
+
+func (s *Server) handleUnsuspendUserMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	return s.moderateUser(ctx, variables, "unsuspendUser", models.ModerationActionUnsuspendUser)
+}
+
+// moderateUser はユーザーを利用停止する・解除する操作を行い、操作を記録します
+func (s *Server) moderateUser(ctx context.Context, variables map[string]interface{}, key, action string) GraphQLResponse {
+	moderator, err := s.requireRole(ctx, models.RoleModerator)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var target models.User
+	if err := s.DB.First(&target, getUint(variables, "userId")).Error; err != nil {
+		return errorResponse("User not found")
+	}
+
+	switch {
+	case action == models.ModerationActionSuspendUser && target.ID == moderator.ID:
+		return errorResponse("Cannot suspend yourself")
+	case !moderator.Outranks(&target):
+		// 同じ役割以上のユーザーは利用停止・解除できない
+		return errorResponse("Cannot moderate a user with an equal or higher role")
+	case action == models.ModerationActionSuspendUser && target.IsSuspended():
+		return errorResponse("User is already suspended")
+	case action == models.ModerationActionUnsuspendUser && !target.IsSuspended():
+		return errorResponse("User is not suspended")
+	}
+
+	until, err := getTime(variables, "until")
+	if err != nil {
+		return errorResponse("Invalid until: must be an RFC 3339 timestamp")
+	}
+	if until != nil && !until.After(time.Now()) {
+		return errorResponse(models.ErrSuspensionExpired.Error())
+	}
+
+	record, err := s.recordModerationAction(moderator, models.ReportTargetUser, target.ID, action, variables, func(tx *gorm.DB) error {
+		if action == models.ModerationActionSuspendUser {
+			return models.SuspendUser(tx, &target, getString(variables, "reason"), until)
+		}
+		return models.UnsuspendUser(tx, &target)
+	})
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+	return dataResponse(key, record)
+}
+
+// recordModerationAction は対象への操作と操作の記録を同じトランザクションで行います
+// 非表示・利用停止の場合は対象への未対応の通報を全て対応済みにする
+func (s *Server) recordModerationAction(moderator *models.User, targetType string, targetID uint, action string, variables map[string]interface{}, apply func(tx *gorm.DB) error) (*models.ModerationAction, error) {
+	record := models.ModerationAction{
+		ModeratorID: moderator.ID,
+		Action:      action,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Reason:      getString(variables, "reason"),
+	}
+
+	if reportID := getUint(variables, "reportId"); reportID != 0 {
+		var report models.Report
+		err := s.DB.Where("id = ? AND target_type = ? AND target_id = ?", reportID, targetType, targetID).First(&report).Error
+		if err != nil {
+			return nil, errReportNotFound
+		}
+		record.ReportID = &report.ID
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		if err := apply(tx); err != nil {
+			return err
+		}
+		if action == models.ModerationActionHidePost || action == models.ModerationActionSuspendUser {
+			_, err := models.ResolveReportsForTarget(tx, targetType, targetID, moderator.ID, record.Reason)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to moderate content: %v", err)
+	}
+
+	record.Moderator = *moderator
+	return &record, nil
+}
+
+// lastModerationAction は対象への最後のモデレーターの操作を返します（操作がない場合は空文字）
+func (s *Server) lastModerationAction(targetType string, targetID uint) string {
+	var record models.ModerationAction
+	err := s.DB.Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Order("created_at DESC, id DESC").
+		First(&record).Error
+	if err != nil {
+		return ""
+	}
+	return record.Action
+}
+
+func (s *Server) handleModerationActionsQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	if _, err := s.requireRole(ctx, models.RoleModerator); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	targetType := getString(variables, "targetType")
+	if !models.IsValidReportTargetType(targetType) {
+		return errorResponse("Invalid target type")
+	}
+
+	var records []models.ModerationAction
+	err := s.DB.Preload("Moderator").
+		Where("target_type = ? AND target_id = ?", targetType, getUint(variables, "targetId")).
+		Order("created_at DESC, id DESC").
+		Find(&records).Error
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("moderationActions", records)
+}
@@ -0,0 +1,305 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestModerationIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	moddy := testutil.CreateTestUser(t, db, "moddy", "moddy@example.com", "Moddy")
+	if err := models.SetUserRole(db, moddy, models.RoleModerator); err != nil {
+		t.Fatalf("Failed to set role: %v", err)
+	}
+	reporter := testutil.CreateTestUser(t, db, "reporter", "reporter@example.com", "Reporter")
+	witness := testutil.CreateTestUser(t, db, "witness", "witness@example.com", "Witness")
+	spammer := testutil.CreateTestUser(t, db, "spammer", "spammer@example.com", "Spammer")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	decode := func(resp GraphQLResponse, field string, v interface{}) {
+		t.Helper()
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})[field])
+		json.Unmarshal(data, v)
+	}
+	expectError := func(resp GraphQLResponse, message string) {
+		t.Helper()
+		if len(resp.Errors) == 0 {
+			t.Fatalf("Expected error %q, got data %v", message, resp.Data)
+		}
+		if resp.Errors[0].Message != message {
+			t.Errorf("Expected error %q, got %q", message, resp.Errors[0].Message)
+		}
+	}
+	report := func(user *models.User, targetType string, targetID uint, reason string) GraphQLResponse {
+		t.Helper()
+		return execute(user, `mutation { reportContent(targetType: $targetType, targetId: $targetId, reason: $reason) { id status reporter { username } } }`, map[string]interface{}{
+			"targetType": targetType,
+			"targetId":   fmt.Sprint(targetID),
+			"reason":     reason,
+		})
+	}
+
+	type reportResult struct {
+		ID         json.Number `json:"id"`
+		TargetType string      `json:"targetType"`
+		Status     string      `json:"status"`
+		Reason     string      `json:"reason"`
+		Reporter   struct {
+			Username string `json:"username"`
+		} `json:"reporter"`
+		ResolvedBy *struct {
+			Username string `json:"username"`
+		} `json:"resolvedBy"`
+		Post *struct {
+			Content string `json:"content"`
+		} `json:"post"`
+		User *struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	}
+	listReports := func(status string) []reportResult {
+		t.Helper()
+		var list struct {
+			Reports []reportResult `json:"reports"`
+		}
+		decode(execute(moddy, `query { reports(status: $status) { reports { id targetType status reason reporter { username } resolvedBy { username } post { content } user { username } } hasNextPage cursor } }`,
+			map[string]interface{}{"status": status}), "reports", &list)
+		return list.Reports
+	}
+	postVisible := func(postID uint) bool {
+		t.Helper()
+		resp := execute(witness, `query { post(id: $id) { id content } }`, map[string]interface{}{"id": fmt.Sprint(postID)})
+		return resp.Errors == nil && resp.Data.(map[string]interface{})["post"] != nil
+	}
+
+	spam := testutil.CreateTestPost(t, db, spammer.ID, "buy cheap followers")
+
+	t.Run("投稿を通報できる", func(t *testing.T) {
+		var first, second reportResult
+		decode(report(reporter, "POST", spam.ID, "spam"), "reportContent", &first)
+		if first.Status != "OPEN" || first.Reporter.Username != "reporter" {
+			t.Errorf("Expected open report by reporter, got %+v", first)
+		}
+
+		// 同じ対象への未対応の通報は重複させない
+		decode(report(reporter, "POST", spam.ID, "spam again"), "reportContent", &second)
+		if second.ID != first.ID {
+			t.Errorf("Expected same report %s, got %s", first.ID, second.ID)
+		}
+	})
+
+	t.Run("無効な通報はエラーになる", func(t *testing.T) {
+		own := testutil.CreateTestPost(t, db, reporter.ID, "my own post")
+		expectError(report(reporter, "POST", own.ID, "spam"), "Cannot report your own post")
+		expectError(report(reporter, "USER", reporter.ID, "spam"), "Cannot report yourself")
+		expectError(report(reporter, "POST", 999999, "spam"), "Post not found")
+		expectError(report(reporter, "COMMENT", spam.ID, "spam"), "Invalid target type")
+
+		resp := report(reporter, "USER", spammer.ID, "   ")
+		if len(resp.Errors) == 0 {
+			t.Error("Expected error for blank reason")
+		}
+	})
+
+	t.Run("モデレーター以外は通報の一覧と操作ができない", func(t *testing.T) {
+		expectError(execute(reporter, `query { reports { reports { id } } }`, nil), "Query.reports requires the MODERATOR role")
+		expectError(execute(reporter, `query { moderationActions(targetType: $targetType, targetId: $targetId) { id } }`,
+			map[string]interface{}{"targetType": "POST", "targetId": fmt.Sprint(spam.ID)}), "Query.moderationActions requires the MODERATOR role")
+		expectError(execute(reporter, `mutation { hidePost(postId: $postId, reason: $reason) { id } }`,
+			map[string]interface{}{"postId": fmt.Sprint(spam.ID), "reason": "spam"}), "Mutation.hidePost requires the MODERATOR role")
+		expectError(execute(reporter, `mutation { suspendUser(userId: $userId, reason: $reason) { id } }`,
+			map[string]interface{}{"userId": fmt.Sprint(spammer.ID), "reason": "spam"}), "Mutation.suspendUser requires the MODERATOR role")
+		expectError(executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { reports { reports { id } } }`}), "authentication required")
+
+		if !postVisible(spam.ID) {
+			t.Error("Expected post to stay visible")
+		}
+	})
+
+	t.Run("モデレーターは通報を確認して投稿を非表示にできる", func(t *testing.T) {
+		decode(report(witness, "POST", spam.ID, "scam"), "reportContent", &reportResult{})
+
+		reports := listReports("OPEN")
+		var reportID json.Number
+		for _, r := range reports {
+			if r.Post != nil && r.Post.Content == "buy cheap followers" {
+				reportID = r.ID
+			}
+		}
+		if reportID == "" {
+			t.Fatalf("Expected report for spam post, got %+v", reports)
+		}
+
+		var reviewing reportResult
+		decode(execute(moddy, `mutation { updateReportStatus(id: $id, status: $status) { id status } }`,
+			map[string]interface{}{"id": reportID.String(), "status": "REVIEWING"}), "updateReportStatus", &reviewing)
+		if reviewing.Status != "REVIEWING" {
+			t.Errorf("Expected REVIEWING, got %s", reviewing.Status)
+		}
+
+		var action struct {
+			Action    string      `json:"action"`
+			Reason    string      `json:"reason"`
+			ReportID  json.Number `json:"reportId"`
+			Moderator struct {
+				Username string `json:"username"`
+			} `json:"moderator"`
+		}
+		decode(execute(moddy, `mutation { hidePost(postId: $postId, reason: $reason, reportId: $reportId) { id action reason reportId moderator { username } } }`,
+			map[string]interface{}{"postId": fmt.Sprint(spam.ID), "reason": "commercial spam", "reportId": reportID.String()}), "hidePost", &action)
+		if action.Action != "HIDE_POST" || action.Reason != "commercial spam" || action.Moderator.Username != "moddy" || action.ReportID != reportID {
+			t.Errorf("Unexpected moderation action: %+v", action)
+		}
+
+		if postVisible(spam.ID) {
+			t.Error("Expected hidden post to be invisible")
+		}
+
+		// 対象への未対応の通報は全て対応済みになり、非表示にした投稿もモデレーターは確認できる
+		if open := listReports("OPEN"); len(open) != 0 {
+			t.Errorf("Expected no open reports for the post, got %+v", open)
+		}
+		resolved := listReports("RESOLVED")
+		if len(resolved) != 2 {
+			t.Fatalf("Expected 2 resolved reports, got %d", len(resolved))
+		}
+		for _, r := range resolved {
+			if r.ResolvedBy == nil || r.ResolvedBy.Username != "moddy" || r.Post == nil {
+				t.Errorf("Expected resolved report with post, got %+v", r)
+			}
+		}
+
+		expectError(execute(moddy, `mutation { updateReportStatus(id: $id, status: $status) { id } }`,
+			map[string]interface{}{"id": reportID.String(), "status": "REVIEWING"}), "Cannot change report status from RESOLVED to REVIEWING")
+		expectError(execute(moddy, `mutation { hidePost(postId: $postId, reason: $reason) { id } }`,
+			map[string]interface{}{"postId": fmt.Sprint(spam.ID), "reason": "again"}), "Post is already hidden")
+	})
+
+	t.Run("非表示にした投稿を元に戻せる", func(t *testing.T) {
+		var action struct {
+			Action string `json:"action"`
+		}
+		decode(execute(moddy, `mutation { restorePost(postId: $postId, reason: $reason) { id action } }`,
+			map[string]interface{}{"postId": fmt.Sprint(spam.ID), "reason": "appeal accepted"}), "restorePost", &action)
+		if action.Action != "RESTORE_POST" {
+			t.Errorf("Expected RESTORE_POST, got %s", action.Action)
+		}
+		if !postVisible(spam.ID) {
+			t.Error("Expected restored post to be visible")
+		}
+
+		var history []struct {
+			Action string `json:"action"`
+			Reason string `json:"reason"`
+		}
+		decode(execute(moddy, `query { moderationActions(targetType: $targetType, targetId: $targetId) { id action reason moderator { username } } }`,
+			map[string]interface{}{"targetType": "POST", "targetId": fmt.Sprint(spam.ID)}), "moderationActions", &history)
+		if len(history) != 2 || history[0].Action != "RESTORE_POST" || history[1].Action != "HIDE_POST" {
+			t.Errorf("Expected restore and hide actions newest first, got %+v", history)
+		}
+
+		// 投稿者自身が削除した投稿は元に戻せない
+		deleted := testutil.CreateTestPost(t, db, spammer.ID, "deleted by author")
+		db.Delete(deleted)
+		expectError(execute(moddy, `mutation { restorePost(postId: $postId, reason: $reason) { id } }`,
+			map[string]interface{}{"postId": fmt.Sprint(deleted.ID), "reason": "restore"}), "Post is not hidden")
+	})
+
+	t.Run("利用停止したユーザーは操作できない", func(t *testing.T) {
+		decode(report(reporter, "USER", spammer.ID, "spam account"), "reportContent", &reportResult{})
+
+		var action struct {
+			Action string `json:"action"`
+		}
+		decode(execute(moddy, `mutation { suspendUser(userId: $userId, reason: $reason) { id action } }`,
+			map[string]interface{}{"userId": fmt.Sprint(spammer.ID), "reason": "repeated spam"}), "suspendUser", &action)
+		if action.Action != "SUSPEND_USER" {
+			t.Errorf("Expected SUSPEND_USER, got %s", action.Action)
+		}
+
+		expectError(execute(spammer, `mutation { followUser(userId: $userId) { id } }`,
+			map[string]interface{}{"userId": fmt.Sprint(witness.ID)}), "account suspended")
+		expectError(execute(spammer, `mutation { createPost(input: $input) { id } }`,
+			map[string]interface{}{"input": map[string]interface{}{"content": "more spam"}}), "account suspended")
+		expectError(execute(moddy, `mutation { suspendUser(userId: $userId, reason: $reason) { id } }`,
+			map[string]interface{}{"userId": fmt.Sprint(spammer.ID), "reason": "again"}), "User is already suspended")
+		expectError(execute(moddy, `mutation { suspendUser(userId: $userId, reason: $reason) { id } }`,
+			map[string]interface{}{"userId": fmt.Sprint(moddy.ID), "reason": "oops"}), "Cannot suspend yourself")
+
+		for _, r := range listReports("OPEN") {
+			if r.User != nil && r.User.Username == "spammer" {
+				t.Errorf("Expected report for suspended user to be resolved, got %+v", r)
+			}
+		}
+
+		decode(execute(moddy, `mutation { unsuspendUser(userId: $userId, reason: $reason) { id action } }`,
+			map[string]interface{}{"userId": fmt.Sprint(spammer.ID), "reason": "suspension served"}), "unsuspendUser", &action)
+		if action.Action != "UNSUSPEND_USER" {
+			t.Errorf("Expected UNSUSPEND_USER, got %s", action.Action)
+		}
+		decode(execute(spammer, `mutation { followUser(userId: $userId) { id } }`,
+			map[string]interface{}{"userId": fmt.Sprint(witness.ID)}), "followUser", &struct{}{})
+	})
+
+	t.Run("同じ役割以上のユーザーは利用停止できない", func(t *testing.T) {
+		otherMod := testutil.CreateTestUser(t, db, "othermod", "othermod@example.com", "Other Mod")
+		if err := models.SetUserRole(db, otherMod, models.RoleModerator); err != nil {
+			t.Fatalf("Failed to set role: %v", err)
+		}
+		admin := testutil.CreateTestUser(t, db, "boss", "boss@example.com", "Boss")
+		if err := models.SetUserRole(db, admin, models.RoleAdmin); err != nil {
+			t.Fatalf("Failed to set role: %v", err)
+		}
+
+		for _, target := range []*models.User{otherMod, admin} {
+			expectError(execute(moddy, `mutation { suspendUser(userId: $userId, reason: $reason) { id } }`,
+				map[string]interface{}{"userId": fmt.Sprint(target.ID), "reason": "abuse"}), "Cannot moderate a user with an equal or higher role")
+		}
+		var saved models.User
+		db.First(&saved, admin.ID)
+		if saved.IsSuspended() {
+			t.Error("Expected admin not to be suspended")
+		}
+
+		// 管理者はモデレーターを利用停止できる
+		var action struct {
+			Action string `json:"action"`
+		}
+		decode(execute(admin, `mutation { suspendUser(userId: $userId, reason: $reason) { id action } }`,
+			map[string]interface{}{"userId": fmt.Sprint(otherMod.ID), "reason": "abuse"}), "suspendUser", &action)
+		if action.Action != "SUSPEND_USER" {
+			t.Errorf("Expected SUSPEND_USER, got %s", action.Action)
+		}
+	})
+
+	t.Run("ユーザーの通報を却下できる", func(t *testing.T) {
+		var created reportResult
+		decode(report(witness, "USER", reporter.ID, "rude"), "reportContent", &created)
+
+		var dismissed reportResult
+		decode(execute(moddy, `mutation { updateReportStatus(id: $id, status: $status, note: $note) { id status resolvedBy { username } user { username } } }`,
+			map[string]interface{}{"id": created.ID.String(), "status": "DISMISSED", "note": "no violation"}), "updateReportStatus", &dismissed)
+		if dismissed.Status != "DISMISSED" || dismissed.ResolvedBy == nil || dismissed.User == nil || dismissed.User.Username != "reporter" {
+			t.Errorf("Unexpected dismissed report: %+v", dismissed)
+		}
+	})
+}
@@ -0,0 +1,306 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+type notificationsResponse struct {
+	Notifications struct {
+		Notifications []struct {
+			ID         json.Number `json:"id"`
+			Type       string      `json:"type"`
+			ActorCount int         `json:"actorCount"`
+			Message    string      `json:"message"`
+			Read       bool        `json:"read"`
+			Actors     []struct {
+				Username string `json:"username"`
+			} `json:"actors"`
+		} `json:"notifications"`
+		HasNextPage bool    `json:"hasNextPage"`
+		Cursor      *string `json:"cursor"`
+	} `json:"notifications"`
+}
+
+func TestNotificationIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	// サブテストごとに別のユーザーを使う
+	var alice, bob, carol, dave *models.User
+	round := 0
+	reset := func() {
+		round++
+		newUser := func(username, name string) *models.User {
+			username = fmt.Sprintf("%s%d", username, round)
+			return testutil.CreateTestUser(t, db, username, username+"@example.com", name)
+		}
+		alice = newUser("alice", "Alice")
+		bob = newUser("bob", "Bob")
+		carol = newUser("carol", "Carol")
+		dave = newUser("dave", "Dave")
+	}
+
+	tokenFor := func(user *models.User) string {
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return token
+	}
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, tokenFor(user))
+	}
+	listNotifications := func(user *models.User, variables map[string]interface{}) notificationsResponse {
+		t.Helper()
+		resp := execute(user, `query { notifications(cursor: $cursor, limit: $limit) { notifications { id type actorCount message read actors { username } } hasNextPage cursor } }`, variables)
+		if resp.Errors != nil {
+			t.Fatalf("Failed to list notifications: %v", resp.Errors)
+		}
+		var result notificationsResponse
+		data, _ := json.Marshal(resp.Data)
+		json.Unmarshal(data, &result)
+		return result
+	}
+	like := func(user *models.User, postID uint) {
+		t.Helper()
+		resp := execute(user, `mutation { likePost(postId: $postId) { id } }`, map[string]interface{}{"postId": postID})
+		if resp.Errors != nil {
+			t.Fatalf("Failed to like post: %v", resp.Errors)
+		}
+	}
+
+	t.Run("同じ投稿へのいいねは1件にまとめる", func(t *testing.T) {
+		reset()
+		post := testutil.CreateTestPost(t, db, alice.ID, "Hello")
+
+		like(bob, post.ID)
+		like(carol, post.ID)
+		like(dave, post.ID)
+		// 自分のいいねは通知しない
+		like(alice, post.ID)
+
+		result := listNotifications(alice, nil)
+		if len(result.Notifications.Notifications) != 1 {
+			t.Fatalf("Expected 1 notification, got %d", len(result.Notifications.Notifications))
+		}
+		n := result.Notifications.Notifications[0]
+		if n.Type != models.NotificationTypeLike || n.ActorCount != 3 {
+			t.Errorf("Expected LIKE with 3 actors, got %s with %d", n.Type, n.ActorCount)
+		}
+		if n.Message != "Dave and 2 others liked your post" {
+			t.Errorf("Unexpected message: %s", n.Message)
+		}
+		if len(n.Actors) != 3 || n.Actors[0].Username != dave.Username {
+			t.Errorf("Expected latest actors first, got %+v", n.Actors)
+		}
+	})
+
+	t.Run("フォローの通知", func(t *testing.T) {
+		reset()
+
+		followQuery := `mutation { followUser(userId: $userId) { id } }`
+		vars := map[string]interface{}{"userId": fmt.Sprint(alice.ID)}
+		if resp := execute(bob, followQuery, vars); resp.Errors != nil {
+			t.Fatalf("Failed to follow: %v", resp.Errors)
+		}
+		// 既にフォロー済みの場合は通知を増やさない
+		execute(bob, followQuery, vars)
+
+		result := listNotifications(alice, nil)
+		if len(result.Notifications.Notifications) != 1 {
+			t.Fatalf("Expected 1 notification, got %d", len(result.Notifications.Notifications))
+		}
+		if n := result.Notifications.Notifications[0]; n.Type != models.NotificationTypeFollow || n.Message != "Bob followed you" {
+			t.Errorf("Unexpected notification: %+v", n)
+		}
+	})
+
+	t.Run("リプライとメンションの通知", func(t *testing.T) {
+		reset()
+		post := testutil.CreateTestPost(t, db, alice.ID, "Hello")
+
+		resp := execute(bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"content":  fmt.Sprintf("@%s @%s nice post", alice.Username, strings.ToUpper(carol.Username)),
+				"parentId": fmt.Sprint(post.ID),
+			},
+		})
+		if resp.Errors != nil {
+			t.Fatalf("Failed to create reply: %v", resp.Errors)
+		}
+
+		// リプライ先の作成者へはリプライの通知のみ
+		aliceResult := listNotifications(alice, nil)
+		if len(aliceResult.Notifications.Notifications) != 1 || aliceResult.Notifications.Notifications[0].Type != models.NotificationTypeReply {
+			t.Errorf("Expected a single REPLY notification, got %+v", aliceResult.Notifications.Notifications)
+		}
+
+		carolResult := listNotifications(carol, nil)
+		if len(carolResult.Notifications.Notifications) != 1 {
+			t.Fatalf("Expected 1 notification, got %d", len(carolResult.Notifications.Notifications))
+		}
+		if n := carolResult.Notifications.Notifications[0]; n.Type != models.NotificationTypeMention || n.Message != "Bob mentioned you" {
+			t.Errorf("Unexpected notification: %+v", n)
+		}
+	})
+
+	t.Run("投稿を見られないユーザーには通知せず、見られなくなった投稿は返さない", func(t *testing.T) {
+		reset()
+		mention := func(author *models.User, content string) {
+			t.Helper()
+			resp := execute(author, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+				"input": map[string]interface{}{"content": content},
+			})
+			if resp.Errors != nil {
+				t.Fatalf("Failed to create post: %v", resp.Errors)
+			}
+		}
+		notificationPosts := func(user *models.User) []map[string]interface{} {
+			t.Helper()
+			resp := execute(user, `query { notifications { notifications { id post { id content } } } }`, nil)
+			if resp.Errors != nil {
+				t.Fatalf("Failed to list notifications: %v", resp.Errors)
+			}
+			var result struct {
+				Notifications struct {
+					Notifications []map[string]interface{} `json:"notifications"`
+				} `json:"notifications"`
+			}
+			data, _ := json.Marshal(resp.Data)
+			json.Unmarshal(data, &result)
+			return result.Notifications.Notifications
+		}
+
+		// 公開中のメンションは通知し、非公開にした後は投稿を返さない
+		mention(bob, "hi @"+carol.Username)
+		if list := notificationPosts(carol); len(list) != 1 || list[0]["post"] == nil {
+			t.Fatalf("Expected mention with post, got %+v", list)
+		}
+		db.Model(bob).Update("is_private", true)
+		if list := notificationPosts(carol); len(list) != 1 || list[0]["post"] != nil {
+			t.Errorf("Expected post to be hidden after bob went private, got %+v", list)
+		}
+
+		// 非公開アカウントのメンション・リプライはフォロワー以外に通知しない
+		post := testutil.CreateTestPost(t, db, dave.ID, "dave's post")
+		resp := execute(bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+			"input": map[string]interface{}{"content": "secret @" + alice.Username, "parentId": fmt.Sprint(post.ID)},
+		})
+		if resp.Errors != nil {
+			t.Fatalf("Failed to create reply: %v", resp.Errors)
+		}
+		for _, user := range []*models.User{alice, dave} {
+			if list := notificationPosts(user); len(list) != 0 {
+				t.Errorf("Expected no notifications for %s, got %+v", user.Username, list)
+			}
+		}
+	})
+
+	t.Run("既読と未読件数", func(t *testing.T) {
+		reset()
+		first := testutil.CreateTestPost(t, db, alice.ID, "first")
+		second := testutil.CreateTestPost(t, db, alice.ID, "second")
+		like(bob, first.ID)
+		like(bob, second.ID)
+
+		unreadCount := func() float64 {
+			t.Helper()
+			resp := execute(alice, `query { me { id unreadNotificationCount } }`, nil)
+			if resp.Errors != nil {
+				t.Fatalf("Failed to query me: %v", resp.Errors)
+			}
+			me := resp.Data.(map[string]interface{})["me"].(map[string]interface{})
+			return me["unreadNotificationCount"].(float64)
+		}
+		if count := unreadCount(); count != 2 {
+			t.Errorf("Expected 2 unread, got %v", count)
+		}
+
+		// 新しい順なので2件目がfirstへのいいね
+		result := listNotifications(alice, nil)
+		resp := execute(alice, `mutation { markNotificationsRead(ids: $ids) }`, map[string]interface{}{
+			"ids": []interface{}{result.Notifications.Notifications[1].ID.String()},
+		})
+		if resp.Errors != nil {
+			t.Fatalf("Failed to mark read: %v", resp.Errors)
+		}
+		if count := unreadCount(); count != 1 {
+			t.Errorf("Expected 1 unread, got %v", count)
+		}
+
+		// 既読になった後のいいねは新しい通知になる
+		like(carol, first.ID)
+		if count := unreadCount(); count != 2 {
+			t.Errorf("Expected 2 unread, got %v", count)
+		}
+
+		// 他人の通知は既読にできない
+		resp = execute(bob, `mutation { markNotificationsRead }`, nil)
+		if got := resp.Data.(map[string]interface{})["markNotificationsRead"]; got != float64(0) {
+			t.Errorf("Expected 0 for other user, got %v", got)
+		}
+
+		resp = execute(alice, `mutation { markNotificationsRead }`, nil)
+		if got := resp.Data.(map[string]interface{})["markNotificationsRead"]; got != float64(2) {
+			t.Errorf("Expected 2 marked, got %v", got)
+		}
+		if count := unreadCount(); count != 0 {
+			t.Errorf("Expected 0 unread, got %v", count)
+		}
+	})
+
+	t.Run("カーソルでページングできる", func(t *testing.T) {
+		reset()
+		for i := 0; i < 5; i++ {
+			post := testutil.CreateTestPost(t, db, alice.ID, fmt.Sprintf("post %d", i))
+			like(bob, post.ID)
+		}
+
+		seen := map[json.Number]bool{}
+		var cursor interface{}
+		for page := 0; page < 3; page++ {
+			result := listNotifications(alice, map[string]interface{}{"limit": 2, "cursor": cursor})
+			for _, n := range result.Notifications.Notifications {
+				if seen[n.ID] {
+					t.Errorf("Notification %s returned twice", n.ID)
+				}
+				seen[n.ID] = true
+			}
+			if !result.Notifications.HasNextPage {
+				break
+			}
+			cursor = *result.Notifications.Cursor
+		}
+		if len(seen) != 5 {
+			t.Errorf("Expected 5 notifications across pages, got %d", len(seen))
+		}
+
+		resp := execute(alice, `query { notifications(cursor: $cursor) { hasNextPage } }`, map[string]interface{}{"cursor": "invalid"})
+		if resp.Errors == nil {
+			t.Error("Expected error for invalid cursor")
+		}
+	})
+
+	t.Run("未認証の場合", func(t *testing.T) {
+		reset()
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { notifications { hasNextPage } }`})
+		if resp.Errors == nil {
+			t.Error("Expected error for unauthenticated notifications")
+		}
+
+		resp = executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { me { id } }`})
+		if resp.Errors != nil || resp.Data.(map[string]interface{})["me"] != nil {
+			t.Errorf("Expected null me, got %+v", resp)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"sns-server/internal/models"
+)
+
+// 通知に表示する行為者の人数（それ以外は「他N人」にまとめる）
+const notificationActorPreview = 3
+
+// notificationView はAPIで返す通知です
+type notificationView struct {
+	models.Notification
+	Actors  []models.User `json:"actors"`
+	Read    bool          `json:"read"`
+	Message string        `json:"message"`
+}
+
+// meView はログイン中のユーザー自身の情報です
+type meView struct {
+	models.User
+	UnreadNotificationCount int64 `json:"unreadNotificationCount"`
+}
+
+// notifyLike はいいねされた投稿の作成者に通知します
+func (s *Server) notifyLike(actorID uint, post *models.Post) {
+	if err := models.RecordNotification(s.DB, post.AuthorID, actorID, models.NotificationTypeLike, &post.ID); err != nil {
+		log.Printf("Failed to record like notification: %v", err)
+	}
+}
+
+// notifyPostCreated はリプライ先の投稿の作成者と、メンションされたユーザーに通知します
+// リプライ先の作成者をメンションしている場合はリプライの通知だけを送ります
+// 投稿を見られないユーザー（非公開アカウントのフォロワー以外・ブロック）には通知しない
+func (s *Server) notifyPostCreated(post *models.Post, parent *models.Post) {
+	notified := map[uint]bool{post.AuthorID: true}
+
+	if parent != nil && s.canViewUserContent(parent.AuthorID, post.AuthorID) {
+		if err := models.RecordNotification(s.DB, parent.AuthorID, post.AuthorID, models.NotificationTypeReply, &post.ID); err != nil {
+			log.Printf("Failed to record reply notification: %v", err)
+		}
+		notified[parent.AuthorID] = true
+	}
+
+	// メンションは投稿の作成時にユーザーに解決済み
+	for _, entity := range post.Entities {
+		if entity.Type != models.PostEntityTypeMention || entity.UserID == nil {
+			continue
+		}
+		userID := *entity.UserID
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+		if !s.canViewUserContent(userID, post.AuthorID) {
+			continue
+		}
+		if err := models.RecordNotification(s.DB, userID, post.AuthorID, models.NotificationTypeMention, &post.ID); err != nil {
+			log.Printf("Failed to record mention notification: %v", err)
+		}
+	}
+}
+
+func (s *Server) handleMeQuery(ctx context.Context) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		// 未認証の場合はnull
+		return dataResponse("me", nil)
+	}
+
+	return dataResponse("me", meView{
+		User:                    *user,
+		UnreadNotificationCount: models.UnreadNotificationCount(s.DB, user.ID),
+	})
+}
+
+func (s *Server) handleNotificationsQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	limit := pageSize(variables)
+	query := s.DB.Preload("Post").Preload("Post.Author").
+		Where("user_id = ?", user.ID).
+		Order("updated_at DESC, id DESC").
+		Limit(limit + 1)
+
+	if cursor := getString(variables, "cursor"); cursor != "" {
+		updatedAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		query = query.Where("updated_at < ? OR (updated_at = ? AND id < ?)", updatedAt, updatedAt, id)
+	}
+
+	var notifications []models.Notification
+	if err := query.Find(&notifications).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	hasNextPage := len(notifications) > limit
+	if hasNextPage {
+		notifications = notifications[:limit]
+	}
+
+	// 通知の後にブロック・非公開になって見られなくなった投稿はnullにする
+	visible := map[uint]bool{}
+	for i := range notifications {
+		post := notifications[i].Post
+		if post == nil {
+			continue
+		}
+		canView, ok := visible[post.AuthorID]
+		if !ok {
+			canView = models.CanViewUserContent(s.DB, user.ID, &post.Author)
+			visible[post.AuthorID] = canView
+		}
+		if !canView {
+			notifications[i].Post = nil
+		}
+	}
+
+	views := make([]notificationView, 0, len(notifications))
+	for _, notification := range notifications {
+		view, err := s.buildNotificationView(notification)
+		if err != nil {
+			return errorResponse(fmt.Sprintf("Database error: %v", err))
+		}
+		views = append(views, view)
+	}
+
+	var nextCursor *string
+	if hasNextPage {
+		last := notifications[len(notifications)-1]
+		cursor := encodeCursor(last.UpdatedAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	return dataResponse("notifications", map[string]interface{}{
+		"notifications": views,
+		"hasNextPage":   hasNextPage,
+		"cursor":        nextCursor,
+	})
+}
+
+// buildNotificationView は新しい順の行為者と文面を付けた通知を作成します
+func (s *Server) buildNotificationView(notification models.Notification) (notificationView, error) {
+	var actors []models.NotificationActor
+	err := s.DB.Preload("Actor").
+		Where("notification_id = ?", notification.ID).
+		Order("created_at DESC").
+		Limit(notificationActorPreview).
+		Find(&actors).Error
+	if err != nil {
+		return notificationView{}, err
+	}
+
+	users := make([]models.User, 0, len(actors))
+	names := make([]string, 0, len(actors))
+	for _, actor := range actors {
+		users = append(users, actor.Actor)
+		names = append(names, actor.Actor.Name)
+	}
+
+	return notificationView{
+		Notification: notification,
+		Actors:       users,
+		Read:         notification.ReadAt != nil,
+		Message:      models.NotificationMessage(notification.Type, names, notification.ActorCount),
+	}, nil
+}
+
+func (s *Server) handleMarkNotificationsReadMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	// idsを省略した場合は全て既読にする
+	ids := getUintList(variables, "ids")
+	if variables["ids"] != nil && len(ids) == 0 {
+		return dataResponse("markNotificationsRead", 0)
+	}
+
+	updated, err := models.MarkNotificationsRead(s.DB, user.ID, ids)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to mark notifications as read: %v", err))
+	}
+
+	return dataResponse("markNotificationsRead", updated)
+}
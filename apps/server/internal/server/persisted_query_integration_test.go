@@ -0,0 +1,93 @@
+package server_test
+
+import (
+	"testing"
+
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/persisted"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func persistedQueryExtension(query string) map[string]interface{} {
+	return map[string]interface{}{
+		"persistedQuery": map[string]interface{}{
+			"version":    1,
+			"sha256Hash": models.HashQuery(query),
+		},
+	}
+}
+
+func errorCodeOf(resp GraphQLResponse) interface{} {
+	if len(resp.Errors) == 0 {
+		return nil
+	}
+	return resp.Errors[0].Extensions["code"]
+}
+
+func TestPersistedQueryIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+	testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+
+	query := `query Users { users { id username } }`
+
+	t.Run("APQ: 未登録ハッシュ→本文付きで登録→ハッシュのみで実行", func(t *testing.T) {
+		resolver, _ := persisted.NewResolver(persisted.NewDBStore(db), persisted.ModeAPQ)
+		srv := &server.Server{DB: db, Config: config.LoadTest(), PersistedQueries: resolver}
+
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Extensions: persistedQueryExtension(query)})
+		if errorCodeOf(resp) != persisted.CodeNotFound {
+			t.Fatalf("Expected %s, got %+v", persisted.CodeNotFound, resp.Errors)
+		}
+
+		resp = executeGraphQLRequest(t, srv, GraphQLRequest{Query: query, Extensions: persistedQueryExtension(query)})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		resp = executeGraphQLRequest(t, srv, GraphQLRequest{Extensions: persistedQueryExtension(query)})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		users := resp.Data.(map[string]interface{})["users"].([]interface{})
+		if len(users) != 1 {
+			t.Errorf("Expected 1 user, got %d", len(users))
+		}
+	})
+
+	t.Run("APQ: ハッシュが一致しない場合は登録しない", func(t *testing.T) {
+		resolver, _ := persisted.NewResolver(persisted.NewDBStore(db), persisted.ModeAPQ)
+		srv := &server.Server{DB: db, Config: config.LoadTest(), PersistedQueries: resolver}
+
+		other := `{ posts { id } }`
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: other, Extensions: persistedQueryExtension(query + " ")})
+		if errorCodeOf(resp) != persisted.CodeHashMismatch {
+			t.Errorf("Expected %s, got %+v", persisted.CodeHashMismatch, resp.Errors)
+		}
+	})
+
+	t.Run("許可リスト: マニフェストのクエリのみ実行できる", func(t *testing.T) {
+		store := persisted.NewDBStore(db)
+		entries, err := persisted.ParseManifest([]byte(`{"` + models.HashQuery(query) + `":"` + query + `"}`))
+		if err != nil {
+			t.Fatalf("Failed to parse manifest: %v", err)
+		}
+		if _, err := store.ImportManifest(entries, true); err != nil {
+			t.Fatalf("Failed to import manifest: %v", err)
+		}
+
+		resolver, _ := persisted.NewResolver(store, persisted.ModeAllowlist)
+		srv := &server.Server{DB: db, Config: config.LoadTest(), PersistedQueries: resolver}
+
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Extensions: persistedQueryExtension(query)})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		resp = executeGraphQLRequest(t, srv, GraphQLRequest{Query: `{ posts { id } }`})
+		if errorCodeOf(resp) != persisted.CodeNotAllowed {
+			t.Errorf("Expected %s, got %+v", persisted.CodeNotAllowed, resp.Errors)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"sns-server/internal/models"
+)
+
+// parsePollInput は投稿に付ける投票の入力を読み込みます（指定がない場合はnil）
+func parsePollInput(input map[string]interface{}, now time.Time) (*models.Poll, error) {
+	pollInput, ok := input["poll"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	list, _ := pollInput["options"].([]interface{})
+	options := make([]string, 0, len(list))
+	for _, item := range list {
+		text, ok := item.(string)
+		if !ok {
+			return nil, errors.New("Invalid poll options")
+		}
+		options = append(options, text)
+	}
+
+	expiresAt, err := getTime(pollInput, "expiresAt")
+	if err != nil {
+		return nil, errors.New("Invalid poll expiresAt: must be an RFC 3339 timestamp")
+	}
+	if expiresAt == nil {
+		return nil, errors.New("Poll expiresAt is required")
+	}
+	return models.NewPoll(options, *expiresAt, now)
+}
+
+func (s *Server) handleVotePollMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	postID := getUint(variables, "postId")
+	optionID := getUint(variables, "optionId")
+	if postID == 0 || optionID == 0 {
+		return errorResponse("Post ID and option ID are required")
+	}
+
+	var post models.Post
+	if err := s.DB.First(&post, postID).Error; err != nil {
+		return errorResponse(errPostNotFound.Error())
+	}
+	if err := s.checkPostAccess(user.ID, &post); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var poll models.Poll
+	if err := s.DB.Where("post_id = ?", post.ID).First(&poll).Error; err != nil {
+		return errorResponse("Post has no poll")
+	}
+
+	// 期限を過ぎた投票と投票済みのユーザーの投票は受け付けない
+	voted, err := models.CastPollVote(s.DB, user.ID, poll.ID, optionID, time.Now())
+	switch {
+	case errors.Is(err, models.ErrPollExpired):
+		return errorResponse("Poll has expired")
+	case errors.Is(err, models.ErrPollOptionInvalid):
+		return errorResponse("Invalid poll option")
+	case err != nil:
+		return errorResponse(fmt.Sprintf("Failed to vote: %v", err))
+	case !voted:
+		return errorResponse("You have already voted in this poll")
+	}
+
+	if err := s.postQuery().First(&post, post.ID).Error; err != nil {
+		return errorResponse(errPostNotFound.Error())
+	}
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("votePoll", view)
+}
@@ -0,0 +1,162 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+// pollResult はレスポンスの投票です
+type pollResult struct {
+	ID             string `json:"id"`
+	IsExpired      bool   `json:"isExpired"`
+	ResultsVisible bool   `json:"resultsVisible"`
+	TotalVotes     *int64 `json:"totalVotes"`
+	ViewerOptionID *uint  `json:"viewerOptionId"`
+	Options        []struct {
+		ID        uint   `json:"id"`
+		Text      string `json:"text"`
+		VoteCount *int64 `json:"voteCount"`
+	} `json:"options"`
+}
+
+func TestPollsIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	pollOf := func(resp GraphQLResponse, key string) *pollResult {
+		t.Helper()
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		var post struct {
+			Poll *pollResult `json:"poll"`
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})[key])
+		json.Unmarshal(data, &post)
+		return post.Poll
+	}
+	createPoll := func(options []interface{}, expiresAt time.Time) (GraphQLResponse, uint) {
+		t.Helper()
+		resp := execute(alice, `mutation { createPost(input: $input) { id poll { id options { id text } } } }`, map[string]interface{}{
+			"input": map[string]interface{}{
+				"content": "どちらにしますか",
+				"poll":    map[string]interface{}{"options": options, "expiresAt": expiresAt.Format(time.RFC3339)},
+			},
+		})
+		var postID uint
+		if resp.Errors == nil {
+			var post struct {
+				ID uint `json:"id"`
+			}
+			data, _ := json.Marshal(resp.Data.(map[string]interface{})["createPost"])
+			json.Unmarshal(data, &post)
+			postID = post.ID
+		}
+		return resp, postID
+	}
+	viewPost := func(user *models.User, postID uint) *pollResult {
+		t.Helper()
+		resp := execute(user, `query { post(id: $id) { id poll { id } } }`, map[string]interface{}{"id": fmt.Sprint(postID)})
+		return pollOf(resp, "post")
+	}
+	vote := func(user *models.User, postID, optionID uint) GraphQLResponse {
+		t.Helper()
+		return execute(user, `mutation { votePoll(postId: $postId, optionId: $optionId) { id poll { id } } }`, map[string]interface{}{
+			"postId":   fmt.Sprint(postID),
+			"optionId": fmt.Sprint(optionID),
+		})
+	}
+
+	t.Run("不正な投票は作成できない", func(t *testing.T) {
+		if resp, _ := createPoll([]interface{}{"only"}, time.Now().Add(time.Hour)); resp.Errors == nil {
+			t.Error("Expected error for a single option")
+		}
+		if resp, _ := createPoll([]interface{}{"yes", "no"}, time.Now().Add(8*24*time.Hour)); resp.Errors == nil {
+			t.Error("Expected error for expiry beyond 7 days")
+		}
+	})
+
+	resp, postID := createPoll([]interface{}{"yes", "no", "maybe"}, time.Now().Add(time.Hour))
+	created := pollOf(resp, "createPost")
+	if created == nil || len(created.Options) != 3 || created.Options[0].Text != "yes" {
+		t.Fatalf("Expected poll with 3 options, got %+v", created)
+	}
+	yes, no := created.Options[0].ID, created.Options[1].ID
+
+	t.Run("投票するまで票数は見えない", func(t *testing.T) {
+		poll := viewPost(bob, postID)
+		if poll.ResultsVisible || poll.TotalVotes != nil || poll.ViewerOptionID != nil || poll.Options[0].VoteCount != nil {
+			t.Errorf("Expected results to be hidden, got %+v", poll)
+		}
+	})
+
+	t.Run("投票すると票数と自分の選択が見える", func(t *testing.T) {
+		poll := pollOf(vote(bob, postID, yes), "votePoll")
+		if !poll.ResultsVisible || poll.TotalVotes == nil || *poll.TotalVotes != 1 {
+			t.Fatalf("Expected results to be visible, got %+v", poll)
+		}
+		if poll.ViewerOptionID == nil || *poll.ViewerOptionID != yes || *poll.Options[0].VoteCount != 1 || *poll.Options[1].VoteCount != 0 {
+			t.Errorf("Expected bob's vote to be counted, got %+v", poll)
+		}
+
+		// 投票していないユーザーには引き続き見えない
+		if poll := viewPost(alice, postID); poll.ResultsVisible {
+			t.Errorf("Expected results to be hidden for alice, got %+v", poll)
+		}
+	})
+
+	t.Run("同じ投票には1回しか投票できない", func(t *testing.T) {
+		if resp := vote(bob, postID, no); resp.Errors == nil {
+			t.Error("Expected error when voting twice")
+		}
+		var votes int64
+		db.Model(&models.PollVote{}).Where("user_id = ?", bob.ID).Count(&votes)
+		if votes != 1 {
+			t.Errorf("Expected 1 vote, got %d", votes)
+		}
+	})
+
+	t.Run("投票が付いていない投稿・未認証はエラー", func(t *testing.T) {
+		plain := testutil.CreateTestPost(t, db, alice.ID, "no poll")
+		if resp := vote(bob, plain.ID, yes); resp.Errors == nil {
+			t.Error("Expected error for a post without a poll")
+		}
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query:     `mutation { votePoll(postId: $postId, optionId: $optionId) { id } }`,
+			Variables: map[string]interface{}{"postId": fmt.Sprint(postID), "optionId": fmt.Sprint(no)},
+		})
+		if resp.Errors == nil {
+			t.Error("Expected error for unauthenticated request")
+		}
+	})
+
+	t.Run("期限を過ぎると投票できず、票数は誰にでも見える", func(t *testing.T) {
+		db.Model(&models.Poll{}).Where("post_id = ?", postID).UpdateColumn("expires_at", time.Now().Add(-time.Second))
+
+		if resp := vote(alice, postID, no); resp.Errors == nil {
+			t.Error("Expected error when voting after expiry")
+		}
+		poll := viewPost(alice, postID)
+		if !poll.IsExpired || !poll.ResultsVisible || poll.ViewerOptionID != nil || *poll.TotalVotes != 1 {
+			t.Errorf("Expected expired poll with visible results, got %+v", poll)
+		}
+	})
+}
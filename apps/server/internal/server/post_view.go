@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+// postView はAPIで返す投稿です（閲覧中のユーザーによって変わるフィールドを含む）
+type postView struct {
+	models.Post
+	// タイムラインでリポストにより表示された場合のリポストしたユーザー
+	RepostedBy *models.User `json:"repostedBy"`
+	// 閲覧中のユーザーがいいね・ブックマークしているか（未認証の場合はfalse）
+	IsLikedByUser bool `json:"isLikedByUser"`
+	IsBookmarked  bool `json:"isBookmarked"`
+	// 投票（投票が付いていない場合はnull）
+	Poll *pollView `json:"poll"`
+}
+
+// pollView はAPIで返す投票です
+// 閲覧中のユーザーが投票するか期限を過ぎるまで、票数はnullにする
+type pollView struct {
+	ID             uint             `json:"id"`
+	ExpiresAt      time.Time        `json:"expiresAt"`
+	IsExpired      bool             `json:"isExpired"`
+	ResultsVisible bool             `json:"resultsVisible"`
+	TotalVotes     *int64           `json:"totalVotes"`
+	Options        []pollOptionView `json:"options"`
+	ViewerOptionID *uint            `json:"viewerOptionId"` // 閲覧中のユーザーが投票した選択肢（未投票の場合はnull）
+}
+
+type pollOptionView struct {
+	ID        uint   `json:"id"`
+	Text      string `json:"text"`
+	VoteCount *int64 `json:"voteCount"`
+}
+
+// newPollView は閲覧中のユーザーの投票状況に応じて投票を返します（votedOptionIDは未投票の場合0）
+func newPollView(poll *models.Poll, votedOptionID uint, now time.Time) *pollView {
+	view := &pollView{
+		ID:        poll.ID,
+		ExpiresAt: poll.ExpiresAt,
+		IsExpired: poll.IsExpired(now),
+		Options:   make([]pollOptionView, 0, len(poll.Options)),
+	}
+	if votedOptionID != 0 {
+		view.ViewerOptionID = &votedOptionID
+	}
+	view.ResultsVisible = view.IsExpired || view.ViewerOptionID != nil
+
+	var total int64
+	for _, option := range poll.Options {
+		optionView := pollOptionView{ID: option.ID, Text: option.Text}
+		if view.ResultsVisible {
+			count := option.VoteCount
+			optionView.VoteCount = &count
+			total += count
+		}
+		view.Options = append(view.Options, optionView)
+	}
+	if view.ResultsVisible {
+		view.TotalVotes = &total
+	}
+	return view
+}
+
+var errPostNotFound = errors.New("Post not found")
+
+// checkPostAccess はユーザーが投稿にいいね・リプライなどの操作をできるかチェックします
+// ブロックの関係にある場合はエラー、見られない非公開アカウントの投稿は存在しないものとして扱う
+func (s *Server) checkPostAccess(userID uint, post *models.Post) error {
+	if err := s.checkNotBlocked(userID, post.AuthorID); err != nil {
+		return err
+	}
+	if !s.canViewUserContent(userID, post.AuthorID) {
+		return errPostNotFound
+	}
+	return nil
+}
+
+// canViewUserContent は閲覧者がユーザーの投稿を見られるかチェックします
+func (s *Server) canViewUserContent(viewerID, userID uint) bool {
+	var user models.User
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		return false
+	}
+	return models.CanViewUserContent(s.DB, viewerID, &user)
+}
+
+// isPublicUser はユーザーが公開アカウントかチェックします
+func (s *Server) isPublicUser(userID uint) bool {
+	var user models.User
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		return false
+	}
+	return !user.IsPrivate
+}
+
+// postQuery は投稿の表示に必要なリレーションをプリロードしたクエリを返します
+func (s *Server) postQuery() *gorm.DB {
+	return models.PreloadPostEntities(s.DB.Preload("Author")).
+		Preload("QuotedPost").
+		Preload("QuotedPost.Author")
+}
+
+// buildPostViews は投稿に閲覧中のユーザーのいいね・ブックマーク状態と投票をまとめて付けます
+func (s *Server) buildPostViews(ctx context.Context, posts []models.Post) ([]postView, error) {
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	liked, err := models.LikedPostIDs(s.DB, viewerID(ctx), ids)
+	if err != nil {
+		return nil, err
+	}
+	bookmarked, err := models.BookmarkedPostIDs(s.DB, viewerID(ctx), ids)
+	if err != nil {
+		return nil, err
+	}
+	polls, err := models.PollsForPosts(s.DB, ids)
+	if err != nil {
+		return nil, err
+	}
+	pollIDs := make([]uint, 0, len(polls))
+	for _, poll := range polls {
+		pollIDs = append(pollIDs, poll.ID)
+	}
+	voted, err := models.VotedOptionIDs(s.DB, viewerID(ctx), pollIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	canView := map[uint]bool{} // 引用元の作成者ごとの閲覧可否
+	views := make([]postView, 0, len(posts))
+	for _, post := range posts {
+		// 引用した後にブロック・非公開になり見られなくなった引用元は返さない
+		if quoted := post.QuotedPost; quoted != nil {
+			visible, ok := canView[quoted.AuthorID]
+			if !ok {
+				visible = s.canViewUserContent(viewerID(ctx), quoted.AuthorID)
+				canView[quoted.AuthorID] = visible
+			}
+			if !visible {
+				post.QuotedPost = nil
+			}
+		}
+
+		view := postView{
+			Post:          post,
+			IsLikedByUser: liked[post.ID],
+			IsBookmarked:  bookmarked[post.ID],
+		}
+		if poll, ok := polls[post.ID]; ok {
+			view.Poll = newPollView(poll, voted[poll.ID], now)
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// buildPostView は1件の投稿に閲覧中のユーザーのいいね・ブックマーク状態を付けます
+func (s *Server) buildPostView(ctx context.Context, post models.Post) (postView, error) {
+	views, err := s.buildPostViews(ctx, []models.Post{post})
+	if err != nil {
+		return postView{}, err
+	}
+	return views[0], nil
+}
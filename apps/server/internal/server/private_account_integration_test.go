@@ -0,0 +1,206 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestPrivateAccountIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	owner := testutil.CreateTestUser(t, db, "owner", "owner@example.com", "Owner")
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	carol := testutil.CreateTestUser(t, db, "carol", "carol@example.com", "Carol")
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	decode := func(resp GraphQLResponse, field string, v interface{}) {
+		t.Helper()
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})[field])
+		json.Unmarshal(data, v)
+	}
+	userVars := func(user *models.User) map[string]interface{} {
+		return map[string]interface{}{"userId": fmt.Sprint(user.ID)}
+	}
+	canSeeSecret := func(user *models.User) bool {
+		t.Helper()
+		var posts []struct {
+			Content string `json:"content"`
+		}
+		decode(execute(user, `query { posts { id content } }`, nil), "posts", &posts)
+		for _, post := range posts {
+			if post.Content == "secret" {
+				return true
+			}
+		}
+		return false
+	}
+	follow := func(user, target *models.User) (isFollowing, requested bool) {
+		t.Helper()
+		var result struct {
+			IsFollowing     bool `json:"isFollowing"`
+			FollowRequested bool `json:"followRequested"`
+		}
+		decode(execute(user, `mutation { followUser(userId: $userId) { id isFollowing followRequested } }`, userVars(target)), "followUser", &result)
+		return result.IsFollowing, result.FollowRequested
+	}
+
+	var isPrivate struct {
+		IsPrivate bool `json:"isPrivate"`
+	}
+	decode(execute(owner, `mutation { updateProfile(input: $input) { id isPrivate } }`, map[string]interface{}{
+		"input": map[string]interface{}{"isPrivate": true},
+	}), "updateProfile", &isPrivate)
+	if !isPrivate.IsPrivate {
+		t.Fatal("Expected account to be private")
+	}
+	secret := testutil.CreateTestPost(t, db, owner.ID, "secret")
+
+	t.Run("非公開アカウントへのフォローはリクエストになる", func(t *testing.T) {
+		isFollowing, requested := follow(alice, owner)
+		if isFollowing || !requested {
+			t.Errorf("Expected pending request, got following=%v requested=%v", isFollowing, requested)
+		}
+		if models.IsFollowing(db, alice.ID, owner.ID) {
+			t.Error("Expected no follow before approval")
+		}
+
+		var requests []struct {
+			Requester struct {
+				Username string `json:"username"`
+			} `json:"requester"`
+		}
+		decode(execute(owner, `query { followRequests { id requester { username } createdAt } }`, nil), "followRequests", &requests)
+		if len(requests) != 1 || requests[0].Requester.Username != "alice" {
+			t.Errorf("Expected request from alice, got %+v", requests)
+		}
+
+		var notifications models.Notification
+		db.Where("user_id = ? AND type = ?", owner.ID, models.NotificationTypeFollowRequest).First(&notifications)
+		if notifications.ID == 0 {
+			t.Error("Expected follow request notification")
+		}
+	})
+
+	t.Run("承認されていないユーザーは投稿とフォロー一覧を見られない", func(t *testing.T) {
+		for _, user := range []*models.User{alice, bob} {
+			if canSeeSecret(user) {
+				t.Errorf("Expected %s not to see private posts", user.Username)
+			}
+		}
+		if !canSeeSecret(owner) {
+			t.Error("Expected owner to see own posts")
+		}
+
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { post(id: $id) { content } }`, Variables: map[string]interface{}{"id": fmt.Sprint(secret.ID)}})
+		if resp.Errors != nil || resp.Data.(map[string]interface{})["post"] != nil {
+			t.Errorf("Expected null post for anonymous viewer, got %+v", resp)
+		}
+
+		for _, field := range []string{"followers", "following"} {
+			query := fmt.Sprintf(`query { %s(userId: $userId) { id username } }`, field)
+			if resp := execute(bob, query, userVars(owner)); resp.Errors == nil {
+				t.Errorf("Expected %s of private account to be hidden", field)
+			}
+		}
+
+		resp = execute(bob, `mutation { likePost(postId: $postId) { id } }`, map[string]interface{}{"postId": secret.ID})
+		if resp.Errors == nil {
+			t.Error("Expected error when liking an invisible post")
+		}
+	})
+
+	t.Run("承認するとフォローになり投稿を見られる", func(t *testing.T) {
+		if resp := execute(owner, `mutation { approveFollowRequest(userId: $userId) { id } }`, userVars(alice)); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if !models.IsFollowing(db, alice.ID, owner.ID) {
+			t.Fatal("Expected alice to follow owner after approval")
+		}
+		if !canSeeSecret(alice) {
+			t.Error("Expected approved follower to see private posts")
+		}
+
+		var followers []struct {
+			Username string `json:"username"`
+		}
+		decode(execute(alice, `query { followers(userId: $userId) { id username } }`, userVars(owner)), "followers", &followers)
+		if len(followers) != 1 || followers[0].Username != "alice" {
+			t.Errorf("Expected alice in followers, got %+v", followers)
+		}
+
+		// 承認済みのフォロワーでもリポスト・引用はできない
+		if resp := execute(alice, `mutation { repost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(secret.ID)}); resp.Errors == nil {
+			t.Error("Expected error when reposting a private post")
+		}
+		resp := execute(alice, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+			"input": map[string]interface{}{"content": "quote", "quotedPostId": fmt.Sprint(secret.ID)},
+		})
+		if resp.Errors == nil {
+			t.Error("Expected error when quoting a private post")
+		}
+
+		if resp := execute(owner, `mutation { approveFollowRequest(userId: $userId) { id } }`, userVars(alice)); resp.Errors == nil {
+			t.Error("Expected error when request does not exist")
+		}
+	})
+
+	t.Run("拒否とリクエストの取り消し", func(t *testing.T) {
+		follow(bob, owner)
+		if resp := execute(owner, `mutation { rejectFollowRequest(userId: $userId) { id } }`, userVars(bob)); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		if models.IsFollowing(db, bob.ID, owner.ID) || canSeeSecret(bob) {
+			t.Error("Expected rejected user not to follow")
+		}
+
+		follow(carol, owner)
+		if resp := execute(carol, `mutation { unfollowUser(userId: $userId) { id } }`, userVars(owner)); resp.Errors != nil {
+			t.Fatalf("Expected unfollow to cancel request, got %v", resp.Errors)
+		}
+		var count int64
+		db.Model(&models.FollowRequest{}).Where("requester_id = ?", carol.ID).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected request to be cancelled, got %d", count)
+		}
+	})
+
+	t.Run("公開アカウントに戻すとリクエストを全て承認する", func(t *testing.T) {
+		follow(bob, owner)
+		decode(execute(owner, `mutation { updateProfile(input: $input) { id isPrivate } }`, map[string]interface{}{
+			"input": map[string]interface{}{"isPrivate": false},
+		}), "updateProfile", &isPrivate)
+		if isPrivate.IsPrivate {
+			t.Fatal("Expected account to be public")
+		}
+		if !models.IsFollowing(db, bob.ID, owner.ID) {
+			t.Error("Expected pending request to be approved")
+		}
+		if !canSeeSecret(carol) {
+			t.Error("Expected public posts to be visible")
+		}
+
+		isFollowing, requested := follow(carol, owner)
+		if !isFollowing || requested {
+			t.Errorf("Expected direct follow for public account, got following=%v requested=%v", isFollowing, requested)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"sns-server/internal/models"
+	"sns-server/internal/timeline"
+)
+
+func (s *Server) handleRepostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	postID := getUint(variables, "postId")
+	if postID == 0 {
+		return errorResponse("Post ID is required")
+	}
+
+	var post models.Post
+	if err := s.postQuery().First(&post, postID).Error; err != nil {
+		return errorResponse("Post not found")
+	}
+	if err := s.checkPostAccess(user.ID, &post); err != nil {
+		return errorResponse(err.Error())
+	}
+	// 非公開アカウントの投稿は本人以外リポストできない
+	if post.Author.IsPrivate && post.AuthorID != user.ID {
+		return errorResponse("Cannot repost a private account's post")
+	}
+
+	// 既にリポスト済みの場合はそのまま返す
+	repost := models.Repost{UserID: user.ID, PostID: post.ID}
+	result := s.DB.Where(repost).FirstOrCreate(&repost)
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Failed to repost: %v", result.Error))
+	}
+	if result.RowsAffected > 0 {
+		s.enqueueTimelineJob(timeline.FanoutJobType, timeline.FanoutPayload{RepostID: repost.ID})
+	}
+	// 更新したリポスト数を返す
+	if err := s.postQuery().First(&post, post.ID).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("repost", view)
+}
+
+func (s *Server) handleUnrepostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	postID := getUint(variables, "postId")
+	if postID == 0 {
+		return errorResponse("Post ID is required")
+	}
+
+	var post models.Post
+	if err := s.postQuery().First(&post, postID).Error; err != nil {
+		return errorResponse("Post not found")
+	}
+
+	removed, err := models.RemoveRepost(s.DB, user.ID, post.ID)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to unrepost: %v", err))
+	}
+	if !removed {
+		return errorResponse("Repost not found")
+	}
+	if err := s.postQuery().First(&post, post.ID).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("unrepost", view)
+}
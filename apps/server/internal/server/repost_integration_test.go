@@ -0,0 +1,235 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+	"sns-server/internal/timeline"
+)
+
+type timelinePostResponse struct {
+	ID          json.Number `json:"id"`
+	Content     string      `json:"content"`
+	RepostCount int         `json:"repostCount"`
+	RepostedBy  *struct {
+		Username string `json:"username"`
+	} `json:"repostedBy"`
+	QuotedPost *struct {
+		Content string `json:"content"`
+	} `json:"quotedPost"`
+}
+
+func TestRepostIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+	fanout := timeline.NewFanout(db, timeline.PolicyFromConfig(cfg))
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	carol := testutil.CreateTestUser(t, db, "carol", "carol@example.com", "Carol")
+	dave := testutil.CreateTestUser(t, db, "dave", "dave@example.com", "Dave")
+	for _, followee := range []*models.User{bob, carol} {
+		db.Create(&models.Follow{FollowerID: alice.ID, FolloweeID: followee.ID})
+	}
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	decode := func(resp GraphQLResponse, field string, v interface{}) {
+		t.Helper()
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})[field])
+		json.Unmarshal(data, v)
+	}
+	repost := func(user *models.User, postID uint) GraphQLResponse {
+		return execute(user, `mutation { repost(postId: $postId) { id repostCount } }`, map[string]interface{}{"postId": fmt.Sprint(postID)})
+	}
+	homeTimeline := func(user *models.User, variables map[string]interface{}) ([]timelinePostResponse, bool, *string) {
+		t.Helper()
+		// 投稿・フォローを直接作成しているため、読み込む前にタイムラインを作り直す
+		if _, err := fanout.Rebuild(user.ID); err != nil {
+			t.Fatalf("Failed to rebuild timeline: %v", err)
+		}
+		var result struct {
+			Posts       []timelinePostResponse `json:"posts"`
+			HasNextPage bool                   `json:"hasNextPage"`
+			Cursor      *string                `json:"cursor"`
+		}
+		resp := execute(user, `query { timeline(limit: $limit, cursor: $cursor) { posts { id content repostCount repostedBy { username } quotedPost { content } } hasNextPage cursor } }`, variables)
+		decode(resp, "timeline", &result)
+		return result.Posts, result.HasNextPage, result.Cursor
+	}
+
+	davePost := testutil.CreateTestPost(t, db, dave.ID, "dave's post")
+
+	t.Run("リポストとリポスト数", func(t *testing.T) {
+		var post timelinePostResponse
+		decode(repost(bob, davePost.ID), "repost", &post)
+		if post.RepostCount != 1 {
+			t.Errorf("Expected 1 repost, got %d", post.RepostCount)
+		}
+
+		// 同じユーザーの重複したリポストは数えない
+		decode(repost(bob, davePost.ID), "repost", &post)
+		if post.RepostCount != 1 {
+			t.Errorf("Expected repost to be idempotent, got %d", post.RepostCount)
+		}
+
+		decode(repost(carol, davePost.ID), "repost", &post)
+		if post.RepostCount != 2 {
+			t.Errorf("Expected 2 reposts, got %d", post.RepostCount)
+		}
+
+		if resp := repost(bob, 99999); resp.Errors == nil {
+			t.Error("Expected error for missing post")
+		}
+	})
+
+	t.Run("引用投稿", func(t *testing.T) {
+		resp := execute(bob, `mutation { createPost(input: $input) { id quotedPost { content } } }`, map[string]interface{}{
+			"input": map[string]interface{}{"content": "look at this", "quotedPostId": fmt.Sprint(davePost.ID)},
+		})
+		var post timelinePostResponse
+		decode(resp, "createPost", &post)
+		if post.QuotedPost == nil || post.QuotedPost.Content != "dave's post" {
+			t.Errorf("Expected quoted post, got %+v", post.QuotedPost)
+		}
+
+		resp = execute(bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+			"input": map[string]interface{}{"content": "missing", "quotedPostId": "99999"},
+		})
+		if resp.Errors == nil {
+			t.Error("Expected error for missing quoted post")
+		}
+	})
+
+	t.Run("タイムラインは同じ投稿のリポストを1件にまとめる", func(t *testing.T) {
+		testutil.CreateTestPost(t, db, dave.ID, "not followed")
+
+		posts, _, _ := homeTimeline(alice, nil)
+		if len(posts) != 2 {
+			t.Fatalf("Expected 2 posts (quote and repost), got %d: %+v", len(posts), posts)
+		}
+		if posts[0].Content != "look at this" || posts[0].RepostedBy != nil {
+			t.Errorf("Expected bob's quote first, got %+v", posts[0])
+		}
+		if posts[1].ID.String() != fmt.Sprint(davePost.ID) || posts[1].RepostedBy == nil || posts[1].RepostedBy.Username != "carol" {
+			t.Errorf("Expected dave's post reposted by carol, got %+v", posts[1])
+		}
+	})
+
+	t.Run("リポストの取り消し", func(t *testing.T) {
+		var post timelinePostResponse
+		resp := execute(carol, `mutation { unrepost(postId: $postId) { id repostCount } }`, map[string]interface{}{"postId": fmt.Sprint(davePost.ID)})
+		decode(resp, "unrepost", &post)
+		if post.RepostCount != 1 {
+			t.Errorf("Expected 1 repost after unrepost, got %d", post.RepostCount)
+		}
+
+		// 残っているbobのリポストで表示される
+		posts, _, _ := homeTimeline(alice, nil)
+		if len(posts) != 2 || posts[1].RepostedBy == nil || posts[1].RepostedBy.Username != "bob" {
+			t.Errorf("Expected dave's post reposted by bob, got %+v", posts)
+		}
+
+		resp = execute(carol, `mutation { unrepost(postId: $postId) { id } }`, map[string]interface{}{"postId": fmt.Sprint(davePost.ID)})
+		if resp.Errors == nil {
+			t.Error("Expected error when repost does not exist")
+		}
+	})
+
+	t.Run("タイムラインのページング", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			testutil.CreateTestPost(t, db, carol.ID, fmt.Sprintf("carol %d", i))
+		}
+		repost(alice, davePost.ID)
+
+		seen := map[string]bool{}
+		var cursor interface{}
+		for page := 0; page < 5; page++ {
+			posts, hasNext, next := homeTimeline(alice, map[string]interface{}{"limit": 2, "cursor": cursor})
+			for _, post := range posts {
+				if seen[post.ID.String()] {
+					t.Errorf("Post %s returned twice", post.ID)
+				}
+				seen[post.ID.String()] = true
+			}
+			if !hasNext {
+				break
+			}
+			cursor = *next
+		}
+		// carolの投稿3件、bobの引用投稿、リポストされたdaveの投稿
+		if len(seen) != 5 {
+			t.Errorf("Expected 5 posts across pages, got %d", len(seen))
+		}
+	})
+
+	t.Run("未認証のタイムラインはエラー", func(t *testing.T) {
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { timeline { posts { id } } }`})
+		if resp.Errors == nil {
+			t.Error("Expected error for unauthenticated timeline")
+		}
+	})
+
+	t.Run("見られなくなった引用元は返さない", func(t *testing.T) {
+		erin := testutil.CreateTestUser(t, db, "erin", "erin@example.com", "Erin")
+		erinPost := testutil.CreateTestPost(t, db, erin.ID, "erin's post")
+		var quote struct {
+			ID json.Number `json:"id"`
+		}
+		decode(execute(bob, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+			"input": map[string]interface{}{"content": "quoting erin", "quotedPostId": fmt.Sprint(erinPost.ID)},
+		}), "createPost", &quote)
+
+		quotedContent := func(viewer *models.User) *string {
+			t.Helper()
+			var post timelinePostResponse
+			decode(execute(viewer, `query { post(id: $id) { id content quotedPost { content } } }`, map[string]interface{}{"id": quote.ID.String()}), "post", &post)
+			if post.Content != "quoting erin" {
+				t.Fatalf("Expected quoting post to be visible, got %+v", post)
+			}
+			if post.QuotedPost == nil {
+				return nil
+			}
+			return &post.QuotedPost.Content
+		}
+
+		if got := quotedContent(alice); got == nil || *got != "erin's post" {
+			t.Fatalf("Expected quoted post before block, got %v", got)
+		}
+
+		// ブロックした後はブロックの関係にあるユーザーに引用元を返さない
+		if err := models.BlockUser(db, erin.ID, alice.ID); err != nil {
+			t.Fatalf("Failed to block: %v", err)
+		}
+		if got := quotedContent(alice); got != nil {
+			t.Errorf("Expected quoted post to be hidden from blocked user, got %q", *got)
+		}
+		if got := quotedContent(carol); got == nil {
+			t.Error("Expected quoted post to remain visible to others")
+		}
+
+		// 非公開にした後はフォロワー以外に引用元を返さない
+		db.Model(erin).Update("is_private", true)
+		if got := quotedContent(carol); got != nil {
+			t.Errorf("Expected quoted post to be hidden after going private, got %q", *got)
+		}
+		if got := quotedContent(erin); got == nil {
+			t.Error("Expected author to still see their own post")
+		}
+	})
+}
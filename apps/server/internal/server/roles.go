@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sns-server/internal/graph"
+	"sns-server/internal/models"
+)
+
+// authorizeQuery はクエリが選択する@hasRoleのフィールドを、ユーザーの役割で実行できるかチェックします
+// ハンドラーを呼ぶ前に実行し、1つでも満たさないフィールドがあれば操作全体を拒否する
+func (s *Server) authorizeQuery(ctx context.Context, query string) error {
+	requirements, err := graph.RequiredRoles(query)
+	if err != nil || len(requirements) == 0 {
+		return err
+	}
+
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return &graph.QueryError{Code: graph.CodeUnauthenticated, Message: err.Error()}
+	}
+	for _, requirement := range requirements {
+		if !user.HasRole(requirement.Role) {
+			return &graph.QueryError{
+				Code:    graph.CodeForbidden,
+				Message: fmt.Sprintf("%s requires the %s role", requirement.Field, requirement.Role),
+				Extensions: map[string]interface{}{
+					"field":        requirement.Field,
+					"requiredRole": requirement.Role,
+				},
+			}
+		}
+	}
+	return nil
+}
+
+var errForbidden = errors.New("forbidden")
+
+// requireRole は指定の役割以上の認証済みユーザーを返します
+// @hasRoleのチェックに加えて、ハンドラー側でも役割を確認するために使います
+func (s *Server) requireRole(ctx context.Context, role string) (*models.User, error) {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasRole(role) {
+		return nil, errForbidden
+	}
+	return user, nil
+}
+
+func (s *Server) handleSetUserRoleMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	admin, err := s.requireRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	var target models.User
+	if err := s.DB.First(&target, getUint(variables, "userId")).Error; err != nil {
+		return errorResponse("User not found")
+	}
+	// 最後の管理者がいなくならないよう、自分自身の役割は変更できない
+	if target.ID == admin.ID {
+		return errorResponse("Cannot change your own role")
+	}
+
+	if err := models.SetUserRole(s.DB, &target, getString(variables, "role")); err != nil {
+		if errors.Is(err, models.ErrInvalidRole) {
+			return errorResponse("Invalid role")
+		}
+		return errorResponse(fmt.Sprintf("Failed to set role: %v", err))
+	}
+	return dataResponse("setUserRole", target)
+}
@@ -0,0 +1,167 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestRoleIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	admin := testutil.CreateTestUser(t, db, "root_admin", "root_admin@example.com", "Root Admin")
+	moderator := testutil.CreateTestUser(t, db, "mod_user", "mod_user@example.com", "Mod User")
+	member := testutil.CreateTestUser(t, db, "member", "member@example.com", "Member")
+	newcomer := testutil.CreateTestUser(t, db, "newcomer", "newcomer@example.com", "Newcomer")
+	for user, role := range map[*models.User]string{admin: models.RoleAdmin, moderator: models.RoleModerator} {
+		if err := models.SetUserRole(db, user, role); err != nil {
+			t.Fatalf("Failed to set role: %v", err)
+		}
+	}
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+	}
+	setRole := func(actor, target *models.User, role string) GraphQLResponse {
+		t.Helper()
+		return execute(actor, `mutation { setUserRole(userId: $userId, role: $role) { id username role } }`,
+			map[string]interface{}{"userId": fmt.Sprint(target.ID), "role": role})
+	}
+	expectForbidden := func(resp GraphQLResponse, field, role string) {
+		t.Helper()
+		if len(resp.Errors) == 0 {
+			t.Fatalf("Expected forbidden error, got data %v", resp.Data)
+		}
+		err := resp.Errors[0]
+		if err.Extensions["code"] != "FORBIDDEN" || err.Extensions["field"] != field || err.Extensions["requiredRole"] != role {
+			t.Errorf("Expected FORBIDDEN for %s (%s), got %q %v", field, role, err.Message, err.Extensions)
+		}
+		if resp.Data != nil {
+			t.Errorf("Expected no data, got %v", resp.Data)
+		}
+	}
+
+	t.Run("一般ユーザーは制限されたフィールドとミューテーションを実行できない", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			query     string
+			variables map[string]interface{}
+			field     string
+			role      string
+		}{
+			{name: "通報の一覧", query: `query { reports { reports { id } } }`, field: "Query.reports", role: "MODERATOR"},
+			{name: "モデレーションの記録", query: `query { moderationActions(targetType: USER, targetId: "1") { id } }`, field: "Query.moderationActions", role: "MODERATOR"},
+			{name: "通報の状態の変更", query: `mutation { updateReportStatus(id: "1", status: DISMISSED) { id } }`, field: "Mutation.updateReportStatus", role: "MODERATOR"},
+			{name: "投稿の非表示", query: `mutation { hidePost(postId: "1", reason: "spam") { id } }`, field: "Mutation.hidePost", role: "MODERATOR"},
+			{name: "利用停止の解除", query: `mutation { unsuspendUser(userId: "1", reason: "ok") { id } }`, field: "Mutation.unsuspendUser", role: "MODERATOR"},
+			{name: "役割の変更", query: `mutation { setUserRole(userId: $userId, role: ADMIN) { id } }`, variables: map[string]interface{}{"userId": fmt.Sprint(member.ID)}, field: "Mutation.setUserRole", role: "ADMIN"},
+			{name: "フラグメント内の制限されたフィールド", query: `query { me { id } ...Queue } fragment Queue on Query { reports { cursor } }`, field: "Query.reports", role: "MODERATOR"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				expectForbidden(execute(member, tt.query, tt.variables), tt.field, tt.role)
+			})
+		}
+
+		var user models.User
+		db.First(&user, member.ID)
+		if user.Role != models.RoleUser {
+			t.Errorf("Expected role to stay USER, got %s", user.Role)
+		}
+	})
+
+	t.Run("未認証の場合はUNAUTHENTICATED", func(t *testing.T) {
+		resp := executeGraphQLRequest(t, srv, GraphQLRequest{Query: `query { reports { reports { id } } }`})
+		if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != "UNAUTHENTICATED" {
+			t.Errorf("Expected UNAUTHENTICATED error, got %v", resp.Errors)
+		}
+	})
+
+	t.Run("モデレーターは管理者のミューテーションを実行できない", func(t *testing.T) {
+		resp := execute(moderator, `query { reports { hasNextPage } }`, nil)
+		if resp.Errors != nil {
+			t.Errorf("Expected moderator to list reports, got %v", resp.Errors)
+		}
+		expectForbidden(setRole(moderator, member, "MODERATOR"), "Mutation.setUserRole", "ADMIN")
+	})
+
+	t.Run("管理者は役割を変更でき、モデレーターの権限も持つ", func(t *testing.T) {
+		resp := execute(admin, `query { reports { hasNextPage } }`, nil)
+		if resp.Errors != nil {
+			t.Errorf("Expected admin to list reports, got %v", resp.Errors)
+		}
+
+		if resp := execute(newcomer, `query { reports { hasNextPage } }`, nil); len(resp.Errors) == 0 {
+			t.Fatal("Expected newcomer to be rejected before promotion")
+		}
+
+		var promoted struct {
+			Role string `json:"role"`
+		}
+		resp = setRole(admin, newcomer, "MODERATOR")
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})["setUserRole"])
+		json.Unmarshal(data, &promoted)
+		if promoted.Role != "MODERATOR" {
+			t.Errorf("Expected MODERATOR, got %s", promoted.Role)
+		}
+
+		if resp := execute(newcomer, `query { reports { hasNextPage } }`, nil); resp.Errors != nil {
+			t.Errorf("Expected promoted user to list reports, got %v", resp.Errors)
+		}
+
+		// USERに戻すと権限を失う
+		if resp := setRole(admin, newcomer, "USER"); resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		expectForbidden(execute(newcomer, `query { reports { hasNextPage } }`, nil), "Query.reports", "MODERATOR")
+	})
+
+	t.Run("無効な役割の変更", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			target  *models.User
+			role    string
+			message string
+		}{
+			{name: "自分自身の役割", target: admin, role: "USER", message: "Cannot change your own role"},
+			{name: "存在しない役割", target: member, role: "OWNER", message: "Invalid role"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				resp := setRole(admin, tt.target, tt.role)
+				if len(resp.Errors) == 0 || resp.Errors[0].Message != tt.message {
+					t.Errorf("Expected error %q, got %v", tt.message, resp.Errors)
+				}
+			})
+		}
+	})
+
+	t.Run("利用停止された管理者は制限されたフィールドを実行できない", func(t *testing.T) {
+		suspended := testutil.CreateTestUser(t, db, "former_admin", "former_admin@example.com", "Former Admin")
+		models.SetUserRole(db, suspended, models.RoleAdmin)
+		now := time.Now()
+		db.Model(suspended).Update("suspended_at", &now)
+
+		resp := execute(suspended, `query { reports { hasNextPage } }`, nil)
+		if len(resp.Errors) == 0 || resp.Errors[0].Message != "account suspended" {
+			t.Errorf("Expected account suspended error, got %v", resp.Errors)
+		}
+	})
+}
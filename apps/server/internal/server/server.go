@@ -1,24 +1,65 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"regexp"
 	"strconv"
+	"time"
 
 	"gorm.io/gorm"
+	"sns-server/internal/auth"
 	"sns-server/internal/config"
+	"sns-server/internal/graph"
+	"sns-server/internal/idempotency"
+	"sns-server/internal/jobs"
+	"sns-server/internal/lockout"
+	"sns-server/internal/mailer"
 	"sns-server/internal/models"
+	"sns-server/internal/persisted"
+	"sns-server/internal/pubsub"
+	"sns-server/internal/ratelimit"
+	"sns-server/internal/storage"
+	"sns-server/internal/timeline"
 )
 
 type Server struct {
 	DB     *gorm.DB
 	Config *config.Config
+	Mailer mailer.Mailer
+
+	RateLimiter      ratelimit.Limiter
+	LoginGuard       *lockout.Guard
+	PersistedQueries *persisted.Resolver // nilの場合は永続化クエリを使わない
+	PubSub           pubsub.Broker       // nilの場合はサブスクリプションを使わない
+	Storage          storage.Storage     // データエクスポートのファイルの保存先
+	Jobs             *jobs.Queue         // バックグラウンドジョブのキュー（nilの場合はタイムラインへの配信を行わない）
+	Idempotency      *idempotency.Guard  // nilの場合はIdempotency-Keyを無視する
 }
 
 type GraphQLRequest struct {
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables"`
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    *RequestExtensions     `json:"extensions,omitempty"`
+}
+
+// RequestExtensions はリクエストのextensionsフィールドです
+type RequestExtensions struct {
+	PersistedQuery *persisted.Extension `json:"persistedQuery,omitempty"`
+	IdempotencyKey string               `json:"idempotencyKey,omitempty"` // Idempotency-Keyヘッダーの代わりに指定できる
+}
+
+// persistedQuery はリクエストに含まれる永続化クエリの指定を返します
+func (req *GraphQLRequest) persistedQuery() *persisted.Extension {
+	if req.Extensions == nil {
+		return nil
+	}
+	return req.Extensions.PersistedQuery
 }
 
 type GraphQLResponse struct {
@@ -27,62 +68,392 @@ type GraphQLResponse struct {
 }
 
 type GraphQLError struct {
-	Message string `json:"message"`
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"` // エラーコードなどの付加情報
 }
 
 func (s *Server) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
+	w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, Idempotency-Key")
+	w.Header().Set("Access-Control-Expose-Headers", "Idempotent-Replayed")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
+	if s.Config.MaxRequestBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(s.Config.MaxRequestBodyBytes))
+	}
+
 	var req GraphQLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.sendRequestTooLarge(w, maxBytesErr.Limit)
+			return
+		}
 		s.sendError(w, "Invalid JSON")
 		return
 	}
 
+	// 永続化クエリのハッシュから実行するクエリを解決
+	resolved, err := s.PersistedQueries.Resolve(req.Query, req.persistedQuery())
+	if err != nil {
+		s.sendPersistedQueryError(w, err)
+		return
+	}
+
+	// 深さ・複雑度の上限チェック（実行前に拒否する）
+	if _, err := graph.CheckQueryLimits(resolved.Query, req.Variables, s.queryLimits()); err != nil {
+		s.sendQueryError(w, err)
+		return
+	}
+
+	// 検証に通ったクエリのみAPQとして登録する
+	if err := s.PersistedQueries.Register(resolved, req.OperationName); err != nil {
+		log.Printf("Failed to register persisted query %s: %v", resolved.Hash, err)
+	}
+
 	// 簡単なクエリルーティング
-	response := s.executeQuery(req.Query, req.Variables)
+	ctx := withClientIP(r.Context(), clientIP(r))
+
+	// Idempotency-Keyを指定したミューテーションは、再送に最初の結果を返す
+	if key := req.idempotencyKey(r); key != "" && s.Idempotency != nil && contains(resolved.Query, "mutation") {
+		s.executeIdempotent(ctx, w, key, resolved.Query, &req)
+		return
+	}
+
+	response := s.executeQuery(ctx, resolved.Query, req.Variables)
 	json.NewEncoder(w).Encode(response)
 }
 
-func (s *Server) executeQuery(query string, variables map[string]interface{}) GraphQLResponse {
-	// 非常にシンプルなクエリパーサー（実際のプロジェクトでは適切なGraphQLライブラリを使用）
+// detectOperation はクエリ文字列から実行する操作名を判定します
+// 非常にシンプルなクエリパーサー（実際のプロジェクトでは適切なGraphQLライブラリを使用）
+// 部分一致で判定するため、他の操作名を含む操作は先にチェックする
+func detectOperation(query string) string {
+	isMutation := contains(query, "mutation")
+
+	switch {
+	// 投票
+	case contains(query, "votePoll") && isMutation:
+		return "votePoll"
+
+	// 下書き・予約投稿
+	case contains(query, "saveDraft") && isMutation:
+		return "saveDraft"
+	case contains(query, "publishDraft") && isMutation:
+		return "publishDraft"
+	case contains(query, "deleteDraft") && isMutation:
+		return "deleteDraft"
+	case containsField(query, "drafts") && !isMutation:
+		return "drafts"
+
+	// 通知の既読化ミューテーション
+	case contains(query, "markNotificationsRead") && isMutation:
+		return "markNotificationsRead"
+
+	// 通知一覧クエリ
+	case contains(query, "notifications") && !isMutation:
+		return "notifications"
+
+	// 通報・モデレーション・役割の変更（対象の"post"・"user"などのフィールドを含むため先にチェック）
+	case contains(query, "reportContent") && isMutation:
+		return "reportContent"
+	case contains(query, "updateReportStatus") && isMutation:
+		return "updateReportStatus"
+	case contains(query, "hidePost") && isMutation:
+		return "hidePost"
+	case contains(query, "restorePost") && isMutation:
+		return "restorePost"
+	case contains(query, "unsuspendUser") && isMutation:
+		return "unsuspendUser"
+	case contains(query, "suspendUser") && isMutation:
+		return "suspendUser"
+	case contains(query, "setUserRole") && isMutation:
+		return "setUserRole"
+	case containsField(query, "reports") && !isMutation:
+		return "reports"
+	case contains(query, "moderationActions") && !isMutation:
+		return "moderationActions"
+
+	// データエクスポート（"me"より先にチェック）
+	case contains(query, "requestDataExport") && isMutation:
+		return "requestDataExport"
+	case contains(query, "dataExports") && !isMutation:
+		return "dataExports"
+
+	// ログイン中のユーザー情報クエリ（"name"などに含まれる"me"と区別する）
+	case containsField(query, "me") && !isMutation:
+		return "me"
+
+	// ユーザー名の利用可否クエリ
+	case contains(query, "usernameAvailable") && !isMutation:
+		return "usernameAvailable"
+
+	// ブロック・ミュートしたユーザーの一覧クエリ（"users"より先にチェック）
+	case contains(query, "blockedUsers") && !isMutation:
+		return "blockedUsers"
+	case contains(query, "mutedUsers") && !isMutation:
+		return "mutedUsers"
+
+	// フォローリクエスト一覧クエリ
+	case contains(query, "followRequests") && !isMutation:
+		return "followRequests"
 
 	// ユーザー一覧クエリ
-	if contains(query, "users") && !contains(query, "mutation") {
-		return s.handleUsersQuery()
-	}
+	case contains(query, "users") && !isMutation:
+		return "users"
+
+	// フォロワー・フォロー中のユーザーの一覧クエリ（"followerCount"などのフィールドと区別する）
+	case containsField(query, "followers") && !isMutation:
+		return "followers"
+	case containsField(query, "following") && !isMutation:
+		return "following"
 
 	// ユーザー登録ミューテーション
-	if contains(query, "register") && contains(query, "mutation") {
-		return s.handleRegisterMutation(variables)
-	}
+	case contains(query, "register") && isMutation:
+		return "register"
+
+	// ログインミューテーション
+	case contains(query, "login") && isMutation:
+		return "login"
+
+	// メールアドレス確認ミューテーション
+	case contains(query, "verifyEmail") && isMutation:
+		return "verifyEmail"
+
+	// パスワードリセット要求ミューテーション
+	case contains(query, "requestPasswordReset") && isMutation:
+		return "requestPasswordReset"
+
+	// パスワード再設定ミューテーション
+	case contains(query, "resetPassword") && isMutation:
+		return "resetPassword"
+
+	// プロフィール更新ミューテーション
+	case contains(query, "updateProfile") && isMutation:
+		return "updateProfile"
+
+	// 退会ミューテーション
+	case contains(query, "deactivateAccount") && isMutation:
+		return "deactivateAccount"
 
 	// 投稿作成ミューテーション
-	if contains(query, "createPost") && contains(query, "mutation") {
-		return s.handleCreatePostMutation(variables)
-	}
+	case contains(query, "createPost") && isMutation:
+		return "createPost"
+
+	// リポスト取り消しミューテーション（先にチェック、"repostCount"などのフィールドと区別する）
+	case containsField(query, "unrepost") && isMutation:
+		return "unrepost"
+
+	// リポストミューテーション
+	case containsField(query, "repost") && isMutation:
+		return "repost"
+
+	// ブックマーク取り消しミューテーション（先にチェック）
+	case contains(query, "unbookmarkPost") && isMutation:
+		return "unbookmarkPost"
+
+	// ブックマークミューテーション
+	case contains(query, "bookmarkPost") && isMutation:
+		return "bookmarkPost"
+
+	// ブックマークのコレクション作成・削除ミューテーション
+	case contains(query, "createBookmarkCollection") && isMutation:
+		return "createBookmarkCollection"
+	case contains(query, "deleteBookmarkCollection") && isMutation:
+		return "deleteBookmarkCollection"
+
+	// ブックマークのコレクション一覧クエリ
+	case contains(query, "bookmarkCollections") && !isMutation:
+		return "bookmarkCollections"
+
+	// ブックマーク一覧クエリ（"isBookmarked"などのフィールドと区別する）
+	case containsField(query, "bookmarks") && !isMutation:
+		return "bookmarks"
+
+	// タイムラインクエリ（"posts"を含むため先にチェック）
+	case containsField(query, "timeline") && !isMutation:
+		return "timeline"
+
+	// トレンドクエリ
+	case contains(query, "trending") && !isMutation:
+		return "trending"
+
+	// ハッシュタグの投稿一覧クエリ（先にチェック）
+	case contains(query, "postsByHashtag") && !isMutation:
+		return "postsByHashtag"
 
 	// 投稿一覧クエリ
-	if contains(query, "posts") && !contains(query, "mutation") {
-		return s.handlePostsQuery()
-	}
+	case contains(query, "posts") && !isMutation:
+		return "posts"
+
+	// 投稿の詳細クエリ（"posts"などと区別する）
+	case containsField(query, "post") && !isMutation:
+		return "post"
+
+	// フォローリクエストの承認・拒否ミューテーション
+	case contains(query, "approveFollowRequest") && isMutation:
+		return "approveFollowRequest"
+	case contains(query, "rejectFollowRequest") && isMutation:
+		return "rejectFollowRequest"
+
+	// ブロック・ミュートの解除ミューテーション（先にチェック）
+	case contains(query, "unblockUser") && isMutation:
+		return "unblockUser"
+	case contains(query, "unmuteUser") && isMutation:
+		return "unmuteUser"
+
+	// ブロック・ミュートミューテーション
+	case contains(query, "blockUser") && isMutation:
+		return "blockUser"
+	case contains(query, "muteUser") && isMutation:
+		return "muteUser"
+
+	// フォロー解除ミューテーション（先にチェック）
+	case contains(query, "unfollowUser") && isMutation:
+		return "unfollowUser"
+
+	// フォローミューテーション
+	case contains(query, "followUser") && isMutation:
+		return "followUser"
 
 	// いいね取り消しミューテーション（先にチェック）
-	if contains(query, "unlikePost") && contains(query, "mutation") {
-		return s.handleUnlikePostMutation(variables)
-	}
+	case contains(query, "unlikePost") && isMutation:
+		return "unlikePost"
 
 	// いいねミューテーション
-	if contains(query, "likePost") && contains(query, "mutation") {
-		return s.handleLikePostMutation(variables)
+	case contains(query, "likePost") && isMutation:
+		return "likePost"
+	}
+
+	return ""
+}
+
+func (s *Server) executeQuery(ctx context.Context, query string, variables map[string]interface{}) GraphQLResponse {
+	// @hasRoleで制限されたフィールドはハンドラーを呼ぶ前に拒否する
+	if err := s.authorizeQuery(ctx, query); err != nil {
+		return GraphQLResponse{Errors: []GraphQLError{operationError(err)}}
+	}
+
+	switch detectOperation(query) {
+	case "markNotificationsRead":
+		return s.handleMarkNotificationsReadMutation(ctx, variables)
+	case "notifications":
+		return s.handleNotificationsQuery(ctx, variables)
+	case "reportContent":
+		return s.handleReportContentMutation(ctx, variables)
+	case "updateReportStatus":
+		return s.handleUpdateReportStatusMutation(ctx, variables)
+	case "hidePost":
+		return s.handleHidePostMutation(ctx, variables)
+	case "restorePost":
+		return s.handleRestorePostMutation(ctx, variables)
+	case "unsuspendUser":
+		return s.handleUnsuspendUserMutation(ctx, variables)
+	case "suspendUser":
+		return s.handleSuspendUserMutation(ctx, variables)
+	case "setUserRole":
+		return s.handleSetUserRoleMutation(ctx, variables)
+	case "reports":
+		return s.handleReportsQuery(ctx, variables)
+	case "moderationActions":
+		return s.handleModerationActionsQuery(ctx, variables)
+	case "requestDataExport":
+		return s.handleRequestDataExportMutation(ctx)
+	case "dataExports":
+		return s.handleDataExportsQuery(ctx)
+	case "me":
+		return s.handleMeQuery(ctx)
+	case "usernameAvailable":
+		return s.handleUsernameAvailableQuery(variables)
+	case "users":
+		return s.handleUsersQuery(ctx)
+	case "register":
+		return s.handleRegisterMutation(variables)
+	case "login":
+		return s.handleLoginMutation(ctx, variables)
+	case "verifyEmail":
+		return s.handleVerifyEmailMutation(variables)
+	case "requestPasswordReset":
+		return s.handleRequestPasswordResetMutation(variables)
+	case "resetPassword":
+		return s.handleResetPasswordMutation(variables)
+	case "createPost":
+		return s.handleCreatePostMutation(ctx, variables)
+	case "votePoll":
+		return s.handleVotePollMutation(ctx, variables)
+	case "saveDraft":
+		return s.handleSaveDraftMutation(ctx, variables)
+	case "publishDraft":
+		return s.handlePublishDraftMutation(ctx, variables)
+	case "deleteDraft":
+		return s.handleDeleteDraftMutation(ctx, variables)
+	case "drafts":
+		return s.handleDraftsQuery(ctx)
+	case "unrepost":
+		return s.handleUnrepostMutation(ctx, variables)
+	case "repost":
+		return s.handleRepostMutation(ctx, variables)
+	case "unbookmarkPost":
+		return s.handleUnbookmarkPostMutation(ctx, variables)
+	case "bookmarkPost":
+		return s.handleBookmarkPostMutation(ctx, variables)
+	case "createBookmarkCollection":
+		return s.handleCreateBookmarkCollectionMutation(ctx, variables)
+	case "deleteBookmarkCollection":
+		return s.handleDeleteBookmarkCollectionMutation(ctx, variables)
+	case "bookmarkCollections":
+		return s.handleBookmarkCollectionsQuery(ctx)
+	case "bookmarks":
+		return s.handleBookmarksQuery(ctx, variables)
+	case "timeline":
+		return s.handleTimelineQuery(ctx, variables)
+	case "trending":
+		return s.handleTrendingQuery(ctx, variables)
+	case "postsByHashtag":
+		return s.handlePostsByHashtagQuery(ctx, variables)
+	case "posts":
+		return s.handlePostsQuery(ctx)
+	case "post":
+		return s.handlePostQuery(ctx, variables)
+	case "followRequests":
+		return s.handleFollowRequestsQuery(ctx)
+	case "followers":
+		return s.handleFollowersQuery(ctx, variables)
+	case "following":
+		return s.handleFollowingQuery(ctx, variables)
+	case "updateProfile":
+		return s.handleUpdateProfileMutation(ctx, variables)
+	case "deactivateAccount":
+		return s.handleDeactivateAccountMutation(ctx, variables)
+	case "approveFollowRequest":
+		return s.handleApproveFollowRequestMutation(ctx, variables)
+	case "rejectFollowRequest":
+		return s.handleRejectFollowRequestMutation(ctx, variables)
+	case "blockedUsers":
+		return s.handleBlockedUsersQuery(ctx)
+	case "mutedUsers":
+		return s.handleMutedUsersQuery(ctx)
+	case "unblockUser":
+		return s.handleUnblockUserMutation(ctx, variables)
+	case "unmuteUser":
+		return s.handleUnmuteUserMutation(ctx, variables)
+	case "blockUser":
+		return s.handleBlockUserMutation(ctx, variables)
+	case "muteUser":
+		return s.handleMuteUserMutation(ctx, variables)
+	case "unfollowUser":
+		return s.handleUnfollowUserMutation(ctx, variables)
+	case "followUser":
+		return s.handleFollowUserMutation(ctx, variables)
+	case "unlikePost":
+		return s.handleUnlikePostMutation(ctx, variables)
+	case "likePost":
+		return s.handleLikePostMutation(ctx, variables)
 	}
 
 	return GraphQLResponse{
@@ -90,24 +461,75 @@ func (s *Server) executeQuery(query string, variables map[string]interface{}) Gr
 	}
 }
 
-func (s *Server) handleUsersQuery() GraphQLResponse {
+func (s *Server) handleUsersQuery(ctx context.Context) GraphQLResponse {
+	// ブロックの関係にあるユーザーは含めない
 	var users []models.User
-	if err := s.DB.Find(&users).Error; err != nil {
+	if err := models.ExcludeBlocked(s.DB, "id", viewerID(ctx)).Find(&users).Error; err != nil {
 		return errorResponse(fmt.Sprintf("Database error: %v", err))
 	}
 	return dataResponse("users", users)
 }
 
+func (s *Server) handleUsernameAvailableQuery(variables map[string]interface{}) GraphQLResponse {
+	username := getString(variables, "username")
+	if username == "" {
+		return errorResponse("Username is required")
+	}
+
+	available, err := models.IsUsernameAvailable(s.DB, username)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("usernameAvailable", available)
+}
+
 func (s *Server) handleRegisterMutation(variables map[string]interface{}) GraphQLResponse {
 	input, ok := variables["input"].(map[string]interface{})
 	if !ok {
 		return errorResponse("Invalid input format")
 	}
 
+	username := models.NormalizeUsername(getString(input, "username"))
+	if err := models.ValidateUsername(username); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	email := models.NormalizeEmail(getString(input, "email"))
+	if err := models.ValidateEmail(email); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	// 大文字小文字を区別せずに重複をチェック
+	available, err := models.IsUsernameAvailable(s.DB, username)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	if !available {
+		return errorResponse("username is already taken")
+	}
+
+	taken, err := models.IsEmailTaken(s.DB, email)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	if taken {
+		return errorResponse("email is already registered")
+	}
+
+	password := getString(input, "password")
+	if err := auth.ValidatePassword(password); err != nil {
+		return errorResponse(err.Error())
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return errorResponse("Failed to hash password")
+	}
+
 	user := models.User{
-		Username: getString(input, "username"),
-		Email:    getString(input, "email"),
-		Password: getString(input, "password"), // TODO: ハッシュ化
+		Username: username,
+		Email:    email,
+		Password: hash,
 		Name:     getString(input, "name"),
 		Bio:      getString(input, "bio"),
 	}
@@ -116,13 +538,23 @@ func (s *Server) handleRegisterMutation(variables map[string]interface{}) GraphQ
 		return errorResponse(fmt.Sprintf("Failed to create user: %v", err))
 	}
 
+	// 確認メールの送信失敗では登録自体は失敗させない
+	if err := s.sendVerificationEmail(&user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	token, err := auth.IssueSessionToken(s.Config.JWTSecret, user.ID, s.Config.SessionTTL)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to issue token: %v", err))
+	}
+
 	return dataResponse("register", map[string]interface{}{
-		"token": "temp_token_" + strconv.Itoa(int(user.ID)),
+		"token": token,
 		"user":  user,
 	})
 }
 
-func (s *Server) handleCreatePostMutation(variables map[string]interface{}) GraphQLResponse {
+func (s *Server) handleCreatePostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
 	input, ok := variables["input"].(map[string]interface{})
 	if !ok {
 		return errorResponse("Invalid input format - variables required")
@@ -133,33 +565,210 @@ func (s *Server) handleCreatePostMutation(variables map[string]interface{}) Grap
 		return errorResponse("Content is required")
 	}
 
-	// デフォルトユーザーを取得または作成
-	user, err := s.ensureDefaultUser()
+	// 認証済みユーザー（未認証の場合はデフォルトユーザー）を取得
+	user, err := s.actingUser(ctx)
 	if err != nil {
 		return errorResponse(err.Error())
 	}
 
-	post := models.Post{
-		Content:  content,
-		AuthorID: user.ID,
+	post, parent, err := s.preparePost(user.ID, content, getUint(input, "parentId"), getUint(input, "quotedPostId"))
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	// 投票は投稿と一緒に作成する
+	if post.Poll, err = parsePollInput(input, time.Now()); err != nil {
+		return errorResponse(err.Error())
 	}
 
 	if err := s.DB.Create(&post).Error; err != nil {
 		return errorResponse(fmt.Sprintf("Failed to create post: %v", err))
 	}
+	s.postCreated(ctx, &post, parent)
 
-	// 作成者情報をプリロード
-	s.DB.Preload("Author").First(&post, post.ID)
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("createPost", view)
+}
 
-	return dataResponse("createPost", post)
+// preparePost はリプライ先・引用元をチェックして作成する投稿を返します（リプライの場合はリプライ先も返す）
+func (s *Server) preparePost(userID uint, content string, parentID, quotedPostID uint) (models.Post, *models.Post, error) {
+	post := models.Post{
+		Content:  content,
+		AuthorID: userID,
+	}
+
+	// リプライの場合はリプライ先が存在するかチェック
+	var parent *models.Post
+	if parentID != 0 {
+		parent = &models.Post{}
+		if err := s.DB.First(parent, parentID).Error; err != nil {
+			return post, nil, errors.New("Parent post not found")
+		}
+		if err := s.checkPostAccess(userID, parent); err != nil {
+			return post, nil, err
+		}
+		post.ParentID = &parent.ID
+	}
+
+	// 引用投稿の場合は引用元が存在するかチェック
+	if quotedPostID != 0 {
+		var quoted models.Post
+		if err := s.DB.First(&quoted, quotedPostID).Error; err != nil {
+			return post, nil, errors.New("Quoted post not found")
+		}
+		if err := s.checkPostAccess(userID, &quoted); err != nil {
+			return post, nil, err
+		}
+		// 非公開アカウントの投稿は本人以外引用できない（フォロワー以外に公開されてしまうため）
+		if quoted.AuthorID != userID && !s.isPublicUser(quoted.AuthorID) {
+			return post, nil, errors.New("Cannot quote a private account's post")
+		}
+		post.QuotedPostID = &quoted.ID
+	}
+
+	return post, parent, nil
 }
 
-func (s *Server) handlePostsQuery() GraphQLResponse {
+// postCreated は作成した投稿を通知・サブスクリプション・タイムラインへ配信します
+func (s *Server) postCreated(ctx context.Context, post *models.Post, parent *models.Post) {
+	// 作成者情報・エンティティ・引用元をプリロード
+	s.postQuery().First(post, post.ID)
+
+	s.notifyPostCreated(post, parent)
+	s.publishPostCreated(ctx, post)
+	s.enqueueTimelineJob(timeline.FanoutJobType, timeline.FanoutPayload{PostID: post.ID})
+}
+
+func (s *Server) handlePostsQuery(ctx context.Context) GraphQLResponse {
+	// ブロックの関係にあるユーザーと見られない非公開アカウントの投稿は含めない
 	var posts []models.Post
-	if err := s.DB.Preload("Author").Order("created_at DESC").Find(&posts).Error; err != nil {
+	if err := models.ExcludeHidden(s.postQuery(), "author_id", viewerID(ctx)).Order("created_at DESC").Find(&posts).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	views, err := s.buildPostViews(ctx, posts)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("posts", views)
+}
+
+// postDetailView は1件の投稿とリプライです
+type postDetailView struct {
+	postView
+	Replies []postView `json:"replies"`
+}
+
+func (s *Server) handlePostQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	postID := getUint(variables, "id")
+	if postID == 0 {
+		return errorResponse("Post ID is required")
+	}
+
+	// 存在しない投稿と見られない投稿（ブロック・非公開）はnull
+	viewer := viewerID(ctx)
+	var post models.Post
+	result := models.ExcludeHidden(s.postQuery(), "author_id", viewer).Limit(1).Find(&post, postID)
+	if result.Error != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", result.Error))
+	}
+	if result.RowsAffected == 0 {
+		return dataResponse("post", nil)
+	}
+
+	// リプライは古い順（見られないユーザーのリプライは含めない）
+	var replies []models.Post
+	err := models.ExcludeHidden(s.postQuery(), "author_id", viewer).
+		Where("parent_id = ?", post.ID).
+		Order("created_at, id").
+		Find(&replies).Error
+	if err != nil {
 		return errorResponse(fmt.Sprintf("Database error: %v", err))
 	}
-	return dataResponse("posts", posts)
+
+	views, err := s.buildPostViews(ctx, append([]models.Post{post}, replies...))
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	return dataResponse("post", postDetailView{postView: views[0], Replies: views[1:]})
+}
+
+func (s *Server) queryLimits() graph.QueryLimits {
+	return graph.QueryLimits{
+		MaxDepth:        s.Config.MaxQueryDepth,
+		MaxComplexity:   s.Config.MaxQueryComplexity,
+		DefaultListSize: s.Config.DefaultListSize,
+		MaxListSize:     maxPageSize,
+	}
+}
+
+// operationError は操作の開始前のエラーをエラーコード付きのGraphQLErrorに変換します
+func operationError(err error) GraphQLError {
+	var pqErr *persisted.Error
+	if errors.As(err, &pqErr) {
+		return GraphQLError{Message: pqErr.Message, Extensions: map[string]interface{}{"code": pqErr.Code}}
+	}
+
+	var queryErr *graph.QueryError
+	if errors.As(err, &queryErr) {
+		extensions := map[string]interface{}{"code": queryErr.Code}
+		for k, v := range queryErr.Extensions {
+			extensions[k] = v
+		}
+		return GraphQLError{Message: queryErr.Message, Extensions: extensions}
+	}
+
+	return GraphQLError{Message: err.Error()}
+}
+
+// sendQueryError はクエリの解析・制限エラーをレスポンスとして返します
+func (s *Server) sendQueryError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(GraphQLResponse{
+		Errors: []GraphQLError{operationError(err)},
+	})
+}
+
+// sendPersistedQueryError は永続化クエリの解決エラーをレスポンスとして返します
+// 未登録・非対応の場合、クライアントはクエリ本文を付けて再送するためステータスは200にする
+func (s *Server) sendPersistedQueryError(w http.ResponseWriter, err error) {
+	var pqErr *persisted.Error
+	if !errors.As(err, &pqErr) {
+		log.Printf("Failed to resolve persisted query: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(errorResponse("Failed to resolve persisted query"))
+		return
+	}
+
+	status := http.StatusBadRequest
+	if pqErr.Code == persisted.CodeNotFound || pqErr.Code == persisted.CodeNotSupported {
+		status = http.StatusOK
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(GraphQLResponse{
+		Errors: []GraphQLError{{
+			Message:    pqErr.Message,
+			Extensions: map[string]interface{}{"code": pqErr.Code},
+		}},
+	})
+}
+
+// sendRequestTooLarge はリクエストボディが上限を超えた場合のレスポンスを返します
+func (s *Server) sendRequestTooLarge(w http.ResponseWriter, limit int64) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(GraphQLResponse{
+		Errors: []GraphQLError{{
+			Message: fmt.Sprintf("Request body exceeds the maximum size of %d bytes", limit),
+			Extensions: map[string]interface{}{
+				"code":     "REQUEST_TOO_LARGE",
+				"maxBytes": limit,
+			},
+		}},
+	})
 }
 
 func (s *Server) sendError(w http.ResponseWriter, message string) {
@@ -170,12 +779,60 @@ func (s *Server) sendError(w http.ResponseWriter, message string) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// containsField はクエリにフィールドの選択（"me {" や "me(" など）が含まれるかを判定します
+// 他の単語の一部（"name" の "me" など）には一致しません
+func containsField(query, field string) bool {
+	pattern := regexp.MustCompile(`(^|[^A-Za-z0-9_])` + regexp.QuoteMeta(field) + `\s*[({]`)
+	return pattern.MatchString(query)
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr ||
 		(len(s) > len(substr) && (s[:len(substr)] == substr ||
 			contains(s[1:], substr))))
 }
 
+// actingUser は操作を行うユーザーを返します
+// 認証済みの場合はそのユーザー、未認証の場合はデフォルトユーザーを使います
+func (s *Server) actingUser(ctx context.Context) (*models.User, error) {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return s.ensureDefaultUser()
+	}
+
+	var user models.User
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		return nil, fmt.Errorf("認証ユーザーが見つかりません: %v", err)
+	}
+	if user.IsSuspended() {
+		return nil, errAccountSuspended
+	}
+	return &user, nil
+}
+
+// requireUser は認証済みのユーザーを返します（未認証の場合はエラー）
+// デフォルトユーザーで代用しない操作（フォロー・通知など）で使います
+func (s *Server) requireUser(ctx context.Context) (*models.User, error) {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, errAuthenticationRequired
+	}
+
+	var user models.User
+	if err := s.DB.First(&user, userID).Error; err != nil {
+		return nil, errAuthenticationRequired
+	}
+	if user.IsSuspended() {
+		return nil, errAccountSuspended
+	}
+	return &user, nil
+}
+
+var errAuthenticationRequired = errors.New("authentication required")
+
+// モデレーターに利用停止されたユーザーの操作のエラー
+var errAccountSuspended = errors.New("account suspended")
+
 // デフォルトユーザーIDの定数
 const defaultUserID uint = 1
 
@@ -230,23 +887,49 @@ func getUint(m map[string]interface{}, key string) uint {
 		if num, ok := val.(int); ok {
 			return uint(num)
 		}
+		// ID型は文字列で渡されることもある
+		if str, ok := val.(string); ok {
+			if num, err := strconv.ParseUint(str, 10, 64); err == nil {
+				return uint(num)
+			}
+		}
 	}
 	return 0
 }
 
-func (s *Server) handleLikePostMutation(variables map[string]interface{}) GraphQLResponse {
-	input, ok := variables["input"].(map[string]interface{})
-	if !ok {
-		return errorResponse("Invalid input format")
+// getUintList はID型のリストを数値のスライスとして取得します（不正な要素は無視）
+func getUintList(m map[string]interface{}, key string) []uint {
+	list, _ := m[key].([]interface{})
+	ids := make([]uint, 0, len(list))
+	for _, item := range list {
+		if id := getUint(map[string]interface{}{"id": item}, "id"); id != 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// getTime はRFC 3339形式の時刻を取得します（指定がない場合はnil）
+func getTime(m map[string]interface{}, key string) (*time.Time, error) {
+	str := getString(m, key)
+	if str == "" {
+		return nil, nil
 	}
+	t, err := time.Parse(time.RFC3339, str)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
 
-	postID := getUint(input, "postId")
+func (s *Server) handleLikePostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	postID := getUint(variables, "postId")
 	if postID == 0 {
 		return errorResponse("Post ID is required")
 	}
 
-	// デフォルトユーザーを取得または作成
-	user, err := s.ensureDefaultUser()
+	// 認証済みユーザー（未認証の場合はデフォルトユーザー）を取得
+	user, err := s.actingUser(ctx)
 	if err != nil {
 		return errorResponse(err.Error())
 	}
@@ -256,49 +939,63 @@ func (s *Server) handleLikePostMutation(variables map[string]interface{}) GraphQ
 	if err := s.DB.First(&post, postID).Error; err != nil {
 		return errorResponse("Post not found")
 	}
-
-	// いいねを作成
-	like := models.Like{
-		UserID: user.ID,
-		PostID: postID,
+	if err := s.checkPostAccess(user.ID, &post); err != nil {
+		return errorResponse(err.Error())
 	}
 
-	if err := s.DB.Create(&like).Error; err != nil {
+	// 既にいいね済みの場合はそのまま返す
+	liked, err := models.LikePost(s.DB, user.ID, post.ID)
+	if err != nil {
 		return errorResponse(fmt.Sprintf("Failed to like post: %v", err))
 	}
+	if liked {
+		s.notifyLike(user.ID, &post)
+		s.publishLikeCountChanged(ctx, post.ID)
+	}
 
-	// 作成されたいいねをリレーション込みで取得
-	s.DB.Preload("User").Preload("Post").First(&like, like.ID)
-
-	return dataResponse("likePost", like)
+	return s.likedPostResponse(ctx, "likePost", post.ID, true)
 }
 
-func (s *Server) handleUnlikePostMutation(variables map[string]interface{}) GraphQLResponse {
-	input, ok := variables["input"].(map[string]interface{})
-	if !ok {
-		return errorResponse("Invalid input format")
-	}
-
-	postID := getUint(input, "postId")
+func (s *Server) handleUnlikePostMutation(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	postID := getUint(variables, "postId")
 	if postID == 0 {
 		return errorResponse("Post ID is required")
 	}
 
-	// デフォルトユーザーを取得または作成
-	user, err := s.ensureDefaultUser()
+	// 認証済みユーザー（未認証の場合はデフォルトユーザー）を取得
+	user, err := s.actingUser(ctx)
 	if err != nil {
 		return errorResponse(err.Error())
 	}
 
-	// いいねを削除
-	result := s.DB.Where("user_id = ? AND post_id = ?", user.ID, postID).Delete(&models.Like{})
-	if result.Error != nil {
-		return errorResponse(fmt.Sprintf("Failed to unlike post: %v", result.Error))
+	var post models.Post
+	if err := s.DB.First(&post, postID).Error; err != nil {
+		return errorResponse("Post not found")
 	}
 
-	if result.RowsAffected == 0 {
-		return errorResponse("Like not found")
+	// いいねしていない場合はそのまま返す
+	removed, err := models.RemoveLike(s.DB, user.ID, post.ID)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Failed to unlike post: %v", err))
+	}
+	if removed {
+		s.publishLikeCountChanged(ctx, post.ID)
 	}
 
-	return dataResponse("unlikePost", true)
+	return s.likedPostResponse(ctx, "unlikePost", post.ID, false)
+}
+
+// likedPostResponse はいいね・取り消し後の投稿を更新したいいね数と共に返します
+// 未認証でデフォルトユーザーが操作した場合もisLikedByUserは操作したユーザーの状態にする
+func (s *Server) likedPostResponse(ctx context.Context, key string, postID uint, liked bool) GraphQLResponse {
+	var post models.Post
+	if err := s.postQuery().First(&post, postID).Error; err != nil {
+		return errorResponse(errPostNotFound.Error())
+	}
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+	view.IsLikedByUser = liked
+	return dataResponse(key, view)
 }
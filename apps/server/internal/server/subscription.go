@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"sns-server/internal/auth"
+	"sns-server/internal/graph"
+	"sns-server/internal/graph/model"
+	"sns-server/internal/models"
+)
+
+// subscriptionSource はサブスクリプションのイベントの購読先と、イベントから送信するデータの変換です
+// resolveがfalseを返したイベントは送信しない（購読条件に合わない）
+type subscriptionSource struct {
+	topic   string
+	resolve func(payload []byte) (interface{}, bool, error)
+}
+
+// runOperation はWebSocketで受け付けた操作を実行します
+// クエリは1回だけ結果を返し、サブスクリプションはイベントごとに結果を返します
+// 開始前のエラー（検証エラーなど）は戻り値で返し、errorメッセージとして通知します
+func (s *Server) runOperation(ctx context.Context, c *wsConnection, id string, payload subscribePayload) []GraphQLError {
+	resolved, err := s.PersistedQueries.Resolve(payload.Query, payload.persistedQuery())
+	if err != nil {
+		return []GraphQLError{operationError(err)}
+	}
+
+	if _, err := graph.CheckQueryLimits(resolved.Query, payload.Variables, s.queryLimits()); err != nil {
+		return []GraphQLError{operationError(err)}
+	}
+
+	schema, err := graph.Schema()
+	if err != nil {
+		return []GraphQLError{{Message: "Failed to load schema"}}
+	}
+	doc, gqlErrs := gqlparser.LoadQuery(schema, resolved.Query)
+	if len(gqlErrs) > 0 {
+		errs := make([]GraphQLError, len(gqlErrs))
+		for i, e := range gqlErrs {
+			errs[i] = GraphQLError{Message: e.Message}
+		}
+		return errs
+	}
+
+	op := doc.Operations.ForName(payload.OperationName)
+	if op == nil {
+		return []GraphQLError{{Message: "Operation not found"}}
+	}
+
+	switch op.Operation {
+	case ast.Query:
+		// executeQueryはクエリ文字列の部分一致で操作を判定するため、選択した操作だけを渡す
+		// （同じドキュメントの他の操作やコメント・文字列中のミューテーション名で実行されないように）
+		query := operationSource(doc, op)
+		if schema.Mutation != nil && schema.Mutation.Fields.ForName(detectOperation(query)) != nil {
+			return []GraphQLError{{Message: "Mutations are not supported over WebSocket, use POST /query"}}
+		}
+		s.PersistedQueries.Register(resolved, payload.OperationName)
+		c.sendNext(id, s.executeQuery(ctx, query, payload.Variables))
+		return nil
+	case ast.Mutation:
+		// レート制限などのHTTPミドルウェアを通らないため、ミューテーションはPOST /queryのみ受け付ける
+		return []GraphQLError{{Message: "Mutations are not supported over WebSocket, use POST /query"}}
+	}
+
+	// サブスクリプションはルートフィールドが1つだけ（検証済み）
+	field, ok := op.SelectionSet[0].(*ast.Field)
+	if !ok {
+		return []GraphQLError{{Message: "Subscription must select a single field"}}
+	}
+
+	source, err := s.subscriptionSource(ctx, field, payload.Variables)
+	if err != nil {
+		return []GraphQLError{{Message: err.Error()}}
+	}
+	if s.PubSub == nil {
+		return []GraphQLError{{Message: "Subscriptions are not available"}}
+	}
+
+	s.PersistedQueries.Register(resolved, payload.OperationName)
+
+	sub := s.PubSub.Subscribe(source.topic)
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.C():
+			if !ok {
+				return nil
+			}
+
+			data, matched, err := source.resolve(event)
+			if err != nil {
+				log.Printf("Failed to resolve %s event: %v", field.Name, err)
+				continue
+			}
+			if matched {
+				c.sendNext(id, dataResponse(field.Alias, data))
+			}
+		}
+	}
+}
+
+// operationSource はドキュメントから1つの操作とフラグメントだけを文字列に戻します（コメントは含まない）
+func operationSource(doc *ast.QueryDocument, op *ast.OperationDefinition) string {
+	var b strings.Builder
+	formatter.NewFormatter(&b).FormatQueryDocument(&ast.QueryDocument{
+		Operations: ast.OperationList{op},
+		Fragments:  doc.Fragments,
+	})
+	return b.String()
+}
+
+// subscriptionSource はSubscription型のフィールドに対応する購読先を返します
+func (s *Server) subscriptionSource(ctx context.Context, field *ast.Field, variables map[string]interface{}) (*subscriptionSource, error) {
+	args := field.ArgumentMap(variables)
+
+	switch field.Name {
+	case "postCreated":
+		authorID, err := optionalID(args["authorId"])
+		if err != nil {
+			return nil, err
+		}
+		return &subscriptionSource{
+			topic: topicPostCreated,
+			resolve: func(payload []byte) (interface{}, bool, error) {
+				var event postCreatedEvent
+				if err := json.Unmarshal(payload, &event); err != nil {
+					return nil, false, err
+				}
+				if authorID != 0 && event.AuthorID != authorID {
+					return nil, false, nil
+				}
+				// 見られないユーザー（ブロック・非公開）の投稿は送らない
+				if !s.canViewUserContent(viewerID(ctx), event.AuthorID) {
+					return nil, false, nil
+				}
+				return s.loadPost(ctx, event.PostID)
+			},
+		}, nil
+
+	case "timelineUpdated":
+		userID, ok := auth.UserIDFromContext(ctx)
+		if !ok {
+			return nil, errAuthenticationRequired
+		}
+		return &subscriptionSource{
+			topic: topicPostCreated,
+			resolve: func(payload []byte) (interface{}, bool, error) {
+				var event postCreatedEvent
+				if err := json.Unmarshal(payload, &event); err != nil {
+					return nil, false, err
+				}
+				// 自分とフォロー中のユーザーの投稿のみ（タイムラインと同じくミュート・ブロックしたユーザーは除く）
+				if event.AuthorID != userID {
+					if !models.IsFollowing(s.DB, userID, event.AuthorID) ||
+						models.IsMuted(s.DB, userID, event.AuthorID) ||
+						models.IsBlockedBetween(s.DB, userID, event.AuthorID) {
+						return nil, false, nil
+					}
+				}
+				return s.loadPost(ctx, event.PostID)
+			},
+		}, nil
+
+	case "postLikeCountChanged":
+		postID, err := optionalID(args["postId"])
+		if err != nil {
+			return nil, err
+		}
+		if postID == 0 {
+			return nil, fmt.Errorf("postId is required")
+		}
+		// 見られない投稿（ブロック・非公開）は存在しない投稿と同じく拒否する
+		var post models.Post
+		if err := s.DB.First(&post, postID).Error; err != nil {
+			return nil, errPostNotFound
+		}
+		if err := s.checkPostAccess(viewerID(ctx), &post); err != nil {
+			return nil, errPostNotFound
+		}
+		return &subscriptionSource{
+			topic: topicPostLikeCount,
+			resolve: func(payload []byte) (interface{}, bool, error) {
+				var event postLikeCountEvent
+				if err := json.Unmarshal(payload, &event); err != nil {
+					return nil, false, err
+				}
+				if event.PostID != postID {
+					return nil, false, nil
+				}
+				// 購読後にブロック・非公開になった場合は送らない
+				if !s.canViewUserContent(viewerID(ctx), post.AuthorID) {
+					return nil, false, nil
+				}
+				return model.PostLikeCount{
+					PostID:    strconv.FormatUint(uint64(event.PostID), 10),
+					LikeCount: int(event.LikeCount),
+				}, true, nil
+			},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown subscription: %s", field.Name)
+}
+
+// loadPost はイベントの投稿を作成者・エンティティ込みで読み込みます（削除済みの場合は送信しない）
+func (s *Server) loadPost(ctx context.Context, postID uint) (interface{}, bool, error) {
+	var post models.Post
+	result := s.postQuery().Limit(1).Find(&post, postID)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, false, nil
+	}
+	view, err := s.buildPostView(ctx, post)
+	if err != nil {
+		return nil, false, err
+	}
+	return view, true, nil
+}
+
+// optionalID はID型の引数を数値に変換します（未指定の場合は0）
+// IDはリテラルでは文字列、変数ではJSONの文字列または数値で渡される
+func optionalID(value interface{}) (uint, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ID: %s", v)
+		}
+		return uint(id), nil
+	case float64:
+		return uint(v), nil
+	case int64:
+		return uint(v), nil
+	case json.Number:
+		id, err := strconv.ParseUint(v.String(), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ID: %s", v)
+		}
+		return uint(id), nil
+	}
+	return 0, fmt.Errorf("invalid ID: %v", value)
+}
@@ -0,0 +1,169 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/pubsub"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+)
+
+func TestSubscriptionIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg, PubSub: pubsub.NewMemoryBroker()}
+	url := newWebSocketServer(t, srv)
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	carol := testutil.CreateTestUser(t, db, "carol", "carol@example.com", "Carol")
+	if err := db.Create(&models.Follow{FollowerID: alice.ID, FolloweeID: bob.ID}).Error; err != nil {
+		t.Fatalf("Failed to create follow: %v", err)
+	}
+
+	tokenFor := func(user *models.User) string {
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		return token
+	}
+	createPost := func(user *models.User, content string) {
+		resp := executeAuthenticatedRequest(t, srv, GraphQLRequest{
+			Query:     `mutation { createPost(input: $input) { id } }`,
+			Variables: map[string]interface{}{"input": map[string]interface{}{"content": content}},
+		}, tokenFor(user))
+		if resp.Errors != nil {
+			t.Fatalf("Failed to create post: %v", resp.Errors)
+		}
+	}
+	postContent := func(msg wsMessage, field string) string {
+		var resp struct {
+			Data map[string]struct {
+				Content string `json:"content"`
+			} `json:"data"`
+		}
+		json.Unmarshal(msg.Payload, &resp)
+		return resp.Data[field].Content
+	}
+
+	t.Run("postCreatedはauthorIdで絞り込める", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(
+			`{"query":"subscription ($authorId: ID) { postCreated(authorId: $authorId) { id content } }","variables":{"authorId":` + fmt.Sprint(bob.ID) + `}}`,
+		)})
+		time.Sleep(50 * time.Millisecond)
+
+		createPost(carol, "carol's post")
+		createPost(bob, "bob's post")
+
+		msg := readWS(t, conn)
+		if msg.Type != "next" || postContent(msg, "postCreated") != "bob's post" {
+			t.Errorf("Expected bob's post, got %s %s", msg.Type, msg.Payload)
+		}
+	})
+
+	t.Run("timelineUpdatedは自分とフォロー中のユーザーの投稿のみ", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, `{"authorization":"Bearer `+tokenFor(alice)+`"}`)
+		sendWS(t, conn, wsMessage{ID: "timeline", Type: "subscribe", Payload: json.RawMessage(
+			`{"query":"subscription { timelineUpdated { id content } }"}`,
+		)})
+		time.Sleep(50 * time.Millisecond)
+
+		createPost(carol, "not followed")
+		createPost(bob, "followed")
+		createPost(alice, "own")
+
+		for _, expected := range []string{"followed", "own"} {
+			msg := readWS(t, conn)
+			if got := postContent(msg, "timelineUpdated"); got != expected {
+				t.Errorf("Expected %q, got %q", expected, got)
+			}
+		}
+	})
+
+	t.Run("timelineUpdatedはミュートしたユーザーの投稿を送らない", func(t *testing.T) {
+		if err := db.Create(&models.Follow{FollowerID: alice.ID, FolloweeID: carol.ID}).Error; err != nil {
+			t.Fatalf("Failed to create follow: %v", err)
+		}
+		if err := db.Create(&models.Mute{MuterID: alice.ID, MutedID: carol.ID}).Error; err != nil {
+			t.Fatalf("Failed to create mute: %v", err)
+		}
+
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, `{"authorization":"Bearer `+tokenFor(alice)+`"}`)
+		sendWS(t, conn, wsMessage{ID: "timeline", Type: "subscribe", Payload: json.RawMessage(
+			`{"query":"subscription { timelineUpdated { id content } }"}`,
+		)})
+		time.Sleep(50 * time.Millisecond)
+
+		createPost(carol, "muted")
+		createPost(bob, "not muted")
+
+		if got := postContent(readWS(t, conn), "timelineUpdated"); got != "not muted" {
+			t.Errorf("Expected muted user's post to be skipped, got %q", got)
+		}
+	})
+
+	t.Run("いいねでpostLikeCountChangedが届く", func(t *testing.T) {
+		post := testutil.CreateTestPost(t, db, bob.ID, "like me")
+
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{ID: "likes", Type: "subscribe", Payload: json.RawMessage(
+			`{"query":"subscription { postLikeCountChanged(postId: ` + fmt.Sprint(post.ID) + `) { likeCount } }"}`,
+		)})
+		time.Sleep(50 * time.Millisecond)
+
+		resp := executeAuthenticatedRequest(t, srv, GraphQLRequest{
+			Query:     `mutation { likePost(postId: $postId) { id } }`,
+			Variables: map[string]interface{}{"postId": float64(post.ID)},
+		}, tokenFor(alice))
+		if resp.Errors != nil {
+			t.Fatalf("Failed to like post: %v", resp.Errors)
+		}
+
+		msg := readWS(t, conn)
+		var data struct {
+			Data struct {
+				PostLikeCountChanged struct {
+					LikeCount int `json:"likeCount"`
+				} `json:"postLikeCountChanged"`
+			} `json:"data"`
+		}
+		json.Unmarshal(msg.Payload, &data)
+		if data.Data.PostLikeCountChanged.LikeCount != 1 {
+			t.Errorf("Expected like count 1, got %s", msg.Payload)
+		}
+	})
+
+	t.Run("見られない投稿のpostLikeCountChangedは購読できない", func(t *testing.T) {
+		dave := testutil.CreateTestUser(t, db, "dave", "dave@example.com", "Dave")
+		db.Model(dave).Update("is_private", true)
+		post := testutil.CreateTestPost(t, db, dave.ID, "private")
+
+		for _, tc := range []struct {
+			name   string
+			postID uint
+		}{{"非公開アカウントの投稿", post.ID}, {"存在しない投稿", post.ID + 1000}} {
+			conn := dialWebSocket(t, url)
+			initWS(t, conn, `{"authorization":"Bearer `+tokenFor(alice)+`"}`)
+			sendWS(t, conn, wsMessage{ID: "likes", Type: "subscribe", Payload: json.RawMessage(
+				`{"query":"subscription { postLikeCountChanged(postId: ` + fmt.Sprint(tc.postID) + `) { likeCount } }"}`,
+			)})
+
+			msg := readWS(t, conn)
+			var errs []GraphQLError
+			json.Unmarshal(msg.Payload, &errs)
+			if msg.Type != "error" || len(errs) != 1 || errs[0].Message != "Post not found" {
+				t.Errorf("%s: expected Post not found error, got %s %s", tc.name, msg.Type, msg.Payload)
+			}
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"sns-server/internal/models"
+	"sns-server/internal/timeline"
+)
+
+// タイムラインに表示する投稿のID
+// 自分とフォロー中のユーザーの投稿・リポストを投稿ごとにまとめ、最後の活動時刻の順に並べる
+// （同じ投稿を複数人がリポストしても1件だけ表示する）
+// フォロー中のユーザーの活動は書き込み時に配信したtimeline_entriesから読み、
+// 自分とフォロワーの多いアカウント（配信しない）の活動は投稿・リポストから直接読む
+// ブロックの関係にあるユーザー、ミュートしたユーザー、フォローしていない非公開アカウント、退会したユーザーの投稿・リポストは表示しない
+const timelineQuery = `
+WITH hidden AS (
+	SELECT blocked_id AS user_id FROM blocks WHERE blocker_id = @user
+	UNION SELECT blocker_id FROM blocks WHERE blocked_id = @user
+	UNION SELECT muted_id FROM mutes WHERE muter_id = @user
+	UNION SELECT id FROM users WHERE is_private = @private AND id <> @user
+		AND id NOT IN (SELECT followee_id FROM follows WHERE follower_id = @user)
+	UNION SELECT id FROM users WHERE deleted_at IS NOT NULL
+),
+followees AS (
+	SELECT followee_id FROM follows WHERE follower_id = @user
+),
+live AS (
+	SELECT id AS user_id FROM users
+	WHERE id IN (SELECT followee_id FROM followees) AND follower_count >= @fanoutThreshold
+)
+SELECT post_id FROM (
+	SELECT timeline_entries.post_id, timeline_entries.activity_at
+	FROM timeline_entries
+	JOIN posts ON posts.id = timeline_entries.post_id AND posts.deleted_at IS NULL
+	WHERE timeline_entries.user_id = @user
+		AND timeline_entries.actor_id IN (SELECT followee_id FROM followees)
+		AND timeline_entries.actor_id NOT IN (SELECT user_id FROM hidden)
+		AND posts.author_id NOT IN (SELECT user_id FROM hidden)
+	UNION ALL
+	SELECT posts.id AS post_id, posts.created_at AS activity_at
+	FROM posts
+	WHERE posts.deleted_at IS NULL
+		AND (posts.author_id = @user OR posts.author_id IN (SELECT user_id FROM live))
+		AND posts.author_id NOT IN (SELECT user_id FROM hidden)
+	UNION ALL
+	SELECT reposts.post_id, reposts.created_at AS activity_at
+	FROM reposts
+	JOIN posts ON posts.id = reposts.post_id AND posts.deleted_at IS NULL
+	WHERE (reposts.user_id = @user OR reposts.user_id IN (SELECT user_id FROM live))
+		AND reposts.user_id NOT IN (SELECT user_id FROM hidden)
+		AND posts.author_id NOT IN (SELECT user_id FROM hidden)
+) AS activity
+GROUP BY post_id
+%s
+ORDER BY MAX(activity_at) DESC, post_id DESC
+LIMIT @limit`
+
+func (s *Server) handleTimelineQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	user, err := s.requireUser(ctx)
+	if err != nil {
+		return errorResponse(err.Error())
+	}
+
+	limit := pageSize(variables)
+	params := map[string]interface{}{
+		"user":            user.ID,
+		"limit":           limit + 1,
+		"private":         true,
+		"fanoutThreshold": timeline.PolicyFromConfig(s.Config).FanoutThreshold(),
+	}
+	having := ""
+	if cursor := getString(variables, "cursor"); cursor != "" {
+		activityAt, id, err := decodeCursor(cursor)
+		if err != nil {
+			return errorResponse(err.Error())
+		}
+		having = "HAVING MAX(activity_at) < @at OR (MAX(activity_at) = @at AND post_id < @id)"
+		params["at"] = activityAt
+		params["id"] = id
+	}
+
+	var ids []uint
+	if err := s.DB.Raw(fmt.Sprintf(timelineQuery, having), params).Scan(&ids).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	hasNextPage := len(ids) > limit
+	if hasNextPage {
+		ids = ids[:limit]
+	}
+
+	items, err := s.buildTimelineItems(ctx, user.ID, ids)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	var nextCursor *string
+	if hasNextPage && len(items) > 0 {
+		last := items[len(items)-1]
+		cursor := encodeCursor(last.activityAt, last.ID)
+		nextCursor = &cursor
+	}
+
+	views := make([]postView, 0, len(items))
+	for _, item := range items {
+		views = append(views, item.postView)
+	}
+
+	return dataResponse("timeline", map[string]interface{}{
+		"posts":       views,
+		"hasNextPage": hasNextPage,
+		"cursor":      nextCursor,
+	})
+}
+
+// timelineItem はタイムラインの1件です
+type timelineItem struct {
+	postView
+	activityAt time.Time
+}
+
+// buildTimelineItems はIDの順に投稿を読み込み、リポストで表示される投稿には最後にリポストしたユーザーを付けます
+func (s *Server) buildTimelineItems(ctx context.Context, userID uint, ids []uint) ([]timelineItem, error) {
+	if len(ids) == 0 {
+		return []timelineItem{}, nil
+	}
+
+	var posts []models.Post
+	if err := s.postQuery().Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	views, err := s.buildPostViews(ctx, posts)
+	if err != nil {
+		return nil, err
+	}
+	viewsByID := make(map[uint]postView, len(views))
+	for _, view := range views {
+		viewsByID[view.ID] = view
+	}
+
+	// 自分とフォロー中のユーザー（ミュートしたユーザーを除く）による最新のリポスト
+	var reposts []models.Repost
+	err = models.ExcludeMuted(s.DB, "user_id", userID).Preload("User").
+		Where("post_id IN ?", ids).
+		Where("user_id = ? OR user_id IN (?)", userID,
+			s.DB.Model(&models.Follow{}).Select("followee_id").Where("follower_id = ?", userID)).
+		Order("created_at DESC").
+		Find(&reposts).Error
+	if err != nil {
+		return nil, err
+	}
+	latestRepost := make(map[uint]models.Repost)
+	for _, repost := range reposts {
+		if _, ok := latestRepost[repost.PostID]; !ok {
+			latestRepost[repost.PostID] = repost
+		}
+	}
+
+	following := make(map[uint]bool)
+	var followeeIDs []uint
+	if err := s.DB.Model(&models.Follow{}).Where("follower_id = ?", userID).Pluck("followee_id", &followeeIDs).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range followeeIDs {
+		following[id] = true
+	}
+	following[userID] = true
+
+	items := make([]timelineItem, 0, len(ids))
+	for _, id := range ids {
+		view, ok := viewsByID[id]
+		if !ok {
+			continue
+		}
+
+		// 投稿とリポストのうち新しい方で表示する（カーソルの位置にもなる）
+		item := timelineItem{postView: view}
+		if following[view.AuthorID] {
+			item.activityAt = view.CreatedAt
+		}
+		if repost, ok := latestRepost[id]; ok && repost.CreatedAt.After(item.activityAt) {
+			user := repost.User
+			item.RepostedBy = &user
+			item.activityAt = repost.CreatedAt
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// enqueueTimelineJob はタイムラインへの配信ジョブを登録します
+// 登録に失敗しても元の操作は失敗させない（Jobsがnilの場合は配信しない）
+func (s *Server) enqueueTimelineJob(jobType string, payload interface{}) {
+	if s.Jobs == nil {
+		return
+	}
+	if _, err := s.Jobs.Enqueue(jobType, payload); err != nil {
+		log.Printf("Failed to enqueue %s job: %v", jobType, err)
+	}
+}
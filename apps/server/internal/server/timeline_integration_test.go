@@ -0,0 +1,197 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/jobs"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+	"sns-server/internal/timeline"
+)
+
+func TestTimelineFanoutIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	cfg.TimelineFanoutMaxFollowers = 2
+	queue := jobs.NewQueue(jobs.NewMemoryStore(), jobs.PolicyFromConfig(cfg))
+	srv := &server.Server{DB: db, Config: cfg, Jobs: queue}
+	fanout := timeline.NewFanout(db, timeline.PolicyFromConfig(cfg))
+	queue.Register(timeline.FanoutJobType, fanout.HandleFanoutJob)
+	queue.Register(timeline.BackfillJobType, fanout.HandleBackfillJob)
+
+	alice := testutil.CreateTestUser(t, db, "alice", "alice@example.com", "Alice")
+	bob := testutil.CreateTestUser(t, db, "bob", "bob@example.com", "Bob")
+	carol := testutil.CreateTestUser(t, db, "carol", "carol@example.com", "Carol")
+	celeb := testutil.CreateTestUser(t, db, "celeb", "celeb@example.com", "Celeb")
+	moddy := testutil.CreateTestUser(t, db, "moddy", "moddy@example.com", "Moddy")
+	if err := models.SetUserRole(db, moddy, models.RoleModerator); err != nil {
+		t.Fatalf("Failed to set role: %v", err)
+	}
+
+	execute := func(user *models.User, query string, variables map[string]interface{}) GraphQLResponse {
+		t.Helper()
+		token, _ := auth.IssueSessionToken(cfg.JWTSecret, user.ID, time.Hour)
+		resp := executeAuthenticatedRequest(t, srv, GraphQLRequest{Query: query, Variables: variables}, token)
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		return resp
+	}
+	runJobs := func() {
+		t.Helper()
+		if _, err := queue.RunPending(context.Background()); err != nil {
+			t.Fatalf("Failed to run jobs: %v", err)
+		}
+	}
+	userVars := func(user *models.User) map[string]interface{} {
+		return map[string]interface{}{"userId": fmt.Sprint(user.ID)}
+	}
+	postVars := func(postID uint) map[string]interface{} {
+		return map[string]interface{}{"postId": fmt.Sprint(postID)}
+	}
+	createPost := func(user *models.User, content string) uint {
+		t.Helper()
+		resp := execute(user, `mutation { createPost(input: $input) { id } }`, map[string]interface{}{
+			"input": map[string]interface{}{"content": content},
+		})
+		var post struct {
+			ID json.Number `json:"id"`
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})["createPost"])
+		json.Unmarshal(data, &post)
+		id, _ := post.ID.Int64()
+		return uint(id)
+	}
+	contents := func(user *models.User) map[string]bool {
+		t.Helper()
+		resp := execute(user, `query { timeline { posts { content } } }`, nil)
+		var page struct {
+			Posts []struct {
+				Content string `json:"content"`
+			} `json:"posts"`
+		}
+		data, _ := json.Marshal(resp.Data.(map[string]interface{})["timeline"])
+		json.Unmarshal(data, &page)
+		result := map[string]bool{}
+		for _, post := range page.Posts {
+			result[post.Content] = true
+		}
+		return result
+	}
+	entries := func(query string, args ...interface{}) int64 {
+		var count int64
+		db.Model(&models.TimelineEntry{}).Where(query, args...).Count(&count)
+		return count
+	}
+
+	testutil.CreateTestPost(t, db, bob.ID, "bob before follow")
+
+	t.Run("フォローすると最近の投稿をタイムラインに追加する", func(t *testing.T) {
+		execute(alice, `mutation { followUser(userId: $userId) { id } }`, userVars(bob))
+		execute(alice, `mutation { followUser(userId: $userId) { id } }`, userVars(carol))
+		runJobs()
+
+		if !contents(alice)["bob before follow"] {
+			t.Error("Expected followed user's recent post to be backfilled")
+		}
+	})
+
+	var bobPostID uint
+	t.Run("投稿はジョブでフォロワーのタイムラインに配信する", func(t *testing.T) {
+		bobPostID = createPost(bob, "bob fanned out")
+		if contents(alice)["bob fanned out"] {
+			t.Error("Expected post not to appear before the fan-out job runs")
+		}
+
+		runJobs()
+		if !contents(alice)["bob fanned out"] {
+			t.Error("Expected post to appear after the fan-out job runs")
+		}
+		if n := entries("user_id = ? AND post_id = ?", alice.ID, bobPostID); n != 1 {
+			t.Errorf("Expected 1 timeline entry, got %d", n)
+		}
+		// 自分の投稿は配信せず読み込み時に取得する
+		if n := entries("user_id = ?", bob.ID); n != 0 {
+			t.Errorf("Expected no entries for the author, got %d", n)
+		}
+		if !contents(bob)["bob fanned out"] {
+			t.Error("Expected author to see their own post")
+		}
+	})
+
+	t.Run("フォロワーの多いアカウントは読み込み時に取得する", func(t *testing.T) {
+		execute(alice, `mutation { followUser(userId: $userId) { id } }`, userVars(celeb))
+		execute(bob, `mutation { followUser(userId: $userId) { id } }`, userVars(celeb))
+		createPost(celeb, "celeb live")
+		runJobs()
+
+		if n := entries("actor_id = ?", celeb.ID); n != 0 {
+			t.Errorf("Expected no fan-out for account over the threshold, got %d entries", n)
+		}
+		if !contents(alice)["celeb live"] || !contents(bob)["celeb live"] {
+			t.Error("Expected followers to see the post at read time")
+		}
+	})
+
+	t.Run("リポストの配信と取り消し", func(t *testing.T) {
+		execute(carol, `mutation { repost(postId: $postId) { id } }`, postVars(bobPostID))
+		runJobs()
+		if n := entries("user_id = ? AND actor_id = ? AND kind = ?", alice.ID, carol.ID, models.TimelineEntryRepost); n != 1 {
+			t.Errorf("Expected repost to be fanned out, got %d entries", n)
+		}
+
+		execute(carol, `mutation { unrepost(postId: $postId) { id } }`, postVars(bobPostID))
+		if n := entries("actor_id = ? AND kind = ?", carol.ID, models.TimelineEntryRepost); n != 0 {
+			t.Errorf("Expected repost to be removed, got %d entries", n)
+		}
+	})
+
+	t.Run("モデレーションで非表示にすると取り除き、戻すと再配信する", func(t *testing.T) {
+		carolPostID := createPost(carol, "carol moderated")
+		runJobs()
+
+		execute(moddy, `mutation { hidePost(postId: $postId, reason: $reason) { id } }`,
+			map[string]interface{}{"postId": fmt.Sprint(carolPostID), "reason": "spam"})
+		if n := entries("post_id = ?", carolPostID); n != 0 {
+			t.Errorf("Expected hidden post to be removed, got %d entries", n)
+		}
+		if contents(alice)["carol moderated"] {
+			t.Error("Expected hidden post to be absent from the timeline")
+		}
+
+		execute(moddy, `mutation { restorePost(postId: $postId, reason: $reason) { id } }`,
+			map[string]interface{}{"postId": fmt.Sprint(carolPostID), "reason": "appeal accepted"})
+		runJobs()
+		if !contents(alice)["carol moderated"] {
+			t.Error("Expected restored post to be fanned out again")
+		}
+	})
+
+	t.Run("フォロー解除で取り除く", func(t *testing.T) {
+		execute(alice, `mutation { unfollowUser(userId: $userId) { id } }`, userVars(carol))
+		if n := entries("user_id = ? AND actor_id = ?", alice.ID, carol.ID); n != 0 {
+			t.Errorf("Expected unfollowed user's entries to be removed, got %d", n)
+		}
+		if contents(alice)["carol moderated"] {
+			t.Error("Expected unfollowed user's post to be absent")
+		}
+	})
+
+	t.Run("ブロックで取り除く", func(t *testing.T) {
+		execute(bob, `mutation { blockUser(userId: $userId) { id } }`, userVars(alice))
+		if n := entries("user_id = ? AND actor_id = ?", alice.ID, bob.ID); n != 0 {
+			t.Errorf("Expected blocked user's entries to be removed, got %d", n)
+		}
+		if contents(alice)["bob fanned out"] {
+			t.Error("Expected blocking user's post to be absent")
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"sns-server/internal/models"
+)
+
+func (s *Server) handleTrendingQuery(ctx context.Context, variables map[string]interface{}) GraphQLResponse {
+	window := getString(variables, "window")
+	if window == "" {
+		window = models.TrendingWindowDay
+	}
+	if models.TrendingWindowDuration(window) == 0 {
+		return errorResponse("Invalid trending window")
+	}
+	limit := pageSize(variables)
+
+	var hashtags []models.TrendingHashtag
+	if err := s.DB.Where("time_window = ?", window).Order("rank").Limit(limit).Find(&hashtags).Error; err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	// 集計後に削除された投稿と見られない投稿（ブロック・非公開）は除く
+	var ranked []models.TrendingPost
+	err := models.ExcludeHidden(s.DB, "posts.author_id", viewerID(ctx)).Joins("JOIN posts ON posts.id = trending_posts.post_id AND posts.deleted_at IS NULL").
+		Where("trending_posts.time_window = ?", window).
+		Order("trending_posts.rank").
+		Limit(limit).
+		Find(&ranked).Error
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	posts, err := s.trendingPostViews(ctx, ranked)
+	if err != nil {
+		return errorResponse(fmt.Sprintf("Database error: %v", err))
+	}
+
+	var computedAt interface{}
+	if len(hashtags) > 0 {
+		computedAt = hashtags[0].ComputedAt
+	} else if len(ranked) > 0 {
+		computedAt = ranked[0].ComputedAt
+	}
+
+	return dataResponse("trending", map[string]interface{}{
+		"window":     window,
+		"hashtags":   hashtags,
+		"posts":      posts,
+		"computedAt": computedAt,
+	})
+}
+
+// trendingPostView はAPIで返すトレンドの投稿です
+type trendingPostView struct {
+	models.TrendingPost
+	Post postView `json:"post"`
+}
+
+// trendingPostViews は順位の順に投稿を読み込みます
+func (s *Server) trendingPostViews(ctx context.Context, ranked []models.TrendingPost) ([]trendingPostView, error) {
+	ids := make([]uint, 0, len(ranked))
+	for _, r := range ranked {
+		ids = append(ids, r.PostID)
+	}
+
+	var posts []models.Post
+	if err := s.postQuery().Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	views, err := s.buildPostViews(ctx, posts)
+	if err != nil {
+		return nil, err
+	}
+	viewsByID := make(map[uint]postView, len(views))
+	for _, view := range views {
+		viewsByID[view.ID] = view
+	}
+
+	result := make([]trendingPostView, 0, len(ranked))
+	for _, r := range ranked {
+		if view, ok := viewsByID[r.PostID]; ok {
+			result = append(result, trendingPostView{TrendingPost: r, Post: view})
+		}
+	}
+	return result, nil
+}
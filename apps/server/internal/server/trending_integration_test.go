@@ -0,0 +1,117 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/server"
+	"sns-server/internal/testutil"
+	"sns-server/internal/trending"
+)
+
+func TestTrendingIntegration(t *testing.T) {
+	db := testutil.SetupTestDB(t)
+
+	cfg := config.LoadTest()
+	srv := &server.Server{DB: db, Config: cfg}
+
+	var users []*models.User
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("user%d", i)
+		users = append(users, testutil.CreateTestUser(t, db, name, name+"@example.com", name))
+	}
+	var posts []*models.Post
+	for _, user := range users[:3] {
+		posts = append(posts, testutil.CreateTestPost(t, db, user.ID, "I love #Go"))
+	}
+	// 1人だけが使ったタグはトレンドにならない
+	testutil.CreateTestPost(t, db, users[0].ID, "#solo #solo")
+	// 作成者以外の3人がいいねした投稿
+	for _, user := range users[1:] {
+		db.Create(&models.Like{UserID: user.ID, PostID: posts[0].ID})
+	}
+	deleted := posts[1]
+	for _, user := range []*models.User{users[0], users[2], users[3]} {
+		db.Create(&models.Like{UserID: user.ID, PostID: deleted.ID})
+	}
+
+	calculator := trending.NewCalculator(db, trending.PolicyFromConfig(cfg))
+	if err := calculator.Refresh(); err != nil {
+		t.Fatalf("Failed to refresh trending: %v", err)
+	}
+	// 集計後に削除された投稿は返さない
+	db.Delete(deleted)
+
+	query := func(variables map[string]interface{}) GraphQLResponse {
+		return executeGraphQLRequest(t, srv, GraphQLRequest{
+			Query:     `query { trending(window: $window, limit: $limit) { window hashtags { tag rank score postCount accountCount } posts { rank score post { id content author { username } } } computedAt } }`,
+			Variables: variables,
+		})
+	}
+
+	t.Run("ハッシュタグと投稿のトレンド", func(t *testing.T) {
+		resp := query(map[string]interface{}{"window": "DAY"})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+
+		var result struct {
+			Trending struct {
+				Window   string `json:"window"`
+				Hashtags []struct {
+					Tag          string  `json:"tag"`
+					Rank         int     `json:"rank"`
+					Score        float64 `json:"score"`
+					AccountCount int     `json:"accountCount"`
+				} `json:"hashtags"`
+				Posts []struct {
+					Rank int `json:"rank"`
+					Post struct {
+						ID json.Number `json:"id"`
+					} `json:"post"`
+				} `json:"posts"`
+				ComputedAt *string `json:"computedAt"`
+			} `json:"trending"`
+		}
+		data, _ := json.Marshal(resp.Data)
+		json.Unmarshal(data, &result)
+
+		got := result.Trending
+		if got.Window != "DAY" || got.ComputedAt == nil {
+			t.Errorf("Unexpected window or computedAt: %+v", got)
+		}
+		if len(got.Hashtags) != 1 || got.Hashtags[0].Tag != "go" || got.Hashtags[0].Rank != 1 || got.Hashtags[0].AccountCount != 3 {
+			t.Errorf("Expected only #go trending, got %+v", got.Hashtags)
+		}
+		if len(got.Posts) != 1 || got.Posts[0].Post.ID.String() != fmt.Sprint(posts[0].ID) {
+			t.Errorf("Expected only the liked post, got %+v", got.Posts)
+		}
+	})
+
+	t.Run("集計されていない場合は空", func(t *testing.T) {
+		db.Where("time_window = ?", models.TrendingWindowWeek).Delete(&models.TrendingHashtag{})
+		db.Where("time_window = ?", models.TrendingWindowWeek).Delete(&models.TrendingPost{})
+
+		resp := query(map[string]interface{}{"window": "WEEK"})
+		if resp.Errors != nil {
+			t.Fatalf("Unexpected errors: %v", resp.Errors)
+		}
+		trendingData := resp.Data.(map[string]interface{})["trending"].(map[string]interface{})
+		if hashtags, ok := trendingData["hashtags"].([]interface{}); !ok || len(hashtags) != 0 {
+			t.Errorf("Expected empty hashtags, got %v", trendingData["hashtags"])
+		}
+		if trendingData["computedAt"] != nil {
+			t.Errorf("Expected null computedAt, got %v", trendingData["computedAt"])
+		}
+	})
+
+	t.Run("不正な期間はエラー", func(t *testing.T) {
+		resp := query(map[string]interface{}{"window": "MONTH"})
+		if resp.Errors == nil {
+			t.Error("Expected error for invalid window")
+		}
+	})
+}
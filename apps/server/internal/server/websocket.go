@@ -0,0 +1,391 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"sns-server/internal/auth"
+	"sns-server/internal/persisted"
+)
+
+// graphql-transport-wsプロトコル
+// https://github.com/enisdenjo/graphql-ws/blob/master/PROTOCOL.md
+const wsSubprotocol = "graphql-transport-ws"
+
+// メッセージの種類
+const (
+	wsConnectionInit = "connection_init"
+	wsConnectionAck  = "connection_ack"
+	wsPing           = "ping"
+	wsPong           = "pong"
+	wsSubscribe      = "subscribe"
+	wsNext           = "next"
+	wsError          = "error"
+	wsComplete       = "complete"
+)
+
+// プロトコルで定められた切断コード
+const (
+	wsCloseBadRequest          = 4400
+	wsCloseUnauthorized        = 4401
+	wsCloseForbidden           = 4403
+	wsCloseSubprotocol         = 4406
+	wsCloseInitTimeout         = 4408
+	wsCloseSubscriberExists    = 4409
+	wsCloseTooManyInitRequests = 4429
+)
+
+const (
+	wsMaxMessageBytes = 64 << 10
+	wsWriteTimeout    = 10 * time.Second
+	wsDefaultInitWait = 10 * time.Second
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsConnection はWebSocketの1接続です
+// 書き込みはwriteMuで直列化し、実行中の操作はIDごとにキャンセルできるように保持します
+type wsConnection struct {
+	server *Server
+	conn   *websocket.Conn
+
+	// 接続のコンテキスト（connection_init後は認証ユーザーを含む）
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	writeMu sync.Mutex
+
+	mu          sync.Mutex
+	initialized bool
+	operations  map[string]*wsOperation
+}
+
+// wsOperation は実行中の操作です（同じIDの再利用と区別するためポインタで管理する）
+type wsOperation struct {
+	cancel context.CancelFunc
+}
+
+// HandleSubscriptions はWebSocketでGraphQLのサブスクリプションを提供します
+// 認証はAuthorizationヘッダーではなくconnection_initのペイロードで行います
+func (s *Server) HandleSubscriptions(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{
+		Subprotocols: []string{wsSubprotocol},
+		CheckOrigin:  s.checkWebSocketOrigin,
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgraderがエラーレスポンスを返している
+		return
+	}
+
+	ctx, cancel := context.WithCancel(withClientIP(context.Background(), clientIP(r)))
+	c := &wsConnection{
+		server:     s,
+		conn:       conn,
+		ctx:        ctx,
+		cancel:     cancel,
+		operations: make(map[string]*wsOperation),
+	}
+
+	if conn.Subprotocol() != wsSubprotocol {
+		c.close(wsCloseSubprotocol, "Subprotocol not acceptable")
+		return
+	}
+
+	c.run()
+}
+
+// checkWebSocketOrigin はCORS設定と同じオリジンのみ接続を許可します
+func (s *Server) checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// ブラウザ以外のクライアント
+		return true
+	}
+	for _, allowed := range s.Config.CORSOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *wsConnection) run() {
+	defer c.shutdown()
+
+	c.conn.SetReadLimit(wsMaxMessageBytes)
+
+	// connection_initが来なければ切断する
+	initTimeout := c.server.Config.WebSocketInitTimeout
+	if initTimeout <= 0 {
+		initTimeout = wsDefaultInitWait
+	}
+	timer := time.AfterFunc(initTimeout, func() {
+		c.mu.Lock()
+		initialized := c.initialized
+		c.mu.Unlock()
+		if !initialized {
+			c.close(wsCloseInitTimeout, "Connection initialisation timeout")
+		}
+	})
+	defer timer.Stop()
+
+	if keepAlive := c.server.Config.WebSocketKeepAlive; keepAlive > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(2 * keepAlive))
+		c.conn.SetPongHandler(func(string) error {
+			return c.conn.SetReadDeadline(time.Now().Add(2 * keepAlive))
+		})
+		go c.keepAlive(c.ctx, keepAlive)
+	}
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.close(wsCloseBadRequest, "Invalid message received")
+			return
+		}
+
+		if !c.handleMessage(msg) {
+			return
+		}
+	}
+}
+
+// handleMessage はクライアントからのメッセージを処理します（falseの場合は切断する）
+func (c *wsConnection) handleMessage(msg wsMessage) bool {
+	switch msg.Type {
+	case wsConnectionInit:
+		return c.handleConnectionInit(msg.Payload)
+
+	case wsPing:
+		c.write(wsMessage{Type: wsPong, Payload: msg.Payload})
+		return true
+
+	case wsPong:
+		return true
+
+	case wsSubscribe:
+		c.mu.Lock()
+		initialized := c.initialized
+		c.mu.Unlock()
+		if !initialized {
+			c.close(wsCloseUnauthorized, "Unauthorized")
+			return false
+		}
+
+		var payload subscribePayload
+		if msg.ID == "" || json.Unmarshal(msg.Payload, &payload) != nil {
+			c.close(wsCloseBadRequest, "Invalid subscribe message")
+			return false
+		}
+		return c.startOperation(msg.ID, payload)
+
+	case wsComplete:
+		c.stopOperation(msg.ID)
+		return true
+	}
+
+	c.close(wsCloseBadRequest, fmt.Sprintf("Invalid message type %q", msg.Type))
+	return false
+}
+
+// handleConnectionInit はconnection_initのペイロードのトークンで接続を認証します
+// トークンがない場合は未認証の接続として受け入れます
+func (c *wsConnection) handleConnectionInit(raw json.RawMessage) bool {
+	c.mu.Lock()
+	if c.initialized {
+		c.mu.Unlock()
+		c.close(wsCloseTooManyInitRequests, "Too many initialisation requests")
+		return false
+	}
+	c.mu.Unlock()
+
+	var payload map[string]interface{}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			c.close(wsCloseBadRequest, "Invalid connection_init payload")
+			return false
+		}
+	}
+
+	if token := connectionInitToken(payload); token != "" {
+		userID, err := auth.ParseSessionToken(c.server.Config.JWTSecret, token)
+		if err != nil {
+			c.close(wsCloseForbidden, "Forbidden")
+			return false
+		}
+		// 退会済み・利用停止中のユーザーは接続させない
+		if user, ok := c.server.sessionUser(userID); !ok || user.IsSuspended() {
+			c.close(wsCloseForbidden, "Forbidden")
+			return false
+		}
+		c.ctx = auth.WithUserID(c.ctx, userID)
+	}
+
+	c.mu.Lock()
+	c.initialized = true
+	c.mu.Unlock()
+
+	c.write(wsMessage{Type: wsConnectionAck})
+	return true
+}
+
+// connectionInitToken はconnection_initのペイロードからセッショントークンを取り出します
+// {"authorization": "Bearer <token>"} または {"token": "<token>"} の形式に対応します
+func connectionInitToken(payload map[string]interface{}) string {
+	for _, key := range []string{"authorization", "Authorization"} {
+		if header := getString(payload, key); header != "" {
+			token, _ := strings.CutPrefix(header, "Bearer ")
+			return token
+		}
+	}
+	return getString(payload, "token")
+}
+
+type subscribePayload struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+	Extensions    *RequestExtensions     `json:"extensions"`
+}
+
+// startOperation は操作を別のゴルーチンで開始します
+func (c *wsConnection) startOperation(id string, payload subscribePayload) bool {
+	c.mu.Lock()
+	if _, exists := c.operations[id]; exists {
+		c.mu.Unlock()
+		c.close(wsCloseSubscriberExists, fmt.Sprintf("Subscriber for %s already exists", id))
+		return false
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	op := &wsOperation{cancel: cancel}
+	c.operations[id] = op
+	c.mu.Unlock()
+
+	go func() {
+		errs := c.server.runOperation(ctx, c, id, payload)
+		c.finishOperation(id, op, errs)
+	}()
+	return true
+}
+
+// stopOperation はクライアントからのcompleteで操作を止めます
+func (c *wsConnection) stopOperation(id string) {
+	c.mu.Lock()
+	op, ok := c.operations[id]
+	delete(c.operations, id)
+	c.mu.Unlock()
+
+	if ok {
+		op.cancel()
+	}
+}
+
+// finishOperation は操作が終了した時に登録を外し、completeまたはerrorを送ります
+// クライアントのcompleteや切断で既に登録が外れている場合は何も送らない
+func (c *wsConnection) finishOperation(id string, op *wsOperation, errs []GraphQLError) {
+	c.mu.Lock()
+	current, ok := c.operations[id]
+	if ok && current == op {
+		delete(c.operations, id)
+	}
+	c.mu.Unlock()
+
+	op.cancel()
+	if !ok || current != op {
+		return
+	}
+
+	if len(errs) > 0 {
+		payload, _ := json.Marshal(errs)
+		c.write(wsMessage{ID: id, Type: wsError, Payload: payload})
+		return
+	}
+	c.write(wsMessage{ID: id, Type: wsComplete})
+}
+
+func (c *wsConnection) sendNext(id string, response GraphQLResponse) {
+	payload, _ := json.Marshal(response)
+	c.write(wsMessage{ID: id, Type: wsNext, Payload: payload})
+}
+
+func (c *wsConnection) write(msg wsMessage) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.cancel()
+		c.conn.Close()
+	}
+}
+
+// keepAlive は定期的にpingを送り、応答のない接続を検出します
+func (c *wsConnection) keepAlive(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			c.writeMu.Unlock()
+			if err != nil {
+				c.cancel()
+				c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// close は切断コードを送って接続を閉じます
+func (c *wsConnection) close(code int, reason string) {
+	c.writeMu.Lock()
+	c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+	c.writeMu.Unlock()
+
+	c.cancel()
+	c.conn.Close()
+}
+
+// shutdown は実行中の操作を全て止めて接続を閉じます
+func (c *wsConnection) shutdown() {
+	c.cancel()
+
+	c.mu.Lock()
+	for id, op := range c.operations {
+		op.cancel()
+		delete(c.operations, id)
+	}
+	c.mu.Unlock()
+
+	c.conn.Close()
+}
+
+// persistedQuery は操作に含まれる永続化クエリの指定を返します
+func (p *subscribePayload) persistedQuery() *persisted.Extension {
+	if p.Extensions == nil {
+		return nil
+	}
+	return p.Extensions.PersistedQuery
+}
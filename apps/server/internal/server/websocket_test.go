@@ -0,0 +1,240 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"sns-server/internal/auth"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+	"sns-server/internal/pubsub"
+	"sns-server/internal/server"
+)
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func newWebSocketServer(t *testing.T, srv *server.Server) string {
+	t.Helper()
+	httpServer := httptest.NewServer(http.HandlerFunc(srv.HandleSubscriptions))
+	t.Cleanup(httpServer.Close)
+	return "ws" + strings.TrimPrefix(httpServer.URL, "http")
+}
+
+func dialWebSocket(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func sendWS(t *testing.T, conn *websocket.Conn, msg wsMessage) {
+	t.Helper()
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+}
+
+func readWS(t *testing.T, conn *websocket.Conn) wsMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg wsMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Failed to read: %v", err)
+	}
+	return msg
+}
+
+// expectClose は接続が指定のコードで閉じられることを確認します
+func expectClose(t *testing.T, conn *websocket.Conn, code int) {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, code) {
+		t.Errorf("Expected close code %d, got %v", code, err)
+	}
+}
+
+func initWS(t *testing.T, conn *websocket.Conn, payload string) {
+	t.Helper()
+	msg := wsMessage{Type: "connection_init"}
+	if payload != "" {
+		msg.Payload = json.RawMessage(payload)
+	}
+	sendWS(t, conn, msg)
+	if ack := readWS(t, conn); ack.Type != "connection_ack" {
+		t.Fatalf("Expected connection_ack, got %+v", ack)
+	}
+}
+
+func TestWebSocketProtocol(t *testing.T) {
+	srv := &server.Server{
+		DB: newSessionDB(t),
+		Config: &config.Config{
+			JWTSecret:            "test-secret",
+			WebSocketInitTimeout: 200 * time.Millisecond,
+		},
+		PubSub: pubsub.NewMemoryBroker(),
+	}
+	url := newWebSocketServer(t, srv)
+
+	t.Run("トークン付きのconnection_initでack", func(t *testing.T) {
+		token, _ := auth.IssueSessionToken("test-secret", 1, time.Hour)
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, `{"authorization":"Bearer `+token+`"}`)
+	})
+
+	t.Run("無効なトークンは4403で切断", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		sendWS(t, conn, wsMessage{Type: "connection_init", Payload: json.RawMessage(`{"token":"invalid"}`)})
+		expectClose(t, conn, 4403)
+	})
+
+	t.Run("connection_init前のsubscribeは4401で切断", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		sendWS(t, conn, wsMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"subscription { timelineUpdated { id } }"}`)})
+		expectClose(t, conn, 4401)
+	})
+
+	t.Run("connection_initの重複は4429で切断", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{Type: "connection_init"})
+		expectClose(t, conn, 4429)
+	})
+
+	t.Run("connection_initがなければ4408で切断", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		expectClose(t, conn, 4408)
+	})
+
+	t.Run("pingにpongで応答", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{Type: "ping"})
+		if msg := readWS(t, conn); msg.Type != "pong" {
+			t.Errorf("Expected pong, got %+v", msg)
+		}
+	})
+
+	t.Run("同じIDの購読は4409で切断", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		subscribe := wsMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"subscription { postLikeCountChanged(postId: 1) { likeCount } }"}`)}
+		sendWS(t, conn, subscribe)
+		sendWS(t, conn, subscribe)
+		expectClose(t, conn, 4409)
+	})
+
+	t.Run("未認証のtimelineUpdatedはerror", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"subscription { timelineUpdated { id } }"}`)})
+		if msg := readWS(t, conn); msg.Type != "error" || msg.ID != "1" {
+			t.Errorf("Expected error, got %+v", msg)
+		}
+	})
+
+	t.Run("スキーマに合わない購読はerror", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"subscription { unknownField }"}`)})
+		if msg := readWS(t, conn); msg.Type != "error" {
+			t.Errorf("Expected error, got %+v", msg)
+		}
+	})
+
+	t.Run("ミューテーションはerror", func(t *testing.T) {
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"mutation { deletePost(id: 1) }"}`)})
+		if msg := readWS(t, conn); msg.Type != "error" {
+			t.Errorf("Expected error, got %+v", msg)
+		}
+	})
+
+	t.Run("複数の操作を含むドキュメントでは選択したクエリだけを実行する", func(t *testing.T) {
+		token, _ := auth.IssueSessionToken("test-secret", 1, time.Hour)
+		queries := []string{
+			`query Q { me { id } } mutation M { createPost(input: {content: \"hi\"}) { id } }`,
+			`# mutation createPost\nquery Q { me { id } }`,
+		}
+		for _, query := range queries {
+			conn := dialWebSocket(t, url)
+			initWS(t, conn, `{"authorization":"Bearer `+token+`"}`)
+			sendWS(t, conn, wsMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(
+				`{"query":"` + query + `","operationName":"Q"}`,
+			)})
+
+			msg := readWS(t, conn)
+			var resp struct {
+				Data   map[string]json.RawMessage `json:"data"`
+				Errors []GraphQLError             `json:"errors"`
+			}
+			json.Unmarshal(msg.Payload, &resp)
+			if msg.Type != "next" || resp.Data["me"] == nil || resp.Data["createPost"] != nil {
+				t.Errorf("Expected only me to run for %q, got %s %s", query, msg.Type, msg.Payload)
+			}
+		}
+	})
+
+	t.Run("postLikeCountChangedは対象の投稿のみ届く", func(t *testing.T) {
+		// 購読の開始時に投稿を見られるかチェックする
+		if err := srv.DB.AutoMigrate(&models.Post{}, &models.PostEntity{}); err != nil {
+			t.Fatalf("Failed to migrate database: %v", err)
+		}
+		if err := srv.DB.Create(&models.Post{ID: 7, Content: "like me", AuthorID: 1}).Error; err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+
+		conn := dialWebSocket(t, url)
+		initWS(t, conn, "")
+		sendWS(t, conn, wsMessage{ID: "likes", Type: "subscribe", Payload: json.RawMessage(
+			`{"query":"subscription ($id: ID!) { postLikeCountChanged(postId: $id) { postId likeCount } }","variables":{"id":"7"}}`,
+		)})
+
+		// 購読の開始を待ってから配信する
+		time.Sleep(50 * time.Millisecond)
+		srv.PubSub.Publish(context.Background(), "post.like_count", []byte(`{"postId":8,"likeCount":1}`))
+		srv.PubSub.Publish(context.Background(), "post.like_count", []byte(`{"postId":7,"likeCount":3}`))
+
+		msg := readWS(t, conn)
+		if msg.Type != "next" || msg.ID != "likes" {
+			t.Fatalf("Expected next, got %+v", msg)
+		}
+		var resp struct {
+			Data struct {
+				PostLikeCountChanged struct {
+					PostID    string `json:"postId"`
+					LikeCount int    `json:"likeCount"`
+				} `json:"postLikeCountChanged"`
+			} `json:"data"`
+		}
+		json.Unmarshal(msg.Payload, &resp)
+		if got := resp.Data.PostLikeCountChanged; got.PostID != "7" || got.LikeCount != 3 {
+			t.Errorf("Expected post 7 with 3 likes, got %+v", got)
+		}
+
+		// completeで購読を止めた後は届かない
+		sendWS(t, conn, wsMessage{ID: "likes", Type: "complete"})
+		time.Sleep(50 * time.Millisecond)
+		srv.PubSub.Publish(context.Background(), "post.like_count", []byte(`{"postId":7,"likeCount":4}`))
+		sendWS(t, conn, wsMessage{Type: "ping"})
+		if msg := readWS(t, conn); msg.Type != "pong" {
+			t.Errorf("Expected no more events after complete, got %+v", msg)
+		}
+	})
+}
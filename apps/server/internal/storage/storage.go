@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"sns-server/internal/config"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Storage はファイル（データエクスポートなど）の保存先の抽象インターフェースです
+// キーは "exports/1/abc.zip" のようなスラッシュ区切りの相対パスです
+type Storage interface {
+	Put(key string, r io.Reader) (int64, error)
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error // 存在しない場合もエラーにしない
+}
+
+// New は設定に応じたStorageを作成します
+func New(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case "local", "":
+		return NewLocalStorage(cfg.StorageDir)
+	case "memory":
+		return NewMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+}
+
+// validateKey は保存先の外を指すキー（".." や絶対パス）を拒否します
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStorage(t *testing.T) {
+	local, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create local storage: %v", err)
+	}
+
+	backends := map[string]Storage{
+		"ローカル": local,
+		"メモリ":  NewMemoryStorage(),
+	}
+
+	for name, store := range backends {
+		t.Run(name, func(t *testing.T) {
+			size, err := store.Put("exports/1/data.zip", strings.NewReader("hello"))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if size != 5 {
+				t.Errorf("Expected size 5, got %d", size)
+			}
+
+			r, err := store.Open("exports/1/data.zip")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			data, _ := io.ReadAll(r)
+			r.Close()
+			if string(data) != "hello" {
+				t.Errorf("Expected 'hello', got %q", data)
+			}
+
+			if err := store.Delete("exports/1/data.zip"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if _, err := store.Open("exports/1/data.zip"); err != ErrNotFound {
+				t.Errorf("Expected ErrNotFound after delete, got %v", err)
+			}
+			if err := store.Delete("exports/1/data.zip"); err != nil {
+				t.Errorf("Expected deleting a missing object to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "相対パス", key: "exports/1/data.zip", wantErr: false},
+		{name: "空のキー", key: "", wantErr: true},
+		{name: "絶対パス", key: "/etc/passwd", wantErr: true},
+		{name: "親ディレクトリ", key: "../secret", wantErr: true},
+		{name: "途中の親ディレクトリ", key: "exports/../../secret", wantErr: true},
+		{name: "バックスラッシュ", key: `exports\1`, wantErr: true},
+		{name: "末尾のスラッシュ", key: "exports/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateKey(tt.key); (err != nil) != tt.wantErr {
+				t.Errorf("Expected wantErr=%v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLocalStorage_WritesInsideDir(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := NewLocalStorage(dir)
+
+	if _, err := store.Put("../outside.txt", strings.NewReader("x")); err != ErrInvalidKey {
+		t.Errorf("Expected ErrInvalidKey, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "outside.txt")); err == nil {
+		t.Error("Expected no file outside the storage directory")
+	}
+}
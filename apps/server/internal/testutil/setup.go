@@ -27,8 +27,34 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Post{},
+		&models.Draft{},
+		&models.Poll{},
+		&models.PollOption{},
+		&models.PollVote{},
 		&models.Like{},
 		&models.Follow{},
+		&models.Block{},
+		&models.Mute{},
+		&models.FollowRequest{},
+		&models.Repost{},
+		&models.BookmarkCollection{},
+		&models.Bookmark{},
+		&models.Report{},
+		&models.ModerationAction{},
+		&models.DataExport{},
+		&models.Job{},
+		&models.TimelineEntry{},
+		&models.UserToken{},
+		&models.RateLimitBucket{},
+		&models.LoginAttempt{},
+		&models.LockoutEvent{},
+		&models.PersistedQuery{},
+		&models.IdempotencyKey{},
+		&models.Notification{},
+		&models.NotificationActor{},
+		&models.PostEntity{},
+		&models.TrendingHashtag{},
+		&models.TrendingPost{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
@@ -40,7 +66,7 @@ func SetupTestDB(t *testing.T) *gorm.DB {
 // CleanupDB はテスト用データベースをクリーンアップします
 func CleanupDB(t *testing.T, db *gorm.DB) {
 	// 外部キー制約があるため、順序に注意してテーブルを削除
-	tables := []string{"likes", "follows", "posts", "users"}
+	tables := []string{"timeline_entries", "trending_posts", "trending_hashtags", "post_entities", "notification_actors", "notifications", "persisted_queries", "idempotency_keys", "lockout_events", "login_attempts", "rate_limit_buckets", "user_tokens", "data_exports", "jobs", "moderation_actions", "reports", "bookmarks", "bookmark_collections", "reposts", "likes", "mutes", "blocks", "follow_requests", "follows", "poll_votes", "poll_options", "polls", "drafts", "posts", "users"}
 
 	for _, table := range tables {
 		if err := db.Exec("TRUNCATE TABLE " + table + " RESTART IDENTITY CASCADE").Error; err != nil {
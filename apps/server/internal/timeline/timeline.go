@@ -0,0 +1,225 @@
+// timelineはホームタイムラインを書き込み時にフォロワーへ配信（ファンアウト）します
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+	"sns-server/internal/jobs"
+	"sns-server/internal/models"
+)
+
+// バックグラウンドジョブの種類
+const (
+	FanoutJobType   = "timeline.fanout"   // 投稿・リポストをフォロワーのタイムラインに配信する（ペイロードはFanoutPayload）
+	BackfillJobType = "timeline.backfill" // フォローしたユーザーの最近の投稿・リポストをタイムラインに追加する（ペイロードはBackfillPayload）
+)
+
+// FanoutPayload は配信するジョブのペイロードです（どちらか一方を指定する）
+type FanoutPayload struct {
+	PostID   uint `json:"postId,omitempty"`   // 投稿と、その投稿の既存のリポスト
+	RepostID uint `json:"repostId,omitempty"` // 1件のリポスト
+}
+
+// BackfillPayload はフォローした時のジョブのペイロードです
+type BackfillPayload struct {
+	UserID     uint `json:"userId"`
+	FolloweeID uint `json:"followeeId"`
+}
+
+// Policy は配信の設定です
+type Policy struct {
+	MaxFanoutFollowers int // フォロワーがこれ以上のアカウントは配信せず、読み込み時に取得する（0で常に配信）
+	BackfillLimit      int // フォローした時に追加する投稿・リポストのそれぞれの件数
+}
+
+// PolicyFromConfig は設定からPolicyを作成します
+func PolicyFromConfig(cfg *config.Config) Policy {
+	return Policy{
+		MaxFanoutFollowers: cfg.TimelineFanoutMaxFollowers,
+		BackfillLimit:      cfg.TimelineBackfillLimit,
+	}
+}
+
+// FanoutThreshold は読み込み時に取得するアカウントのフォロワー数の下限を返します
+func (p Policy) FanoutThreshold() int {
+	if p.MaxFanoutFollowers <= 0 {
+		return math.MaxInt32
+	}
+	return p.MaxFanoutFollowers
+}
+
+// Fanout はタイムラインへの配信を行います
+type Fanout struct {
+	db     *gorm.DB
+	policy Policy
+}
+
+// NewFanout はFanoutを作成します
+func NewFanout(db *gorm.DB, policy Policy) *Fanout {
+	return &Fanout{db: db, policy: policy}
+}
+
+// readsLive はユーザーの投稿・リポストを配信せず読み込み時に取得するかを返します
+func (f *Fanout) readsLive(userID uint) (bool, error) {
+	var user models.User
+	if err := f.db.Unscoped().Select("follower_count").Where("id = ?", userID).Limit(1).Find(&user).Error; err != nil {
+		return false, err
+	}
+	return user.FollowerCount >= int64(f.policy.FanoutThreshold()), nil
+}
+
+// HandleFanoutJob は配信するジョブのハンドラーです
+func (f *Fanout) HandleFanoutJob(ctx context.Context, job *models.Job) error {
+	var payload FanoutPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+
+	if payload.RepostID != 0 {
+		_, err := f.FanOutRepost(payload.RepostID)
+		return err
+	}
+	_, err := f.FanOutPost(payload.PostID)
+	return err
+}
+
+// FanOutPost は投稿と、その投稿の既存のリポスト（モデレーションで非表示から戻した場合）をフォロワーのタイムラインに配信し、追加した件数を返します
+// 削除された投稿は配信しません
+func (f *Fanout) FanOutPost(postID uint) (int, error) {
+	var post models.Post
+	if err := f.db.Where("id = ?", postID).Limit(1).Find(&post).Error; err != nil || post.ID == 0 {
+		return 0, err
+	}
+
+	added := 0
+	live, err := f.readsLive(post.AuthorID)
+	if err != nil {
+		return 0, err
+	}
+	if !live {
+		result := f.db.Exec(`
+			INSERT INTO timeline_entries (user_id, post_id, actor_id, kind, activity_at)
+			SELECT follows.follower_id, posts.id, posts.author_id, ?, posts.created_at
+			FROM posts
+			JOIN follows ON follows.followee_id = posts.author_id
+			WHERE posts.id = ?
+			ON CONFLICT DO NOTHING`,
+			models.TimelineEntryPost, post.ID)
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		added += int(result.RowsAffected)
+	}
+
+	var repostIDs []uint
+	if err := f.db.Model(&models.Repost{}).Where("post_id = ?", post.ID).Pluck("id", &repostIDs).Error; err != nil {
+		return added, err
+	}
+	for _, id := range repostIDs {
+		n, err := f.FanOutRepost(id)
+		if err != nil {
+			return added, err
+		}
+		added += n
+	}
+	return added, nil
+}
+
+// FanOutRepost はリポストをフォロワーのタイムラインに配信し、追加した件数を返します
+func (f *Fanout) FanOutRepost(repostID uint) (int, error) {
+	var repost models.Repost
+	if err := f.db.Where("id = ?", repostID).Limit(1).Find(&repost).Error; err != nil || repost.ID == 0 {
+		return 0, err
+	}
+	if live, err := f.readsLive(repost.UserID); err != nil || live {
+		return 0, err
+	}
+
+	result := f.db.Exec(`
+		INSERT INTO timeline_entries (user_id, post_id, actor_id, kind, activity_at)
+		SELECT follows.follower_id, reposts.post_id, reposts.user_id, ?, reposts.created_at
+		FROM reposts
+		JOIN posts ON posts.id = reposts.post_id AND posts.deleted_at IS NULL
+		JOIN follows ON follows.followee_id = reposts.user_id
+		WHERE reposts.id = ?
+		ON CONFLICT DO NOTHING`,
+		models.TimelineEntryRepost, repost.ID)
+	return int(result.RowsAffected), result.Error
+}
+
+// HandleBackfillJob はフォローした時のジョブのハンドラーです
+func (f *Fanout) HandleBackfillJob(ctx context.Context, job *models.Job) error {
+	var payload BackfillPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid payload: %w", err))
+	}
+	_, err := f.Backfill(payload.UserID, payload.FolloweeID)
+	return err
+}
+
+// Backfill はフォローしたユーザーの最近の投稿・リポストをタイムラインに追加し、追加した件数を返します
+// ジョブの実行までにフォローを解除していた場合は何もしません
+func (f *Fanout) Backfill(userID, followeeID uint) (int, error) {
+	if !models.IsFollowing(f.db, userID, followeeID) {
+		return 0, nil
+	}
+	if live, err := f.readsLive(followeeID); err != nil || live {
+		return 0, err
+	}
+
+	posts := f.db.Exec(`
+		INSERT INTO timeline_entries (user_id, post_id, actor_id, kind, activity_at)
+		SELECT follows.follower_id, posts.id, posts.author_id, ?, posts.created_at
+		FROM posts
+		JOIN follows ON follows.followee_id = posts.author_id
+		WHERE follows.follower_id = ? AND follows.followee_id = ? AND posts.deleted_at IS NULL
+		ORDER BY posts.created_at DESC
+		LIMIT ?
+		ON CONFLICT DO NOTHING`,
+		models.TimelineEntryPost, userID, followeeID, f.policy.BackfillLimit)
+	if posts.Error != nil {
+		return 0, posts.Error
+	}
+
+	reposts := f.db.Exec(`
+		INSERT INTO timeline_entries (user_id, post_id, actor_id, kind, activity_at)
+		SELECT follows.follower_id, reposts.post_id, reposts.user_id, ?, reposts.created_at
+		FROM reposts
+		JOIN posts ON posts.id = reposts.post_id AND posts.deleted_at IS NULL
+		JOIN follows ON follows.followee_id = reposts.user_id
+		WHERE follows.follower_id = ? AND follows.followee_id = ?
+		ORDER BY reposts.created_at DESC
+		LIMIT ?
+		ON CONFLICT DO NOTHING`,
+		models.TimelineEntryRepost, userID, followeeID, f.policy.BackfillLimit)
+	return int(posts.RowsAffected + reposts.RowsAffected), reposts.Error
+}
+
+// Rebuild はユーザーのタイムラインを作り直し、追加した件数を返します（機能の導入時・不整合の修復用）
+func (f *Fanout) Rebuild(userID uint) (int, error) {
+	var followeeIDs []uint
+	if err := f.db.Model(&models.Follow{}).Where("follower_id = ?", userID).Pluck("followee_id", &followeeIDs).Error; err != nil {
+		return 0, err
+	}
+
+	added := 0
+	err := f.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.TimelineEntry{}).Error; err != nil {
+			return err
+		}
+		rebuilder := &Fanout{db: tx, policy: f.policy}
+		for _, followeeID := range followeeIDs {
+			n, err := rebuilder.Backfill(userID, followeeID)
+			if err != nil {
+				return err
+			}
+			added += n
+		}
+		return nil
+	})
+	return added, err
+}
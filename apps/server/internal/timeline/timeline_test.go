@@ -0,0 +1,209 @@
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+func setupTestFanout(t *testing.T, policy Policy) (*Fanout, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Post{}, &models.PostEntity{}, &models.Follow{}, &models.Repost{}, &models.TimelineEntry{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	return NewFanout(db, policy), db
+}
+
+func createUsers(t *testing.T, db *gorm.DB, names ...string) []*models.User {
+	t.Helper()
+	users := make([]*models.User, 0, len(names))
+	for _, name := range names {
+		user := &models.User{Username: name, Email: name + "@example.com", Password: "password", Name: name}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+func createPost(t *testing.T, db *gorm.DB, authorID uint, content string, createdAt time.Time) *models.Post {
+	t.Helper()
+	post := &models.Post{Content: content, AuthorID: authorID, CreatedAt: createdAt}
+	if err := db.Create(post).Error; err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	return post
+}
+
+// entries はユーザーのタイムラインの "投稿ID:行為者ID:種類" を返します
+func entries(db *gorm.DB, userID uint) []string {
+	var rows []models.TimelineEntry
+	db.Where("user_id = ?", userID).Find(&rows)
+
+	result := make([]string, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, fmt.Sprintf("%d:%d:%s", row.PostID, row.ActorID, row.Kind))
+	}
+	sort.Strings(result)
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFanout_FanOutPost(t *testing.T) {
+	fanout, db := setupTestFanout(t, Policy{MaxFanoutFollowers: 2, BackfillLimit: 10})
+	users := createUsers(t, db, "alice", "bob", "carol", "dave")
+	alice, bob, carol, dave := users[0], users[1], users[2], users[3]
+
+	// aliceのフォロワーはbobとcarol、daveのフォロワーはbobのみ
+	db.Create(&models.Follow{FollowerID: bob.ID, FolloweeID: alice.ID})
+	db.Create(&models.Follow{FollowerID: carol.ID, FolloweeID: dave.ID})
+
+	now := time.Now()
+	post := createPost(t, db, dave.ID, "dave's post", now)
+
+	t.Run("フォロワーのタイムラインに配信する", func(t *testing.T) {
+		added, err := fanout.FanOutPost(post.ID)
+		if err != nil || added != 1 {
+			t.Fatalf("Expected 1 entry, got %d, %v", added, err)
+		}
+		want := []string{fmt.Sprintf("%d:%d:POST", post.ID, dave.ID)}
+		if got := entries(db, carol.ID); !equal(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+		if got := entries(db, dave.ID); len(got) != 0 {
+			t.Errorf("Expected author's own timeline to be empty, got %v", got)
+		}
+	})
+
+	t.Run("同じ投稿を再度配信しても重複しない", func(t *testing.T) {
+		added, err := fanout.FanOutPost(post.ID)
+		if err != nil || added != 0 {
+			t.Errorf("Expected no new entries, got %d, %v", added, err)
+		}
+	})
+
+	t.Run("リポストを配信する", func(t *testing.T) {
+		repost := &models.Repost{UserID: alice.ID, PostID: post.ID}
+		db.Create(repost)
+
+		added, err := fanout.FanOutRepost(repost.ID)
+		if err != nil || added != 1 {
+			t.Fatalf("Expected 1 entry, got %d, %v", added, err)
+		}
+		want := []string{fmt.Sprintf("%d:%d:REPOST", post.ID, alice.ID)}
+		if got := entries(db, bob.ID); !equal(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("フォロワーの多いアカウントは配信しない", func(t *testing.T) {
+		db.Create(&models.Follow{FollowerID: carol.ID, FolloweeID: alice.ID})
+		popular := createPost(t, db, alice.ID, "popular", now)
+
+		added, err := fanout.FanOutPost(popular.ID)
+		if err != nil || added != 0 {
+			t.Errorf("Expected no entries for account with %d followers, got %d, %v", 2, added, err)
+		}
+	})
+
+	t.Run("削除された投稿は配信しない", func(t *testing.T) {
+		deleted := createPost(t, db, dave.ID, "deleted", now)
+		db.Delete(deleted)
+
+		added, err := fanout.FanOutPost(deleted.ID)
+		if err != nil || added != 0 {
+			t.Errorf("Expected no entries, got %d, %v", added, err)
+		}
+	})
+
+	t.Run("ジョブのハンドラー", func(t *testing.T) {
+		later := createPost(t, db, dave.ID, "later", now.Add(time.Minute))
+		payload, _ := json.Marshal(FanoutPayload{PostID: later.ID})
+		if err := fanout.HandleFanoutJob(context.Background(), &models.Job{Payload: string(payload)}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if got := entries(db, carol.ID); len(got) != 2 {
+			t.Errorf("Expected 2 entries for carol, got %v", got)
+		}
+
+		if err := fanout.HandleFanoutJob(context.Background(), &models.Job{Payload: "not json"}); err == nil {
+			t.Error("Expected error for invalid payload")
+		}
+	})
+}
+
+func TestFanout_Backfill(t *testing.T) {
+	fanout, db := setupTestFanout(t, Policy{MaxFanoutFollowers: 0, BackfillLimit: 2})
+	users := createUsers(t, db, "alice", "bob", "carol")
+	alice, bob, carol := users[0], users[1], users[2]
+
+	now := time.Now()
+	var posts []*models.Post
+	for i := 0; i < 3; i++ {
+		posts = append(posts, createPost(t, db, bob.ID, fmt.Sprintf("bob %d", i), now.Add(time.Duration(i)*time.Minute)))
+	}
+	carolPost := createPost(t, db, carol.ID, "carol", now)
+	db.Create(&models.Repost{UserID: bob.ID, PostID: carolPost.ID})
+
+	t.Run("フォローしていない場合は何もしない", func(t *testing.T) {
+		added, err := fanout.Backfill(alice.ID, bob.ID)
+		if err != nil || added != 0 {
+			t.Errorf("Expected no entries, got %d, %v", added, err)
+		}
+	})
+
+	db.Create(&models.Follow{FollowerID: alice.ID, FolloweeID: bob.ID})
+
+	t.Run("最近の投稿とリポストを上限まで追加する", func(t *testing.T) {
+		added, err := fanout.Backfill(alice.ID, bob.ID)
+		if err != nil || added != 3 {
+			t.Fatalf("Expected 3 entries, got %d, %v", added, err)
+		}
+		want := []string{
+			fmt.Sprintf("%d:%d:POST", posts[1].ID, bob.ID),
+			fmt.Sprintf("%d:%d:POST", posts[2].ID, bob.ID),
+			fmt.Sprintf("%d:%d:REPOST", carolPost.ID, bob.ID),
+		}
+		sort.Strings(want)
+		if got := entries(db, alice.ID); !equal(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("作り直すと配信済みのエントリーを入れ替える", func(t *testing.T) {
+		db.Create(&models.TimelineEntry{UserID: alice.ID, PostID: 999, ActorID: carol.ID, Kind: models.TimelineEntryPost, ActivityAt: now})
+
+		added, err := fanout.Rebuild(alice.ID)
+		if err != nil || added != 3 {
+			t.Fatalf("Expected 3 entries, got %d, %v", added, err)
+		}
+		if got := entries(db, alice.ID); len(got) != 3 {
+			t.Errorf("Expected stale entry to be removed, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,343 @@
+package trending
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"sns-server/internal/config"
+	"sns-server/internal/models"
+)
+
+// エンゲージメントの種類
+const (
+	kindLike   = "like"
+	kindReply  = "reply"
+	kindRepost = "repost"
+)
+
+// エンゲージメントの種類ごとの重み
+const usageWeight = 1.0 // ハッシュタグを使った投稿
+
+var engagementWeights = map[string]float64{
+	kindLike:   1,
+	kindReply:  2,
+	kindRepost: 3,
+}
+
+// Policy はトレンドの集計設定です
+type Policy struct {
+	MinAccounts     int     // タグを使ったアカウント数がこれ未満のタグはトレンドにしない
+	MinEngagers     int     // 作成者以外のエンゲージしたアカウント数がこれ未満の投稿はトレンドにしない
+	MaxAccountScore float64 // 1つのアカウントが1つのタグのスコアに寄与できる上限
+	HalfLifeRatio   float64 // 集計期間に対するスコアの半減期の割合
+	Limit           int     // 期間ごとに保存する件数
+}
+
+// PolicyFromConfig は設定からPolicyを作成します
+func PolicyFromConfig(cfg *config.Config) Policy {
+	return Policy{
+		MinAccounts:     cfg.TrendingMinAccounts,
+		MinEngagers:     cfg.TrendingMinAccounts,
+		MaxAccountScore: 3,
+		HalfLifeRatio:   0.25,
+		Limit:           50,
+	}
+}
+
+// Calculator は期間内のエンゲージメントからトレンドを集計し、テーブルに保存します
+type Calculator struct {
+	db     *gorm.DB
+	policy Policy
+	now    func() time.Time
+}
+
+// NewCalculator はCalculatorを作成します
+func NewCalculator(db *gorm.DB, policy Policy) *Calculator {
+	return &Calculator{db: db, policy: policy, now: time.Now}
+}
+
+// Refresh は全ての期間のトレンドを集計し直します
+func (c *Calculator) Refresh() error {
+	for _, window := range models.TrendingWindows {
+		if err := c.RefreshWindow(window); err != nil {
+			return fmt.Errorf("refresh %s: %w", window, err)
+		}
+	}
+	return nil
+}
+
+// RefreshWindow は指定した期間のトレンドを集計し、以前の集計結果と置き換えます
+func (c *Calculator) RefreshWindow(window string) error {
+	duration := models.TrendingWindowDuration(window)
+	if duration == 0 {
+		return fmt.Errorf("unknown trending window: %s", window)
+	}
+
+	now := c.now()
+	hashtags, posts, err := c.compute(now, duration)
+	if err != nil {
+		return err
+	}
+
+	for i := range hashtags {
+		hashtags[i].Window = window
+	}
+	for i := range posts {
+		posts[i].Window = window
+	}
+
+	return c.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("time_window = ?", window).Delete(&models.TrendingHashtag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("time_window = ?", window).Delete(&models.TrendingPost{}).Error; err != nil {
+			return err
+		}
+		if len(hashtags) > 0 {
+			if err := tx.Create(&hashtags).Error; err != nil {
+				return err
+			}
+		}
+		if len(posts) > 0 {
+			if err := tx.Omit("Post").Create(&posts).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// candidatePost は期間内に作成された投稿です
+type candidatePost struct {
+	ID        uint
+	AuthorID  uint
+	CreatedAt time.Time
+}
+
+// engagement は投稿へのいいね・リプライ・リポストです
+type engagement struct {
+	Kind      string
+	UserID    uint
+	PostID    uint
+	CreatedAt time.Time
+}
+
+// engagementKey は同じアカウントの同じ種類のエンゲージメントを1回と数えるためのキーです
+type engagementKey struct {
+	kind   string
+	userID uint
+	postID uint
+}
+
+// compute は期間内の投稿とエンゲージメントから時間減衰したスコアを計算します
+func (c *Calculator) compute(now time.Time, duration time.Duration) ([]models.TrendingHashtag, []models.TrendingPost, error) {
+	since := now.Add(-duration)
+	halfLife := time.Duration(float64(duration) * c.policy.HalfLifeRatio)
+	decay := func(t time.Time) float64 {
+		age := now.Sub(t)
+		if age < 0 || halfLife <= 0 {
+			return 1
+		}
+		return math.Pow(0.5, float64(age)/float64(halfLife))
+	}
+
+	var posts []candidatePost
+	if err := c.db.Model(&models.Post{}).
+		Select("id, author_id, created_at").
+		Where("created_at >= ? AND created_at <= ?", since, now).
+		Scan(&posts).Error; err != nil {
+		return nil, nil, err
+	}
+	postsByID := make(map[uint]candidatePost, len(posts))
+	for _, post := range posts {
+		postsByID[post.ID] = post
+	}
+
+	engagements, err := c.loadEngagements(since, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 作成者自身のエンゲージメントは数えず、同じアカウントの同じ種類は最新の1回のみ数える
+	scores := make(map[engagementKey]float64)
+	for _, e := range engagements {
+		post, ok := postsByID[e.PostID]
+		if !ok || e.UserID == post.AuthorID {
+			continue
+		}
+		key := engagementKey{kind: e.Kind, userID: e.UserID, postID: e.PostID}
+		scores[key] = math.Max(scores[key], engagementWeights[e.Kind]*decay(e.CreatedAt))
+	}
+
+	// 投稿ごと・エンゲージしたアカウントごとのスコア
+	postScores := make(map[uint]map[uint]float64)
+	for key, score := range scores {
+		if postScores[key.postID] == nil {
+			postScores[key.postID] = make(map[uint]float64)
+		}
+		postScores[key.postID][key.userID] += score
+	}
+
+	trendingPosts := c.rankPosts(postScores, now)
+
+	tags, err := c.loadHashtags(since, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	trendingHashtags := c.rankHashtags(tags, postsByID, postScores, decay, now)
+
+	return trendingHashtags, trendingPosts, nil
+}
+
+// publicUsers は非公開・退会済みのユーザーの行を除きます（集計結果は誰でも見られるため）
+func publicUsers(db *gorm.DB, column string) *gorm.DB {
+	return db.Joins("JOIN users ON users.id = "+column).
+		Where("users.is_private = ? AND users.deleted_at IS NULL", false)
+}
+
+// loadEngagements は期間内のいいね・リプライ・リポストを読み込みます
+// 非公開・退会済みのアカウントのエンゲージメントは数えない
+func (c *Calculator) loadEngagements(since, now time.Time) ([]engagement, error) {
+	var likes []engagement
+	if err := publicUsers(c.db.Model(&models.Like{}), "likes.user_id").
+		Select("likes.user_id, likes.post_id, likes.created_at").
+		Where("likes.created_at >= ? AND likes.created_at <= ?", since, now).
+		Scan(&likes).Error; err != nil {
+		return nil, err
+	}
+	for i := range likes {
+		likes[i].Kind = kindLike
+	}
+
+	var replies []engagement
+	if err := publicUsers(c.db.Model(&models.Post{}), "posts.author_id").
+		Select("posts.author_id AS user_id, posts.parent_id AS post_id, posts.created_at").
+		Where("posts.parent_id IS NOT NULL AND posts.created_at >= ? AND posts.created_at <= ?", since, now).
+		Scan(&replies).Error; err != nil {
+		return nil, err
+	}
+	for i := range replies {
+		replies[i].Kind = kindReply
+	}
+
+	var reposts []engagement
+	if err := publicUsers(c.db.Model(&models.Repost{}), "reposts.user_id").
+		Select("reposts.user_id, reposts.post_id, reposts.created_at").
+		Where("reposts.created_at >= ? AND reposts.created_at <= ?", since, now).
+		Scan(&reposts).Error; err != nil {
+		return nil, err
+	}
+	for i := range reposts {
+		reposts[i].Kind = kindRepost
+	}
+
+	engagements := append(likes, replies...)
+	return append(engagements, reposts...), nil
+}
+
+// loadHashtags は期間内の投稿で使われたハッシュタグを投稿ごとに読み込みます
+// 非公開・退会済みのアカウントの投稿のハッシュタグは数えない
+func (c *Calculator) loadHashtags(since, now time.Time) (map[string][]uint, error) {
+	var rows []struct {
+		PostID uint
+		Tag    string
+	}
+	err := c.db.Model(&models.PostEntity{}).
+		Distinct("post_id", "tag").
+		Where("type = ? AND post_id IN (?)", models.PostEntityTypeHashtag,
+			publicUsers(c.db.Model(&models.Post{}), "posts.author_id").Select("posts.id").
+				Where("posts.created_at >= ? AND posts.created_at <= ?", since, now)).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string][]uint)
+	for _, row := range rows {
+		tags[row.Tag] = append(tags[row.Tag], row.PostID)
+	}
+	return tags, nil
+}
+
+// rankPosts はエンゲージしたアカウントが少ない投稿を除いてスコア順に並べます
+func (c *Calculator) rankPosts(postScores map[uint]map[uint]float64, computedAt time.Time) []models.TrendingPost {
+	var ranked []models.TrendingPost
+	for postID, byAccount := range postScores {
+		if len(byAccount) < c.policy.MinEngagers {
+			continue
+		}
+		var score float64
+		for _, s := range byAccount {
+			score += s
+		}
+		ranked = append(ranked, models.TrendingPost{PostID: postID, Score: score})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].PostID > ranked[j].PostID
+	})
+	if len(ranked) > c.policy.Limit {
+		ranked = ranked[:c.policy.Limit]
+	}
+
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+		ranked[i].ComputedAt = computedAt
+	}
+	return ranked
+}
+
+// rankHashtags はタグを使った投稿とそのエンゲージメントからタグのスコアを計算します
+// 1つのアカウントの寄与はMaxAccountScoreまでに制限し、使ったアカウントが少ないタグは除外するため、
+// 1つのアカウントが大量に投稿・エンゲージしてもタグをトレンドにできない
+func (c *Calculator) rankHashtags(tags map[string][]uint, postsByID map[uint]candidatePost, postScores map[uint]map[uint]float64, decay func(time.Time) float64, computedAt time.Time) []models.TrendingHashtag {
+	var ranked []models.TrendingHashtag
+	for tag, postIDs := range tags {
+		byAccount := make(map[uint]float64)
+		authors := make(map[uint]bool)
+		for _, postID := range postIDs {
+			post := postsByID[postID]
+			authors[post.AuthorID] = true
+			byAccount[post.AuthorID] += usageWeight * decay(post.CreatedAt)
+			for userID, score := range postScores[postID] {
+				byAccount[userID] += score
+			}
+		}
+		if len(authors) < c.policy.MinAccounts {
+			continue
+		}
+
+		var score float64
+		for _, s := range byAccount {
+			score += math.Min(s, c.policy.MaxAccountScore)
+		}
+		ranked = append(ranked, models.TrendingHashtag{
+			Tag:          tag,
+			Score:        score,
+			PostCount:    len(postIDs),
+			AccountCount: len(authors),
+		})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Tag < ranked[j].Tag
+	})
+	if len(ranked) > c.policy.Limit {
+		ranked = ranked[:c.policy.Limit]
+	}
+
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+		ranked[i].ComputedAt = computedAt
+	}
+	return ranked
+}
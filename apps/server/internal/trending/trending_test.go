@@ -0,0 +1,264 @@
+package trending
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"sns-server/internal/models"
+)
+
+type fixture struct {
+	t    *testing.T
+	db   *gorm.DB
+	calc *Calculator
+	now  time.Time
+}
+
+func setupTestCalculator(t *testing.T) *fixture {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Post{}, &models.Like{}, &models.Repost{}, &models.PostEntity{},
+		&models.TrendingHashtag{}, &models.TrendingPost{})
+	if err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	calc := NewCalculator(db, Policy{
+		MinAccounts:     3,
+		MinEngagers:     2,
+		MaxAccountScore: 3,
+		HalfLifeRatio:   0.25,
+		Limit:           10,
+	})
+	calc.now = func() time.Time { return now }
+
+	return &fixture{t: t, db: db, calc: calc, now: now}
+}
+
+func (f *fixture) user(name string) *models.User {
+	user := &models.User{Username: name, Email: name + "@example.com", Password: "password", Name: name}
+	if err := f.db.Create(user).Error; err != nil {
+		f.t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func (f *fixture) users(prefix string, n int) []*models.User {
+	var users []*models.User
+	for i := 0; i < n; i++ {
+		users = append(users, f.user(fmt.Sprintf("%s%d", prefix, i)))
+	}
+	return users
+}
+
+func (f *fixture) post(author *models.User, content string, ago time.Duration) *models.Post {
+	post := &models.Post{Content: content, AuthorID: author.ID, CreatedAt: f.now.Add(-ago)}
+	if err := f.db.Create(post).Error; err != nil {
+		f.t.Fatalf("Failed to create post: %v", err)
+	}
+	return post
+}
+
+func (f *fixture) like(user *models.User, post *models.Post, ago time.Duration) {
+	like := &models.Like{UserID: user.ID, PostID: post.ID, CreatedAt: f.now.Add(-ago)}
+	if err := f.db.Create(like).Error; err != nil {
+		f.t.Fatalf("Failed to create like: %v", err)
+	}
+}
+
+func (f *fixture) refresh(window string) ([]models.TrendingHashtag, []models.TrendingPost) {
+	if err := f.calc.RefreshWindow(window); err != nil {
+		f.t.Fatalf("Failed to refresh: %v", err)
+	}
+	var hashtags []models.TrendingHashtag
+	f.db.Where("time_window = ?", window).Order("rank").Find(&hashtags)
+	var posts []models.TrendingPost
+	f.db.Where("time_window = ?", window).Order("rank").Find(&posts)
+	return hashtags, posts
+}
+
+func TestCalculator_HashtagGuardrails(t *testing.T) {
+	f := setupTestCalculator(t)
+
+	// 1つのアカウントが大量に投稿したタグ
+	spammer := f.user("spammer")
+	for i := 0; i < 20; i++ {
+		f.post(spammer, fmt.Sprintf("#spam %d", i), time.Minute)
+	}
+
+	// 3つのアカウントが使ったタグ
+	for _, user := range f.users("organic", 3) {
+		f.post(user, "#organic", time.Minute)
+	}
+
+	// 1つのアカウントの投稿が大半を占めるタグ
+	f.post(f.user("other1"), "#mixed", time.Minute)
+	f.post(f.user("other2"), "#mixed", time.Minute)
+	for i := 0; i < 20; i++ {
+		f.post(spammer, fmt.Sprintf("#mixed %d", i), time.Minute)
+	}
+
+	hashtags, _ := f.refresh(models.TrendingWindowDay)
+
+	scores := make(map[string]models.TrendingHashtag)
+	for _, h := range hashtags {
+		scores[h.Tag] = h
+	}
+
+	if _, ok := scores["spam"]; ok {
+		t.Error("Tag used by a single account should not trend")
+	}
+	organic, ok := scores["organic"]
+	if !ok {
+		t.Fatal("Tag used by 3 accounts should trend")
+	}
+	if organic.AccountCount != 3 || organic.PostCount != 3 {
+		t.Errorf("Expected 3 accounts and 3 posts, got %+v", organic)
+	}
+
+	// 1つのアカウントの寄与は上限までに制限される
+	mixed := scores["mixed"]
+	if mixed.PostCount != 22 {
+		t.Errorf("Expected 22 posts, got %d", mixed.PostCount)
+	}
+	if mixed.Score > 3+2 {
+		t.Errorf("Expected spammer contribution to be capped, got score %f", mixed.Score)
+	}
+}
+
+func TestCalculator_TimeDecay(t *testing.T) {
+	f := setupTestCalculator(t)
+	authors := f.users("author", 3)
+	fans := f.users("fan", 3)
+
+	old := f.post(authors[0], "old", 20*time.Hour)
+	recent := f.post(authors[1], "recent", 10*time.Minute)
+	for _, fan := range fans {
+		f.like(fan, old, 20*time.Hour)
+		f.like(fan, recent, 5*time.Minute)
+	}
+
+	_, posts := f.refresh(models.TrendingWindowDay)
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 trending posts, got %d", len(posts))
+	}
+	if posts[0].PostID != recent.ID || posts[0].Rank != 1 {
+		t.Errorf("Expected recent post first, got %+v", posts)
+	}
+	if posts[0].Score <= posts[1].Score {
+		t.Errorf("Expected recent post to score higher, got %f <= %f", posts[0].Score, posts[1].Score)
+	}
+
+	// 期間外の投稿は含まない
+	_, posts = f.refresh(models.TrendingWindowHour)
+	if len(posts) != 1 || posts[0].PostID != recent.ID {
+		t.Errorf("Expected only recent post in hour window, got %+v", posts)
+	}
+}
+
+func TestCalculator_PostGuardrails(t *testing.T) {
+	f := setupTestCalculator(t)
+	author := f.user("author")
+	fans := f.users("fan", 2)
+
+	// 作成者自身のいいねとリプライは数えない
+	selfPromoted := f.post(author, "self", time.Minute)
+	f.like(author, selfPromoted, time.Minute)
+	f.like(fans[0], selfPromoted, time.Minute)
+	for i := 0; i < 5; i++ {
+		reply := &models.Post{Content: "me too", AuthorID: author.ID, ParentID: &selfPromoted.ID, CreatedAt: f.now.Add(-time.Minute)}
+		f.db.Create(reply)
+	}
+
+	// 同じアカウントの複数のリプライは1回と数える
+	popular := f.post(author, "popular", time.Minute)
+	for _, fan := range fans {
+		for i := 0; i < 3; i++ {
+			reply := &models.Post{Content: "reply", AuthorID: fan.ID, ParentID: &popular.ID, CreatedAt: f.now}
+			f.db.Create(reply)
+		}
+		f.db.Create(&models.Repost{UserID: fan.ID, PostID: popular.ID, CreatedAt: f.now})
+	}
+
+	_, posts := f.refresh(models.TrendingWindowDay)
+	if len(posts) != 1 || posts[0].PostID != popular.ID {
+		t.Fatalf("Expected only the popular post, got %+v", posts)
+	}
+	if posts[0].Score != 10 {
+		t.Errorf("Expected score 10 (2 fans x (reply 2 + repost 3)), got %f", posts[0].Score)
+	}
+}
+
+func TestCalculator_ExcludesPrivateAndDeactivated(t *testing.T) {
+	f := setupTestCalculator(t)
+
+	// 非公開のアカウントのハッシュタグは数えない
+	for _, user := range f.users("private", 3) {
+		f.db.Model(user).Update("is_private", true)
+		f.post(user, "#secret", time.Minute)
+	}
+
+	// 退会したアカウントの投稿を除くと使ったアカウントが足りない
+	members := f.users("member", 3)
+	for _, user := range members {
+		f.post(user, "#club", time.Minute)
+	}
+	f.db.Delete(members[0])
+
+	// 非公開・退会済みのアカウントのいいねは数えない
+	author := f.user("author")
+	liked := f.post(author, "liked", time.Minute)
+	private := f.user("lurker")
+	f.db.Model(private).Update("is_private", true)
+	f.like(private, liked, time.Minute)
+	f.like(members[0], liked, time.Minute)
+	f.like(members[1], liked, time.Minute)
+
+	hashtags, posts := f.refresh(models.TrendingWindowDay)
+	if len(hashtags) != 0 {
+		t.Errorf("Expected no hashtags, got %+v", hashtags)
+	}
+	if len(posts) != 0 {
+		t.Errorf("Expected no posts, got %+v", posts)
+	}
+
+	// 公開アカウントのいいねが揃えば集計する
+	f.like(members[2], liked, time.Minute)
+	_, posts = f.refresh(models.TrendingWindowDay)
+	if len(posts) != 1 || posts[0].PostID != liked.ID {
+		t.Errorf("Expected the liked post, got %+v", posts)
+	}
+}
+
+func TestCalculator_RefreshReplacesPrevious(t *testing.T) {
+	f := setupTestCalculator(t)
+	for _, user := range f.users("user", 3) {
+		f.post(user, "#go", time.Minute)
+	}
+
+	hashtags, _ := f.refresh(models.TrendingWindowDay)
+	if len(hashtags) != 1 {
+		t.Fatalf("Expected 1 hashtag, got %d", len(hashtags))
+	}
+
+	// 削除された投稿は次の集計で除かれる
+	var post models.Post
+	f.db.First(&post)
+	f.db.Delete(&post)
+
+	hashtags, _ = f.refresh(models.TrendingWindowDay)
+	if len(hashtags) != 0 {
+		t.Errorf("Expected no hashtags after deletion, got %+v", hashtags)
+	}
+
+	if err := f.calc.RefreshWindow("MONTH"); err == nil {
+		t.Error("Expected error for unknown window")
+	}
+}